@@ -15,6 +15,7 @@ type Config struct {
 	SigningKey     string
 	RateLimit      uint64
 	CryptoKey      string `json:"crypto_key"`
+	GRPCAddress    string `json:"grpc_address"`
 }
 
 func loadConfigFromFile(path string) (Config, error) {
@@ -43,6 +44,7 @@ func NewConfig() Config {
 		signingKey     string
 		rateLimit      uint64
 		cryptoKey      string
+		grpcAddress    string
 		configFile     string
 	)
 
@@ -52,6 +54,7 @@ func NewConfig() Config {
 	flag.StringVar(&signingKey, "k", "", "data signing key")
 	flag.Uint64Var(&rateLimit, "l", 1, "rate limit of batched request")
 	flag.StringVar(&cryptoKey, "crypto-key", "", "path to the encryption key")
+	flag.StringVar(&grpcAddress, "grpc-address", "", "address of gRPC server to stream data to instead of HTTP")
 	flag.StringVar(&configFile, "c", "cmd/agent/default_config.json", "path to the configuration file")
 	flag.Parse()
 
@@ -97,6 +100,10 @@ func NewConfig() Config {
 		cryptoKey = cryptoKeyEnv
 	}
 
+	if grpcAddressEnv := os.Getenv("GRPC_ADDRESS"); grpcAddressEnv != "" {
+		grpcAddress = grpcAddressEnv
+	}
+
 	if configFileEnv := os.Getenv("CONFIG"); configFileEnv != "" {
 		configFile = configFileEnv
 	}
@@ -123,6 +130,10 @@ func NewConfig() Config {
 		if cryptoKey == "" {
 			cryptoKey = fileConfig.CryptoKey
 		}
+
+		if grpcAddress == "" {
+			grpcAddress = fileConfig.GRPCAddress
+		}
 	}
 
 	return Config{
@@ -132,5 +143,6 @@ func NewConfig() Config {
 		signingKey,
 		rateLimit,
 		cryptoKey,
+		grpcAddress,
 	}
 }
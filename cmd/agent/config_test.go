@@ -28,6 +28,7 @@ func TestLoadConfigFromFile(t *testing.T) {
 			ReportInterval: 10,
 			PollInterval:   2,
 			CryptoKey:      "path/to/crypto_key.pem",
+			GRPCAddress:    "localhost:3200",
 		}
 		configPath := "test_config.json"
 
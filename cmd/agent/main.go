@@ -15,6 +15,7 @@ import (
 
 	_ "github.com/daremove/go-metrics-service/cmd/buildversion"
 	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/proto"
 	"github.com/daremove/go-metrics-service/internal/services/metrics"
 	"github.com/daremove/go-metrics-service/internal/services/stats"
 	"github.com/daremove/go-metrics-service/internal/utils"
@@ -25,7 +26,22 @@ type Job struct {
 	metricValue float64
 }
 
-func jobWorker(ctx context.Context, wg *sync.WaitGroup, jobs <-chan Job, config Config, publicKey *rsa.PublicKey, localIP string) {
+// Sender отправляет накопленные метрики на сервер.
+type Sender func(payload []models.Metrics) error
+
+// newHTTPSender создает отправку метрик пакетом по HTTP.
+func newHTTPSender(config Config, publicKey *rsa.PublicKey, localIP string) Sender {
+	return func(payload []models.Metrics) error {
+		return serverrouter.SendMetricModelData(payload, serverrouter.SendMetricModelDataConfig{
+			URL:        fmt.Sprintf("http://%s", config.Endpoint),
+			SigningKey: config.SigningKey,
+			PublicKey:  publicKey,
+			LocalIP:    localIP,
+		})
+	}
+}
+
+func jobWorker(ctx context.Context, wg *sync.WaitGroup, jobs <-chan Job, config Config, send Sender) {
 	defer wg.Done()
 
 	var (
@@ -57,12 +73,7 @@ func jobWorker(ctx context.Context, wg *sync.WaitGroup, jobs <-chan Job, config
 			}
 
 			if len(payload) > 0 {
-				if err := serverrouter.SendMetricModelData(payload, serverrouter.SendMetricModelDataConfig{
-					URL:        fmt.Sprintf("http://%s", config.Endpoint),
-					SigningKey: config.SigningKey,
-					PublicKey:  publicKey,
-					LocalIP:    localIP,
-				}); err != nil {
+				if err := send(payload); err != nil {
 					log.Printf("failed to send metric data: %s", err)
 				}
 			}
@@ -85,12 +96,7 @@ func jobWorker(ctx context.Context, wg *sync.WaitGroup, jobs <-chan Job, config
 
 			payload = append(payload, payloadItem)
 		case <-ticker.C:
-			if err := serverrouter.SendMetricModelData(payload, serverrouter.SendMetricModelDataConfig{
-				URL:        fmt.Sprintf("http://%s", config.Endpoint),
-				SigningKey: config.SigningKey,
-				PublicKey:  publicKey,
-				LocalIP:    localIP,
-			}); err != nil {
+			if err := send(payload); err != nil {
 				log.Printf("failed to send metric data: %s", err)
 			} else {
 				payload = nil
@@ -174,16 +180,41 @@ func main() {
 		log.Fatalf("Local IP wasn't defined due to %s", err)
 	}
 
+	var (
+		send         = newHTTPSender(config, pubicKey, localIP)
+		endpoint     = config.Endpoint
+		streamClient *proto.StreamClient
+	)
+
+	if config.GRPCAddress != "" {
+		streamClient, err = proto.NewStreamClient(config.GRPCAddress)
+
+		if err != nil {
+			log.Fatalf("gRPC stream client wasn't created due to %s", err)
+		}
+
+		// Неотправленный пакет остается в очереди клиента и будет отправлен в новом потоке,
+		// поэтому ошибка не возвращается, чтобы пакет не был отправлен повторно вместе со следующими данными.
+		send = func(payload []models.Metrics) error {
+			if err := streamClient.Send(payload); err != nil {
+				log.Printf("metrics batch was queued for resending due to %s", err)
+			}
+
+			return nil
+		}
+		endpoint = config.GRPCAddress
+	}
+
 	log.Printf(
 		"Starting read stats data every %v and send it every %v to %s",
 		time.Duration(config.PollInterval)*time.Second,
 		time.Duration(config.ReportInterval)*time.Second,
-		config.Endpoint,
+		endpoint,
 	)
 
 	for i := 0; i < int(config.RateLimit); i++ {
 		wg.Add(1)
-		go jobWorker(ctx, &wg, jobsCh, config, pubicKey, localIP)
+		go jobWorker(ctx, &wg, jobsCh, config, send)
 	}
 
 	<-stop
@@ -191,5 +222,15 @@ func main() {
 
 	cancel()
 	wg.Wait()
+
+	if streamClient != nil {
+		closeCtx, closeCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer closeCancel()
+
+		if err := streamClient.Close(closeCtx); err != nil {
+			log.Printf("failed to close metrics stream: %s", err)
+		}
+	}
+
 	log.Println("Agent stopped gracefully.")
 }
@@ -6,20 +6,48 @@ import (
 	"log"
 	"os"
 	"strconv"
+
+	"github.com/daremove/go-metrics-service/internal/services/alerting"
+	"github.com/daremove/go-metrics-service/internal/services/webhook"
 )
 
 type Config struct {
-	Endpoint        string `json:"address"`
-	StoreInterval   int    `json:"store_interval"`
-	FileStoragePath string `json:"store_file"`
-	Restore         bool   `json:"restore"`
-	Dsn             string `json:"database_dsn"`
-	LogLevel        string
-	SigningKey      string
-	CryptoKey       string `json:"crypto_key"`
-	TrustedSubnet   string `json:"trusted_subnet"`
+	Endpoint         string `json:"address"`
+	StoreInterval    int    `json:"store_interval"`
+	FileStoragePath  string `json:"store_file"`
+	Restore          bool   `json:"restore"`
+	Dsn              string `json:"database_dsn"`
+	LogLevel         string
+	SigningKey       string
+	CryptoKey        string                `json:"crypto_key"`
+	TrustedSubnet    string                `json:"trusted_subnet"`
+	StaleTTL         string                `json:"stale_ttl"`
+	StaleTTLPrefix   string                `json:"stale_ttl_prefix"`
+	StaleMode        string                `json:"stale_mode"`
+	StaleSweep       string                `json:"stale_sweep_interval"`
+	SnapshotKeep     int                   `json:"snapshot_keep"`
+	DBMaxConns       int                   `json:"database_max_conns"`
+	DBMinConns       int                   `json:"database_min_conns"`
+	DBConnLifetime   string                `json:"database_max_conn_lifetime"`
+	DBConnIdleTime   string                `json:"database_max_conn_idle_time"`
+	DBConnTimeout    string                `json:"database_connect_timeout"`
+	RemoteWriteType  string                `json:"remote_write_type_rules"`
+	AlertRules       []alerting.RuleConfig `json:"alert_rules"`
+	AlertInterval    string                `json:"alert_evaluation_interval"`
+	Webhooks         webhook.Config        `json:"webhooks"`
+	StatsDAddress    string                `json:"statsd_address"`
+	StatsDFlush      string                `json:"statsd_flush_interval"`
+	InfluxSuffix     string                `json:"influx_counter_suffix"`
+	GraphiteAddress  string                `json:"graphite_address"`
+	GraphiteRules    string                `json:"graphite_counter_rules"`
+	GraphiteConns    int                   `json:"graphite_max_connections"`
+	GraphiteIdle     string                `json:"graphite_idle_timeout"`
+	HistoryRetention string                `json:"history_retention"`
 }
 
+// defaultSnapshotKeep количество предыдущих снимков файлового хранилища, если оно не задано.
+const defaultSnapshotKeep = 1
+
 func loadConfigFromFile(path string) (Config, error) {
 	file, err := os.Open(path)
 
@@ -40,16 +68,38 @@ func loadConfigFromFile(path string) (Config, error) {
 
 func NewConfig() Config {
 	var (
-		endpoint        string
-		storeInterval   int
-		fileStoragePath string
-		restore         bool
-		dsn             string
-		logLevel        string
-		signingKey      string
-		cryptoKey       string
-		configFile      string
-		trustedSubnet   string
+		endpoint         string
+		storeInterval    int
+		fileStoragePath  string
+		restore          bool
+		dsn              string
+		logLevel         string
+		signingKey       string
+		cryptoKey        string
+		configFile       string
+		trustedSubnet    string
+		staleTTL         string
+		staleTTLPrefix   string
+		staleMode        string
+		staleSweep       string
+		snapshotKeep     int
+		dbMaxConns       int
+		dbMinConns       int
+		dbConnLifetime   string
+		dbConnIdleTime   string
+		dbConnTimeout    string
+		remoteWriteType  string
+		alertRules       []alerting.RuleConfig
+		alertInterval    string
+		webhooks         webhook.Config
+		statsDAddress    string
+		statsDFlush      string
+		influxSuffix     string
+		graphiteAddress  string
+		graphiteRules    string
+		graphiteConns    int
+		graphiteIdle     string
+		historyRetention string
 	)
 
 	flag.StringVar(&endpoint, "a", "", "address and port to run server")
@@ -61,6 +111,26 @@ func NewConfig() Config {
 	flag.StringVar(&cryptoKey, "crypto-key", "", "path to the encryption key")
 	flag.StringVar(&configFile, "c", "cmd/server/default_config.json", "path to the configuration file")
 	flag.StringVar(&trustedSubnet, "t", "", "CIDR of agent")
+	flag.StringVar(&staleTTL, "stale-ttl", "", "time after which metrics without updates are considered stale, e.g. 10m")
+	flag.StringVar(&staleTTLPrefix, "stale-ttl-prefix", "", "stale ttl per metric name prefix, e.g. agent_=5m,batch_=24h")
+	flag.StringVar(&staleMode, "stale-mode", "", "how to handle stale metrics: hide or purge")
+	flag.StringVar(&staleSweep, "stale-sweep-interval", "", "interval of purging stale metrics")
+	flag.IntVar(&snapshotKeep, "snapshot-keep", -1, "number of previous storage file snapshots and log parts to keep")
+	flag.IntVar(&dbMaxConns, "db-max-conns", 0, "maximum number of database connections")
+	flag.IntVar(&dbMinConns, "db-min-conns", 0, "minimum number of open database connections")
+	flag.StringVar(&dbConnLifetime, "db-max-conn-lifetime", "", "duration after which database connection is closed, e.g. 1h")
+	flag.StringVar(&dbConnIdleTime, "db-max-conn-idle-time", "", "duration after which idle database connection is closed, e.g. 30m")
+	flag.StringVar(&dbConnTimeout, "db-connect-timeout", "", "timeout of establishing database connection, e.g. 5s")
+	flag.StringVar(&remoteWriteType, "remote-write-type-rules", "", "metric type by name suffix for remote write data, e.g. _total=counter,_ratio=gauge")
+	flag.StringVar(&alertInterval, "alert-interval", "", "interval of evaluating alert rules, e.g. 30s")
+	flag.StringVar(&statsDAddress, "statsd-address", "", "UDP address to receive StatsD metrics, e.g. :8125")
+	flag.StringVar(&statsDFlush, "statsd-flush-interval", "", "interval of saving aggregated StatsD metrics, e.g. 10s")
+	flag.StringVar(&influxSuffix, "influx-counter-suffix", "", "suffix of integer InfluxDB fields saved as counters, default _total")
+	flag.StringVar(&graphiteAddress, "graphite-address", "", "TCP address to receive Graphite plaintext metrics, e.g. :2003")
+	flag.StringVar(&graphiteRules, "graphite-counter-rules", "", "Graphite path patterns saved as counters, e.g. stats.counts.*,*.requests")
+	flag.IntVar(&graphiteConns, "graphite-max-connections", 0, "maximum number of simultaneous Graphite connections")
+	flag.StringVar(&graphiteIdle, "graphite-idle-timeout", "", "duration after which idle Graphite connection is closed, e.g. 1m")
+	flag.StringVar(&historyRetention, "history-retention", "", "duration of keeping metric history, 0 disables pruning, default 168h")
 	flag.Parse()
 
 	if address := os.Getenv("ADDRESS"); address != "" {
@@ -117,6 +187,110 @@ func NewConfig() Config {
 		trustedSubnet = trustedSubnetEnv
 	}
 
+	if staleTTLEnv := os.Getenv("STALE_TTL"); staleTTLEnv != "" {
+		staleTTL = staleTTLEnv
+	}
+
+	if staleTTLPrefixEnv := os.Getenv("STALE_TTL_PREFIX"); staleTTLPrefixEnv != "" {
+		staleTTLPrefix = staleTTLPrefixEnv
+	}
+
+	if staleModeEnv := os.Getenv("STALE_MODE"); staleModeEnv != "" {
+		staleMode = staleModeEnv
+	}
+
+	if staleSweepEnv := os.Getenv("STALE_SWEEP_INTERVAL"); staleSweepEnv != "" {
+		staleSweep = staleSweepEnv
+	}
+
+	if snapshotKeepEnv := os.Getenv("SNAPSHOT_KEEP"); snapshotKeepEnv != "" {
+		v, err := strconv.Atoi(snapshotKeepEnv)
+
+		if err != nil {
+			log.Fatalf("SNAPSHOT_KEEP couldn't parsed %s", err)
+		}
+
+		snapshotKeep = v
+	}
+
+	if dbMaxConnsEnv := os.Getenv("DATABASE_MAX_CONNS"); dbMaxConnsEnv != "" {
+		v, err := strconv.Atoi(dbMaxConnsEnv)
+
+		if err != nil {
+			log.Fatalf("DATABASE_MAX_CONNS couldn't parsed %s", err)
+		}
+
+		dbMaxConns = v
+	}
+
+	if dbMinConnsEnv := os.Getenv("DATABASE_MIN_CONNS"); dbMinConnsEnv != "" {
+		v, err := strconv.Atoi(dbMinConnsEnv)
+
+		if err != nil {
+			log.Fatalf("DATABASE_MIN_CONNS couldn't parsed %s", err)
+		}
+
+		dbMinConns = v
+	}
+
+	if dbConnLifetimeEnv := os.Getenv("DATABASE_MAX_CONN_LIFETIME"); dbConnLifetimeEnv != "" {
+		dbConnLifetime = dbConnLifetimeEnv
+	}
+
+	if dbConnIdleTimeEnv := os.Getenv("DATABASE_MAX_CONN_IDLE_TIME"); dbConnIdleTimeEnv != "" {
+		dbConnIdleTime = dbConnIdleTimeEnv
+	}
+
+	if dbConnTimeoutEnv := os.Getenv("DATABASE_CONNECT_TIMEOUT"); dbConnTimeoutEnv != "" {
+		dbConnTimeout = dbConnTimeoutEnv
+	}
+
+	if remoteWriteTypeEnv := os.Getenv("REMOTE_WRITE_TYPE_RULES"); remoteWriteTypeEnv != "" {
+		remoteWriteType = remoteWriteTypeEnv
+	}
+
+	if alertIntervalEnv := os.Getenv("ALERT_EVALUATION_INTERVAL"); alertIntervalEnv != "" {
+		alertInterval = alertIntervalEnv
+	}
+
+	if statsDAddressEnv := os.Getenv("STATSD_ADDRESS"); statsDAddressEnv != "" {
+		statsDAddress = statsDAddressEnv
+	}
+
+	if statsDFlushEnv := os.Getenv("STATSD_FLUSH_INTERVAL"); statsDFlushEnv != "" {
+		statsDFlush = statsDFlushEnv
+	}
+
+	if influxSuffixEnv := os.Getenv("INFLUX_COUNTER_SUFFIX"); influxSuffixEnv != "" {
+		influxSuffix = influxSuffixEnv
+	}
+
+	if graphiteAddressEnv := os.Getenv("GRAPHITE_ADDRESS"); graphiteAddressEnv != "" {
+		graphiteAddress = graphiteAddressEnv
+	}
+
+	if graphiteRulesEnv := os.Getenv("GRAPHITE_COUNTER_RULES"); graphiteRulesEnv != "" {
+		graphiteRules = graphiteRulesEnv
+	}
+
+	if graphiteConnsEnv := os.Getenv("GRAPHITE_MAX_CONNECTIONS"); graphiteConnsEnv != "" {
+		v, err := strconv.Atoi(graphiteConnsEnv)
+
+		if err != nil {
+			log.Fatalf("GRAPHITE_MAX_CONNECTIONS couldn't parsed %s", err)
+		}
+
+		graphiteConns = v
+	}
+
+	if graphiteIdleEnv := os.Getenv("GRAPHITE_IDLE_TIMEOUT"); graphiteIdleEnv != "" {
+		graphiteIdle = graphiteIdleEnv
+	}
+
+	if historyRetentionEnv := os.Getenv("HISTORY_RETENTION"); historyRetentionEnv != "" {
+		historyRetention = historyRetentionEnv
+	}
+
 	if configFile != "" {
 		fileConfig, err := loadConfigFromFile(configFile)
 
@@ -151,6 +325,93 @@ func NewConfig() Config {
 		if trustedSubnet == "" {
 			trustedSubnet = fileConfig.TrustedSubnet
 		}
+
+		if staleTTL == "" {
+			staleTTL = fileConfig.StaleTTL
+		}
+
+		if staleTTLPrefix == "" {
+			staleTTLPrefix = fileConfig.StaleTTLPrefix
+		}
+
+		if staleMode == "" {
+			staleMode = fileConfig.StaleMode
+		}
+
+		if staleSweep == "" {
+			staleSweep = fileConfig.StaleSweep
+		}
+
+		if snapshotKeep < 0 && fileConfig.SnapshotKeep > 0 {
+			snapshotKeep = fileConfig.SnapshotKeep
+		}
+
+		if dbMaxConns == 0 {
+			dbMaxConns = fileConfig.DBMaxConns
+		}
+
+		if dbMinConns == 0 {
+			dbMinConns = fileConfig.DBMinConns
+		}
+
+		if dbConnLifetime == "" {
+			dbConnLifetime = fileConfig.DBConnLifetime
+		}
+
+		if dbConnIdleTime == "" {
+			dbConnIdleTime = fileConfig.DBConnIdleTime
+		}
+
+		if dbConnTimeout == "" {
+			dbConnTimeout = fileConfig.DBConnTimeout
+		}
+
+		if remoteWriteType == "" {
+			remoteWriteType = fileConfig.RemoteWriteType
+		}
+
+		if alertInterval == "" {
+			alertInterval = fileConfig.AlertInterval
+		}
+
+		if statsDAddress == "" {
+			statsDAddress = fileConfig.StatsDAddress
+		}
+
+		if statsDFlush == "" {
+			statsDFlush = fileConfig.StatsDFlush
+		}
+
+		if influxSuffix == "" {
+			influxSuffix = fileConfig.InfluxSuffix
+		}
+
+		if graphiteAddress == "" {
+			graphiteAddress = fileConfig.GraphiteAddress
+		}
+
+		if graphiteRules == "" {
+			graphiteRules = fileConfig.GraphiteRules
+		}
+
+		if graphiteConns == 0 {
+			graphiteConns = fileConfig.GraphiteConns
+		}
+
+		if graphiteIdle == "" {
+			graphiteIdle = fileConfig.GraphiteIdle
+		}
+
+		if historyRetention == "" {
+			historyRetention = fileConfig.HistoryRetention
+		}
+
+		alertRules = fileConfig.AlertRules
+		webhooks = fileConfig.Webhooks
+	}
+
+	if snapshotKeep < 0 {
+		snapshotKeep = defaultSnapshotKeep
 	}
 
 	return Config{
@@ -163,5 +424,27 @@ func NewConfig() Config {
 		signingKey,
 		cryptoKey,
 		trustedSubnet,
+		staleTTL,
+		staleTTLPrefix,
+		staleMode,
+		staleSweep,
+		snapshotKeep,
+		dbMaxConns,
+		dbMinConns,
+		dbConnLifetime,
+		dbConnIdleTime,
+		dbConnTimeout,
+		remoteWriteType,
+		alertRules,
+		alertInterval,
+		webhooks,
+		statsDAddress,
+		statsDFlush,
+		influxSuffix,
+		graphiteAddress,
+		graphiteRules,
+		graphiteConns,
+		graphiteIdle,
+		historyRetention,
 	}
 }
@@ -30,6 +30,7 @@ func TestLoadConfigFromFile(t *testing.T) {
 			Restore:         true,
 			Dsn:             "database_dsn",
 			CryptoKey:       "path/to/crypto_key.pem",
+			SnapshotKeep:    3,
 		}
 		configPath := "test_config.json"
 		writeJSONFile(t, configPath, configContent)
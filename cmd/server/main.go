@@ -3,12 +3,14 @@ package main
 import (
 	"context"
 	"crypto/rsa"
+	"fmt"
 	"log"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/daremove/go-metrics-service/internal/proto"
 	"google.golang.org/grpc"
@@ -16,9 +18,17 @@ import (
 	_ "github.com/daremove/go-metrics-service/cmd/buildversion"
 	"github.com/daremove/go-metrics-service/internal/http/serverrouter"
 	"github.com/daremove/go-metrics-service/internal/logger"
+	"github.com/daremove/go-metrics-service/internal/services/alerting"
 	"github.com/daremove/go-metrics-service/internal/services/filestorage"
+	"github.com/daremove/go-metrics-service/internal/services/graphite"
 	"github.com/daremove/go-metrics-service/internal/services/healthcheck"
+	"github.com/daremove/go-metrics-service/internal/services/influx"
 	"github.com/daremove/go-metrics-service/internal/services/metrics"
+	"github.com/daremove/go-metrics-service/internal/services/remotewrite"
+	"github.com/daremove/go-metrics-service/internal/services/retention"
+	"github.com/daremove/go-metrics-service/internal/services/staleness"
+	"github.com/daremove/go-metrics-service/internal/services/statsd"
+	"github.com/daremove/go-metrics-service/internal/services/webhook"
 	"github.com/daremove/go-metrics-service/internal/storage/database"
 	"github.com/daremove/go-metrics-service/internal/storage/memstorage"
 	"github.com/daremove/go-metrics-service/internal/utils"
@@ -30,56 +40,338 @@ func initializeLogger(logLevel string) error {
 	return logger.Initialize(logLevel)
 }
 
-func initializeStorage(ctx context.Context, config Config) (metrics.Storage, *healthcheck.HealthCheck, error) {
+// initializeStorage создает хранилище метрик и возвращает вместе с ним функцию его закрытия,
+// которая вызывается последним шагом остановки сервера.
+func initializeStorage(ctx context.Context, config Config) (metrics.Storage, *healthcheck.HealthCheck, func(context.Context) error, error) {
 	var storage metrics.Storage
 	var healthCheckService *healthcheck.HealthCheck
 
+	closeStorage := func(context.Context) error { return nil }
+
 	if config.Dsn == "" {
 		fileStorage, err := filestorage.New(ctx, memstorage.New(), filestorage.Config{
 			StoreInterval:   config.StoreInterval,
 			FileStoragePath: config.FileStoragePath,
 			Restore:         config.Restore,
+			SnapshotKeep:    config.SnapshotKeep,
 		})
 
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 
 		storage = fileStorage
 		healthCheckService = healthcheck.New(nil)
-
-		utils.HandleTerminationProcess(func() {
-			if err := fileStorage.BackupData(ctx); err != nil {
-				log.Fatalf("Cannot backup data after termination process %s", err)
-			}
-		})
+		closeStorage = fileStorage.Close
 	} else {
-		db, err := database.New(ctx, config.Dsn)
+		databaseConfig, err := initializeDatabaseConfig(config)
+
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		db, err := database.NewWithConfig(ctx, config.Dsn, databaseConfig)
 
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 
 		storage = db
 		healthCheckService = healthcheck.New(db)
 	}
 
-	return storage, healthCheckService, nil
+	return storage, healthCheckService, closeStorage, nil
+}
+
+func initializeDatabaseConfig(config Config) (database.Config, error) {
+	if config.DBMaxConns < 0 || config.DBMinConns < 0 {
+		return database.Config{}, fmt.Errorf("database connections number must not be negative")
+	}
+
+	if config.DBMaxConns > 0 && config.DBMinConns > config.DBMaxConns {
+		return database.Config{}, fmt.Errorf("database min connections must not exceed max connections")
+	}
+
+	result := database.Config{
+		MaxConns: int32(config.DBMaxConns),
+		MinConns: int32(config.DBMinConns),
+	}
+
+	durations := []struct {
+		name   string
+		value  string
+		target *time.Duration
+	}{
+		{"database max connection lifetime", config.DBConnLifetime, &result.MaxConnLifetime},
+		{"database max connection idle time", config.DBConnIdleTime, &result.MaxConnIdleTime},
+		{"database connect timeout", config.DBConnTimeout, &result.ConnectTimeout},
+	}
+
+	for _, item := range durations {
+		if item.value == "" {
+			continue
+		}
+
+		duration, err := time.ParseDuration(item.value)
+
+		if err != nil {
+			return database.Config{}, fmt.Errorf("%s is invalid: %w", item.name, err)
+		}
+
+		*item.target = duration
+	}
+
+	return result, nil
+}
+
+// defaultStaleSweepInterval используется, если интервал очистки устаревших метрик не задан.
+const defaultStaleSweepInterval = time.Minute
+
+func initializeStaleness(config Config) (staleness.Config, error) {
+	result := staleness.Config{
+		Mode:          staleness.ModeHide,
+		SweepInterval: defaultStaleSweepInterval,
+	}
+
+	if config.StaleTTL != "" {
+		ttl, err := time.ParseDuration(config.StaleTTL)
+
+		if err != nil {
+			return staleness.Config{}, fmt.Errorf("stale ttl is invalid: %w", err)
+		}
+
+		result.Policy.TTL = ttl
+	}
+
+	if config.StaleTTLPrefix != "" {
+		prefixTTL, err := staleness.ParsePrefixTTL(config.StaleTTLPrefix)
+
+		if err != nil {
+			return staleness.Config{}, err
+		}
+
+		result.Policy.PrefixTTL = prefixTTL
+	}
+
+	switch config.StaleMode {
+	case "", staleness.ModeHide:
+	case staleness.ModePurge:
+		result.Mode = staleness.ModePurge
+	default:
+		return staleness.Config{}, fmt.Errorf("stale mode %q isn't supported", config.StaleMode)
+	}
+
+	if config.StaleSweep != "" {
+		interval, err := time.ParseDuration(config.StaleSweep)
+
+		if err != nil {
+			return staleness.Config{}, fmt.Errorf("stale sweep interval is invalid: %w", err)
+		}
+
+		if interval <= 0 {
+			return staleness.Config{}, fmt.Errorf("stale sweep interval must be positive")
+		}
+
+		result.SweepInterval = interval
+	}
+
+	return result, nil
+}
+
+func initializeRemoteWrite(config Config) (remotewrite.Config, error) {
+	result := remotewrite.Config{}
+
+	if config.RemoteWriteType != "" {
+		typeRules, err := remotewrite.ParseTypeRules(config.RemoteWriteType)
+
+		if err != nil {
+			return remotewrite.Config{}, err
+		}
+
+		result.TypeRules = typeRules
+	}
+
+	return result, nil
+}
+
+// defaultAlertInterval используется, если интервал проверки правил алертинга не задан.
+const defaultAlertInterval = 30 * time.Second
+
+func initializeAlerting(config Config) (alerting.Config, error) {
+	result := alerting.Config{Interval: defaultAlertInterval}
+
+	rules, err := alerting.ParseRules(config.AlertRules)
+
+	if err != nil {
+		return alerting.Config{}, err
+	}
+
+	result.Rules = rules
+
+	if config.AlertInterval != "" {
+		interval, err := time.ParseDuration(config.AlertInterval)
+
+		if err != nil {
+			return alerting.Config{}, fmt.Errorf("alert evaluation interval is invalid: %w", err)
+		}
+
+		if interval <= 0 {
+			return alerting.Config{}, fmt.Errorf("alert evaluation interval must be positive")
+		}
+
+		result.Interval = interval
+	}
+
+	return result, nil
+}
+
+func initializeWebhooks(config Config) (*webhook.Notifier, error) {
+	var deliveryLog webhook.DeliveryLog
+
+	if config.Webhooks.DeliveryLog != "" {
+		fileDeliveryLog, err := webhook.NewFileDeliveryLog(config.Webhooks.DeliveryLog)
+
+		if err != nil {
+			return nil, fmt.Errorf("webhook delivery log wasn't opened: %w", err)
+		}
+
+		deliveryLog = fileDeliveryLog
+	}
+
+	return webhook.New(config.Webhooks, deliveryLog)
+}
+
+// initializeHistoryRetention возвращает срок хранения истории метрик; 0 отключает удаление истории.
+func initializeHistoryRetention(config Config) (time.Duration, error) {
+	if config.HistoryRetention == "" {
+		return retention.DefaultRetention, nil
+	}
+
+	value, err := time.ParseDuration(config.HistoryRetention)
+
+	if err != nil {
+		return 0, fmt.Errorf("history retention is invalid: %w", err)
+	}
+
+	if value < 0 {
+		return 0, fmt.Errorf("history retention must not be negative")
+	}
+
+	return value, nil
+}
+
+func initializeStatsD(config Config) (statsd.Config, error) {
+	result := statsd.Config{Address: config.StatsDAddress, FlushInterval: statsd.DefaultFlushInterval}
+
+	if config.StatsDFlush != "" {
+		interval, err := time.ParseDuration(config.StatsDFlush)
+
+		if err != nil {
+			return statsd.Config{}, fmt.Errorf("statsd flush interval is invalid: %w", err)
+		}
+
+		if interval <= 0 {
+			return statsd.Config{}, fmt.Errorf("statsd flush interval must be positive")
+		}
+
+		result.FlushInterval = interval
+	}
+
+	return result, nil
+}
+
+func initializeGraphite(config Config) (graphite.Config, error) {
+	result := graphite.Config{
+		Address:        config.GraphiteAddress,
+		MaxConnections: graphite.DefaultMaxConnections,
+		IdleTimeout:    graphite.DefaultIdleTimeout,
+	}
+
+	rules, err := graphite.ParseCounterRules(config.GraphiteRules)
+
+	if err != nil {
+		return graphite.Config{}, err
+	}
+
+	result.CounterRules = rules
+
+	if config.GraphiteConns < 0 {
+		return graphite.Config{}, fmt.Errorf("graphite max connections must not be negative")
+	}
+
+	if config.GraphiteConns > 0 {
+		result.MaxConnections = config.GraphiteConns
+	}
+
+	if config.GraphiteIdle != "" {
+		timeout, err := time.ParseDuration(config.GraphiteIdle)
+
+		if err != nil {
+			return graphite.Config{}, fmt.Errorf("graphite idle timeout is invalid: %w", err)
+		}
+
+		if timeout <= 0 {
+			return graphite.Config{}, fmt.Errorf("graphite idle timeout must be positive")
+		}
+
+		result.IdleTimeout = timeout
+	}
+
+	return result, nil
 }
 
-func runServer(ctx context.Context, config Config, metricsService *metrics.Metrics, healthCheckService *healthcheck.HealthCheck, privateKey *rsa.PrivateKey) *http.Server {
+// runStatsDListener запускает прием метрик StatsD и возвращает функцию остановки,
+// которая дожидается сохранения накопленных значений.
+func runStatsDListener(ctx context.Context, config statsd.Config, metricsService *metrics.Metrics) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
 
-	router := serverrouter.New(metricsService, healthCheckService, serverrouter.RouterConfig{
+	go func() {
+		defer close(done)
+
+		log.Printf("Receiving StatsD metrics on %s\n", config.Address)
+
+		if err := statsd.New(config, metricsService).ListenAndServe(ctx); err != nil {
+			log.Fatalf("Could not listen StatsD on %s: %v\n", config.Address, err)
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func runGraphiteServer(config graphite.Config, metricsService *metrics.Metrics) *graphite.Server {
+	server := graphite.New(config, metricsService)
+
+	go func() {
+		log.Printf("Receiving Graphite metrics on %s\n", config.Address)
+
+		if err := server.ListenAndServe(); err != nil && err != graphite.ErrServerClosed {
+			log.Fatalf("Could not listen Graphite on %s: %v\n", config.Address, err)
+		}
+	}()
+
+	return server
+}
+
+func runServer(ctx context.Context, config Config, metricsService *metrics.Metrics, healthCheckService *healthcheck.HealthCheck, alertsService *alerting.Evaluator, privateKey *rsa.PrivateKey, remoteWriteConfig remotewrite.Config) *http.Server {
+
+	router := serverrouter.New(metricsService, healthCheckService, alertsService, serverrouter.RouterConfig{
 		Endpoint:      config.Endpoint,
 		SigningKey:    config.SigningKey,
 		PrivateKey:    privateKey,
 		TrustedSubnet: config.TrustedSubnet,
+		RemoteWrite:   remoteWriteConfig,
+		Influx:        influx.Config{CounterSuffix: config.InfluxSuffix},
 	})
 
 	server := &http.Server{
 		Addr:    config.Endpoint,
 		Handler: router.Get(ctx),
 	}
+	server.RegisterOnShutdown(router.CloseStreams)
 
 	go func() {
 		log.Printf("Running server on %s\n", config.Endpoint)
@@ -92,10 +384,10 @@ func runServer(ctx context.Context, config Config, metricsService *metrics.Metri
 	return server
 }
 
-func runGRPCServer(metricsService *metrics.Metrics) *grpc.Server {
+func runGRPCServer(metricsService *metrics.Metrics, healthCheckService *healthcheck.HealthCheck, alertsService *alerting.Evaluator) (*grpc.Server, *proto.MetricsServer) {
 	address := ":3200"
 	server := grpc.NewServer()
-	metricsServer := proto.NewMetricsServer(metricsService)
+	metricsServer := proto.NewMetricsServer(metricsService, healthCheckService, alertsService)
 
 	go func() {
 		listen, err := net.Listen("tcp", address)
@@ -113,11 +405,23 @@ func runGRPCServer(metricsService *metrics.Metrics) *grpc.Server {
 		}
 	}()
 
-	return server
+	return server, metricsServer
 }
 
+// shutdownTimeout время, в течение которого при остановке ожидается завершение обработки запросов.
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	ctx := context.Background()
+
+	if len(os.Args) > 1 && os.Args[1] == migrateCommand {
+		if err := runMigrate(ctx, os.Args[2:], os.Stdout, openMigrator); err != nil {
+			log.Fatalf("Migration failed due to %s", err)
+		}
+
+		return
+	}
+
 	config := NewConfig()
 
 	privateKey, privateKeyErr := utils.LoadPrivateKey(config.CryptoKey)
@@ -130,15 +434,88 @@ func main() {
 		log.Fatalf("Logger wasn't initialized due to %s", err)
 	}
 
-	storage, healthCheckService, err := initializeStorage(ctx, config)
+	storage, healthCheckService, closeStorage, err := initializeStorage(ctx, config)
 
 	if err != nil {
 		log.Fatalf("Storage wasn't initialized due to %s", err)
 	}
 
-	metricsService := metrics.New(storage)
-	server := runServer(ctx, config, metricsService, healthCheckService, privateKey)
-	grpcServer := runGRPCServer(metricsService)
+	stalenessConfig, err := initializeStaleness(config)
+
+	if err != nil {
+		log.Fatalf("Staleness wasn't initialized due to %s", err)
+	}
+
+	historyRetention, err := initializeHistoryRetention(config)
+
+	if err != nil {
+		log.Fatalf("History retention wasn't initialized due to %s", err)
+	}
+
+	if historyRetention > 0 {
+		go retention.NewPruner(storage, historyRetention, retention.DefaultPruneInterval).Run(ctx)
+	}
+
+	remoteWriteConfig, err := initializeRemoteWrite(config)
+
+	if err != nil {
+		log.Fatalf("Remote write wasn't initialized due to %s", err)
+	}
+
+	alertingConfig, err := initializeAlerting(config)
+
+	if err != nil {
+		log.Fatalf("Alerting wasn't initialized due to %s", err)
+	}
+
+	metricsService := metrics.NewWithConfig(storage, metrics.Config{Staleness: stalenessConfig.Policy})
+
+	alertsService := alerting.New(metricsService, alertingConfig.Rules)
+
+	if len(alertingConfig.Rules) > 0 {
+		go alertsService.Run(ctx, alertingConfig.Interval)
+	}
+
+	if stalenessConfig.Policy.Enabled() && stalenessConfig.Mode == staleness.ModePurge {
+		go staleness.NewSweeper(storage, metricsService, stalenessConfig.Policy, stalenessConfig.SweepInterval).Run(ctx)
+	}
+
+	if config.Webhooks.Enabled() {
+		notifier, err := initializeWebhooks(config)
+
+		if err != nil {
+			log.Fatalf("Webhooks weren't initialized due to %s", err)
+		}
+
+		go notifier.Run(ctx, metricsService)
+	}
+
+	statsDConfig, err := initializeStatsD(config)
+
+	if err != nil {
+		log.Fatalf("StatsD wasn't initialized due to %s", err)
+	}
+
+	stopStatsD := func() {}
+
+	if statsDConfig.Address != "" {
+		stopStatsD = runStatsDListener(ctx, statsDConfig, metricsService)
+	}
+
+	graphiteConfig, err := initializeGraphite(config)
+
+	if err != nil {
+		log.Fatalf("Graphite wasn't initialized due to %s", err)
+	}
+
+	var graphiteServer *graphite.Server
+
+	if graphiteConfig.Address != "" {
+		graphiteServer = runGraphiteServer(graphiteConfig, metricsService)
+	}
+
+	server := runServer(ctx, config, metricsService, healthCheckService, alertsService, privateKey, remoteWriteConfig)
+	grpcServer, metricsServer := runGRPCServer(metricsService, healthCheckService, alertsService)
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
@@ -147,11 +524,29 @@ func main() {
 
 	log.Println("Shutting down the server...")
 
+	metricsServer.CloseStreams()
 	grpcServer.GracefulStop()
 
-	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+	shutdownCtx, cancel := context.WithTimeout(ctx, shutdownTimeout)
+	defer cancel()
+
+	if graphiteServer != nil {
+		if err := graphiteServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Graphite server forced to shutdown: %v", err)
+		}
+	}
+
+	stopStatsD()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server forced to shutdown: %v", err)
+		server.Close()
+	} else {
+		log.Println("Server stopped gracefully.")
 	}
 
-	log.Println("Server stopped gracefully.")
+	// Хранилище закрывается последним, когда все источники метрик уже остановлены и сохранили данные.
+	if err := closeStorage(ctx); err != nil {
+		log.Fatalf("Cannot backup data after termination process %s", err)
+	}
 }
@@ -4,12 +4,22 @@ import (
 	"context"
 	"log"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/daremove/go-metrics-service/internal/services/metrics"
 
 	"github.com/daremove/go-metrics-service/internal/logger"
+	"github.com/daremove/go-metrics-service/internal/services/alerting"
+	"github.com/daremove/go-metrics-service/internal/services/graphite"
 	"github.com/daremove/go-metrics-service/internal/services/healthcheck"
+	"github.com/daremove/go-metrics-service/internal/services/remotewrite"
+	"github.com/daremove/go-metrics-service/internal/services/retention"
+	"github.com/daremove/go-metrics-service/internal/services/staleness"
+	"github.com/daremove/go-metrics-service/internal/services/statsd"
+	"github.com/daremove/go-metrics-service/internal/services/webhook"
+	"github.com/daremove/go-metrics-service/internal/storage/database"
 	"github.com/daremove/go-metrics-service/internal/storage/memstorage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -47,11 +57,222 @@ func TestInitializeStorage(t *testing.T) {
 			Restore:         true,
 		}
 
-		storage, healthCheckService, err := initializeStorage(ctx, config)
+		storage, healthCheckService, closeStorage, err := initializeStorage(ctx, config)
 
 		require.NoError(t, err)
 		assert.NotNil(t, storage)
 		assert.NotNil(t, healthCheckService)
+		assert.NoError(t, closeStorage(ctx))
+	})
+}
+
+func TestInitializeStaleness(t *testing.T) {
+	t.Run("Should hide stale metrics by default", func(t *testing.T) {
+		result, err := initializeStaleness(Config{})
+
+		require.NoError(t, err)
+		assert.False(t, result.Policy.Enabled())
+		assert.Equal(t, staleness.ModeHide, result.Mode)
+		assert.Equal(t, defaultStaleSweepInterval, result.SweepInterval)
+	})
+
+	t.Run("Should parse staleness settings", func(t *testing.T) {
+		result, err := initializeStaleness(Config{
+			StaleTTL:       "10m",
+			StaleTTLPrefix: "agent_=1m",
+			StaleMode:      staleness.ModePurge,
+			StaleSweep:     "30s",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, staleness.Config{
+			Policy:        staleness.Policy{TTL: 10 * time.Minute, PrefixTTL: map[string]time.Duration{"agent_": time.Minute}},
+			Mode:          staleness.ModePurge,
+			SweepInterval: 30 * time.Second,
+		}, result)
+	})
+
+	t.Run("Should return error for unknown mode", func(t *testing.T) {
+		_, err := initializeStaleness(Config{StaleMode: "drop"})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestInitializeRemoteWrite(t *testing.T) {
+	t.Run("Should use default type rules", func(t *testing.T) {
+		result, err := initializeRemoteWrite(Config{})
+
+		require.NoError(t, err)
+		assert.Nil(t, result.TypeRules)
+	})
+
+	t.Run("Should parse type rules", func(t *testing.T) {
+		result, err := initializeRemoteWrite(Config{RemoteWriteType: "_total=counter,_ratio=gauge"})
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"_total": "counter", "_ratio": "gauge"}, result.TypeRules)
+	})
+
+	t.Run("Should return error for unknown type", func(t *testing.T) {
+		_, err := initializeRemoteWrite(Config{RemoteWriteType: "_bucket=histogram"})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestInitializeAlerting(t *testing.T) {
+	t.Run("Should use default interval without rules", func(t *testing.T) {
+		result, err := initializeAlerting(Config{})
+
+		require.NoError(t, err)
+		assert.Empty(t, result.Rules)
+		assert.Equal(t, defaultAlertInterval, result.Interval)
+	})
+
+	t.Run("Should parse rules and interval", func(t *testing.T) {
+		result, err := initializeAlerting(Config{
+			AlertRules:    []alerting.RuleConfig{{Name: "heap", Expr: "HeapAlloc > 5e8 for 2m"}, {Expr: "counter rate(PollCount) == 0 for 1m"}},
+			AlertInterval: "10s",
+		})
+
+		require.NoError(t, err)
+		require.Len(t, result.Rules, 2)
+		assert.Equal(t, "heap", result.Rules[0].Name)
+		assert.Equal(t, "PollCount", result.Rules[1].Key)
+		assert.Equal(t, 10*time.Second, result.Interval)
+	})
+
+	t.Run("Should return error for invalid rule", func(t *testing.T) {
+		_, err := initializeAlerting(Config{AlertRules: []alerting.RuleConfig{{Expr: "HeapAlloc is big"}}})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("Should return error for invalid interval", func(t *testing.T) {
+		_, err := initializeAlerting(Config{AlertInterval: "-1s"})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestInitializeWebhooks(t *testing.T) {
+	t.Run("Should create notifier with delivery log", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "deliveries.log")
+
+		notifier, err := initializeWebhooks(Config{Webhooks: webhook.Config{
+			Receivers:   []webhook.ReceiverConfig{{Name: "ops", URL: "http://localhost:9000/hook"}},
+			Watches:     []webhook.WatchConfig{{Name: "heap", Metric: "HeapAlloc", Type: "gauge", Condition: webhook.ConditionAbove, Threshold: 5e8}},
+			DeliveryLog: path,
+		}})
+
+		require.NoError(t, err)
+		assert.NotNil(t, notifier)
+		assert.FileExists(t, path)
+	})
+
+	t.Run("Should return error for invalid watch", func(t *testing.T) {
+		_, err := initializeWebhooks(Config{Webhooks: webhook.Config{
+			Watches: []webhook.WatchConfig{{Name: "heap", Metric: "HeapAlloc", Type: "gauge", Condition: "equal"}},
+		}})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("Should return error if delivery log can't be opened", func(t *testing.T) {
+		_, err := initializeWebhooks(Config{Webhooks: webhook.Config{DeliveryLog: filepath.Join(t.TempDir(), "missing", "deliveries.log")}})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestInitializeHistoryRetention(t *testing.T) {
+	t.Run("Should use default retention", func(t *testing.T) {
+		result, err := initializeHistoryRetention(Config{})
+
+		require.NoError(t, err)
+		assert.Equal(t, retention.DefaultRetention, result)
+	})
+
+	t.Run("Should parse retention", func(t *testing.T) {
+		result, err := initializeHistoryRetention(Config{HistoryRetention: "24h"})
+
+		require.NoError(t, err)
+		assert.Equal(t, 24*time.Hour, result)
+	})
+
+	t.Run("Should disable pruning for zero retention", func(t *testing.T) {
+		result, err := initializeHistoryRetention(Config{HistoryRetention: "0"})
+
+		require.NoError(t, err)
+		assert.Zero(t, result)
+	})
+
+	t.Run("Should return error for invalid retention", func(t *testing.T) {
+		_, err := initializeHistoryRetention(Config{HistoryRetention: "-1h"})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestInitializeStatsD(t *testing.T) {
+	t.Run("Should use default flush interval", func(t *testing.T) {
+		result, err := initializeStatsD(Config{StatsDAddress: ":8125"})
+
+		require.NoError(t, err)
+		assert.Equal(t, statsd.Config{Address: ":8125", FlushInterval: statsd.DefaultFlushInterval}, result)
+	})
+
+	t.Run("Should parse flush interval", func(t *testing.T) {
+		result, err := initializeStatsD(Config{StatsDAddress: ":8125", StatsDFlush: "5s"})
+
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Second, result.FlushInterval)
+	})
+
+	t.Run("Should return error for invalid flush interval", func(t *testing.T) {
+		_, err := initializeStatsD(Config{StatsDFlush: "0s"})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestInitializeGraphite(t *testing.T) {
+	t.Run("Should use default limits", func(t *testing.T) {
+		result, err := initializeGraphite(Config{GraphiteAddress: ":2003"})
+
+		require.NoError(t, err)
+		assert.Equal(t, graphite.Config{
+			Address:        ":2003",
+			MaxConnections: graphite.DefaultMaxConnections,
+			IdleTimeout:    graphite.DefaultIdleTimeout,
+		}, result)
+	})
+
+	t.Run("Should parse counter rules and limits", func(t *testing.T) {
+		result, err := initializeGraphite(Config{
+			GraphiteAddress: ":2003",
+			GraphiteRules:   "stats.counts.*,*.requests",
+			GraphiteConns:   10,
+			GraphiteIdle:    "30s",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"stats.counts.*", "*.requests"}, result.CounterRules)
+		assert.Equal(t, 10, result.MaxConnections)
+		assert.Equal(t, 30*time.Second, result.IdleTimeout)
+	})
+
+	t.Run("Should return error for invalid counter rule", func(t *testing.T) {
+		_, err := initializeGraphite(Config{GraphiteRules: "stats.[counts"})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("Should return error for invalid idle timeout", func(t *testing.T) {
+		_, err := initializeGraphite(Config{GraphiteIdle: "-1s"})
+
+		assert.Error(t, err)
 	})
 }
 
@@ -70,9 +291,42 @@ func TestRunServer(t *testing.T) {
 		healthCheckService := healthcheck.New(nil)
 
 		go func() {
-			runServer(ctx, config, metricsService, healthCheckService, nil)
+			runServer(ctx, config, metricsService, healthCheckService, alerting.New(metricsService, nil), nil, remotewrite.Config{})
 		}()
 
 		cancel()
 	})
 }
+
+func TestInitializeDatabaseConfig(t *testing.T) {
+	t.Run("Should parse database pool settings", func(t *testing.T) {
+		result, err := initializeDatabaseConfig(Config{
+			DBMaxConns:     10,
+			DBMinConns:     2,
+			DBConnLifetime: "1h",
+			DBConnIdleTime: "30m",
+			DBConnTimeout:  "5s",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, database.Config{
+			MaxConns:        10,
+			MinConns:        2,
+			MaxConnLifetime: time.Hour,
+			MaxConnIdleTime: 30 * time.Minute,
+			ConnectTimeout:  5 * time.Second,
+		}, result)
+	})
+
+	t.Run("Should return error if duration is invalid", func(t *testing.T) {
+		_, err := initializeDatabaseConfig(Config{DBConnTimeout: "soon"})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("Should return error if min connections exceed max connections", func(t *testing.T) {
+		_, err := initializeDatabaseConfig(Config{DBMaxConns: 2, DBMinConns: 5})
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/daremove/go-metrics-service/internal/storage/database"
+)
+
+// migrateCommand режим запуска сервера для управления миграциями схемы базы данных:
+// server migrate up|down|status [-d dsn] [-c config].
+const migrateCommand = "migrate"
+
+// Действия режима migrate.
+const (
+	migrateUp     = "up"
+	migrateDown   = "down"
+	migrateStatus = "status"
+)
+
+type schemaMigrator interface {
+	Up() error
+	Down() error
+	Status() (database.SchemaStatus, error)
+	Close() error
+}
+
+func openMigrator(ctx context.Context, dsn string) (schemaMigrator, error) {
+	return database.NewMigrator(ctx, dsn)
+}
+
+// parseMigrateArgs разбирает действие и строку подключения к базе данных.
+// Строка подключения берется из флага -d, переменной окружения DATABASE_DSN либо файла конфигурации.
+func parseMigrateArgs(args []string) (string, string, error) {
+	var (
+		dsn        string
+		configFile string
+	)
+
+	flags := flag.NewFlagSet(migrateCommand, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&dsn, "d", "", "data source name for database connection")
+	flags.StringVar(&configFile, "c", "cmd/server/default_config.json", "path to the configuration file")
+
+	if err := flags.Parse(args); err != nil {
+		return "", "", err
+	}
+
+	if flags.NArg() == 0 {
+		return "", "", fmt.Errorf("migrate action is required: %s, %s or %s", migrateUp, migrateDown, migrateStatus)
+	}
+
+	action := flags.Arg(0)
+
+	if err := flags.Parse(flags.Args()[1:]); err != nil {
+		return "", "", err
+	}
+
+	if flags.NArg() > 0 {
+		return "", "", fmt.Errorf("unexpected arguments %v", flags.Args())
+	}
+
+	switch action {
+	case migrateUp, migrateDown, migrateStatus:
+	default:
+		return "", "", fmt.Errorf("migrate action %q isn't supported", action)
+	}
+
+	if d := os.Getenv("DATABASE_DSN"); d != "" {
+		dsn = d
+	}
+
+	if dsn == "" && configFile != "" {
+		if fileConfig, err := loadConfigFromFile(configFile); err == nil {
+			dsn = fileConfig.Dsn
+		}
+	}
+
+	if dsn == "" {
+		return "", "", fmt.Errorf("data source name for database connection is required")
+	}
+
+	return action, dsn, nil
+}
+
+// runMigrate выполняет действие режима migrate и выводит итоговое состояние схемы.
+func runMigrate(ctx context.Context, args []string, out io.Writer, open func(ctx context.Context, dsn string) (schemaMigrator, error)) error {
+	action, dsn, err := parseMigrateArgs(args)
+
+	if err != nil {
+		return err
+	}
+
+	migrator, err := open(ctx, dsn)
+
+	if err != nil {
+		return err
+	}
+
+	defer migrator.Close()
+
+	switch action {
+	case migrateUp:
+		err = migrator.Up()
+	case migrateDown:
+		err = migrator.Down()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	status, err := migrator.Status()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(out, "version: %d, latest: %d, dirty: %t\n", status.Version, status.Latest, status.Dirty)
+
+	return err
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/daremove/go-metrics-service/internal/storage/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockMigrator struct {
+	status database.SchemaStatus
+	calls  []string
+	err    error
+}
+
+func (m *mockMigrator) Up() error {
+	m.calls = append(m.calls, migrateUp)
+	m.status.Version = m.status.Latest
+	return m.err
+}
+
+func (m *mockMigrator) Down() error {
+	m.calls = append(m.calls, migrateDown)
+	m.status.Version--
+	return m.err
+}
+
+func (m *mockMigrator) Status() (database.SchemaStatus, error) {
+	return m.status, nil
+}
+
+func (m *mockMigrator) Close() error {
+	m.calls = append(m.calls, "close")
+	return nil
+}
+
+func TestParseMigrateArgs(t *testing.T) {
+	t.Setenv("DATABASE_DSN", "")
+
+	testCases := []struct {
+		testName       string
+		args           []string
+		expectedAction string
+		expectedDsn    string
+		hasError       bool
+	}{
+		{
+			testName:       "Should parse action with flags after it",
+			args:           []string{"up", "-d", "postgres://localhost/metrics", "-c", ""},
+			expectedAction: migrateUp,
+			expectedDsn:    "postgres://localhost/metrics",
+		},
+		{
+			testName:       "Should parse action with flags before it",
+			args:           []string{"-c", "", "-d", "postgres://localhost/metrics", "status"},
+			expectedAction: migrateStatus,
+			expectedDsn:    "postgres://localhost/metrics",
+		},
+		{
+			testName: "Should return error without action",
+			args:     []string{"-d", "postgres://localhost/metrics"},
+			hasError: true,
+		},
+		{
+			testName: "Should return error for unknown action",
+			args:     []string{"force", "-d", "postgres://localhost/metrics"},
+			hasError: true,
+		},
+		{
+			testName: "Should return error without dsn",
+			args:     []string{"up", "-c", ""},
+			hasError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			action, dsn, err := parseMigrateArgs(tc.args)
+
+			if tc.hasError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedAction, action)
+			assert.Equal(t, tc.expectedDsn, dsn)
+		})
+	}
+}
+
+func TestRunMigrate(t *testing.T) {
+	t.Setenv("DATABASE_DSN", "")
+	ctx := context.Background()
+
+	t.Run("Should apply migrations and print status", func(t *testing.T) {
+		migrator := &mockMigrator{status: database.SchemaStatus{Version: 1, Latest: 3}}
+		out := &bytes.Buffer{}
+
+		err := runMigrate(ctx, []string{"up", "-d", "dsn"}, out, func(ctx context.Context, dsn string) (schemaMigrator, error) {
+			assert.Equal(t, "dsn", dsn)
+			return migrator, nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{migrateUp, "close"}, migrator.calls)
+		assert.Equal(t, "version: 3, latest: 3, dirty: false\n", out.String())
+	})
+
+	t.Run("Should roll back migration", func(t *testing.T) {
+		migrator := &mockMigrator{status: database.SchemaStatus{Version: 3, Latest: 3}}
+		out := &bytes.Buffer{}
+
+		err := runMigrate(ctx, []string{"down", "-d", "dsn"}, out, func(ctx context.Context, dsn string) (schemaMigrator, error) {
+			return migrator, nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "version: 2, latest: 3, dirty: false\n", out.String())
+	})
+
+	t.Run("Should return migration error", func(t *testing.T) {
+		migrator := &mockMigrator{status: database.SchemaStatus{Latest: 3}, err: errors.New("failed")}
+
+		err := runMigrate(ctx, []string{"up", "-d", "dsn"}, &bytes.Buffer{}, func(ctx context.Context, dsn string) (schemaMigrator, error) {
+			return migrator, nil
+		})
+
+		assert.EqualError(t, err, "failed")
+		assert.Equal(t, []string{migrateUp, "close"}, migrator.calls)
+	})
+}
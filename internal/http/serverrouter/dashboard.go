@@ -0,0 +1,437 @@
+package serverrouter
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/daremove/go-metrics-service/internal/logger"
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/services"
+	"github.com/daremove/go-metrics-service/internal/storage"
+)
+
+//go:embed templates/*.html static/*
+var dashboardFS embed.FS
+
+// Шаблоны страниц панели метрик. Каждая страница разбирается вместе с общим макетом,
+// чтобы блоки content разных страниц не переопределяли друг друга.
+var (
+	indexTemplate  = template.Must(template.ParseFS(dashboardFS, "templates/layout.html", "templates/index.html"))
+	metricTemplate = template.Must(template.ParseFS(dashboardFS, "templates/layout.html", "templates/metric.html"))
+)
+
+// Столбцы и порядок сортировки таблиц панели метрик.
+const (
+	sortByName    = "name"
+	sortByValue   = "value"
+	sortByUpdated = "updated"
+
+	orderAsc  = "asc"
+	orderDesc = "desc"
+)
+
+// defaultDashboardRefresh интервал автообновления в секундах, включаемый переключателем на странице.
+const defaultDashboardRefresh = 10
+
+// dashboardTimeLayout формат времени обновления метрик на страницах панели.
+const dashboardTimeLayout = "2006-01-02 15:04:05 MST"
+
+// dashboardRow строка таблицы метрик.
+type dashboardRow struct {
+	Key       string // Ключ метрики вместе с метками
+	Value     string // Значение метрики
+	UpdatedAt string // Время последнего обновления
+	DetailURL string // Ссылка на страницу метрики
+
+	sortValue float64
+	updatedAt time.Time
+}
+
+// dashboardSection таблица метрик одного типа.
+type dashboardSection struct {
+	Title string
+	Rows  []dashboardRow
+}
+
+// dashboardIndexPage данные главной страницы панели метрик.
+type dashboardIndexPage struct {
+	Title      string
+	Search     string
+	Sort       string
+	Order      string
+	Refresh    int
+	Matchers   []string
+	RefreshURL string
+	SortURLs   map[string]string
+	Sections   []dashboardSection
+}
+
+// dashboardBucket строка таблицы корзин гистограммы.
+type dashboardBucket struct {
+	Bound string
+	Count uint64
+}
+
+// dashboardLabel метка метрики.
+type dashboardLabel struct {
+	Name  string
+	Value string
+}
+
+// dashboardMetricPage данные страницы метрики.
+type dashboardMetricPage struct {
+	Title     string
+	Refresh   int
+	Metric    models.Metrics
+	Labels    []dashboardLabel
+	Value     string
+	UpdatedAt string
+	Buckets   []dashboardBucket
+}
+
+// dashboardQuery параметры главной страницы панели метрик.
+type dashboardQuery struct {
+	search   string
+	sort     string
+	order    string
+	refresh  int
+	matchers []string
+}
+
+func parseDashboardQuery(r *http.Request) (dashboardQuery, error) {
+	query := r.URL.Query()
+	result := dashboardQuery{
+		search:   strings.TrimSpace(query.Get("q")),
+		sort:     sortByName,
+		order:    orderAsc,
+		matchers: query["match"],
+	}
+
+	switch column := query.Get("sort"); column {
+	case "":
+	case sortByName, sortByValue, sortByUpdated:
+		result.sort = column
+	default:
+		return dashboardQuery{}, errors.New("parameter sort must be one of name, value, updated")
+	}
+
+	switch order := query.Get("order"); order {
+	case "":
+	case orderAsc, orderDesc:
+		result.order = order
+	default:
+		return dashboardQuery{}, errors.New("parameter order must be asc or desc")
+	}
+
+	if refresh := query.Get("refresh"); refresh != "" {
+		v, err := strconv.Atoi(refresh)
+
+		if err != nil || v < 0 {
+			return dashboardQuery{}, errors.New("parameter refresh must be a non-negative integer")
+		}
+
+		result.refresh = v
+	}
+
+	return result, nil
+}
+
+// url формирует ссылку на главную страницу с текущими параметрами, измененными функцией update.
+func (q dashboardQuery) url(update func(values url.Values)) string {
+	values := url.Values{}
+
+	if q.search != "" {
+		values.Set("q", q.search)
+	}
+
+	values.Set("sort", q.sort)
+	values.Set("order", q.order)
+
+	if q.refresh > 0 {
+		values.Set("refresh", strconv.Itoa(q.refresh))
+	}
+
+	for _, matcher := range q.matchers {
+		values.Add("match", matcher)
+	}
+
+	update(values)
+
+	return "/?" + values.Encode()
+}
+
+func (q dashboardQuery) sortURLs() map[string]string {
+	result := make(map[string]string, 3)
+
+	for _, column := range []string{sortByName, sortByValue, sortByUpdated} {
+		order := orderAsc
+
+		if column == q.sort && q.order == orderAsc {
+			order = orderDesc
+		}
+
+		result[column] = q.url(func(values url.Values) {
+			values.Set("sort", column)
+			values.Set("order", order)
+		})
+	}
+
+	return result
+}
+
+func (q dashboardQuery) refreshURL() string {
+	return q.url(func(values url.Values) {
+		if q.refresh > 0 {
+			values.Del("refresh")
+		} else {
+			values.Set("refresh", strconv.Itoa(defaultDashboardRefresh))
+		}
+	})
+}
+
+// metricDetailURL формирует ссылку на страницу метрики.
+func metricDetailURL(metric models.Metrics) string {
+	values := url.Values{}
+	values.Set("type", metric.MType)
+	values.Set("key", storage.SeriesKey(metric.ID, metric.Labels))
+
+	return "/dashboard/metric?" + values.Encode()
+}
+
+func formatUpdatedAt(updatedAt *time.Time) string {
+	if updatedAt == nil {
+		return "—"
+	}
+
+	return updatedAt.UTC().Format(dashboardTimeLayout)
+}
+
+// formatMetricValue возвращает значение метрики и число, по которому сортируется таблица.
+func formatMetricValue(metric models.Metrics) (string, float64) {
+	switch {
+	case metric.Value != nil:
+		return strconv.FormatFloat(*metric.Value, 'g', -1, 64), *metric.Value
+	case metric.Delta != nil:
+		return strconv.FormatInt(*metric.Delta, 10), float64(*metric.Delta)
+	case metric.Histogram != nil:
+		return "count=" + strconv.FormatUint(metric.Histogram.Count, 10) + " sum=" + strconv.FormatFloat(metric.Histogram.Sum, 'g', -1, 64),
+			float64(metric.Histogram.Count)
+	default:
+		return "", 0
+	}
+}
+
+func newDashboardRow(metric models.Metrics) dashboardRow {
+	value, sortValue := formatMetricValue(metric)
+	row := dashboardRow{
+		Key:       storage.SeriesKey(metric.ID, metric.Labels),
+		Value:     value,
+		UpdatedAt: formatUpdatedAt(metric.UpdatedAt),
+		DetailURL: metricDetailURL(metric),
+		sortValue: sortValue,
+	}
+
+	if metric.UpdatedAt != nil {
+		row.updatedAt = *metric.UpdatedAt
+	}
+
+	return row
+}
+
+func sortDashboardRows(rows []dashboardRow, column, order string) {
+	less := func(a, b dashboardRow) bool {
+		switch column {
+		case sortByValue:
+			if a.sortValue != b.sortValue {
+				return a.sortValue < b.sortValue
+			}
+		case sortByUpdated:
+			if !a.updatedAt.Equal(b.updatedAt) {
+				return a.updatedAt.Before(b.updatedAt)
+			}
+		}
+
+		return a.Key < b.Key
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if order == orderDesc {
+			return less(rows[j], rows[i])
+		}
+
+		return less(rows[i], rows[j])
+	})
+}
+
+// newDashboardSections раскладывает метрики по таблицам типов, оставляя подходящие под строку поиска.
+func newDashboardSections(metrics []models.Metrics, query dashboardQuery) []dashboardSection {
+	sections := []dashboardSection{{Title: "Gauges"}, {Title: "Counters"}, {Title: "Histograms"}}
+	search := strings.ToLower(query.search)
+
+	for _, metric := range metrics {
+		row := newDashboardRow(metric)
+
+		if search != "" && !strings.Contains(strings.ToLower(row.Key), search) {
+			continue
+		}
+
+		switch metric.MType {
+		case models.GaugeMetricType:
+			sections[0].Rows = append(sections[0].Rows, row)
+		case models.CounterMetricType:
+			sections[1].Rows = append(sections[1].Rows, row)
+		case models.HistogramMetricType:
+			sections[2].Rows = append(sections[2].Rows, row)
+		}
+	}
+
+	for i := range sections {
+		sortDashboardRows(sections[i].Rows, query.sort, query.order)
+	}
+
+	if len(sections[2].Rows) == 0 {
+		sections = sections[:2]
+	}
+
+	return sections
+}
+
+// renderTemplate выполняет шаблон в буфер, чтобы ошибка выполнения не оставила клиенту половину страницы.
+func renderTemplate(w http.ResponseWriter, tmpl *template.Template, data interface{}) {
+	var buf bytes.Buffer
+
+	if err := tmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+		logger.Log.Error("failed to render template", zap.Error(err))
+		http.Error(w, "failed to render page", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	if _, err := buf.WriteTo(w); err != nil {
+		logger.Log.Error("failed to write data", zap.Error(err))
+	}
+}
+
+func dashboardHandler(ctx context.Context, metricsService MetricsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query, err := parseDashboardQuery(r)
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		matchers, err := parseLabelMatchers(r)
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		metricData, err := metricsService.GetAllModels(ctx, matchers)
+
+		if err != nil {
+			logger.Log.Error("error get all metric data", zap.Error(err))
+			handleServiceError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		renderTemplate(w, indexTemplate, dashboardIndexPage{
+			Title:      "All metrics",
+			Search:     query.search,
+			Sort:       query.sort,
+			Order:      query.order,
+			Refresh:    query.refresh,
+			Matchers:   query.matchers,
+			RefreshURL: query.refreshURL(),
+			SortURLs:   query.sortURLs(),
+			Sections:   newDashboardSections(metricData, query),
+		})
+	}
+}
+
+func dashboardMetricHandler(ctx context.Context, metricsService MetricsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query, err := parseDashboardQuery(r)
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		name, labels, err := storage.ParseSeriesKey(r.URL.Query().Get("key"))
+
+		if err != nil || name == "" {
+			http.Error(w, "parameter key is invalid", http.StatusBadRequest)
+			return
+		}
+
+		metric, err := metricsService.GetModel(ctx, models.Metrics{ID: name, MType: r.URL.Query().Get("type"), Labels: labels})
+
+		if err != nil {
+			if errors.Is(err, services.ErrMetricNotFound) {
+				http.Error(w, "Metric value with such parameters wasn't found", http.StatusNotFound)
+				return
+			}
+
+			logger.Log.Error("error get metric data", zap.Error(err))
+			handleServiceError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		value, _ := formatMetricValue(metric)
+		page := dashboardMetricPage{
+			Title:     storage.SeriesKey(metric.ID, metric.Labels),
+			Refresh:   query.refresh,
+			Metric:    metric,
+			Value:     value,
+			UpdatedAt: formatUpdatedAt(metric.UpdatedAt),
+		}
+
+		for labelName, labelValue := range metric.Labels {
+			page.Labels = append(page.Labels, dashboardLabel{Name: labelName, Value: labelValue})
+		}
+
+		sort.Slice(page.Labels, func(i, j int) bool {
+			return page.Labels[i].Name < page.Labels[j].Name
+		})
+
+		if metric.Histogram != nil {
+			for i, count := range metric.Histogram.Counts {
+				bound := "+Inf"
+
+				if i < len(metric.Histogram.Bounds) {
+					bound = strconv.FormatFloat(metric.Histogram.Bounds[i], 'g', -1, 64)
+				}
+
+				page.Buckets = append(page.Buckets, dashboardBucket{Bound: bound, Count: count})
+			}
+		}
+
+		renderTemplate(w, metricTemplate, page)
+	}
+}
+
+// dashboardStaticHandler отдает встроенные в бинарный файл стили панели метрик.
+func dashboardStaticHandler() http.Handler {
+	static, err := fs.Sub(dashboardFS, "static")
+
+	if err != nil {
+		panic(err)
+	}
+
+	return http.StripPrefix("/dashboard/static/", http.FileServer(http.FS(static)))
+}
@@ -0,0 +1,141 @@
+package serverrouter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/storage"
+	"github.com/daremove/go-metrics-service/internal/utils"
+)
+
+func TestServerRouterDashboard(t *testing.T) {
+	var (
+		loadMock     = 1.5
+		tempMock     = 60.0
+		scriptMock   = 2.0
+		requestsMock = int64(3)
+		updatedAt    = time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	)
+
+	testServer := httptest.NewServer(
+		New(metricsServiceMock{
+			modelData: map[string]models.Metrics{
+				"load":     {ID: "load", MType: models.GaugeMetricType, Value: &loadMock, Labels: map[string]string{"host": "a"}, UpdatedAt: &updatedAt},
+				"temp":     {ID: "temp", MType: models.GaugeMetricType, Value: &tempMock},
+				"<script>": {ID: "<script>", MType: models.GaugeMetricType, Value: &scriptMock},
+				"requests": {ID: "requests", MType: models.CounterMetricType, Delta: &requestsMock},
+				"latency": {
+					ID:        "latency",
+					MType:     models.HistogramMetricType,
+					Histogram: &models.Histogram{Bounds: []float64{0.5}, Counts: []uint64{1, 2}, Sum: 2.5, Count: 3},
+				},
+			},
+		}, healthCheckServiceMock{}, alertsServiceMock{}, RouterConfig{}).Get(context.TODO()),
+	)
+	defer testServer.Close()
+
+	t.Run("Should render gauge and counter tables with escaped names", func(t *testing.T) {
+		res, mes := utils.TestRequest(t, testServer, http.MethodGet, "/", nil, nil)
+		res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "text/html; charset=utf-8", res.Header.Get("Content-Type"))
+		assert.Contains(t, mes, "<h2>Gauges <span class=\"count\">3</span></h2>")
+		assert.Contains(t, mes, "<h2>Counters <span class=\"count\">1</span></h2>")
+		assert.Contains(t, mes, "<h2>Histograms <span class=\"count\">1</span></h2>")
+		assert.Contains(t, mes, "&lt;script&gt;")
+		assert.NotContains(t, mes, "<script>")
+		assert.Contains(t, mes, "2024-01-01 10:00:00 UTC")
+		assert.Contains(t, mes, "Auto-refresh: off")
+	})
+
+	t.Run("Should sort rows by value", func(t *testing.T) {
+		res, mes := utils.TestRequest(t, testServer, http.MethodGet, "/?sort=value&order=desc", nil, nil)
+		res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Less(t, strings.Index(mes, ">temp<"), strings.Index(mes, ">&lt;script&gt;<"))
+		assert.Less(t, strings.Index(mes, ">&lt;script&gt;<"), strings.Index(mes, `>load{host=&#34;a&#34;}<`))
+	})
+
+	t.Run("Should filter rows by search string and enable auto refresh", func(t *testing.T) {
+		res, mes := utils.TestRequest(t, testServer, http.MethodGet, "/?q=LOA&refresh=5", nil, nil)
+		res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Contains(t, mes, `<meta http-equiv="refresh" content="5">`)
+		assert.Contains(t, mes, "Auto-refresh: on")
+		assert.Contains(t, mes, "<h2>Gauges <span class=\"count\">1</span></h2>")
+		assert.NotContains(t, mes, ">temp<")
+	})
+
+	t.Run("Should render metric detail page", func(t *testing.T) {
+		res, mes := utils.TestRequest(t, testServer, http.MethodGet, "/dashboard/metric?type=gauge&key="+url.QueryEscape(`load{host="a"}`), nil, nil)
+		res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Contains(t, mes, "<dt>host</dt><dd>a</dd>")
+		assert.Contains(t, mes, `<dd class="value">1.5</dd>`)
+		assert.Contains(t, mes, "<dd>2024-01-01 10:00:00 UTC</dd>")
+	})
+
+	t.Run("Should render histogram buckets on detail page", func(t *testing.T) {
+		res, mes := utils.TestRequest(t, testServer, http.MethodGet, "/dashboard/metric?type=histogram&key=latency", nil, nil)
+		res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Contains(t, mes, `<tr><td>0.5</td><td class="value">1</td></tr>`)
+		assert.Contains(t, mes, `<tr><td>&#43;Inf</td><td class="value">2</td></tr>`)
+	})
+
+	t.Run("Should serve embedded stylesheet", func(t *testing.T) {
+		res, mes := utils.TestRequest(t, testServer, http.MethodGet, "/dashboard/static/dashboard.css", nil, nil)
+		res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Contains(t, mes, "border-collapse")
+	})
+
+	testCases := []struct {
+		testName     string
+		targetURL    string
+		expectedCode int
+	}{
+		{testName: "Should return 404 if metric wasn't found", targetURL: "/dashboard/metric?type=gauge&key=unknown", expectedCode: http.StatusNotFound},
+		{testName: "Should return 400 if metric key is invalid", targetURL: "/dashboard/metric?type=gauge&key=" + url.QueryEscape("load{host}"), expectedCode: http.StatusBadRequest},
+		{testName: "Should return 400 if sort column is unknown", targetURL: "/?sort=size", expectedCode: http.StatusBadRequest},
+		{testName: "Should return 400 if label matcher is invalid", targetURL: "/?match=host", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			res, _ := utils.TestRequest(t, testServer, http.MethodGet, tc.targetURL, nil, nil)
+			res.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, res.StatusCode)
+		})
+	}
+
+	t.Run("Should return service unavailable when storage is down", func(t *testing.T) {
+		unavailableServer := httptest.NewServer(
+			New(metricsServiceMock{
+				saveErr: &storage.UnavailableError{Err: errors.New("connection refused"), RetryAfter: time.Second},
+			}, healthCheckServiceMock{}, alertsServiceMock{}, RouterConfig{}).Get(context.TODO()),
+		)
+		defer unavailableServer.Close()
+
+		res, _ := utils.TestRequest(t, unavailableServer, http.MethodGet, "/", nil, nil)
+		res.Body.Close()
+
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	})
+}
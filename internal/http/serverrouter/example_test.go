@@ -24,7 +24,7 @@ func Example() {
 	metricsService := metrics.New(storage)
 	healthCheckService := healthcheck.New(storage)
 
-	router.Get("/", getAllMetricsHandler(ctx, metricsService))
+	router.Get("/", dashboardHandler(ctx, metricsService))
 
 	router.Post("/update/{metricType}/{metricName}/{metricValue}", updateMetricHandler(ctx, metricsService))
 	router.Post("/update", updateMetricWithJSONHandler(ctx, metricsService))
@@ -13,9 +13,12 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
-	"sort"
-	"strings"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/daremove/go-metrics-service/internal/middlewares/profiler"
 
@@ -23,7 +26,13 @@ import (
 	"github.com/daremove/go-metrics-service/internal/middlewares/dataintergity"
 	"github.com/daremove/go-metrics-service/internal/middlewares/gzipm"
 	"github.com/daremove/go-metrics-service/internal/models"
+	otlppb "github.com/daremove/go-metrics-service/internal/proto/otlp"
 	"github.com/daremove/go-metrics-service/internal/services"
+	"github.com/daremove/go-metrics-service/internal/services/exposition"
+	"github.com/daremove/go-metrics-service/internal/services/influx"
+	"github.com/daremove/go-metrics-service/internal/services/otlp"
+	"github.com/daremove/go-metrics-service/internal/services/remotewrite"
+	"github.com/daremove/go-metrics-service/internal/storage"
 	"github.com/daremove/go-metrics-service/internal/utils"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -32,27 +41,38 @@ import (
 
 // RouterConfig содержит конфигурацию для маршрутизатора сервера.
 type RouterConfig struct {
-	Endpoint      string          // URL-адрес конечной точки сервера
-	SigningKey    string          // Ключ для подписи данных
-	PrivateKey    *rsa.PrivateKey // Приватный ключ для дешифрования данных
-	TrustedSubnet string          // Доверенная подсеть
+	Endpoint      string             // URL-адрес конечной точки сервера
+	SigningKey    string             // Ключ для подписи данных
+	PrivateKey    *rsa.PrivateKey    // Приватный ключ для дешифрования данных
+	TrustedSubnet string             // Доверенная подсеть
+	RemoteWrite   remotewrite.Config // Настройки приема данных Prometheus remote_write
+	Influx        influx.Config      // Настройки приема данных в формате протокола InfluxDB
 }
 
 // ServerRouter предоставляет маршрутизацию запросов к сервисам метрик и проверки состояния.
 type ServerRouter struct {
 	metricsService     MetricsService     // Сервис для работы с метриками
 	healthCheckService HealthCheckService // Сервис для проверки состояния
+	alertsService      AlertsService      // Сервис алертинга
 	config             RouterConfig       // Конфигурация маршрутизатора
+	done               chan struct{}      // Закрывается при остановке сервера, чтобы завершить потоки событий
+	closeOnce          sync.Once
 }
 
 // MetricsService определяет интерфейс для сервиса метрик.
 type MetricsService interface {
-	Save(ctx context.Context, parameters services.MetricSaveParameters) error         // Сохраняет метрику
-	SaveModel(ctx context.Context, parameters models.Metrics) error                   // Сохраняет модель метрик
-	SaveModels(ctx context.Context, parameters []models.Metrics) error                // Сохраняет несколько моделей метрик
-	Get(ctx context.Context, parameters services.MetricGetParameters) (string, error) // Получает значение метрики
-	GetModel(ctx context.Context, parameters models.Metrics) (models.Metrics, error)  // Получает модель метрики
-	GetAll(ctx context.Context) ([]services.MetricEntry, error)                       // Получает все метрики
+	Save(ctx context.Context, parameters services.MetricSaveParameters) error                                  // Сохраняет метрику
+	SaveModel(ctx context.Context, parameters models.Metrics) error                                            // Сохраняет модель метрик
+	SaveModels(ctx context.Context, parameters []models.Metrics) error                                         // Сохраняет несколько моделей метрик
+	Get(ctx context.Context, parameters services.MetricGetParameters) (string, error)                          // Получает значение метрики
+	GetModel(ctx context.Context, parameters models.Metrics) (models.Metrics, error)                           // Получает модель метрики
+	GetAllModels(ctx context.Context, matchers []services.LabelMatcher) ([]models.Metrics, error)              // Получает все метрики в виде моделей
+	List(ctx context.Context, parameters services.MetricListParameters) (models.MetricList, error)             // Получает страницу метрик
+	GetHistory(ctx context.Context, parameters services.MetricHistoryParameters) (models.MetricHistory, error) // Получает историю значений метрики
+	Delete(ctx context.Context, parameters services.MetricGetParameters) error                                 // Удаляет метрику
+	DeleteByPrefix(ctx context.Context, prefix string) (int, error)                                            // Удаляет метрики по префиксу имени
+	ResetCounter(ctx context.Context, parameters services.MetricGetParameters) error                           // Обнуляет счетчик
+	Subscribe(parameters services.MetricStreamParameters) (<-chan models.MetricEvent, func())                  // Подписывает на события изменения метрик
 }
 
 // HealthCheckService определяет интерфейс для сервиса проверки состояния.
@@ -60,9 +80,28 @@ type HealthCheckService interface {
 	CheckStorageConnection(ctx context.Context) error // Проверяет соединение с хранилищем
 }
 
+// AlertsService определяет интерфейс для сервиса алертинга.
+type AlertsService interface {
+	Alerts(states ...string) []models.Alert // Возвращает алерты в указанных состояниях, по умолчанию активные
+}
+
 // New создает новый экземпляр ServerRouter.
-func New(metricsService MetricsService, healthCheckService HealthCheckService, config RouterConfig) *ServerRouter {
-	return &ServerRouter{metricsService, healthCheckService, config}
+func New(metricsService MetricsService, healthCheckService HealthCheckService, alertsService AlertsService, config RouterConfig) *ServerRouter {
+	return &ServerRouter{
+		metricsService:     metricsService,
+		healthCheckService: healthCheckService,
+		alertsService:      alertsService,
+		config:             config,
+		done:               make(chan struct{}),
+	}
+}
+
+// CloseStreams завершает открытые потоки событий /stream, чтобы остановка сервера не ожидала их бесконечно.
+// Новые подключения к потоку после этого сразу завершаются. Метод подходит для http.Server.RegisterOnShutdown.
+func (router *ServerRouter) CloseStreams() {
+	router.closeOnce.Do(func() {
+		close(router.done)
+	})
 }
 
 // Get инициализирует и возвращает маршрутизатор с предварительно сконфигурированными маршрутами.
@@ -79,7 +118,25 @@ func (router *ServerRouter) Get(ctx context.Context) chi.Router {
 	r.Mount("/debug", profiler.Profiler())
 
 	r.Route("/", func(r chi.Router) {
-		r.Get("/", getAllMetricsHandler(ctx, router.metricsService))
+		r.Get("/", dashboardHandler(ctx, router.metricsService))
+		r.Get("/dashboard/metric", dashboardMetricHandler(ctx, router.metricsService))
+		r.Handle("/dashboard/static/*", dashboardStaticHandler())
+		r.Get("/metrics", metricsExpositionHandler(ctx, router.metricsService))
+		r.Get("/stream", streamMetricsHandler(ctx, router.metricsService, router.done))
+
+		r.Route("/api/v1", func(r chi.Router) {
+			r.Get("/metrics", listMetricsHandler(ctx, router.metricsService))
+			r.Get("/alerts", listAlertsHandler(router.alertsService))
+			r.Post("/write", remoteWriteHandler(ctx, router.metricsService, remotewrite.New(router.config.RemoteWrite)))
+		})
+
+		r.Route("/v1", func(r chi.Router) {
+			r.Post("/metrics", otlpMetricsHandler(ctx, router.metricsService, otlp.New()))
+		})
+
+		r.Route("/api/v2", func(r chi.Router) {
+			r.Post("/write", influxWriteHandler(ctx, router.metricsService, influx.New(router.config.Influx)))
+		})
 
 		r.Route("/update", func(r chi.Router) {
 			r.Route("/{metricType}", func(r chi.Router) {
@@ -110,8 +167,24 @@ func (router *ServerRouter) Get(ctx context.Context) chi.Router {
 
 		r.Route("/value", func(r chi.Router) {
 			r.Get("/{metricType}/{metricName}", getMetricValueHandler(ctx, router.metricsService))
+			r.Delete("/{metricType}/{metricName}",
+				utils.VerifyIPMiddleware(router.config.TrustedSubnet)(deleteMetricHandler(ctx, router.metricsService)),
+			)
 
 			r.Post("/", getMetricValueWithJSONHandler(ctx, router.metricsService))
+			r.Delete("/",
+				utils.VerifyIPMiddleware(router.config.TrustedSubnet)(deleteMetricsByPrefixHandler(ctx, router.metricsService)),
+			)
+		})
+
+		r.Route("/reset", func(r chi.Router) {
+			r.Post("/{metricType}/{metricName}",
+				utils.VerifyIPMiddleware(router.config.TrustedSubnet)(resetMetricHandler(ctx, router.metricsService)),
+			)
+		})
+
+		r.Route("/history", func(r chi.Router) {
+			r.Get("/{metricType}/{metricName}", getMetricHistoryHandler(ctx, router.metricsService))
 		})
 
 		r.Route("/ping", func(r chi.Router) {
@@ -137,6 +210,20 @@ func handleJSONError(w http.ResponseWriter, err error) {
 	}
 }
 
+// handleServiceError отправляет ответ с ошибкой сервиса метрик. Если хранилище временно недоступно,
+// возвращается статус 503 с заголовком Retry-After, иначе — переданный статус.
+func handleServiceError(w http.ResponseWriter, err error, status int) {
+	var unavailable *storage.UnavailableError
+
+	if errors.As(err, &unavailable) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(unavailable.RetryAfter.Seconds()))))
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	http.Error(w, err.Error(), status)
+}
+
 func updateMetricHandler(ctx context.Context, metricsService MetricsService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if err := metricsService.Save(ctx, services.MetricSaveParameters{
@@ -144,7 +231,7 @@ func updateMetricHandler(ctx context.Context, metricsService MetricsService) htt
 			MetricName:  chi.URLParam(r, "metricName"),
 			MetricValue: chi.URLParam(r, "metricValue"),
 		}); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			handleServiceError(w, err, http.StatusBadRequest)
 			return
 		}
 
@@ -163,7 +250,7 @@ func updateMetricWithJSONHandler(ctx context.Context, metricsService MetricsServ
 
 		if err := metricsService.SaveModel(ctx, data); err != nil {
 			logger.Log.Error("error saving data in metrics service", zap.Error(err))
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			handleServiceError(w, err, http.StatusBadRequest)
 			return
 		}
 
@@ -186,7 +273,7 @@ func updateMetricsHandler(ctx context.Context, metricsService MetricsService) ht
 
 		if err := metricsService.SaveModels(ctx, data); err != nil {
 			logger.Log.Error("error saving data in metrics service", zap.Error(err))
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			handleServiceError(w, err, http.StatusBadRequest)
 			return
 		}
 
@@ -198,6 +285,136 @@ func updateMetricsHandler(ctx context.Context, metricsService MetricsService) ht
 	}
 }
 
+// maxIngestBodySize максимальный размер тела запроса с данными внешних форматов.
+const maxIngestBodySize = 10 << 20
+
+// readIngestBody читает тело запроса не больше maxIngestBodySize байт. При ошибке отвечает клиенту
+// кодом 413, если тело превышает ограничение, или 400 в остальных случаях.
+func readIngestBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIngestBodySize))
+
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return nil, false
+		}
+
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	return body, true
+}
+
+// remoteWriteHandler принимает данные Prometheus remote_write: сжатый snappy protobuf WriteRequest.
+func remoteWriteHandler(ctx context.Context, metricsService MetricsService, receiver *remotewrite.Receiver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, ok := readIngestBody(w, r)
+
+		if !ok {
+			return
+		}
+
+		request, err := remotewrite.Decode(body)
+
+		if errors.Is(err, remotewrite.ErrRequestTooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := receiver.Write(ctx, metricsService, request); err != nil {
+			logger.Log.Error("error saving remote write data in metrics service", zap.Error(err))
+			handleServiceError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// otlpMetricsHandler принимает метрики OpenTelemetry в формате protobuf или JSON
+// и отвечает пустым ExportMetricsServiceResponse в том же формате.
+func otlpMetricsHandler(ctx context.Context, metricsService MetricsService, receiver *otlp.Receiver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		contentType := r.Header.Get("Content-Type")
+		body, ok := readIngestBody(w, r)
+
+		if !ok {
+			return
+		}
+
+		request, err := otlp.Decode(body, contentType)
+
+		if errors.Is(err, otlp.ErrUnsupportedContentType) {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := receiver.Write(ctx, metricsService, request); err != nil {
+			logger.Log.Error("error saving otlp data in metrics service", zap.Error(err))
+			handleServiceError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		response, responseContentType, err := otlp.Encode(&otlppb.ExportMetricsServiceResponse{}, contentType)
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", responseContentType)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(response)
+	}
+}
+
+// influxWriteHandler принимает данные в формате протокола InfluxDB. Корректные строки сохраняются,
+// даже если в теле запроса есть некорректные; ошибки разбора возвращаются для каждой строки.
+func influxWriteHandler(ctx context.Context, metricsService MetricsService, receiver *influx.Receiver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		precision, err := influx.ParsePrecision(r.URL.Query().Get("precision"))
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		body, ok := readIngestBody(w, r)
+
+		if !ok {
+			return
+		}
+
+		points, parseErrors := influx.Parse(body, precision, time.Now())
+
+		if err := receiver.Write(ctx, metricsService, points); err != nil {
+			logger.Log.Error("error saving influx data in metrics service", zap.Error(err))
+			handleServiceError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		if len(parseErrors) > 0 {
+			http.Error(w, errors.Join(parseErrors...).Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
 func pingHandler(ctx context.Context, healthCheckService HealthCheckService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if err := healthCheckService.CheckStorageConnection(ctx); err != nil {
@@ -211,11 +428,37 @@ func pingHandler(ctx context.Context, healthCheckService HealthCheckService) htt
 
 }
 
+// parseLabelMatchers разбирает условия по меткам из параметров запроса match, например match=host="a".
+func parseLabelMatchers(r *http.Request) ([]services.LabelMatcher, error) {
+	expressions := r.URL.Query()["match"]
+	matchers := make([]services.LabelMatcher, 0, len(expressions))
+
+	for _, expression := range expressions {
+		matcher, err := services.ParseLabelMatcher(expression)
+
+		if err != nil {
+			return nil, err
+		}
+
+		matchers = append(matchers, matcher)
+	}
+
+	return matchers, nil
+}
+
 func getMetricValueHandler(ctx context.Context, metricsService MetricsService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		matchers, err := parseLabelMatchers(r)
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		value, err := metricsService.Get(ctx, services.MetricGetParameters{
 			MetricType: chi.URLParam(r, "metricType"),
 			MetricName: chi.URLParam(r, "metricName"),
+			Matchers:   matchers,
 		})
 
 		if err != nil {
@@ -225,7 +468,7 @@ func getMetricValueHandler(ctx context.Context, metricsService MetricsService) h
 			}
 
 			logger.Log.Error("error get metric data", zap.Error(err))
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			handleServiceError(w, err, http.StatusBadRequest)
 			return
 		}
 
@@ -235,6 +478,85 @@ func getMetricValueHandler(ctx context.Context, metricsService MetricsService) h
 	}
 }
 
+func deleteMetricHandler(ctx context.Context, metricsService MetricsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		matchers, err := parseLabelMatchers(r)
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := metricsService.Delete(ctx, services.MetricGetParameters{
+			MetricType: chi.URLParam(r, "metricType"),
+			MetricName: chi.URLParam(r, "metricName"),
+			Matchers:   matchers,
+		}); err != nil {
+			if errors.Is(err, services.ErrMetricNotFound) {
+				http.Error(w, "Metric value with such parameters wasn't found", http.StatusNotFound)
+				return
+			}
+
+			logger.Log.Error("error delete metric data", zap.Error(err))
+			handleServiceError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// DeleteMetricsResult описывает результат удаления метрик по префиксу.
+type DeleteMetricsResult struct {
+	Deleted int `json:"deleted"` // Количество удаленных метрик
+}
+
+func deleteMetricsByPrefixHandler(ctx context.Context, metricsService MetricsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		deleted, err := metricsService.DeleteByPrefix(ctx, r.URL.Query().Get("prefix"))
+
+		if err != nil {
+			logger.Log.Error("error delete metric data by prefix", zap.Error(err))
+			handleServiceError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		if err := utils.EncodeJSONRequest[DeleteMetricsResult](w, DeleteMetricsResult{Deleted: deleted}); err != nil {
+			logger.Log.Error("error encoding response", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+}
+
+func resetMetricHandler(ctx context.Context, metricsService MetricsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		matchers, err := parseLabelMatchers(r)
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := metricsService.ResetCounter(ctx, services.MetricGetParameters{
+			MetricType: chi.URLParam(r, "metricType"),
+			MetricName: chi.URLParam(r, "metricName"),
+			Matchers:   matchers,
+		}); err != nil {
+			if errors.Is(err, services.ErrMetricNotFound) {
+				http.Error(w, "Metric value with such parameters wasn't found", http.StatusNotFound)
+				return
+			}
+
+			logger.Log.Error("error reset metric data", zap.Error(err))
+			handleServiceError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
 func getMetricValueWithJSONHandler(ctx context.Context, metricsService MetricsService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		data, err := utils.DecodeJSONRequest[models.Metrics](r)
@@ -253,7 +575,7 @@ func getMetricValueWithJSONHandler(ctx context.Context, metricsService MetricsSe
 			}
 
 			logger.Log.Error("error get model metric data", zap.Error(err))
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			handleServiceError(w, err, http.StatusBadRequest)
 			return
 		}
 
@@ -265,27 +587,332 @@ func getMetricValueWithJSONHandler(ctx context.Context, metricsService MetricsSe
 	}
 }
 
-func getAllMetricsHandler(ctx context.Context, metricsService MetricsService) http.HandlerFunc {
+// defaultHistoryPeriod период истории, возвращаемый при отсутствии параметра from.
+const defaultHistoryPeriod = time.Hour
+
+// parseHistoryTime разбирает время в формате RFC3339 либо в виде Unix timestamp в секундах.
+func parseHistoryTime(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+func parseMetricHistoryParameters(r *http.Request) (services.MetricHistoryParameters, error) {
+	query := r.URL.Query()
+	parameters := services.MetricHistoryParameters{
+		MetricType: chi.URLParam(r, "metricType"),
+		MetricName: chi.URLParam(r, "metricName"),
+		To:         time.Now().UTC(),
+	}
+
+	if to := query.Get("to"); to != "" {
+		v, err := parseHistoryTime(to)
+
+		if err != nil {
+			return services.MetricHistoryParameters{}, fmt.Errorf("parameter to is invalid: %w", err)
+		}
+
+		parameters.To = v
+	}
+
+	parameters.From = parameters.To.Add(-defaultHistoryPeriod)
+
+	if from := query.Get("from"); from != "" {
+		v, err := parseHistoryTime(from)
+
+		if err != nil {
+			return services.MetricHistoryParameters{}, fmt.Errorf("parameter from is invalid: %w", err)
+		}
+
+		parameters.From = v
+	}
+
+	if step := query.Get("step"); step != "" {
+		v, err := time.ParseDuration(step)
+
+		if err != nil {
+			return services.MetricHistoryParameters{}, fmt.Errorf("parameter step is invalid: %w", err)
+		}
+
+		parameters.Step = v
+	}
+
+	return parameters, nil
+}
+
+// parseMetricListParameters разбирает параметры списка метрик: type, prefix, regex, match, limit и cursor.
+func parseMetricListParameters(r *http.Request) (services.MetricListParameters, error) {
+	query := r.URL.Query()
+	parameters := services.MetricListParameters{
+		Prefix: query.Get("prefix"),
+		Cursor: query.Get("cursor"),
+	}
+
+	types, err := parseMetricTypes(r)
+
+	if err != nil {
+		return services.MetricListParameters{}, err
+	}
+
+	parameters.Types = types
+
+	pattern, err := parseNamePattern(r)
+
+	if err != nil {
+		return services.MetricListParameters{}, err
+	}
+
+	parameters.Pattern = pattern
+
+	if limit := query.Get("limit"); limit != "" {
+		v, err := strconv.Atoi(limit)
+
+		if err != nil || v <= 0 {
+			return services.MetricListParameters{}, fmt.Errorf("parameter limit must be a positive integer")
+		}
+
+		parameters.Limit = v
+	}
+
+	matchers, err := parseLabelMatchers(r)
+
+	if err != nil {
+		return services.MetricListParameters{}, err
+	}
+
+	parameters.Matchers = matchers
+
+	return parameters, nil
+}
+
+// parseMetricTypes разбирает повторяющийся параметр запроса type.
+func parseMetricTypes(r *http.Request) ([]string, error) {
+	var types []string
+
+	for _, metricType := range r.URL.Query()["type"] {
+		switch metricType {
+		case models.GaugeMetricType, models.CounterMetricType, models.HistogramMetricType:
+			types = append(types, metricType)
+		default:
+			return nil, fmt.Errorf("parameter type %q isn't supported", metricType)
+		}
+	}
+
+	return types, nil
+}
+
+// parseNamePattern разбирает параметр запроса regex с регулярным выражением для имени метрики.
+func parseNamePattern(r *http.Request) (*regexp.Regexp, error) {
+	regex := r.URL.Query().Get("regex")
+
+	if regex == "" {
+		return nil, nil
+	}
+
+	pattern, err := regexp.Compile(regex)
+
+	if err != nil {
+		return nil, fmt.Errorf("parameter regex is invalid: %w", err)
+	}
+
+	return pattern, nil
+}
+
+func listMetricsHandler(ctx context.Context, metricsService MetricsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		parameters, err := parseMetricListParameters(r)
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		list, err := metricsService.List(ctx, parameters)
+
+		if err != nil {
+			logger.Log.Error("error list metrics", zap.Error(err))
+			handleServiceError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		if err := utils.EncodeJSONRequest[models.MetricList](w, list); err != nil {
+			logger.Log.Error("error encoding response", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+}
+
+// listAlertsHandler возвращает алерты в состояниях из повторяющегося параметра state, по умолчанию — активные.
+func listAlertsHandler(alertsService AlertsService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		metricData, err := metricsService.GetAll(ctx)
+		states := r.URL.Query()["state"]
+
+		for _, state := range states {
+			switch state {
+			case models.AlertPendingState, models.AlertFiringState, models.AlertResolvedState:
+			default:
+				http.Error(w, fmt.Sprintf("parameter state %q isn't supported", state), http.StatusBadRequest)
+				return
+			}
+		}
+
+		if err := utils.EncodeJSONRequest[[]models.Alert](w, alertsService.Alerts(states...)); err != nil {
+			logger.Log.Error("error encoding response", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+}
+
+// streamKeepAliveInterval интервал отправки комментариев, поддерживающих соединение потока событий.
+const streamKeepAliveInterval = 15 * time.Second
+
+// parseMetricStreamParameters разбирает условия отбора событий: type, regex и match.
+func parseMetricStreamParameters(r *http.Request) (services.MetricStreamParameters, error) {
+	types, err := parseMetricTypes(r)
+
+	if err != nil {
+		return services.MetricStreamParameters{}, err
+	}
+
+	pattern, err := parseNamePattern(r)
+
+	if err != nil {
+		return services.MetricStreamParameters{}, err
+	}
+
+	matchers, err := parseLabelMatchers(r)
+
+	if err != nil {
+		return services.MetricStreamParameters{}, err
+	}
+
+	return services.MetricStreamParameters{Types: types, Pattern: pattern, Matchers: matchers}, nil
+}
+
+// streamMetricsHandler отправляет изменения метрик в формате Server-Sent Events до отключения клиента
+// или остановки сервера.
+// Если клиент не успевает читать события, сервис метрик отключает его и поток завершается.
+func streamMetricsHandler(ctx context.Context, metricsService MetricsService, done <-chan struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		parameters, err := parseMetricStreamParameters(r)
 
 		if err != nil {
-			logger.Log.Error("error get all metric data", zap.Error(err))
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		result := make([]string, 0, len(metricData))
+		flusher, ok := w.(http.Flusher)
+
+		if !ok {
+			http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+			return
+		}
+
+		events, unsubscribe := metricsService.Subscribe(parameters)
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
 
-		for _, el := range metricData {
-			result = append(result, fmt.Sprintf("%s - %s", el.Name, el.Value))
+		keepAlive := time.NewTicker(streamKeepAliveInterval)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.Context().Done():
+				return
+			case <-done:
+				return
+			case <-keepAlive.C:
+				if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+			case event, ok := <-events:
+				if !ok {
+					logger.Log.Debug("metrics stream subscriber was dropped", zap.String("remote", r.RemoteAddr))
+					return
+				}
+
+				data, err := json.Marshal(event)
+
+				if err != nil {
+					logger.Log.Error("error encoding metric event", zap.Error(err))
+					continue
+				}
+
+				if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Action, data); err != nil {
+					return
+				}
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+func getMetricHistoryHandler(ctx context.Context, metricsService MetricsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		parameters, err := parseMetricHistoryParameters(r)
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		history, err := metricsService.GetHistory(ctx, parameters)
+
+		if err != nil {
+			if errors.Is(err, services.ErrMetricNotFound) {
+				http.Error(w, "Metric value with such parameters wasn't found", http.StatusNotFound)
+				return
+			}
+
+			logger.Log.Error("error get metric history", zap.Error(err))
+			handleServiceError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		if err := utils.EncodeJSONRequest[models.MetricHistory](w, history); err != nil {
+			logger.Log.Error("error encoding response", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+}
+
+// metricsExpositionHandler отдает все метрики в текстовом формате Prometheus либо в формате OpenMetrics,
+// если клиент запросил его в заголовке Accept.
+func metricsExpositionHandler(ctx context.Context, metricsService MetricsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		matchers, err := parseLabelMatchers(r)
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		metricData, err := metricsService.GetAllModels(ctx, matchers)
+
+		if err != nil {
+			logger.Log.Error("error get all metric models", zap.Error(err))
+			handleServiceError(w, err, http.StatusBadRequest)
+			return
 		}
 
-		sort.Strings(result)
+		format := exposition.Negotiate(r.Header.Get("Accept"))
 
-		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Content-Type", format.ContentType())
 
-		if _, err := io.WriteString(w, fmt.Sprintf("<html><head><title>All metrics</title></head><body>%s</body></html>", strings.Join(result, "<br />"))); err != nil {
+		if err := exposition.Write(w, metricData, format); err != nil {
 			logger.Log.Error("failed to write data", zap.Error(err))
 		}
 	}
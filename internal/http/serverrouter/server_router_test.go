@@ -5,22 +5,32 @@ import (
 	"compress/gzip"
 	"context"
 	"crypto/rsa"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/daremove/go-metrics-service/internal/middlewares/dataintergity"
 	"github.com/daremove/go-metrics-service/internal/middlewares/gzipm"
 	"github.com/daremove/go-metrics-service/internal/models"
+	otlppb "github.com/daremove/go-metrics-service/internal/proto/otlp"
+	"github.com/daremove/go-metrics-service/internal/proto/prompb"
 	"github.com/daremove/go-metrics-service/internal/services"
+	"github.com/daremove/go-metrics-service/internal/services/remotewrite"
+	"github.com/daremove/go-metrics-service/internal/storage"
 	"github.com/daremove/go-metrics-service/internal/utils"
+	"github.com/golang/snappy"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 )
 
 var publicKey, privateKey = readRSAKeysFromFile()
@@ -167,13 +177,38 @@ func TestSendMetricModelData(t *testing.T) {
 	}
 }
 
+type alertsServiceMock struct {
+	alerts []models.Alert
+}
+
+func (m alertsServiceMock) Alerts(states ...string) []models.Alert {
+	if len(states) == 0 {
+		states = []string{models.AlertPendingState, models.AlertFiringState}
+	}
+
+	result := make([]models.Alert, 0)
+
+	for _, alert := range m.alerts {
+		for _, state := range states {
+			if alert.State == state {
+				result = append(result, alert)
+			}
+		}
+	}
+
+	return result
+}
+
 type metricsServiceMock struct {
 	data      map[string]string
 	modelData map[string]models.Metrics
+	events    []models.MetricEvent
+	keepOpen  bool
+	saveErr   error
 }
 
 func (m metricsServiceMock) Save(_ context.Context, _ services.MetricSaveParameters) error {
-	return nil
+	return m.saveErr
 }
 
 func (m metricsServiceMock) SaveModel(_ context.Context, _ models.Metrics) error {
@@ -204,16 +239,128 @@ func (m metricsServiceMock) GetModel(_ context.Context, parameters models.Metric
 	return value, nil
 }
 
-func (m metricsServiceMock) GetAll(_ context.Context) ([]services.MetricEntry, error) {
-	var result []services.MetricEntry
+func (m metricsServiceMock) GetAllModels(_ context.Context, matchers []services.LabelMatcher) ([]models.Metrics, error) {
+	if m.saveErr != nil {
+		return nil, m.saveErr
+	}
+
+	var result []models.Metrics
+
+	for _, value := range m.modelData {
+		if !services.MatchLabels(value.Labels, matchers) {
+			continue
+		}
+
+		result = append(result, value)
+	}
+
+	return result, nil
+}
+
+func (m metricsServiceMock) List(_ context.Context, parameters services.MetricListParameters) (models.MetricList, error) {
+	if parameters.Cursor != "" && parameters.Cursor != "next" {
+		return models.MetricList{}, services.ErrInvalidCursor
+	}
+
+	result := models.MetricList{Metrics: []models.Metrics{}}
+
+	for _, value := range m.modelData {
+		if len(parameters.Types) > 0 && parameters.Types[0] != value.MType {
+			continue
+		}
+
+		if !strings.HasPrefix(value.ID, parameters.Prefix) || (parameters.Pattern != nil && !parameters.Pattern.MatchString(value.ID)) {
+			continue
+		}
 
-	for key, value := range m.data {
-		result = append(result, services.MetricEntry{Name: key, Value: value})
+		result.Metrics = append(result.Metrics, value)
+	}
+
+	sort.Slice(result.Metrics, func(i, j int) bool {
+		return result.Metrics[i].ID < result.Metrics[j].ID
+	})
+
+	if parameters.Limit > 0 && len(result.Metrics) > parameters.Limit {
+		result.Metrics = result.Metrics[:parameters.Limit]
+		result.NextCursor = "next"
 	}
 
 	return result, nil
 }
 
+func (m metricsServiceMock) GetHistory(_ context.Context, parameters services.MetricHistoryParameters) (models.MetricHistory, error) {
+	if _, ok := m.data[parameters.MetricName]; !ok {
+		return models.MetricHistory{}, services.ErrMetricNotFound
+	}
+
+	return models.MetricHistory{
+		ID:     parameters.MetricName,
+		MType:  parameters.MetricType,
+		From:   parameters.From,
+		To:     parameters.To,
+		Step:   parameters.Step.String(),
+		Points: []models.MetricPoint{{Timestamp: parameters.From, Value: 1.1}},
+	}, nil
+}
+
+func (m metricsServiceMock) Delete(_ context.Context, parameters services.MetricGetParameters) error {
+	if _, ok := m.data[parameters.MetricName]; !ok {
+		return services.ErrMetricNotFound
+	}
+
+	return nil
+}
+
+func (m metricsServiceMock) DeleteByPrefix(_ context.Context, prefix string) (int, error) {
+	if prefix == "" {
+		return 0, errors.New("prefix must not be empty")
+	}
+
+	deleted := 0
+
+	for key := range m.data {
+		if strings.HasPrefix(key, prefix) {
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func (m metricsServiceMock) ResetCounter(_ context.Context, parameters services.MetricGetParameters) error {
+	if parameters.MetricType != models.CounterMetricType {
+		return errors.New("only counter metrics can be reset")
+	}
+
+	if _, ok := m.data[parameters.MetricName]; !ok {
+		return services.ErrMetricNotFound
+	}
+
+	return nil
+}
+
+func (m metricsServiceMock) Subscribe(parameters services.MetricStreamParameters) (<-chan models.MetricEvent, func()) {
+	events := make(chan models.MetricEvent, len(m.events))
+
+	for _, event := range m.events {
+		if len(parameters.Types) > 0 && parameters.Types[0] != event.Metric.MType {
+			continue
+		}
+
+		if parameters.Pattern != nil && !parameters.Pattern.MatchString(event.Metric.ID) {
+			continue
+		}
+
+		events <- event
+	}
+
+	if !m.keepOpen {
+		close(events)
+	}
+
+	return events, func() {}
+}
+
 type healthCheckServiceMock struct{}
 
 func (hc healthCheckServiceMock) CheckStorageConnection(_ context.Context) error {
@@ -226,7 +373,7 @@ func TestServerRouter(t *testing.T) {
 			data: map[string]string{
 				"test": "1.1",
 			},
-		}, healthCheckServiceMock{}, RouterConfig{}).Get(context.TODO()),
+		}, healthCheckServiceMock{}, alertsServiceMock{}, RouterConfig{}).Get(context.TODO()),
 	)
 	defer testServer.Close()
 
@@ -272,11 +419,79 @@ func TestServerRouter(t *testing.T) {
 			expectedMessage: "Metric value with such parameters wasn't found\n",
 		},
 		{
-			testName:        "Should return html page with metrics",
+			testName:        "Should return metric history",
+			methodName:      http.MethodGet,
+			targetURL:       "/history/gauge/test?from=1704103200&to=2024-01-01T11:00:00Z&step=1m",
+			expectedCode:    http.StatusOK,
+			expectedMessage: "{\"id\":\"test\",\"type\":\"gauge\",\"from\":\"2024-01-01T10:00:00Z\",\"to\":\"2024-01-01T11:00:00Z\",\"step\":\"1m0s\",\"points\":[{\"timestamp\":\"2024-01-01T10:00:00Z\",\"value\":1.1}]}",
+		},
+		{
+			testName:        "Should return 400 if history period is invalid",
+			methodName:      http.MethodGet,
+			targetURL:       "/history/gauge/test?from=yesterday",
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "parameter from is invalid: parsing time \"yesterday\" as \"2006-01-02T15:04:05Z07:00\": cannot parse \"yesterday\" as \"2006\"\n",
+		},
+		{
+			testName:        "Should return 404 if metric history wasn't found",
+			methodName:      http.MethodGet,
+			targetURL:       "/history/gauge/another",
+			expectedCode:    http.StatusNotFound,
+			expectedMessage: "Metric value with such parameters wasn't found\n",
+		},
+		{
+			testName:        "Should return 400 if label matcher is invalid",
 			methodName:      http.MethodGet,
-			targetURL:       "/",
+			targetURL:       "/value/gauge/test?match=host",
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "label matcher \"host\" is invalid\n",
+		},
+		{
+			testName:     "Should delete metric",
+			methodName:   http.MethodDelete,
+			targetURL:    "/value/gauge/test",
+			expectedCode: http.StatusOK,
+		},
+		{
+			testName:        "Should return 404 if deleted metric wasn't found",
+			methodName:      http.MethodDelete,
+			targetURL:       "/value/gauge/another",
+			expectedCode:    http.StatusNotFound,
+			expectedMessage: "Metric value with such parameters wasn't found\n",
+		},
+		{
+			testName:        "Should delete metrics by prefix",
+			methodName:      http.MethodDelete,
+			targetURL:       "/value?prefix=te",
 			expectedCode:    http.StatusOK,
-			expectedMessage: "<html><head><title>All metrics</title></head><body>test - 1.1</body></html>",
+			expectedMessage: "{\"deleted\":1}",
+		},
+		{
+			testName:        "Should return 400 if prefix wasn't provided",
+			methodName:      http.MethodDelete,
+			targetURL:       "/value",
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "prefix must not be empty\n",
+		},
+		{
+			testName:     "Should reset counter",
+			methodName:   http.MethodPost,
+			targetURL:    "/reset/counter/test",
+			expectedCode: http.StatusOK,
+		},
+		{
+			testName:        "Should return 400 if reset metric isn't counter",
+			methodName:      http.MethodPost,
+			targetURL:       "/reset/gauge/test",
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "only counter metrics can be reset\n",
+		},
+		{
+			testName:        "Should return 404 if reset metric wasn't found",
+			methodName:      http.MethodPost,
+			targetURL:       "/reset/counter/another",
+			expectedCode:    http.StatusNotFound,
+			expectedMessage: "Metric value with such parameters wasn't found\n",
 		},
 		{
 			testName:        "Should return 414 if appropriate content-type wasn't set for json handler",
@@ -334,7 +549,7 @@ func TestServerRouterJson(t *testing.T) {
 			modelData: map[string]models.Metrics{
 				"gauge_test": {ID: "test", MType: models.GaugeMetricType, Value: &valueMock},
 			},
-		}, healthCheckServiceMock{}, RouterConfig{PrivateKey: privateKey, TrustedSubnet: "192.168.1.0/24"}).Get(context.TODO()),
+		}, healthCheckServiceMock{}, alertsServiceMock{}, RouterConfig{PrivateKey: privateKey, TrustedSubnet: "192.168.1.0/24"}).Get(context.TODO()),
 	)
 	defer testServer.Close()
 
@@ -412,6 +627,568 @@ func TestServerRouterJson(t *testing.T) {
 	}
 }
 
+func TestServerRouterTrustedSubnet(t *testing.T) {
+	testServer := httptest.NewServer(
+		New(metricsServiceMock{
+			data: map[string]string{
+				"test": "1.1",
+			},
+		}, healthCheckServiceMock{}, alertsServiceMock{}, RouterConfig{TrustedSubnet: "192.168.1.0/24"}).Get(context.TODO()),
+	)
+	defer testServer.Close()
+
+	testCases := []struct {
+		testName     string
+		methodName   string
+		targetURL    string
+		headers      map[string]string
+		expectedCode int
+	}{
+		{
+			testName:     "Should reject metric deletion without X-Real-IP",
+			methodName:   http.MethodDelete,
+			targetURL:    "/value/gauge/test",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			testName:     "Should reject metric deletion from untrusted IP",
+			methodName:   http.MethodDelete,
+			targetURL:    "/value/gauge/test",
+			headers:      map[string]string{"X-Real-IP": "10.0.0.1"},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			testName:     "Should reject deletion by prefix from untrusted IP",
+			methodName:   http.MethodDelete,
+			targetURL:    "/value?prefix=te",
+			headers:      map[string]string{"X-Real-IP": "10.0.0.1"},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			testName:     "Should reject counter reset from untrusted IP",
+			methodName:   http.MethodPost,
+			targetURL:    "/reset/counter/test",
+			headers:      map[string]string{"X-Real-IP": "10.0.0.1"},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			testName:     "Should reset counter from trusted IP",
+			methodName:   http.MethodPost,
+			targetURL:    "/reset/counter/test",
+			headers:      map[string]string{"X-Real-IP": "192.168.1.10"},
+			expectedCode: http.StatusOK,
+		},
+		{
+			testName:     "Should delete metric from trusted IP",
+			methodName:   http.MethodDelete,
+			targetURL:    "/value/gauge/test",
+			headers:      map[string]string{"X-Real-IP": "192.168.1.10"},
+			expectedCode: http.StatusOK,
+		},
+		{
+			testName:     "Should not check IP for reading metric",
+			methodName:   http.MethodGet,
+			targetURL:    "/value/gauge/test",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			res, _ := utils.TestRequest(t, testServer, tc.methodName, tc.targetURL, tc.headers, nil)
+			res.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, res.StatusCode)
+		})
+	}
+}
+
+func TestServerRouterStorageUnavailable(t *testing.T) {
+	testServer := httptest.NewServer(
+		New(metricsServiceMock{
+			saveErr: &storage.UnavailableError{Err: errors.New("connection refused"), RetryAfter: 1500 * time.Millisecond},
+		}, healthCheckServiceMock{}, alertsServiceMock{}, RouterConfig{}).Get(context.TODO()),
+	)
+	defer testServer.Close()
+
+	res, mes := utils.TestRequest(t, testServer, http.MethodPost, "/update/counter/test/1", nil, nil)
+	res.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(t, "2", res.Header.Get("Retry-After"))
+	assert.Equal(t, "storage is temporarily unavailable: connection refused\n", mes)
+}
+
+func TestServerRouterMetricsExposition(t *testing.T) {
+	var (
+		gaugeMock   = 1.5
+		counterMock = int64(3)
+	)
+
+	testServer := httptest.NewServer(
+		New(metricsServiceMock{
+			modelData: map[string]models.Metrics{
+				"gauge":   {ID: "Heap.Alloc", MType: models.GaugeMetricType, Value: &gaugeMock, Labels: map[string]string{"host": "a"}},
+				"counter": {ID: "PollCount", MType: models.CounterMetricType, Delta: &counterMock},
+			},
+		}, healthCheckServiceMock{}, alertsServiceMock{}, RouterConfig{}).Get(context.TODO()),
+	)
+	defer testServer.Close()
+
+	testCases := []struct {
+		testName            string
+		targetURL           string
+		headers             map[string]string
+		expectedContentType string
+		expectedMessage     string
+	}{
+		{
+			testName:            "Should return metrics in prometheus text format",
+			targetURL:           "/metrics",
+			expectedContentType: "text/plain; version=0.0.4; charset=utf-8",
+			expectedMessage:     "# TYPE Heap_Alloc gauge\nHeap_Alloc{host=\"a\"} 1.5\n# TYPE PollCount counter\nPollCount 3\n",
+		},
+		{
+			testName:            "Should return metrics in openmetrics format",
+			targetURL:           "/metrics",
+			headers:             map[string]string{"Accept": "application/openmetrics-text; version=1.0.0"},
+			expectedContentType: "application/openmetrics-text; version=1.0.0; charset=utf-8",
+			expectedMessage:     "# TYPE Heap_Alloc gauge\nHeap_Alloc{host=\"a\"} 1.5\n# TYPE PollCount counter\nPollCount_total 3\n# EOF\n",
+		},
+		{
+			testName:            "Should filter metrics by labels",
+			targetURL:           "/metrics?match=host%3D%22a%22",
+			expectedContentType: "text/plain; version=0.0.4; charset=utf-8",
+			expectedMessage:     "# TYPE Heap_Alloc gauge\nHeap_Alloc{host=\"a\"} 1.5\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			res, mes := utils.TestRequest(t, testServer, http.MethodGet, tc.targetURL, tc.headers, nil)
+			res.Body.Close()
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, tc.expectedContentType, res.Header.Get("Content-Type"))
+			assert.Equal(t, tc.expectedMessage, mes)
+		})
+	}
+
+	t.Run("Should return service unavailable when storage is down", func(t *testing.T) {
+		unavailableServer := httptest.NewServer(
+			New(metricsServiceMock{
+				saveErr: &storage.UnavailableError{Err: errors.New("connection refused"), RetryAfter: time.Second},
+			}, healthCheckServiceMock{}, alertsServiceMock{}, RouterConfig{}).Get(context.TODO()),
+		)
+		defer unavailableServer.Close()
+
+		res, _ := utils.TestRequest(t, unavailableServer, http.MethodGet, "/metrics", nil, nil)
+		res.Body.Close()
+
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	})
+}
+
+func TestServerRouterRemoteWrite(t *testing.T) {
+	testServer := httptest.NewServer(
+		New(metricsServiceMock{}, healthCheckServiceMock{}, alertsServiceMock{}, RouterConfig{}).Get(context.TODO()),
+	)
+	defer testServer.Close()
+
+	data, err := proto.Marshal(&prompb.WriteRequest{
+		Timeseries: []*prompb.TimeSeries{{
+			Labels:  []*prompb.Label{{Name: "__name__", Value: "http_requests_total"}},
+			Samples: []*prompb.Sample{{Value: 3, Timestamp: 1}},
+		}},
+	})
+
+	require.NoError(t, err)
+
+	testCases := []struct {
+		testName     string
+		body         []byte
+		expectedCode int
+	}{
+		{testName: "Should accept remote write request", body: snappy.Encode(nil, data), expectedCode: http.StatusNoContent},
+		{testName: "Should reject uncompressed request", body: data, expectedCode: http.StatusBadRequest},
+		{testName: "Should reject too large request", body: make([]byte, maxIngestBodySize+1), expectedCode: http.StatusRequestEntityTooLarge},
+		{
+			testName:     "Should reject request with too large decoded size",
+			body:         append(binary.AppendUvarint(nil, remotewrite.MaxDecodedSize+1), 0),
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			res, _ := utils.TestRequest(t, testServer, http.MethodPost, "/api/v1/write", map[string]string{
+				"Content-Type":     "application/x-protobuf",
+				"Content-Encoding": "snappy",
+			}, bytes.NewBuffer(tc.body))
+			res.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, res.StatusCode)
+		})
+	}
+}
+
+func TestServerRouterOTLPMetrics(t *testing.T) {
+	testServer := httptest.NewServer(
+		New(metricsServiceMock{}, healthCheckServiceMock{}, alertsServiceMock{}, RouterConfig{}).Get(context.TODO()),
+	)
+	defer testServer.Close()
+
+	data, err := proto.Marshal(&otlppb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*otlppb.ResourceMetrics{{
+			ScopeMetrics: []*otlppb.ScopeMetrics{{
+				Metrics: []*otlppb.Metric{{
+					Name: "load",
+					Data: &otlppb.Metric_Gauge{Gauge: &otlppb.Gauge{
+						DataPoints: []*otlppb.NumberDataPoint{{Value: &otlppb.NumberDataPoint_AsDouble{AsDouble: 1.5}}},
+					}},
+				}},
+			}},
+		}},
+	})
+
+	require.NoError(t, err)
+
+	testCases := []struct {
+		testName            string
+		contentType         string
+		body                []byte
+		expectedCode        int
+		expectedContentType string
+		expectedMessage     string
+	}{
+		{
+			testName:            "Should accept protobuf request",
+			contentType:         "application/x-protobuf",
+			body:                data,
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/x-protobuf",
+		},
+		{
+			testName:            "Should accept json request",
+			contentType:         "application/json",
+			body:                []byte(`{"resourceMetrics":[{"scopeMetrics":[{"metrics":[{"name":"load","gauge":{"dataPoints":[{"asDouble":1.5}]}}]}]}]}`),
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/json",
+			expectedMessage:     "{}",
+		},
+		{
+			testName:     "Should reject invalid request",
+			contentType:  "application/json",
+			body:         []byte("{"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			testName:     "Should reject unsupported content type",
+			contentType:  "text/plain",
+			body:         data,
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			testName:     "Should reject too large request",
+			contentType:  "application/x-protobuf",
+			body:         make([]byte, maxIngestBodySize+1),
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			res, body := utils.TestRequest(t, testServer, http.MethodPost, "/v1/metrics", map[string]string{
+				"Content-Type": tc.contentType,
+			}, bytes.NewBuffer(tc.body))
+			res.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, res.StatusCode)
+
+			if tc.expectedContentType != "" {
+				assert.Equal(t, tc.expectedContentType, res.Header.Get("Content-Type"))
+				assert.Equal(t, tc.expectedMessage, body)
+			}
+		})
+	}
+}
+
+func TestServerRouterInfluxWrite(t *testing.T) {
+	testServer := httptest.NewServer(
+		New(metricsServiceMock{}, healthCheckServiceMock{}, alertsServiceMock{}, RouterConfig{}).Get(context.TODO()),
+	)
+	defer testServer.Close()
+
+	testCases := []struct {
+		testName        string
+		targetURL       string
+		body            string
+		expectedCode    int
+		expectedMessage string
+	}{
+		{
+			testName:     "Should accept line protocol",
+			targetURL:    "/api/v2/write?precision=s",
+			body:         "cpu,host=a usage=0.5,requests_total=10i 1700000000\nmem used=1024i\n",
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			testName:        "Should report parse errors per line",
+			targetURL:       "/api/v2/write",
+			body:            "cpu usage=0.5\ncpu\ncpu usage=abc",
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "line 2: line protocol is invalid: fields are missing\nline 3: line protocol is invalid: field \"usage\": value \"abc\" is invalid\n",
+		},
+		{
+			testName:        "Should reject unknown precision",
+			targetURL:       "/api/v2/write?precision=h",
+			body:            "cpu usage=0.5",
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "precision is invalid: \"h\", expected ns, us, ms or s\n",
+		},
+		{
+			testName:     "Should reject too large request",
+			targetURL:    "/api/v2/write",
+			body:         strings.Repeat("cpu usage=0.5\n", maxIngestBodySize/14+1),
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			res, body := utils.TestRequest(t, testServer, http.MethodPost, tc.targetURL, map[string]string{
+				"Content-Type": "text/plain; charset=utf-8",
+			}, strings.NewReader(tc.body))
+			res.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, res.StatusCode)
+
+			if tc.expectedMessage != "" {
+				assert.Equal(t, tc.expectedMessage, body)
+			}
+		})
+	}
+}
+
+func TestServerRouterListMetrics(t *testing.T) {
+	var (
+		gaugeMock   = 1.5
+		counterMock = int64(3)
+	)
+
+	testServer := httptest.NewServer(
+		New(metricsServiceMock{
+			modelData: map[string]models.Metrics{
+				"load":     {ID: "load", MType: models.GaugeMetricType, Value: &gaugeMock},
+				"requests": {ID: "requests", MType: models.CounterMetricType, Delta: &counterMock},
+			},
+		}, healthCheckServiceMock{}, alertsServiceMock{}, RouterConfig{}).Get(context.TODO()),
+	)
+	defer testServer.Close()
+
+	testCases := []struct {
+		testName        string
+		targetURL       string
+		expectedCode    int
+		expectedMessage string
+	}{
+		{
+			testName:        "Should return metrics filtered by type",
+			targetURL:       "/api/v1/metrics?type=counter",
+			expectedCode:    http.StatusOK,
+			expectedMessage: "{\"metrics\":[{\"id\":\"requests\",\"type\":\"counter\",\"delta\":3}]}",
+		},
+		{
+			testName:        "Should return metrics filtered by prefix",
+			targetURL:       "/api/v1/metrics?prefix=lo",
+			expectedCode:    http.StatusOK,
+			expectedMessage: "{\"metrics\":[{\"id\":\"load\",\"type\":\"gauge\",\"value\":1.5}]}",
+		},
+		{
+			testName:        "Should return metrics filtered by regex with next cursor",
+			targetURL:       "/api/v1/metrics?regex=%5E%5Blr%5D&limit=1",
+			expectedCode:    http.StatusOK,
+			expectedMessage: "{\"metrics\":[{\"id\":\"load\",\"type\":\"gauge\",\"value\":1.5}],\"next_cursor\":\"next\"}",
+		},
+		{
+			testName:        "Should reject unknown type",
+			targetURL:       "/api/v1/metrics?type=summary",
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "parameter type \"summary\" isn't supported\n",
+		},
+		{
+			testName:        "Should reject invalid regex",
+			targetURL:       "/api/v1/metrics?regex=%28",
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "parameter regex is invalid: error parsing regexp: missing closing ): `(`\n",
+		},
+		{
+			testName:        "Should reject invalid limit",
+			targetURL:       "/api/v1/metrics?limit=0",
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "parameter limit must be a positive integer\n",
+		},
+		{
+			testName:        "Should reject invalid cursor",
+			targetURL:       "/api/v1/metrics?cursor=bad",
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "cursor is invalid\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			res, mes := utils.TestRequest(t, testServer, http.MethodGet, tc.targetURL, nil, nil)
+			res.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, res.StatusCode)
+			assert.Equal(t, tc.expectedMessage, mes)
+		})
+	}
+}
+
+func TestServerRouterStream(t *testing.T) {
+	value := 1.5
+	delta := int64(2)
+
+	testServer := httptest.NewServer(
+		New(metricsServiceMock{
+			events: []models.MetricEvent{
+				{Action: models.MetricUpdateAction, Metric: models.Metrics{ID: "load", MType: models.GaugeMetricType, Value: &value}},
+				{Action: models.MetricUpdateAction, Metric: models.Metrics{ID: "requests", MType: models.CounterMetricType, Delta: &delta}},
+				{Action: models.MetricDeleteAction, Metric: models.Metrics{ID: "temp", MType: models.GaugeMetricType}},
+			},
+		}, healthCheckServiceMock{}, alertsServiceMock{}, RouterConfig{}).Get(context.TODO()),
+	)
+	defer testServer.Close()
+
+	testCases := []struct {
+		testName        string
+		query           string
+		expectedCode    int
+		expectedMessage string
+	}{
+		{
+			testName:     "Should stream all events",
+			expectedCode: http.StatusOK,
+			expectedMessage: "event: update\ndata: {\"action\":\"update\",\"metric\":{\"id\":\"load\",\"type\":\"gauge\",\"value\":1.5}}\n\n" +
+				"event: update\ndata: {\"action\":\"update\",\"metric\":{\"id\":\"requests\",\"type\":\"counter\",\"delta\":2}}\n\n" +
+				"event: delete\ndata: {\"action\":\"delete\",\"metric\":{\"id\":\"temp\",\"type\":\"gauge\"}}\n\n",
+		},
+		{
+			testName:        "Should stream events filtered by type and name pattern",
+			query:           "?type=gauge&regex=^lo",
+			expectedCode:    http.StatusOK,
+			expectedMessage: "event: update\ndata: {\"action\":\"update\",\"metric\":{\"id\":\"load\",\"type\":\"gauge\",\"value\":1.5}}\n\n",
+		},
+		{
+			testName:        "Should return bad request if type is unknown",
+			query:           "?type=summary",
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "parameter type \"summary\" isn't supported\n",
+		},
+		{
+			testName:        "Should return bad request if regex is invalid",
+			query:           "?regex=(",
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "parameter regex is invalid: error parsing regexp: missing closing ): `(`\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			res, mes := utils.TestRequest(t, testServer, http.MethodGet, "/stream"+tc.query, nil, nil)
+			res.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, res.StatusCode)
+			assert.Equal(t, tc.expectedMessage, mes)
+
+			if tc.expectedCode == http.StatusOK {
+				assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+				assert.Equal(t, "no-cache", res.Header.Get("Cache-Control"))
+			}
+		})
+	}
+}
+
+func TestServerRouterStreamShutdown(t *testing.T) {
+	router := New(metricsServiceMock{keepOpen: true}, healthCheckServiceMock{}, alertsServiceMock{}, RouterConfig{})
+	testServer := httptest.NewUnstartedServer(router.Get(context.TODO()))
+	testServer.Config.RegisterOnShutdown(router.CloseStreams)
+	testServer.Start()
+	defer testServer.Close()
+
+	res, err := http.Get(testServer.URL + "/stream")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, testServer.Config.Shutdown(ctx))
+
+	_, err = io.ReadAll(res.Body)
+	assert.NoError(t, err)
+}
+
+func TestServerRouterAlerts(t *testing.T) {
+	value := 6e8
+	activeAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	testServer := httptest.NewServer(
+		New(metricsServiceMock{}, healthCheckServiceMock{}, alertsServiceMock{
+			alerts: []models.Alert{
+				{Name: "heap", Rule: "HeapAlloc > 5e8 for 2m", State: models.AlertFiringState, Value: &value, ActiveAt: &activeAt, FiredAt: &activeAt},
+				{Name: "poll", Rule: "counter rate(PollCount) == 0 for 1m", State: models.AlertResolvedState, ResolvedAt: &activeAt},
+			},
+		}, RouterConfig{}).Get(context.TODO()),
+	)
+	defer testServer.Close()
+
+	testCases := []struct {
+		testName        string
+		query           string
+		expectedCode    int
+		expectedMessage string
+	}{
+		{
+			testName:        "Should return active alerts",
+			expectedCode:    http.StatusOK,
+			expectedMessage: `[{"name":"heap","rule":"HeapAlloc \u003e 5e8 for 2m","state":"firing","value":600000000,"active_at":"2024-01-01T10:00:00Z","fired_at":"2024-01-01T10:00:00Z"}]`,
+		},
+		{
+			testName:        "Should return alerts filtered by state",
+			query:           "?state=resolved",
+			expectedCode:    http.StatusOK,
+			expectedMessage: `[{"name":"poll","rule":"counter rate(PollCount) == 0 for 1m","state":"resolved","resolved_at":"2024-01-01T10:00:00Z"}]`,
+		},
+		{
+			testName:        "Should return empty list",
+			query:           "?state=pending",
+			expectedCode:    http.StatusOK,
+			expectedMessage: `[]`,
+		},
+		{
+			testName:        "Should return bad request if state is unknown",
+			query:           "?state=inactive",
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "parameter state \"inactive\" isn't supported\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			res, mes := utils.TestRequest(t, testServer, http.MethodGet, "/api/v1/alerts"+tc.query, nil, nil)
+			res.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, res.StatusCode)
+			assert.Equal(t, tc.expectedMessage, mes)
+		})
+	}
+}
+
 func TestServerRouterGzip(t *testing.T) {
 	var valueMock = 1.1
 	testServer := httptest.NewServer(
@@ -422,7 +1199,7 @@ func TestServerRouterGzip(t *testing.T) {
 			modelData: map[string]models.Metrics{
 				"test": {ID: "test", MType: models.GaugeMetricType, Value: &valueMock},
 			},
-		}, healthCheckServiceMock{}, RouterConfig{}).Get(context.TODO()),
+		}, healthCheckServiceMock{}, alertsServiceMock{}, RouterConfig{}).Get(context.TODO()),
 	)
 	defer testServer.Close()
 
@@ -444,7 +1221,7 @@ func TestServerRouterGzip(t *testing.T) {
 
 		require.NoError(t, err)
 
-		assert.Equal(t, "<html><head><title>All metrics</title></head><body>test - 1.1</body></html>", string(result))
+		assert.Contains(t, string(result), `<a href="/dashboard/metric?key=test&amp;type=gauge">test</a>`)
 	})
 
 	t.Run("Should accept gzip data", func(t *testing.T) {
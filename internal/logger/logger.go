@@ -33,6 +33,13 @@ func (r *loggingResponseWriter) WriteHeader(statusCode int) {
 	r.responseData.status = statusCode
 }
 
+// Flush отправляет клиенту буферизованные данные, если это поддерживает встроенный http.ResponseWriter.
+func (r *loggingResponseWriter) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 // Log предоставляет глобальный доступ к логгеру zap.Logger.
 var Log = zap.NewNop()
 
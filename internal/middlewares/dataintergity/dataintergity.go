@@ -52,6 +52,13 @@ func (w ResponseWriterWithSignature) Write(data []byte) (int, error) {
 	return w.ResponseWriter.Write(data)
 }
 
+// Flush отправляет клиенту буферизованные данные, если это поддерживает встроенный ResponseWriter.
+func (w ResponseWriterWithSignature) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 // NewMiddleware создает новый экземпляр middleware для проверки и добавления целостности данных.
 func NewMiddleware(config DataIntegrityMiddlewareConfig) func(http.Handler) http.Handler {
 	return func(h http.Handler) http.Handler {
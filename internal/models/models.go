@@ -1,15 +1,84 @@
 // Package models предназначен для структур данных, используемых во всем приложении.
 package models
 
+import "time"
+
 const (
-	GaugeMetricType   string = "gauge"
-	CounterMetricType string = "counter"
+	GaugeMetricType     string = "gauge"
+	CounterMetricType   string = "counter"
+	HistogramMetricType string = "histogram"
 )
 
-// Metrics описывает структуру данных метрики, которая может быть типа "gauge" или "counter".
+// Metrics описывает структуру данных метрики, которая может быть типа "gauge", "counter" или "histogram".
 type Metrics struct {
-	ID    string   `json:"id"`              // Имя метрики
-	MType string   `json:"type"`            // Тип метрики, принимает значения "gauge" или "counter"
-	Delta *int64   `json:"delta,omitempty"` // Изменение значения для метрик типа "counter"
-	Value *float64 `json:"value,omitempty"` // Текущее значение для метрик типа "gauge"
+	ID        string            `json:"id"`                   // Имя метрики
+	MType     string            `json:"type"`                 // Тип метрики, принимает значения "gauge", "counter" или "histogram"
+	Delta     *int64            `json:"delta,omitempty"`      // Изменение значения для метрик типа "counter"
+	Value     *float64          `json:"value,omitempty"`      // Текущее значение для метрик типа "gauge"
+	Histogram *Histogram        `json:"histogram,omitempty"`  // Распределение значений для метрик типа "histogram"
+	Labels    map[string]string `json:"labels,omitempty"`     // Метки (измерения) метрики
+	UpdatedAt *time.Time        `json:"updated_at,omitempty"` // Время последнего обновления метрики, заполняется в ответах
+}
+
+// Histogram описывает распределение значений метрики типа "histogram".
+// Counts содержит на одну корзину больше, чем Bounds: последняя корзина учитывает значения больше последней границы.
+type Histogram struct {
+	Bounds []float64 `json:"bounds"` // Верхние границы корзин по возрастанию
+	Counts []uint64  `json:"counts"` // Количество значений в каждой корзине
+	Sum    float64   `json:"sum"`    // Сумма всех значений
+	Count  uint64    `json:"count"`  // Количество всех значений
+}
+
+// MetricPoint описывает значение метрики в определенный момент времени.
+type MetricPoint struct {
+	Timestamp time.Time `json:"timestamp"` // Время значения
+	Value     float64   `json:"value"`     // Значение gauge либо приращение counter
+}
+
+// MetricHistory описывает временной ряд значений метрики за период.
+type MetricHistory struct {
+	ID     string        `json:"id"`             // Имя метрики
+	MType  string        `json:"type"`           // Тип метрики
+	From   time.Time     `json:"from"`           // Начало периода
+	To     time.Time     `json:"to"`             // Окончание периода
+	Step   string        `json:"step,omitempty"` // Шаг агрегации значений
+	Points []MetricPoint `json:"points"`         // Значения метрики
+}
+
+// MetricList описывает страницу списка метрик.
+type MetricList struct {
+	Metrics    []Metrics `json:"metrics"`               // Метрики страницы
+	NextCursor string    `json:"next_cursor,omitempty"` // Курсор следующей страницы, пустой на последней странице
+}
+
+// Действия, описываемые событиями изменения метрик.
+const (
+	MetricUpdateAction string = "update"
+	MetricDeleteAction string = "delete"
+	MetricResetAction  string = "reset"
+)
+
+// MetricEvent описывает изменение метрики, примененное сервисом метрик.
+type MetricEvent struct {
+	Action string  `json:"action"` // Действие: "update", "delete" или "reset"
+	Metric Metrics `json:"metric"` // Метрика; для counter в событии update передается приращение
+}
+
+// Состояния алертов.
+const (
+	AlertInactiveState string = "inactive"
+	AlertPendingState  string = "pending"
+	AlertFiringState   string = "firing"
+	AlertResolvedState string = "resolved"
+)
+
+// Alert описывает состояние правила алертинга.
+type Alert struct {
+	Name       string     `json:"name"`                  // Имя правила
+	Rule       string     `json:"rule"`                  // Выражение правила
+	State      string     `json:"state"`                 // Состояние: "pending", "firing" или "resolved"
+	Value      *float64   `json:"value,omitempty"`       // Значение метрики при последней проверке
+	ActiveAt   *time.Time `json:"active_at,omitempty"`   // Время, с которого условие правила выполняется
+	FiredAt    *time.Time `json:"fired_at,omitempty"`    // Время перехода в состояние "firing"
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"` // Время перехода в состояние "resolved"
 }
@@ -44,6 +44,28 @@ func TestMetricsSerialization(t *testing.T) {
 		assert.Equal(t, metric, deserializedMetric)
 	})
 
+	t.Run("Should correctly serialize and deserialize Histogram metric", func(t *testing.T) {
+		metric := Metrics{
+			ID:    "TestHistogram",
+			MType: HistogramMetricType,
+			Histogram: &Histogram{
+				Bounds: []float64{0.5, 1},
+				Counts: []uint64{1, 2, 3},
+				Sum:    7.5,
+				Count:  6,
+			},
+		}
+
+		data, err := json.Marshal(metric)
+		assert.NoError(t, err)
+
+		var deserializedMetric Metrics
+		err = json.Unmarshal(data, &deserializedMetric)
+		assert.NoError(t, err)
+
+		assert.Equal(t, metric, deserializedMetric)
+	})
+
 	t.Run("Should handle missing optional fields", func(t *testing.T) {
 		metric := Metrics{
 			ID:    "TestMissingFields",
@@ -61,5 +83,6 @@ func TestMetricsSerialization(t *testing.T) {
 		assert.Equal(t, metric.MType, deserializedMetric.MType)
 		assert.Nil(t, deserializedMetric.Delta)
 		assert.Nil(t, deserializedMetric.Value)
+		assert.Nil(t, deserializedMetric.Histogram)
 	})
 }
@@ -3,11 +3,19 @@ package proto
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"sync"
 
 	"github.com/daremove/go-metrics-service/internal/models"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/daremove/go-metrics-service/internal/proto/metrics"
 )
@@ -25,17 +33,31 @@ func SendMetricModelData(ctx context.Context, data []models.Metrics) error {
 	payload := make([]*pb.Metrics, len(data))
 
 	for i, metric := range data {
-		if metric.Value == nil {
+		if metric.Histogram != nil {
 			payload[i] = &pb.Metrics{
-				Type:  metric.MType,
-				Id:    metric.ID,
-				Delta: *metric.Delta,
+				Type:   metric.MType,
+				Id:     metric.ID,
+				Labels: metric.Labels,
+				Histogram: &pb.Histogram{
+					Bounds: metric.Histogram.Bounds,
+					Counts: metric.Histogram.Counts,
+					Sum:    metric.Histogram.Sum,
+					Count:  metric.Histogram.Count,
+				},
+			}
+		} else if metric.Value == nil {
+			payload[i] = &pb.Metrics{
+				Type:   metric.MType,
+				Id:     metric.ID,
+				Delta:  *metric.Delta,
+				Labels: metric.Labels,
 			}
 		} else {
 			payload[i] = &pb.Metrics{
-				Type:  metric.MType,
-				Id:    metric.ID,
-				Value: *metric.Value,
+				Type:   metric.MType,
+				Id:     metric.ID,
+				Value:  *metric.Value,
+				Labels: metric.Labels,
 			}
 		}
 	}
@@ -46,3 +68,201 @@ func SendMetricModelData(ctx context.Context, data []models.Metrics) error {
 
 	return err
 }
+
+// maxPendingBatches ограничивает количество неподтвержденных пакетов, хранимых для повторной отправки.
+const maxPendingBatches = 1000
+
+// StreamClient отправляет пакеты метрик через один долгоживущий поток StreamMetrics.
+// Неподтвержденные сервером пакеты хранятся и отправляются повторно в новом потоке,
+// если предыдущий поток был прерван. Все потоки клиента передают один идентификатор,
+// по которому сервер пропускает пакеты, сохраненные до разрыва, но не успевшие получить подтверждение.
+type StreamClient struct {
+	id       string
+	conn     *grpc.ClientConn
+	client   pb.MetricsServiceClient
+	sendMu   sync.Mutex
+	mu       sync.Mutex
+	stream   pb.MetricsService_StreamMetricsClient
+	cancel   context.CancelFunc
+	done     chan struct{}
+	sequence uint64
+	pending  []*pb.StreamMetricsRequest
+}
+
+// NewStreamClient создает клиент потоковой отправки метрик. Соединение и поток
+// открываются при первой отправке.
+func NewStreamClient(address string, opts ...grpc.DialOption) (*StreamClient, error) {
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)
+	conn, err := grpc.NewClient(address, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 16)
+
+	if _, err := rand.Read(id); err != nil {
+		conn.Close()
+
+		return nil, err
+	}
+
+	return &StreamClient{id: hex.EncodeToString(id), conn: conn, client: pb.NewMetricsServiceClient(conn)}, nil
+}
+
+// Send отправляет пакет метрик. Если сервер не успевает сохранять пакеты, вызов блокируется
+// управлением потоком gRPC. При ошибке пакет остается неподтвержденным и будет отправлен повторно
+// при следующем вызове.
+func (c *StreamClient) Send(data []models.Metrics) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	request := &pb.StreamMetricsRequest{StreamId: c.id, Metrics: make([]*pb.Metrics, len(data))}
+
+	for i, metric := range data {
+		request.Metrics[i] = toProto(metric)
+	}
+
+	c.mu.Lock()
+	c.sequence++
+	request.Sequence = c.sequence
+	c.pending = append(c.pending, request)
+
+	if dropped := len(c.pending) - maxPendingBatches; dropped > 0 {
+		log.Printf("%d unacknowledged metric batches were dropped", dropped)
+		c.pending = c.pending[dropped:]
+	}
+
+	stream, requests := c.stream, []*pb.StreamMetricsRequest{request}
+	c.mu.Unlock()
+
+	if stream == nil {
+		opened, err := c.open()
+
+		if err != nil {
+			return err
+		}
+
+		c.mu.Lock()
+		stream, requests = opened, append([]*pb.StreamMetricsRequest(nil), c.pending...)
+		c.mu.Unlock()
+	}
+
+	for _, item := range requests {
+		if err := stream.Send(item); err != nil {
+			c.reset(stream)
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// open открывает новый поток и запускает чтение подтверждений из него.
+func (c *StreamClient) open() (pb.MetricsService_StreamMetricsClient, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := c.client.StreamMetrics(ctx)
+
+	if err != nil {
+		cancel()
+
+		return nil, err
+	}
+
+	done := make(chan struct{})
+
+	c.mu.Lock()
+	c.stream, c.cancel, c.done = stream, cancel, done
+	c.mu.Unlock()
+
+	go c.receive(stream, done)
+
+	return stream, nil
+}
+
+// receive читает подтверждения из потока до его завершения.
+func (c *StreamClient) receive(stream pb.MetricsService_StreamMetricsClient, done chan struct{}) {
+	defer close(done)
+
+	for {
+		ack, err := stream.Recv()
+
+		if err != nil {
+			if !errors.Is(err, io.EOF) && status.Code(err) != codes.Canceled {
+				log.Printf("metrics stream was interrupted: %s", err)
+			}
+
+			c.reset(stream)
+
+			return
+		}
+
+		c.acknowledge(ack.GetAckedSequence())
+	}
+}
+
+// acknowledge удаляет из очереди пакеты с номерами не больше подтвержденного.
+func (c *StreamClient) acknowledge(sequence uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	i := 0
+
+	for i < len(c.pending) && c.pending[i].GetSequence() <= sequence {
+		i++
+	}
+
+	c.pending = c.pending[i:]
+}
+
+// reset закрывает поток, чтобы следующая отправка открыла новый.
+func (c *StreamClient) reset(stream pb.MetricsService_StreamMetricsClient) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stream == stream {
+		c.cancel()
+		c.stream = nil
+	}
+}
+
+// Pending возвращает количество неподтвержденных пакетов.
+func (c *StreamClient) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.pending)
+}
+
+// Close завершает поток, ожидает итогового подтверждения сервера, но не дольше, чем до завершения
+// контекста, и закрывает соединение. Возвращает ошибку, если часть пакетов осталась неподтвержденной.
+func (c *StreamClient) Close(ctx context.Context) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	c.mu.Lock()
+	stream, cancel, done := c.stream, c.cancel, c.done
+	c.mu.Unlock()
+
+	if stream != nil {
+		if err := stream.CloseSend(); err == nil {
+			select {
+			case <-done:
+			case <-ctx.Done():
+			}
+		}
+
+		cancel()
+	}
+
+	if err := c.conn.Close(); err != nil {
+		return err
+	}
+
+	if pending := c.Pending(); pending > 0 {
+		return fmt.Errorf("%d metric batches weren't acknowledged", pending)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package proto
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/storage"
+)
+
+// failingOnceMetricsService возвращает ошибку хранилища при первом сохранении.
+type failingOnceMetricsService struct {
+	metricsServiceMock
+	failed *bool
+}
+
+func (m failingOnceMetricsService) SaveModels(ctx context.Context, parameters []models.Metrics) error {
+	if !*m.failed {
+		*m.failed = true
+
+		return &storage.UnavailableError{Err: errors.New("connection refused")}
+	}
+
+	return m.metricsServiceMock.SaveModels(ctx, parameters)
+}
+
+func TestStreamClient(t *testing.T) {
+	t.Run("Should send batches and wait for final ack on close", func(t *testing.T) {
+		var saved []models.Metrics
+
+		client, err := NewStreamClient("passthrough:///bufnet", startServer(t, NewMetricsServer(metricsServiceMock{saved: &saved}, nil, nil)))
+		require.NoError(t, err)
+
+		first, second := 1.5, int64(2)
+
+		require.NoError(t, client.Send([]models.Metrics{{ID: "load", MType: models.GaugeMetricType, Value: &first}}))
+		require.NoError(t, client.Send([]models.Metrics{{ID: "requests", MType: models.CounterMetricType, Delta: &second}}))
+		require.NoError(t, client.Close(context.Background()))
+
+		assert.Equal(t, 0, client.Pending())
+		require.Len(t, saved, 2)
+		assert.Equal(t, first, *saved[0].Value)
+		assert.Equal(t, second, *saved[1].Delta)
+	})
+
+	t.Run("Should keep unacknowledged batches if stream was interrupted", func(t *testing.T) {
+		server := NewMetricsServer(metricsServiceMock{err: &storage.UnavailableError{Err: errors.New("connection refused")}}, nil, nil)
+		client, err := NewStreamClient("passthrough:///bufnet", startServer(t, server))
+		require.NoError(t, err)
+
+		value := 1.5
+
+		require.NoError(t, client.Send([]models.Metrics{{ID: "load", MType: models.GaugeMetricType, Value: &value}}))
+
+		require.Eventually(t, func() bool {
+			client.mu.Lock()
+			defer client.mu.Unlock()
+
+			return client.stream == nil
+		}, time.Second, 10*time.Millisecond)
+
+		assert.Equal(t, 1, client.Pending())
+		assert.Error(t, client.Close(context.Background()))
+	})
+
+	t.Run("Should resend unacknowledged batches in new stream", func(t *testing.T) {
+		var (
+			saved  []models.Metrics
+			failed bool
+		)
+
+		service := failingOnceMetricsService{metricsServiceMock{saved: &saved}, &failed}
+		client, err := NewStreamClient("passthrough:///bufnet", startServer(t, NewMetricsServer(service, nil, nil)))
+		require.NoError(t, err)
+
+		first, second := 1.5, 2.5
+
+		require.NoError(t, client.Send([]models.Metrics{{ID: "load", MType: models.GaugeMetricType, Value: &first}}))
+
+		require.Eventually(t, func() bool {
+			client.mu.Lock()
+			defer client.mu.Unlock()
+
+			return client.stream == nil
+		}, time.Second, 10*time.Millisecond)
+
+		require.NoError(t, client.Send([]models.Metrics{{ID: "load", MType: models.GaugeMetricType, Value: &second}}))
+		require.NoError(t, client.Close(context.Background()))
+
+		require.Len(t, saved, 2)
+		assert.Equal(t, first, *saved[0].Value)
+		assert.Equal(t, second, *saved[1].Value)
+	})
+}
@@ -2,40 +2,596 @@
 package proto
 
 import (
+	"container/list"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/daremove/go-metrics-service/internal/models"
 	pb "github.com/daremove/go-metrics-service/internal/proto/metrics"
+	"github.com/daremove/go-metrics-service/internal/services"
+	"github.com/daremove/go-metrics-service/internal/storage"
+)
+
+// Параметры подтверждений потоковой загрузки метрик: подтверждение отправляется
+// после каждых streamAckBatches пакетов либо, если с прошлого подтверждения прошло streamAckInterval,
+// после очередного пакета.
+const (
+	streamAckBatches  = 100
+	streamAckInterval = time.Second
 )
 
+// watchSnapshotAction действие событий начального снимка Watch.
+const watchSnapshotAction = "snapshot"
+
 type MetricsServer struct {
 	pb.UnimplementedMetricsServiceServer
-	metricsService MetricsService
+	metricsService     MetricsService
+	healthCheckService HealthCheckService
+	alertsService      AlertsService
+	ackBatches         int
+	ackInterval        time.Duration
+	done               chan struct{}
+	closeOnce          sync.Once
+	streamsMu          sync.Mutex
+	streams            map[string]*list.Element // Состояния потоков по идентификатору
+	streamsOrder       *list.List               // Состояния потоков от недавно использованных к давно не использованным
+	streamTTL          time.Duration
+	maxStreams         int
+}
+
+// Ограничения состояний потоков StreamMetrics: последний сохраненный пакет забывается, если поток
+// не присылал данных дольше streamStateTTL либо если состояний больше maxStreamStates и поток
+// использовался раньше остальных.
+const (
+	streamStateTTL  = 10 * time.Minute
+	maxStreamStates = 10000
+)
+
+// streamState описывает последний сохраненный пакет потока StreamMetrics с указанным идентификатором.
+// Блокировка удерживается на время сохранения пакета, чтобы пакет, повторно отправленный
+// в новом потоке, не сохранялся параллельно с исходным.
+type streamState struct {
+	mu       sync.Mutex
+	id       string
+	sequence uint64
+	used     time.Time // Время последнего обращения к состоянию, изменяется под блокировкой streamsMu
 }
 
+// MetricsService определяет интерфейс для сервиса метрик.
 type MetricsService interface {
-	SaveModels(ctx context.Context, parameters []models.Metrics) error
+	SaveModel(ctx context.Context, parameters models.Metrics) error                                // Сохраняет модель метрик
+	SaveModels(ctx context.Context, parameters []models.Metrics) error                             // Сохраняет несколько моделей метрик
+	GetModel(ctx context.Context, parameters models.Metrics) (models.Metrics, error)               // Получает модель метрики
+	List(ctx context.Context, parameters services.MetricListParameters) (models.MetricList, error) // Получает страницу метрик
+	Subscribe(parameters services.MetricStreamParameters) (<-chan models.MetricEvent, func())      // Подписывает на изменения метрик
+}
+
+// HealthCheckService определяет интерфейс для сервиса проверки состояния.
+type HealthCheckService interface {
+	CheckStorageConnection(ctx context.Context) error // Проверяет соединение с хранилищем
+}
+
+// AlertsService определяет интерфейс для сервиса алертинга.
+type AlertsService interface {
+	Alerts(states ...string) []models.Alert
 }
 
-func NewMetricsServer(metricsService MetricsService) *MetricsServer {
+func NewMetricsServer(metricsService MetricsService, healthCheckService HealthCheckService, alertsService AlertsService) *MetricsServer {
 	return &MetricsServer{
-		metricsService: metricsService,
+		metricsService:     metricsService,
+		healthCheckService: healthCheckService,
+		alertsService:      alertsService,
+		ackBatches:         streamAckBatches,
+		ackInterval:        streamAckInterval,
+		done:               make(chan struct{}),
+		streams:            make(map[string]*list.Element),
+		streamsOrder:       list.New(),
+		streamTTL:          streamStateTTL,
+		maxStreams:         maxStreamStates,
 	}
 }
 
+// CloseStreams завершает открытые вызовы Watch и StreamMetrics, чтобы остановка сервера не ожидала их бесконечно.
+// Новые вызовы после этого сразу завершаются с кодом Unavailable.
+func (metricsServer *MetricsServer) CloseStreams() {
+	metricsServer.closeOnce.Do(func() {
+		close(metricsServer.done)
+	})
+}
+
+// UpdateMetrics сохраняет несколько метрик, аналогично POST /updates/.
 func (metricsServer *MetricsServer) UpdateMetrics(ctx context.Context, in *pb.UpdateMetricsRequest) (*pb.UpdateMetricsResponse, error) {
-	var payload = make([]models.Metrics, len(in.Metrics))
+	if err := metricsServer.metricsService.SaveModels(ctx, fromProtoList(in.Metrics)); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.UpdateMetricsResponse{Success: true}, nil
+}
+
+// UpdateMetric сохраняет одну метрику и возвращает ее, аналогично POST /update/.
+func (metricsServer *MetricsServer) UpdateMetric(ctx context.Context, in *pb.UpdateMetricRequest) (*pb.UpdateMetricResponse, error) {
+	if in.GetMetric() == nil {
+		return nil, status.Error(codes.InvalidArgument, "metric is required")
+	}
+
+	metric := fromProto(in.GetMetric())
+
+	if err := metricsServer.metricsService.SaveModel(ctx, metric); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.UpdateMetricResponse{Metric: toProto(metric)}, nil
+}
+
+// streamMessage содержит результат чтения сообщения из потока StreamMetrics.
+type streamMessage struct {
+	request *pb.StreamMetricsRequest
+	err     error
+}
+
+// StreamMetrics принимает поток пакетов метрик и сохраняет каждый пакет до чтения следующего.
+// Пока пакет сохраняется, новые сообщения не читаются, поэтому управление потоком gRPC
+// приостанавливает отправку на стороне клиента, если хранилище не успевает за ним.
+// Подтверждения накопительные: AckedSequence означает, что сохранены все пакеты с номерами
+// не больше него. При завершении потока клиентом отправляется итоговое подтверждение,
+// а при ошибке сохранения поток завершается с ошибкой, и неподтвержденные пакеты клиент должен отправить повторно.
+// После CloseStreams поток получает итоговое подтверждение и завершается с кодом Unavailable.
+// Если клиент передает StreamId, сервер запоминает последний сохраненный пакет и при повторной отправке
+// в новом потоке подтверждает уже сохраненные пакеты, не сохраняя их снова.
+func (metricsServer *MetricsServer) StreamMetrics(stream pb.MetricsService_StreamMetricsServer) error {
+	var (
+		ack      = &pb.StreamMetricsResponse{}
+		unacked  int
+		ackedAt  = time.Now()
+		received bool
+	)
+
+	messages := make(chan streamMessage)
+
+	go func() {
+		for {
+			in, err := stream.Recv()
 
-	for i, value := range in.Metrics {
-		payload[i] = models.Metrics{
-			MType: value.Type,
-			ID:    value.Id,
-			Value: &value.Value,
-			Delta: &value.Delta,
+			select {
+			case messages <- streamMessage{request: in, err: err}:
+			case <-stream.Context().Done():
+				return
+			}
+
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		var message streamMessage
+
+		select {
+		case <-metricsServer.done:
+			if err := stream.Send(ack); err != nil {
+				return err
+			}
+
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case message = <-messages:
 		}
+
+		in, err := message.request, message.err
+
+		if errors.Is(err, io.EOF) {
+			return stream.Send(ack)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if received && in.GetSequence() <= ack.AckedSequence {
+			return status.Errorf(codes.InvalidArgument, "sequence %d must be greater than %d", in.GetSequence(), ack.AckedSequence)
+		}
+
+		saved, err := metricsServer.saveStreamBatch(stream.Context(), in)
+
+		if err != nil {
+			return toStatusError(err)
+		}
+
+		received = true
+		ack.AckedSequence = in.GetSequence()
+		unacked++
+
+		if saved {
+			ack.Batches++
+			ack.Metrics += uint64(len(in.GetMetrics()))
+		}
+
+		if unacked >= metricsServer.ackBatches || time.Since(ackedAt) >= metricsServer.ackInterval {
+			if err := stream.Send(ack); err != nil {
+				return err
+			}
+
+			unacked, ackedAt = 0, time.Now()
+		}
+	}
+}
+
+// saveStreamBatch сохраняет пакет потока StreamMetrics. Пакет, уже сохраненный ранее в потоке
+// с тем же идентификатором, пропускается; в этом случае возвращается false.
+func (metricsServer *MetricsServer) saveStreamBatch(ctx context.Context, in *pb.StreamMetricsRequest) (bool, error) {
+	if in.GetStreamId() == "" {
+		return true, metricsServer.metricsService.SaveModels(ctx, fromProtoList(in.GetMetrics()))
+	}
+
+	state := metricsServer.lookupStream(in.GetStreamId())
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if in.GetSequence() <= state.sequence {
+		return false, nil
+	}
+
+	if err := metricsServer.metricsService.SaveModels(ctx, fromProtoList(in.GetMetrics())); err != nil {
+		return false, err
+	}
+
+	state.sequence = in.GetSequence()
+
+	return true, nil
+}
+
+// lookupStream возвращает состояние потока с указанным идентификатором, создавая его при необходимости,
+// и удаляет состояния, вышедшие за ограничения streamTTL и maxStreams.
+func (metricsServer *MetricsServer) lookupStream(id string) *streamState {
+	metricsServer.streamsMu.Lock()
+	defer metricsServer.streamsMu.Unlock()
+
+	element, ok := metricsServer.streams[id]
+
+	if ok {
+		metricsServer.streamsOrder.MoveToFront(element)
+	} else {
+		element = metricsServer.streamsOrder.PushFront(&streamState{id: id})
+		metricsServer.streams[id] = element
+	}
+
+	now := time.Now()
+	state := element.Value.(*streamState)
+	state.used = now
+
+	metricsServer.evictStreams(now)
+
+	return state
+}
+
+// evictStreams удаляет давно не использованные состояния потоков, пока их больше maxStreams
+// или пока самое старое из них не использовалось дольше streamTTL. Удаление останавливается
+// на состоянии, пакет которого сохраняется в этот момент.
+// Вызывающий код должен удерживать блокировку streamsMu.
+func (metricsServer *MetricsServer) evictStreams(now time.Time) {
+	for metricsServer.streamsOrder.Len() > 1 {
+		element := metricsServer.streamsOrder.Back()
+		state := element.Value.(*streamState)
+
+		if metricsServer.streamsOrder.Len() <= metricsServer.maxStreams && now.Sub(state.used) <= metricsServer.streamTTL {
+			return
+		}
+
+		if !state.mu.TryLock() {
+			return
+		}
+
+		metricsServer.streamsOrder.Remove(element)
+		delete(metricsServer.streams, state.id)
+		state.mu.Unlock()
+	}
+}
+
+// GetMetric возвращает метрику по типу, имени и меткам, аналогично POST /value/.
+func (metricsServer *MetricsServer) GetMetric(ctx context.Context, in *pb.GetMetricRequest) (*pb.GetMetricResponse, error) {
+	metric, err := metricsServer.metricsService.GetModel(ctx, models.Metrics{
+		ID:     in.GetId(),
+		MType:  in.GetType(),
+		Labels: in.GetLabels(),
+	})
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.GetMetricResponse{Metric: toProto(metric)}, nil
+}
+
+// ListMetrics возвращает страницу метрик, аналогично GET /api/v1/metrics.
+func (metricsServer *MetricsServer) ListMetrics(ctx context.Context, in *pb.ListMetricsRequest) (*pb.ListMetricsResponse, error) {
+	parameters, err := toListParameters(in)
+
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	list, err := metricsServer.metricsService.List(ctx, parameters)
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	response := &pb.ListMetricsResponse{
+		Metrics:    make([]*pb.Metrics, len(list.Metrics)),
+		NextCursor: list.NextCursor,
 	}
 
-	err := metricsServer.metricsService.SaveModels(ctx, payload)
+	for i, metric := range list.Metrics {
+		response.Metrics[i] = toProto(metric)
+	}
+
+	return response, nil
+}
+
+// Watch отправляет изменения метрик, удовлетворяющих условиям, до отмены вызова клиентом,
+// аналогично GET /stream. Если запрошен снимок, сначала отправляются текущие значения метрик
+// с действием "snapshot"; для counter в снимке передается значение счетчика, а в событиях update — приращение.
+// Подписка оформляется до чтения снимка, поэтому изменения во время его отправки не теряются.
+// Клиент, не успевающий читать события, отключается сервисом метрик, не замедляя прием метрик,
+// и вызов завершается с кодом ResourceExhausted.
+func (metricsServer *MetricsServer) Watch(in *pb.WatchRequest, stream pb.MetricsService_WatchServer) error {
+	types, pattern, matchers, err := parseFilters(in.GetTypes(), in.GetRegex(), in.GetMatch())
+
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	events, unsubscribe := metricsServer.metricsService.Subscribe(services.MetricStreamParameters{
+		Types:    types,
+		Pattern:  pattern,
+		Matchers: matchers,
+	})
+	defer unsubscribe()
+
+	if in.GetSnapshot() {
+		parameters := services.MetricListParameters{Types: types, Pattern: pattern, Matchers: matchers}
+
+		if err := metricsServer.sendSnapshot(stream, parameters); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-metricsServer.done:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "watcher was dropped because it didn't keep up with metric changes")
+			}
+
+			if err := stream.Send(&pb.WatchResponse{Action: event.Action, Metric: toProto(event.Metric)}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sendSnapshot отправляет текущие значения метрик постранично.
+func (metricsServer *MetricsServer) sendSnapshot(stream pb.MetricsService_WatchServer, parameters services.MetricListParameters) error {
+	for {
+		list, err := metricsServer.metricsService.List(stream.Context(), parameters)
+
+		if err != nil {
+			return toStatusError(err)
+		}
+
+		for _, metric := range list.Metrics {
+			if err := stream.Send(&pb.WatchResponse{Action: watchSnapshotAction, Metric: toProto(metric)}); err != nil {
+				return err
+			}
+		}
+
+		if list.NextCursor == "" {
+			return nil
+		}
+
+		parameters.Cursor = list.NextCursor
+	}
+}
+
+// Ping проверяет соединение с хранилищем, аналогично GET /ping.
+func (metricsServer *MetricsServer) Ping(ctx context.Context, _ *pb.PingRequest) (*pb.PingResponse, error) {
+	if err := metricsServer.healthCheckService.CheckStorageConnection(ctx); err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+
+	return &pb.PingResponse{}, nil
+}
+
+// GetAlerts возвращает алерты в запрошенных состояниях, по умолчанию — активные.
+func (metricsServer *MetricsServer) GetAlerts(_ context.Context, in *pb.GetAlertsRequest) (*pb.GetAlertsResponse, error) {
+	for _, state := range in.States {
+		switch state {
+		case models.AlertPendingState, models.AlertFiringState, models.AlertResolvedState:
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "state %q isn't supported", state)
+		}
+	}
+
+	alerts := metricsServer.alertsService.Alerts(in.States...)
+	response := &pb.GetAlertsResponse{Alerts: make([]*pb.Alert, len(alerts))}
+
+	for i, alert := range alerts {
+		response.Alerts[i] = &pb.Alert{
+			Name:       alert.Name,
+			Rule:       alert.Rule,
+			State:      alert.State,
+			Value:      alert.Value,
+			ActiveAt:   toTimestamp(alert.ActiveAt),
+			FiredAt:    toTimestamp(alert.FiredAt),
+			ResolvedAt: toTimestamp(alert.ResolvedAt),
+		}
+	}
+
+	return response, nil
+}
+
+// toListParameters проверяет условия отбора метрик и преобразует их в параметры сервиса.
+func toListParameters(in *pb.ListMetricsRequest) (services.MetricListParameters, error) {
+	if in.GetLimit() < 0 {
+		return services.MetricListParameters{}, fmt.Errorf("limit must not be negative")
+	}
+
+	types, pattern, matchers, err := parseFilters(in.GetTypes(), in.GetRegex(), in.GetMatch())
+
+	if err != nil {
+		return services.MetricListParameters{}, err
+	}
+
+	return services.MetricListParameters{
+		Types:    types,
+		Prefix:   in.GetPrefix(),
+		Pattern:  pattern,
+		Matchers: matchers,
+		Cursor:   in.GetCursor(),
+		Limit:    int(in.GetLimit()),
+	}, nil
+}
+
+// parseFilters проверяет типы метрик, регулярное выражение для имени и условия по меткам.
+func parseFilters(types []string, regex string, match []string) ([]string, *regexp.Regexp, []services.LabelMatcher, error) {
+	for _, metricType := range types {
+		switch metricType {
+		case models.GaugeMetricType, models.CounterMetricType, models.HistogramMetricType:
+		default:
+			return nil, nil, nil, fmt.Errorf("type %q isn't supported", metricType)
+		}
+	}
+
+	var pattern *regexp.Regexp
+
+	if regex != "" {
+		compiled, err := regexp.Compile(regex)
+
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("regex is invalid: %w", err)
+		}
+
+		pattern = compiled
+	}
+
+	var matchers []services.LabelMatcher
+
+	for _, expression := range match {
+		matcher, err := services.ParseLabelMatcher(expression)
+
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		matchers = append(matchers, matcher)
+	}
+
+	return types, pattern, matchers, nil
+}
+
+// toStatusError преобразует ошибку сервиса в ошибку gRPC с соответствующим кодом.
+// Ошибкой в параметрах запроса считаются ошибки проверки сервиса метрик и несовпадение
+// границ гистограммы с сохраненной, остальные ошибки возвращаются как внутренние.
+func toStatusError(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.Is(err, services.ErrMetricNotFound), errors.Is(err, storage.ErrDataNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, storage.ErrUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, services.ErrInvalidParameters), errors.Is(err, services.ErrInvalidCursor), errors.Is(err, services.ErrMetricAmbiguous),
+		errors.Is(err, storage.ErrHistogramBoundsMismatch):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// fromProto преобразует метрику gRPC в модель метрики.
+func fromProto(value *pb.Metrics) models.Metrics {
+	result := models.Metrics{
+		MType:  value.GetType(),
+		ID:     value.GetId(),
+		Labels: value.GetLabels(),
+	}
+
+	delta, gaugeValue := value.GetDelta(), value.GetValue()
+	result.Delta, result.Value = &delta, &gaugeValue
+
+	if histogram := value.GetHistogram(); histogram != nil {
+		result.Histogram = &models.Histogram{
+			Bounds: histogram.Bounds,
+			Counts: histogram.Counts,
+			Sum:    histogram.Sum,
+			Count:  histogram.Count,
+		}
+	}
+
+	return result
+}
+
+// fromProtoList преобразует список метрик gRPC в модели метрик.
+func fromProtoList(values []*pb.Metrics) []models.Metrics {
+	result := make([]models.Metrics, len(values))
+
+	for i, value := range values {
+		result[i] = fromProto(value)
+	}
+
+	return result
+}
+
+// toProto преобразует модель метрики в метрику gRPC.
+func toProto(value models.Metrics) *pb.Metrics {
+	result := &pb.Metrics{
+		Id:        value.ID,
+		Type:      value.MType,
+		Labels:    value.Labels,
+		UpdatedAt: toTimestamp(value.UpdatedAt),
+	}
+
+	switch value.MType {
+	case models.GaugeMetricType:
+		if value.Value != nil {
+			result.Value = *value.Value
+		}
+	case models.CounterMetricType:
+		if value.Delta != nil {
+			result.Delta = *value.Delta
+		}
+	case models.HistogramMetricType:
+		if value.Histogram != nil {
+			result.Histogram = &pb.Histogram{
+				Bounds: value.Histogram.Bounds,
+				Counts: value.Histogram.Counts,
+				Sum:    value.Histogram.Sum,
+				Count:  value.Histogram.Count,
+			}
+		}
+	}
+
+	return result
+}
+
+func toTimestamp(value *time.Time) *timestamppb.Timestamp {
+	if value == nil {
+		return nil
+	}
 
-	return nil, err
+	return timestamppb.New(*value)
 }
@@ -0,0 +1,589 @@
+package proto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+	pb "github.com/daremove/go-metrics-service/internal/proto/metrics"
+	"github.com/daremove/go-metrics-service/internal/services"
+	"github.com/daremove/go-metrics-service/internal/storage"
+)
+
+type metricsServiceMock struct {
+	data   map[string]models.Metrics
+	saved  *[]models.Metrics
+	events chan models.MetricEvent
+	err    error
+}
+
+func (m metricsServiceMock) SaveModel(_ context.Context, parameters models.Metrics) error {
+	return m.SaveModels(context.TODO(), []models.Metrics{parameters})
+}
+
+func (m metricsServiceMock) SaveModels(_ context.Context, parameters []models.Metrics) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	*m.saved = append(*m.saved, parameters...)
+
+	return nil
+}
+
+func (m metricsServiceMock) GetModel(_ context.Context, parameters models.Metrics) (models.Metrics, error) {
+	if m.err != nil {
+		return models.Metrics{}, m.err
+	}
+
+	metric, ok := m.data[parameters.MType+"/"+parameters.ID]
+
+	if !ok {
+		return models.Metrics{}, services.ErrMetricNotFound
+	}
+
+	return metric, nil
+}
+
+func (m metricsServiceMock) List(_ context.Context, parameters services.MetricListParameters) (models.MetricList, error) {
+	if m.err != nil {
+		return models.MetricList{}, m.err
+	}
+
+	var result models.MetricList
+
+	for _, key := range []string{"counter/requests", "gauge/load"} {
+		metric := m.data[key]
+
+		if len(parameters.Types) > 0 && parameters.Types[0] != metric.MType {
+			continue
+		}
+
+		result.Metrics = append(result.Metrics, metric)
+	}
+
+	if parameters.Limit == 1 {
+		result.Metrics = result.Metrics[:1]
+		result.NextCursor = "next"
+	}
+
+	return result, nil
+}
+
+func (m metricsServiceMock) Subscribe(_ services.MetricStreamParameters) (<-chan models.MetricEvent, func()) {
+	return m.events, func() {}
+}
+
+type healthCheckServiceMock struct {
+	err error
+}
+
+func (m healthCheckServiceMock) CheckStorageConnection(_ context.Context) error {
+	return m.err
+}
+
+type alertsServiceMock struct {
+	alerts []models.Alert
+}
+
+func (m alertsServiceMock) Alerts(states ...string) []models.Alert {
+	var result []models.Alert
+
+	for _, alert := range m.alerts {
+		if len(states) == 0 || states[0] == alert.State {
+			result = append(result, alert)
+		}
+	}
+
+	return result
+}
+
+func TestMetricsServer_GetAlerts(t *testing.T) {
+	value := 6e8
+	activeAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	server := NewMetricsServer(nil, nil, alertsServiceMock{
+		alerts: []models.Alert{
+			{Name: "heap", Rule: "HeapAlloc > 5e8", State: models.AlertFiringState, Value: &value, ActiveAt: &activeAt, FiredAt: &activeAt},
+			{Name: "poll", Rule: "rate(PollCount) == 0", State: models.AlertResolvedState, ResolvedAt: &activeAt},
+		},
+	})
+
+	t.Run("Should return alerts", func(t *testing.T) {
+		response, err := server.GetAlerts(context.TODO(), &pb.GetAlertsRequest{States: []string{models.AlertFiringState}})
+
+		require.NoError(t, err)
+		require.Len(t, response.Alerts, 1)
+
+		alert := response.Alerts[0]
+		assert.Equal(t, "heap", alert.GetName())
+		assert.Equal(t, "HeapAlloc > 5e8", alert.GetRule())
+		assert.Equal(t, models.AlertFiringState, alert.GetState())
+		assert.Equal(t, value, alert.GetValue())
+		assert.Equal(t, activeAt, alert.GetActiveAt().AsTime())
+		assert.Equal(t, activeAt, alert.GetFiredAt().AsTime())
+		assert.Nil(t, alert.GetResolvedAt())
+	})
+
+	t.Run("Should return alert without value", func(t *testing.T) {
+		response, err := server.GetAlerts(context.TODO(), &pb.GetAlertsRequest{States: []string{models.AlertResolvedState}})
+
+		require.NoError(t, err)
+		require.Len(t, response.Alerts, 1)
+		assert.Nil(t, response.Alerts[0].Value)
+	})
+
+	t.Run("Should return invalid argument for unknown state", func(t *testing.T) {
+		_, err := server.GetAlerts(context.TODO(), &pb.GetAlertsRequest{States: []string{"inactive"}})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestMetricsServer_UpdateMetrics(t *testing.T) {
+	t.Run("Should save metrics and return success", func(t *testing.T) {
+		var saved []models.Metrics
+
+		server := NewMetricsServer(metricsServiceMock{saved: &saved}, nil, nil)
+		response, err := server.UpdateMetrics(context.TODO(), &pb.UpdateMetricsRequest{Metrics: []*pb.Metrics{
+			{Id: "load", Type: models.GaugeMetricType, Value: 1.5},
+			{Id: "requests", Type: models.CounterMetricType, Delta: 3, Labels: map[string]string{"host": "a"}},
+		}})
+
+		require.NoError(t, err)
+		assert.True(t, response.GetSuccess())
+		require.Len(t, saved, 2)
+		assert.Equal(t, 1.5, *saved[0].Value)
+		assert.Equal(t, int64(3), *saved[1].Delta)
+		assert.Equal(t, map[string]string{"host": "a"}, saved[1].Labels)
+	})
+
+	t.Run("Should return unavailable if storage is unavailable", func(t *testing.T) {
+		server := NewMetricsServer(metricsServiceMock{err: &storage.UnavailableError{Err: errors.New("connection refused")}}, nil, nil)
+		_, err := server.UpdateMetrics(context.TODO(), &pb.UpdateMetricsRequest{Metrics: []*pb.Metrics{{Id: "load", Type: models.GaugeMetricType}}})
+
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("Should return invalid argument for invalid metric", func(t *testing.T) {
+		server := NewMetricsServer(metricsServiceMock{err: &services.ValidationError{Err: errors.New("metrict type unknown isn't defined")}}, nil, nil)
+		_, err := server.UpdateMetrics(context.TODO(), &pb.UpdateMetricsRequest{Metrics: []*pb.Metrics{{Id: "load", Type: "unknown"}}})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Should return invalid argument for histogram bounds mismatch", func(t *testing.T) {
+		server := NewMetricsServer(metricsServiceMock{err: fmt.Errorf("cannot add histogram latency: %w", storage.ErrHistogramBoundsMismatch)}, nil, nil)
+		_, err := server.UpdateMetrics(context.TODO(), &pb.UpdateMetricsRequest{Metrics: []*pb.Metrics{{Id: "latency", Type: models.HistogramMetricType}}})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Should return internal error for unexpected error", func(t *testing.T) {
+		server := NewMetricsServer(metricsServiceMock{err: errors.New("disk is full")}, nil, nil)
+		_, err := server.UpdateMetrics(context.TODO(), &pb.UpdateMetricsRequest{Metrics: []*pb.Metrics{{Id: "load", Type: models.GaugeMetricType}}})
+
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+}
+
+func TestMetricsServer_UpdateMetric(t *testing.T) {
+	t.Run("Should save metric and return it", func(t *testing.T) {
+		var saved []models.Metrics
+
+		server := NewMetricsServer(metricsServiceMock{saved: &saved}, nil, nil)
+		response, err := server.UpdateMetric(context.TODO(), &pb.UpdateMetricRequest{Metric: &pb.Metrics{Id: "requests", Type: models.CounterMetricType, Delta: 2}})
+
+		require.NoError(t, err)
+		assert.Equal(t, "requests", response.GetMetric().GetId())
+		assert.Equal(t, int64(2), response.GetMetric().GetDelta())
+		require.Len(t, saved, 1)
+	})
+
+	t.Run("Should return invalid argument without metric", func(t *testing.T) {
+		server := NewMetricsServer(metricsServiceMock{}, nil, nil)
+		_, err := server.UpdateMetric(context.TODO(), &pb.UpdateMetricRequest{})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+// startServer запускает gRPC-сервер в памяти и возвращает параметр подключения к нему.
+func startServer(t *testing.T, server *MetricsServer) grpc.DialOption {
+	t.Helper()
+
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	pb.RegisterMetricsServiceServer(grpcServer, server)
+
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	})
+}
+
+func TestMetricsServer_StreamMetrics(t *testing.T) {
+	connect := func(t *testing.T, server *MetricsServer) pb.MetricsService_StreamMetricsClient {
+		client, err := NewStreamClient("passthrough:///bufnet", startServer(t, server))
+		require.NoError(t, err)
+		t.Cleanup(func() { client.conn.Close() })
+
+		stream, err := client.client.StreamMetrics(context.Background())
+		require.NoError(t, err)
+
+		return stream
+	}
+
+	receiveAll := func(stream pb.MetricsService_StreamMetricsClient) ([]*pb.StreamMetricsResponse, error) {
+		var result []*pb.StreamMetricsResponse
+
+		for {
+			ack, err := stream.Recv()
+
+			if errors.Is(err, io.EOF) {
+				return result, nil
+			}
+
+			if err != nil {
+				return result, err
+			}
+
+			result = append(result, ack)
+		}
+	}
+
+	t.Run("Should save batches and send cumulative acks", func(t *testing.T) {
+		var saved []models.Metrics
+
+		server := NewMetricsServer(metricsServiceMock{saved: &saved}, nil, nil)
+		server.ackBatches, server.ackInterval = 2, time.Hour
+		stream := connect(t, server)
+
+		for sequence := uint64(1); sequence <= 3; sequence++ {
+			require.NoError(t, stream.Send(&pb.StreamMetricsRequest{Sequence: sequence, Metrics: []*pb.Metrics{
+				{Id: "requests", Type: models.CounterMetricType, Delta: int64(sequence)},
+			}}))
+		}
+
+		require.NoError(t, stream.CloseSend())
+
+		acks, err := receiveAll(stream)
+
+		require.NoError(t, err)
+		require.Len(t, acks, 2)
+		assert.Equal(t, uint64(2), acks[0].GetAckedSequence())
+		assert.Equal(t, uint64(2), acks[0].GetBatches())
+		assert.Equal(t, uint64(3), acks[1].GetAckedSequence())
+		assert.Equal(t, uint64(3), acks[1].GetBatches())
+		assert.Equal(t, uint64(3), acks[1].GetMetrics())
+		require.Len(t, saved, 3)
+		assert.Equal(t, int64(3), *saved[2].Delta)
+	})
+
+	t.Run("Should return error if sequence doesn't increase", func(t *testing.T) {
+		var saved []models.Metrics
+
+		stream := connect(t, NewMetricsServer(metricsServiceMock{saved: &saved}, nil, nil))
+
+		require.NoError(t, stream.Send(&pb.StreamMetricsRequest{Sequence: 2}))
+		require.NoError(t, stream.Send(&pb.StreamMetricsRequest{Sequence: 2}))
+
+		_, err := receiveAll(stream)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Should skip batches already saved in previous stream with same id", func(t *testing.T) {
+		var saved []models.Metrics
+
+		server := NewMetricsServer(metricsServiceMock{saved: &saved}, nil, nil)
+		dialer := startServer(t, server)
+		send := func(sequences ...uint64) []*pb.StreamMetricsResponse {
+			client, err := NewStreamClient("passthrough:///bufnet", dialer)
+			require.NoError(t, err)
+			t.Cleanup(func() { client.conn.Close() })
+
+			stream, err := client.client.StreamMetrics(context.Background())
+			require.NoError(t, err)
+
+			for _, sequence := range sequences {
+				require.NoError(t, stream.Send(&pb.StreamMetricsRequest{StreamId: "agent", Sequence: sequence, Metrics: []*pb.Metrics{
+					{Id: "requests", Type: models.CounterMetricType, Delta: int64(sequence)},
+				}}))
+			}
+
+			require.NoError(t, stream.CloseSend())
+
+			acks, err := receiveAll(stream)
+			require.NoError(t, err)
+
+			return acks
+		}
+
+		send(1, 2)
+		acks := send(1, 2, 3)
+
+		require.Len(t, saved, 3)
+		assert.Equal(t, int64(3), *saved[2].Delta)
+		require.NotEmpty(t, acks)
+		assert.Equal(t, uint64(3), acks[len(acks)-1].GetAckedSequence())
+		assert.Equal(t, uint64(1), acks[len(acks)-1].GetBatches())
+	})
+
+	t.Run("Should send final ack and return unavailable if streams were closed", func(t *testing.T) {
+		var saved []models.Metrics
+
+		server := NewMetricsServer(metricsServiceMock{saved: &saved}, nil, nil)
+		server.ackBatches, server.ackInterval = 1, time.Hour
+		stream := connect(t, server)
+
+		require.NoError(t, stream.Send(&pb.StreamMetricsRequest{Sequence: 1, Metrics: []*pb.Metrics{
+			{Id: "requests", Type: models.CounterMetricType, Delta: 1},
+		}}))
+
+		ack, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), ack.GetAckedSequence())
+
+		server.CloseStreams()
+
+		acks, err := receiveAll(stream)
+
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		require.Len(t, acks, 1)
+		assert.Equal(t, uint64(1), acks[0].GetAckedSequence())
+		assert.Len(t, saved, 1)
+	})
+
+	t.Run("Should close stream if storage is unavailable", func(t *testing.T) {
+		server := NewMetricsServer(metricsServiceMock{err: &storage.UnavailableError{Err: errors.New("connection refused")}}, nil, nil)
+		stream := connect(t, server)
+
+		require.NoError(t, stream.Send(&pb.StreamMetricsRequest{Sequence: 1}))
+
+		_, err := receiveAll(stream)
+
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+}
+
+func TestMetricsServer_LookupStream(t *testing.T) {
+	t.Run("Should forget least recently used streams over limit", func(t *testing.T) {
+		server := NewMetricsServer(metricsServiceMock{}, nil, nil)
+		server.maxStreams = 2
+
+		first := server.lookupStream("first")
+		first.sequence = 1
+		server.lookupStream("second").sequence = 1
+		assert.Same(t, first, server.lookupStream("first"))
+
+		server.lookupStream("third")
+
+		assert.Len(t, server.streams, 2)
+		assert.Contains(t, server.streams, "first")
+		assert.NotContains(t, server.streams, "second")
+		assert.Equal(t, uint64(0), server.lookupStream("second").sequence)
+	})
+
+	t.Run("Should forget idle streams", func(t *testing.T) {
+		server := NewMetricsServer(metricsServiceMock{}, nil, nil)
+
+		server.lookupStream("idle")
+		server.streams["idle"].Value.(*streamState).used = time.Now().Add(-2 * streamStateTTL)
+		server.lookupStream("active")
+
+		assert.Len(t, server.streams, 1)
+		assert.Contains(t, server.streams, "active")
+	})
+
+	t.Run("Should keep stream whose batch is being saved", func(t *testing.T) {
+		server := NewMetricsServer(metricsServiceMock{}, nil, nil)
+		server.maxStreams = 1
+
+		busy := server.lookupStream("busy")
+		busy.mu.Lock()
+		defer busy.mu.Unlock()
+
+		server.lookupStream("other")
+
+		assert.Len(t, server.streams, 2)
+	})
+}
+
+func TestMetricsServer_GetMetric(t *testing.T) {
+	value := 1.5
+	updatedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	server := NewMetricsServer(metricsServiceMock{data: map[string]models.Metrics{
+		"gauge/load": {ID: "load", MType: models.GaugeMetricType, Value: &value, UpdatedAt: &updatedAt},
+	}}, nil, nil)
+
+	t.Run("Should return metric", func(t *testing.T) {
+		response, err := server.GetMetric(context.TODO(), &pb.GetMetricRequest{Id: "load", Type: models.GaugeMetricType})
+
+		require.NoError(t, err)
+		assert.Equal(t, value, response.GetMetric().GetValue())
+		assert.Equal(t, updatedAt, response.GetMetric().GetUpdatedAt().AsTime())
+	})
+
+	t.Run("Should return not found for unknown metric", func(t *testing.T) {
+		_, err := server.GetMetric(context.TODO(), &pb.GetMetricRequest{Id: "unknown", Type: models.GaugeMetricType})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func TestMetricsServer_ListMetrics(t *testing.T) {
+	var (
+		value = 1.5
+		delta = int64(3)
+	)
+
+	server := NewMetricsServer(metricsServiceMock{data: map[string]models.Metrics{
+		"gauge/load":       {ID: "load", MType: models.GaugeMetricType, Value: &value},
+		"counter/requests": {ID: "requests", MType: models.CounterMetricType, Delta: &delta},
+	}}, nil, nil)
+
+	t.Run("Should return metrics filtered by type", func(t *testing.T) {
+		response, err := server.ListMetrics(context.TODO(), &pb.ListMetricsRequest{Types: []string{models.CounterMetricType}})
+
+		require.NoError(t, err)
+		require.Len(t, response.GetMetrics(), 1)
+		assert.Equal(t, "requests", response.GetMetrics()[0].GetId())
+		assert.Equal(t, delta, response.GetMetrics()[0].GetDelta())
+	})
+
+	t.Run("Should return next cursor", func(t *testing.T) {
+		response, err := server.ListMetrics(context.TODO(), &pb.ListMetricsRequest{Limit: 1})
+
+		require.NoError(t, err)
+		assert.Len(t, response.GetMetrics(), 1)
+		assert.Equal(t, "next", response.GetNextCursor())
+	})
+
+	testCases := []struct {
+		testName string
+		request  *pb.ListMetricsRequest
+	}{
+		{testName: "Should return invalid argument for unknown type", request: &pb.ListMetricsRequest{Types: []string{"summary"}}},
+		{testName: "Should return invalid argument for invalid regex", request: &pb.ListMetricsRequest{Regex: "("}},
+		{testName: "Should return invalid argument for invalid matcher", request: &pb.ListMetricsRequest{Match: []string{"host"}}},
+		{testName: "Should return invalid argument for negative limit", request: &pb.ListMetricsRequest{Limit: -1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			_, err := server.ListMetrics(context.TODO(), tc.request)
+
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+
+	t.Run("Should return invalid argument for invalid cursor", func(t *testing.T) {
+		server := NewMetricsServer(metricsServiceMock{err: services.ErrInvalidCursor}, nil, nil)
+		_, err := server.ListMetrics(context.TODO(), &pb.ListMetricsRequest{Cursor: "broken"})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestMetricsServer_Watch(t *testing.T) {
+	var (
+		value = 1.5
+		delta = int64(3)
+		data  = map[string]models.Metrics{
+			"gauge/load":       {ID: "load", MType: models.GaugeMetricType, Value: &value},
+			"counter/requests": {ID: "requests", MType: models.CounterMetricType, Delta: &delta},
+		}
+	)
+
+	watch := func(t *testing.T, server *MetricsServer, request *pb.WatchRequest) pb.MetricsService_WatchClient {
+		conn, err := grpc.NewClient("passthrough:///bufnet", startServer(t, server), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+
+		stream, err := pb.NewMetricsServiceClient(conn).Watch(context.Background(), request)
+		require.NoError(t, err)
+
+		return stream
+	}
+
+	t.Run("Should send snapshot and then changes", func(t *testing.T) {
+		events := make(chan models.MetricEvent, 1)
+		stream := watch(t, NewMetricsServer(metricsServiceMock{data: data, events: events}, nil, nil), &pb.WatchRequest{
+			Types:    []string{models.GaugeMetricType},
+			Snapshot: true,
+		})
+
+		snapshot, err := stream.Recv()
+
+		require.NoError(t, err)
+		assert.Equal(t, "snapshot", snapshot.GetAction())
+		assert.Equal(t, "load", snapshot.GetMetric().GetId())
+		assert.Equal(t, value, snapshot.GetMetric().GetValue())
+
+		updated := 2.5
+		events <- models.MetricEvent{Action: models.MetricUpdateAction, Metric: models.Metrics{ID: "load", MType: models.GaugeMetricType, Value: &updated}}
+
+		change, err := stream.Recv()
+
+		require.NoError(t, err)
+		assert.Equal(t, models.MetricUpdateAction, change.GetAction())
+		assert.Equal(t, updated, change.GetMetric().GetValue())
+	})
+
+	t.Run("Should return resource exhausted if watcher was dropped", func(t *testing.T) {
+		events := make(chan models.MetricEvent)
+		close(events)
+
+		stream := watch(t, NewMetricsServer(metricsServiceMock{data: data, events: events}, nil, nil), &pb.WatchRequest{})
+		_, err := stream.Recv()
+
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	t.Run("Should return unavailable if streams were closed", func(t *testing.T) {
+		server := NewMetricsServer(metricsServiceMock{data: data, events: make(chan models.MetricEvent)}, nil, nil)
+		server.CloseStreams()
+		server.CloseStreams()
+
+		_, err := watch(t, server, &pb.WatchRequest{}).Recv()
+
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("Should return invalid argument for invalid regex", func(t *testing.T) {
+		_, err := watch(t, NewMetricsServer(metricsServiceMock{data: data}, nil, nil), &pb.WatchRequest{Regex: "("}).Recv()
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestMetricsServer_Ping(t *testing.T) {
+	t.Run("Should return response if storage is available", func(t *testing.T) {
+		_, err := NewMetricsServer(nil, healthCheckServiceMock{}, nil).Ping(context.TODO(), &pb.PingRequest{})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("Should return unavailable if storage isn't available", func(t *testing.T) {
+		_, err := NewMetricsServer(nil, healthCheckServiceMock{err: errors.New("connection refused")}, nil).Ping(context.TODO(), &pb.PingRequest{})
+
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+}
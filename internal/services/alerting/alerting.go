@@ -0,0 +1,255 @@
+// Package alerting предоставляет правила алертинга по пороговым значениям метрик
+// и периодическую проверку этих правил с отслеживанием состояний pending, firing и resolved.
+package alerting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/daremove/go-metrics-service/internal/logger"
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/services"
+	"github.com/daremove/go-metrics-service/internal/storage"
+)
+
+// Config содержит настройки алертинга.
+type Config struct {
+	Rules    []Rule        // Правила алертинга
+	Interval time.Duration // Интервал проверки правил
+}
+
+// Reader определяет метод чтения метрики, необходимый для проверки правил.
+// Реализация должна скрывать устаревшие метрики и возвращать для них services.ErrMetricNotFound,
+// чтобы правила проверялись по тем же данным, что возвращаются в ответах.
+type Reader interface {
+	GetModel(ctx context.Context, parameters models.Metrics) (models.Metrics, error)
+}
+
+// ruleState содержит состояние правила между проверками.
+type ruleState struct {
+	alert       models.Alert
+	lastValue   float64   // Значение счетчика при предыдущей проверке, используется для rate
+	lastChecked time.Time // Время предыдущей проверки счетчика, нулевое — значения еще не было
+}
+
+// Evaluator периодически проверяет правила алертинга по данным метрик.
+type Evaluator struct {
+	reader Reader
+	rules  []Rule
+	mu     sync.RWMutex
+	states map[string]*ruleState
+	now    func() time.Time
+}
+
+// New создает новый экземпляр Evaluator.
+func New(reader Reader, rules []Rule) *Evaluator {
+	states := make(map[string]*ruleState, len(rules))
+
+	for _, rule := range rules {
+		states[rule.Name] = &ruleState{
+			alert: models.Alert{Name: rule.Name, Rule: rule.Expr, State: models.AlertInactiveState},
+		}
+	}
+
+	return &Evaluator{
+		reader: reader,
+		rules:  rules,
+		states: states,
+		now:    time.Now,
+	}
+}
+
+// reading содержит значение метрики правила, прочитанное из хранилища.
+type reading struct {
+	value float64
+	found bool
+	err   error
+}
+
+// Evaluate проверяет все правила и обновляет их состояния.
+// Значения метрик читаются до блокировки состояний, чтобы медленное хранилище
+// не задерживало чтение алертов. Ошибка чтения метрики не прерывает проверку остальных правил.
+func (e *Evaluator) Evaluate(ctx context.Context) error {
+	var errs []error
+
+	readings := make([]reading, len(e.rules))
+
+	for i, rule := range e.rules {
+		readings[i].value, readings[i].found, readings[i].err = e.read(ctx, rule)
+	}
+
+	now := e.now()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i, rule := range e.rules {
+		if err := readings[i].err; err != nil {
+			errs = append(errs, fmt.Errorf("cannot evaluate alert rule %s: %w", rule.Name, err))
+			continue
+		}
+
+		state := e.states[rule.Name]
+		value, ok := state.value(rule, readings[i].value, readings[i].found, now)
+
+		state.transition(rule, value, ok, now)
+	}
+
+	return errors.Join(errs...)
+}
+
+// read читает значение метрики правила. Второе значение ложно, если метрики нет или она устарела.
+func (e *Evaluator) read(ctx context.Context, rule Rule) (float64, bool, error) {
+	if rule.MetricType != models.GaugeMetricType && rule.MetricType != models.CounterMetricType {
+		return 0, false, fmt.Errorf("metric type %s isn't supported", rule.MetricType)
+	}
+
+	name, labels, err := storage.ParseSeriesKey(rule.Key)
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	metric, err := e.reader.GetModel(ctx, models.Metrics{ID: name, MType: rule.MetricType, Labels: labels})
+
+	if errors.Is(err, services.ErrMetricNotFound) {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	switch {
+	case metric.Value != nil:
+		return *metric.Value, true, nil
+	case metric.Delta != nil:
+		return float64(*metric.Delta), true, nil
+	default:
+		return 0, false, nil
+	}
+}
+
+// value возвращает значение, с которым сравнивается порог правила.
+// Второе значение ложно, если метрики нет или для rate еще недостаточно данных.
+// Отсутствующий счетчик для rate считается нулевым: скорость равна нулю, поэтому условия
+// вида "rate(x) == 0" срабатывают. Первое наблюдение счетчика, в том числе после его отсутствия,
+// только запоминается: накопленное до него значение приростом не считается.
+func (s *ruleState) value(rule Rule, value float64, found bool, now time.Time) (float64, bool) {
+	if rule.MetricType != models.CounterMetricType || rule.Function != RateFunction {
+		return value, found
+	}
+
+	if !found {
+		s.lastValue, s.lastChecked = 0, time.Time{}
+
+		return 0, true
+	}
+
+	previous, checked := s.lastValue, s.lastChecked
+	s.lastValue, s.lastChecked = value, now
+
+	if checked.IsZero() || !now.After(checked) {
+		return 0, false
+	}
+
+	increase := value - previous
+
+	// Уменьшение значения означает сброс счетчика: прирост считается от нуля.
+	if increase < 0 {
+		increase = value
+	}
+
+	return increase / now.Sub(checked).Seconds(), true
+}
+
+// transition переводит правило в следующее состояние по результату проверки.
+func (s *ruleState) transition(rule Rule, value float64, ok bool, now time.Time) {
+	alert := &s.alert
+	active := ok && rule.Matches(value)
+
+	alert.Value = nil
+
+	if ok {
+		alert.Value = &value
+	}
+
+	switch {
+	case active && (alert.State == models.AlertInactiveState || alert.State == models.AlertResolvedState):
+		alert.State = models.AlertPendingState
+		alert.ActiveAt = &now
+		alert.FiredAt = nil
+		alert.ResolvedAt = nil
+
+		if rule.For == 0 {
+			alert.State = models.AlertFiringState
+			alert.FiredAt = &now
+		}
+	case active && alert.State == models.AlertPendingState:
+		if now.Sub(*alert.ActiveAt) >= rule.For {
+			alert.State = models.AlertFiringState
+			alert.FiredAt = &now
+		}
+	case !active && alert.State == models.AlertPendingState:
+		alert.State = models.AlertInactiveState
+		alert.ActiveAt = nil
+	case !active && alert.State == models.AlertFiringState:
+		alert.State = models.AlertResolvedState
+		alert.ResolvedAt = &now
+	default:
+		return
+	}
+
+	logger.Log.Info("alert state changed", zap.String("name", rule.Name), zap.String("state", alert.State), zap.Any("value", alert.Value))
+}
+
+// Alerts возвращает алерты в указанных состояниях, отсортированные по имени.
+// Если состояния не указаны, возвращаются активные алерты: pending и firing.
+func (e *Evaluator) Alerts(states ...string) []models.Alert {
+	if len(states) == 0 {
+		states = []string{models.AlertPendingState, models.AlertFiringState}
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	result := make([]models.Alert, 0)
+
+	for _, state := range e.states {
+		for _, v := range states {
+			if state.alert.State == v {
+				result = append(result, state.alert)
+				break
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+// Run запускает периодическую проверку правил до отмены контекста.
+func (e *Evaluator) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.Evaluate(ctx); err != nil {
+				logger.Log.Error("error evaluate alert rules", zap.Error(err))
+			}
+		}
+	}
+}
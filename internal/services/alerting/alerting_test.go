@@ -0,0 +1,269 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/services/metrics"
+	"github.com/daremove/go-metrics-service/internal/services/staleness"
+	"github.com/daremove/go-metrics-service/internal/storage"
+	"github.com/daremove/go-metrics-service/internal/storage/memstorage"
+)
+
+type failingReader struct{}
+
+func (failingReader) GetModel(_ context.Context, _ models.Metrics) (models.Metrics, error) {
+	return models.Metrics{}, errors.New("connection refused")
+}
+
+// blockingReader блокирует чтение метрики до закрытия release.
+type blockingReader struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r blockingReader) GetModel(_ context.Context, parameters models.Metrics) (models.Metrics, error) {
+	close(r.started)
+	<-r.release
+
+	value := 1.0
+
+	return models.Metrics{ID: parameters.ID, MType: parameters.MType, Value: &value}, nil
+}
+
+func newTestEvaluator(t *testing.T, reader Reader, exprs ...string) (*Evaluator, *time.Time) {
+	configs := make([]RuleConfig, len(exprs))
+
+	for i, expr := range exprs {
+		configs[i] = RuleConfig{Expr: expr}
+	}
+
+	rules, err := ParseRules(configs)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	evaluator := New(reader, rules)
+	evaluator.now = func() time.Time { return now }
+
+	return evaluator, &now
+}
+
+func TestEvaluator(t *testing.T) {
+	t.Run("Should move gauge rule through pending, firing and resolved states", func(t *testing.T) {
+		store := memstorage.New()
+		evaluator, now := newTestEvaluator(t, metrics.New(store), "HeapAlloc > 100 for 2m")
+		startedAt := *now
+
+		require.NoError(t, store.AddGaugeMetric(context.TODO(), "HeapAlloc", 200))
+		require.NoError(t, evaluator.Evaluate(context.TODO()))
+
+		alerts := evaluator.Alerts()
+		require.Len(t, alerts, 1)
+		assert.Equal(t, models.AlertPendingState, alerts[0].State)
+		assert.Equal(t, 200.0, *alerts[0].Value)
+		assert.Equal(t, startedAt, *alerts[0].ActiveAt)
+
+		*now = now.Add(2 * time.Minute)
+		require.NoError(t, evaluator.Evaluate(context.TODO()))
+
+		alerts = evaluator.Alerts(models.AlertFiringState)
+		require.Len(t, alerts, 1)
+		assert.Equal(t, *now, *alerts[0].FiredAt)
+
+		require.NoError(t, store.AddGaugeMetric(context.TODO(), "HeapAlloc", 50))
+		*now = now.Add(time.Minute)
+		require.NoError(t, evaluator.Evaluate(context.TODO()))
+
+		assert.Empty(t, evaluator.Alerts())
+
+		alerts = evaluator.Alerts(models.AlertResolvedState)
+		require.Len(t, alerts, 1)
+		assert.Equal(t, *now, *alerts[0].ResolvedAt)
+		assert.Equal(t, 50.0, *alerts[0].Value)
+	})
+
+	t.Run("Should reset pending rule if condition stops matching", func(t *testing.T) {
+		store := memstorage.New()
+		evaluator, now := newTestEvaluator(t, metrics.New(store), "HeapAlloc > 100 for 2m")
+
+		require.NoError(t, store.AddGaugeMetric(context.TODO(), "HeapAlloc", 200))
+		require.NoError(t, evaluator.Evaluate(context.TODO()))
+
+		require.NoError(t, store.AddGaugeMetric(context.TODO(), "HeapAlloc", 50))
+		*now = now.Add(time.Minute)
+		require.NoError(t, evaluator.Evaluate(context.TODO()))
+
+		assert.Empty(t, evaluator.Alerts(models.AlertPendingState, models.AlertFiringState, models.AlertResolvedState))
+	})
+
+	t.Run("Should fire immediately without duration", func(t *testing.T) {
+		store := memstorage.New()
+		evaluator, _ := newTestEvaluator(t, metrics.New(store), "HeapAlloc >= 100")
+
+		require.NoError(t, store.AddGaugeMetric(context.TODO(), "HeapAlloc", 100))
+		require.NoError(t, evaluator.Evaluate(context.TODO()))
+
+		alerts := evaluator.Alerts()
+		require.Len(t, alerts, 1)
+		assert.Equal(t, models.AlertFiringState, alerts[0].State)
+	})
+
+	t.Run("Should not match missing metric", func(t *testing.T) {
+		evaluator, _ := newTestEvaluator(t, metrics.New(memstorage.New()), "HeapAlloc < 100")
+
+		require.NoError(t, evaluator.Evaluate(context.TODO()))
+
+		assert.Empty(t, evaluator.Alerts())
+	})
+
+	t.Run("Should evaluate counter rate between checks", func(t *testing.T) {
+		store := memstorage.New()
+		evaluator, now := newTestEvaluator(t, metrics.New(store), "counter rate(PollCount) == 0 for 1m", "rate(PollCount) > 1")
+
+		require.NoError(t, store.AddCounterMetric(context.TODO(), "PollCount", 10))
+		require.NoError(t, evaluator.Evaluate(context.TODO()))
+		assert.Empty(t, evaluator.Alerts())
+
+		require.NoError(t, store.AddCounterMetric(context.TODO(), "PollCount", 120))
+		*now = now.Add(time.Minute)
+		require.NoError(t, evaluator.Evaluate(context.TODO()))
+
+		alerts := evaluator.Alerts()
+		require.Len(t, alerts, 1)
+		assert.Equal(t, "rate(PollCount) > 1", alerts[0].Name)
+		assert.Equal(t, 2.0, *alerts[0].Value)
+
+		*now = now.Add(time.Minute)
+		require.NoError(t, evaluator.Evaluate(context.TODO()))
+
+		*now = now.Add(time.Minute)
+		require.NoError(t, evaluator.Evaluate(context.TODO()))
+
+		alerts = evaluator.Alerts()
+		require.Len(t, alerts, 1)
+		assert.Equal(t, "counter rate(PollCount) == 0 for 1m", alerts[0].Name)
+		assert.Equal(t, models.AlertFiringState, alerts[0].State)
+		assert.Equal(t, 0.0, *alerts[0].Value)
+	})
+
+	t.Run("Should treat decreased counter as reset", func(t *testing.T) {
+		store := memstorage.New()
+		evaluator, now := newTestEvaluator(t, metrics.New(store), "rate(PollCount) > 0")
+
+		require.NoError(t, store.AddCounterMetric(context.TODO(), "PollCount", 100))
+		require.NoError(t, evaluator.Evaluate(context.TODO()))
+
+		require.NoError(t, store.ResetCounterMetric(context.TODO(), "PollCount"))
+		require.NoError(t, store.AddCounterMetric(context.TODO(), "PollCount", 30))
+		*now = now.Add(10 * time.Second)
+		require.NoError(t, evaluator.Evaluate(context.TODO()))
+
+		alerts := evaluator.Alerts()
+		require.Len(t, alerts, 1)
+		assert.Equal(t, 3.0, *alerts[0].Value)
+	})
+
+	t.Run("Should treat missing counter as zero rate", func(t *testing.T) {
+		store := memstorage.New()
+		evaluator, now := newTestEvaluator(t, metrics.New(store), "rate(PollCount) == 0")
+
+		require.NoError(t, evaluator.Evaluate(context.TODO()))
+
+		alerts := evaluator.Alerts()
+		require.Len(t, alerts, 1)
+		assert.Equal(t, models.AlertFiringState, alerts[0].State)
+		assert.Equal(t, 0.0, *alerts[0].Value)
+
+		require.NoError(t, store.AddCounterMetric(context.TODO(), "PollCount", 6000))
+		*now = now.Add(time.Minute)
+		require.NoError(t, evaluator.Evaluate(context.TODO()))
+
+		alerts = evaluator.Alerts(models.AlertResolvedState)
+		require.Len(t, alerts, 1)
+		assert.Nil(t, alerts[0].Value)
+
+		require.NoError(t, store.AddCounterMetric(context.TODO(), "PollCount", 60))
+		*now = now.Add(time.Minute)
+		require.NoError(t, evaluator.Evaluate(context.TODO()))
+
+		alerts = evaluator.Alerts(models.AlertResolvedState)
+		require.Len(t, alerts, 1)
+		assert.Equal(t, 1.0, *alerts[0].Value)
+	})
+
+	t.Run("Should not count accumulated value of first observed counter as increase", func(t *testing.T) {
+		store := memstorage.New()
+		evaluator, now := newTestEvaluator(t, metrics.New(store), "rate(PollCount) > 1")
+
+		require.NoError(t, store.AddCounterMetric(context.TODO(), "PollCount", 100000))
+		require.NoError(t, evaluator.Evaluate(context.TODO()))
+		assert.Empty(t, evaluator.Alerts())
+
+		*now = now.Add(time.Minute)
+		require.NoError(t, evaluator.Evaluate(context.TODO()))
+
+		assert.Empty(t, evaluator.Alerts())
+	})
+
+	t.Run("Should not match stale metric", func(t *testing.T) {
+		store := memstorage.New()
+		reader := metrics.NewWithConfig(store, metrics.Config{Staleness: staleness.Policy{TTL: time.Minute}})
+		evaluator, _ := newTestEvaluator(t, reader, "HeapAlloc > 100", "rate(PollCount) == 0")
+
+		updatedAt := time.Now().Add(-time.Hour)
+		require.NoError(t, store.RestoreMetrics(context.TODO(),
+			[]storage.GaugeMetric{{Name: "HeapAlloc", Value: 200, UpdatedAt: updatedAt}},
+			[]storage.CounterMetric{{Name: "PollCount", Value: 10, UpdatedAt: updatedAt}},
+			nil,
+		))
+		require.NoError(t, evaluator.Evaluate(context.TODO()))
+
+		alerts := evaluator.Alerts()
+		require.Len(t, alerts, 1)
+		assert.Equal(t, "rate(PollCount) == 0", alerts[0].Name)
+	})
+
+	t.Run("Should return alerts while metric is being read", func(t *testing.T) {
+		reader := blockingReader{started: make(chan struct{}), release: make(chan struct{})}
+		evaluator, _ := newTestEvaluator(t, reader, "HeapAlloc > 0")
+		done := make(chan error, 1)
+
+		go func() {
+			done <- evaluator.Evaluate(context.TODO())
+		}()
+
+		<-reader.started
+
+		alerts := make(chan []models.Alert, 1)
+
+		go func() {
+			alerts <- evaluator.Alerts()
+		}()
+
+		select {
+		case result := <-alerts:
+			assert.Empty(t, result)
+		case <-time.After(time.Second):
+			t.Fatal("alerts were blocked by storage read")
+		}
+
+		close(reader.release)
+		require.NoError(t, <-done)
+		assert.Len(t, evaluator.Alerts(), 1)
+	})
+
+	t.Run("Should return error if metric cannot be read", func(t *testing.T) {
+		evaluator, _ := newTestEvaluator(t, failingReader{}, "HeapAlloc > 1", "PollCount > 1")
+
+		err := evaluator.Evaluate(context.TODO())
+
+		assert.ErrorContains(t, err, "HeapAlloc > 1")
+		assert.ErrorContains(t, err, "PollCount > 1")
+	})
+}
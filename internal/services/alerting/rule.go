@@ -0,0 +1,158 @@
+package alerting
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/storage"
+)
+
+// RateFunction функция правила, вычисляющая скорость роста счетчика в секунду.
+const RateFunction = "rate"
+
+// Операторы сравнения значения метрики с порогом.
+const (
+	OpGreater      = ">"
+	OpGreaterEqual = ">="
+	OpLess         = "<"
+	OpLessEqual    = "<="
+	OpEqual        = "=="
+	OpNotEqual     = "!="
+)
+
+// ruleRegexp разбирает выражение вида "[type] metric|rate(metric) op threshold [for duration]".
+var ruleRegexp = regexp.MustCompile(`^\s*(?:(gauge|counter)\s+)?(?:(rate)\(\s*([^\s(){}]+(?:\{[^}]*\})?)\s*\)|([^\s(){}<>=!]+(?:\{[^}]*\})?))\s*(>=|<=|==|!=|>|<)\s*(\S+?)(?:\s+for\s+(\S+))?\s*$`)
+
+// RuleConfig описывает правило алертинга в конфигурационном файле.
+type RuleConfig struct {
+	Name string `json:"name"` // Имя правила, по умолчанию совпадает с выражением
+	Expr string `json:"expr"` // Выражение правила, например "HeapAlloc > 5e8 for 2m"
+}
+
+// Rule описывает разобранное правило алертинга.
+type Rule struct {
+	Name       string        // Имя правила
+	Expr       string        // Исходное выражение правила
+	MetricType string        // Тип метрики: "gauge" или "counter"
+	Key        string        // Ключ хранения метрики
+	Function   string        // Функция над значением метрики, пустая — значение как есть
+	Op         string        // Оператор сравнения
+	Threshold  float64       // Пороговое значение
+	For        time.Duration // Время, в течение которого условие должно выполняться до срабатывания
+}
+
+// ParseRule разбирает выражение правила, например "HeapAlloc > 5e8 for 2m" или
+// "counter rate(PollCount) == 0 for 1m". Если тип метрики не указан, для rate используется
+// counter, иначе — gauge. Если имя не задано, им становится выражение.
+func ParseRule(config RuleConfig) (Rule, error) {
+	match := ruleRegexp.FindStringSubmatch(config.Expr)
+
+	if match == nil {
+		return Rule{}, fmt.Errorf("alert rule %q must be in format \"[type] metric|rate(metric) op threshold [for duration]\"", config.Expr)
+	}
+
+	rule := Rule{
+		Name:       config.Name,
+		Expr:       config.Expr,
+		MetricType: match[1],
+		Function:   match[2],
+		Op:         match[5],
+	}
+
+	if rule.Name == "" {
+		rule.Name = config.Expr
+	}
+
+	metric := match[4]
+
+	if rule.Function == RateFunction {
+		metric = match[3]
+	}
+
+	switch {
+	case rule.MetricType == "" && rule.Function == RateFunction:
+		rule.MetricType = models.CounterMetricType
+	case rule.MetricType == "":
+		rule.MetricType = models.GaugeMetricType
+	case rule.MetricType != models.CounterMetricType && rule.Function == RateFunction:
+		return Rule{}, fmt.Errorf("alert rule %q: rate can be applied only to counter metrics", config.Expr)
+	}
+
+	name, labels, err := storage.ParseSeriesKey(metric)
+
+	if err != nil || name == "" {
+		return Rule{}, fmt.Errorf("alert rule %q has invalid metric %q", config.Expr, metric)
+	}
+
+	rule.Key = storage.SeriesKey(name, labels)
+
+	threshold, err := strconv.ParseFloat(match[6], 64)
+
+	if err != nil {
+		return Rule{}, fmt.Errorf("alert rule %q has invalid threshold: %w", config.Expr, err)
+	}
+
+	rule.Threshold = threshold
+
+	if match[7] != "" {
+		duration, err := time.ParseDuration(match[7])
+
+		if err != nil {
+			return Rule{}, fmt.Errorf("alert rule %q has invalid duration: %w", config.Expr, err)
+		}
+
+		if duration < 0 {
+			return Rule{}, fmt.Errorf("alert rule %q duration must not be negative", config.Expr)
+		}
+
+		rule.For = duration
+	}
+
+	return rule, nil
+}
+
+// ParseRules разбирает набор правил и проверяет уникальность их имен.
+func ParseRules(configs []RuleConfig) ([]Rule, error) {
+	result := make([]Rule, 0, len(configs))
+	names := map[string]struct{}{}
+
+	for _, config := range configs {
+		rule, err := ParseRule(config)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := names[rule.Name]; ok {
+			return nil, fmt.Errorf("alert rule name %q is duplicated", rule.Name)
+		}
+
+		names[rule.Name] = struct{}{}
+		result = append(result, rule)
+	}
+
+	return result, nil
+}
+
+// Matches проверяет, выполняется ли условие правила для значения.
+func (r Rule) Matches(value float64) bool {
+	switch r.Op {
+	case OpGreater:
+		return value > r.Threshold
+	case OpGreaterEqual:
+		return value >= r.Threshold
+	case OpLess:
+		return value < r.Threshold
+	case OpLessEqual:
+		return value <= r.Threshold
+	case OpEqual:
+		return value == r.Threshold
+	case OpNotEqual:
+		return value != r.Threshold
+	default:
+		return false
+	}
+}
@@ -0,0 +1,117 @@
+package alerting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+)
+
+func TestParseRule(t *testing.T) {
+	testCases := []struct {
+		testName string
+		config   RuleConfig
+		expected Rule
+		hasError bool
+	}{
+		{
+			testName: "Should parse gauge rule with duration",
+			config:   RuleConfig{Expr: "HeapAlloc > 5e8 for 2m"},
+			expected: Rule{Name: "HeapAlloc > 5e8 for 2m", Expr: "HeapAlloc > 5e8 for 2m", MetricType: models.GaugeMetricType, Key: "HeapAlloc", Op: OpGreater, Threshold: 5e8, For: 2 * time.Minute},
+		},
+		{
+			testName: "Should parse counter rate rule",
+			config:   RuleConfig{Name: "agent down", Expr: "counter rate(PollCount) == 0 for 1m"},
+			expected: Rule{Name: "agent down", Expr: "counter rate(PollCount) == 0 for 1m", MetricType: models.CounterMetricType, Key: "PollCount", Function: RateFunction, Op: OpEqual, Threshold: 0, For: time.Minute},
+		},
+		{
+			testName: "Should use counter type for rate by default",
+			config:   RuleConfig{Expr: "rate(requests)>=10"},
+			expected: Rule{Name: "rate(requests)>=10", Expr: "rate(requests)>=10", MetricType: models.CounterMetricType, Key: "requests", Function: RateFunction, Op: OpGreaterEqual, Threshold: 10},
+		},
+		{
+			testName: "Should parse metric with labels",
+			config:   RuleConfig{Expr: `cpu{host="a",dc="eu"} != 1`},
+			expected: Rule{Name: `cpu{host="a",dc="eu"} != 1`, Expr: `cpu{host="a",dc="eu"} != 1`, MetricType: models.GaugeMetricType, Key: `cpu{dc="eu",host="a"}`, Op: OpNotEqual, Threshold: 1},
+		},
+		{
+			testName: "Should return error if rate is applied to gauge",
+			config:   RuleConfig{Expr: "gauge rate(HeapAlloc) > 1"},
+			hasError: true,
+		},
+		{
+			testName: "Should return error if operator is unknown",
+			config:   RuleConfig{Expr: "HeapAlloc => 1"},
+			hasError: true,
+		},
+		{
+			testName: "Should return error if threshold is invalid",
+			config:   RuleConfig{Expr: "HeapAlloc > many"},
+			hasError: true,
+		},
+		{
+			testName: "Should return error if duration is invalid",
+			config:   RuleConfig{Expr: "HeapAlloc > 1 for ever"},
+			hasError: true,
+		},
+		{
+			testName: "Should return error if type is unknown",
+			config:   RuleConfig{Expr: "histogram latency > 1"},
+			hasError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			rule, err := ParseRule(tc.config)
+
+			if tc.hasError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, rule)
+		})
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	t.Run("Should parse rules", func(t *testing.T) {
+		rules, err := ParseRules([]RuleConfig{{Expr: "HeapAlloc > 1"}, {Name: "low", Expr: "HeapAlloc < 1"}})
+
+		require.NoError(t, err)
+		assert.Len(t, rules, 2)
+	})
+
+	t.Run("Should return error if names are duplicated", func(t *testing.T) {
+		_, err := ParseRules([]RuleConfig{{Name: "heap", Expr: "HeapAlloc > 1"}, {Name: "heap", Expr: "HeapAlloc < 1"}})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestRuleMatches(t *testing.T) {
+	testCases := []struct {
+		op       string
+		value    float64
+		expected bool
+	}{
+		{OpGreater, 2, true},
+		{OpGreater, 1, false},
+		{OpGreaterEqual, 1, true},
+		{OpLess, 0, true},
+		{OpLessEqual, 2, false},
+		{OpEqual, 1, true},
+		{OpNotEqual, 1, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.op, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Rule{Op: tc.op, Threshold: 1}.Matches(tc.value))
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package services
+
+// counterDeltaLimit граница диапазона int64: приращение счетчика лежит в [-counterDeltaLimit, counterDeltaLimit).
+const counterDeltaLimit = 1 << 63
+
+// CounterDelta преобразует значение в приращение счетчика, отбрасывая дробную часть.
+// Возвращает false, если значение не конечно либо не помещается в int64: такие значения
+// при преобразовании переполняются и сохранялись бы как огромное отрицательное приращение.
+func CounterDelta(value float64) (int64, bool) {
+	if !(value >= -counterDeltaLimit && value < counterDeltaLimit) {
+		return 0, false
+	}
+
+	return int64(value), true
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounterDelta(t *testing.T) {
+	testCases := []struct {
+		name  string
+		value float64
+		delta int64
+		ok    bool
+	}{
+		{name: "integer", value: 5, delta: 5, ok: true},
+		{name: "fraction is truncated", value: 2.9, delta: 2, ok: true},
+		{name: "negative", value: -3.5, delta: -3, ok: true},
+		{name: "min int64", value: math.MinInt64, delta: math.MinInt64, ok: true},
+		{name: "max int64 overflows", value: math.MaxInt64},
+		{name: "huge", value: 1e300},
+		{name: "huge negative", value: -1e300},
+		{name: "positive infinity", value: math.Inf(1)},
+		{name: "negative infinity", value: math.Inf(-1)},
+		{name: "NaN", value: math.NaN()},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			delta, ok := CounterDelta(tc.value)
+
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.delta, delta)
+		})
+	}
+}
@@ -0,0 +1,310 @@
+// Package exposition формирует представление метрик в текстовом формате Prometheus и в формате OpenMetrics.
+package exposition
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/services"
+)
+
+// Format формат представления метрик.
+type Format int
+
+// Поддерживаемые форматы представления метрик.
+const (
+	FormatText        Format = iota // Текстовый формат Prometheus 0.0.4
+	FormatOpenMetrics               // Формат OpenMetrics 1.0.0
+)
+
+// Типы содержимого ответов для поддерживаемых форматов.
+const (
+	ContentTypeText        = "text/plain; version=0.0.4; charset=utf-8"
+	ContentTypeOpenMetrics = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// ContentType возвращает тип содержимого ответа для формата.
+func (f Format) ContentType() string {
+	if f == FormatOpenMetrics {
+		return ContentTypeOpenMetrics
+	}
+
+	return ContentTypeText
+}
+
+// Negotiate выбирает формат по заголовку Accept. OpenMetrics используется, только если клиент
+// явно запросил его и не предпочел текстовый формат с большим весом.
+func Negotiate(accept string) Format {
+	openMetricsQuality, textQuality := -1.0, -1.0
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+
+		if q, ok := params["q"]; ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = v
+			}
+		}
+
+		switch mediaType {
+		case "application/openmetrics-text":
+			openMetricsQuality = math.Max(openMetricsQuality, quality)
+		case "text/plain":
+			textQuality = math.Max(textQuality, quality)
+		}
+	}
+
+	if openMetricsQuality > 0 && openMetricsQuality >= textQuality {
+		return FormatOpenMetrics
+	}
+
+	return FormatText
+}
+
+// SanitizeName приводит имя метрики к допустимому в Prometheus набору символов [a-zA-Z_:][a-zA-Z0-9_:]*.
+// Недопустимые символы заменяются на "_".
+func SanitizeName(name string) string {
+	if name == "" {
+		return "_"
+	}
+
+	var b strings.Builder
+
+	b.Grow(len(name) + 1)
+
+	for i, r := range name {
+		valid := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == ':'
+
+		if i > 0 && r >= '0' && r <= '9' {
+			valid = true
+		}
+
+		if i == 0 && r >= '0' && r <= '9' {
+			b.WriteByte('_')
+			b.WriteRune(r)
+			continue
+		}
+
+		if valid {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+
+	return b.String()
+}
+
+// family группа метрик одного имени и типа.
+type family struct {
+	name       string
+	metricType string
+	metrics    []models.Metrics
+}
+
+// typeOrder определяет, какой тип остается в выводе, если метрики разных типов дают одно имя.
+var typeOrder = map[string]int{
+	models.CounterMetricType:   0,
+	models.GaugeMetricType:     1,
+	models.HistogramMetricType: 2,
+}
+
+// groupFamilies группирует метрики по очищенному имени. Если одно имя получили метрики разных типов,
+// в вывод попадает только тип с наименьшим typeOrder, так как Prometheus не допускает такие конфликты.
+func groupFamilies(metrics []models.Metrics, format Format) []*family {
+	families := make(map[string]*family)
+
+	for _, metric := range metrics {
+		name := SanitizeName(metric.ID)
+
+		if format == FormatOpenMetrics && metric.MType == models.CounterMetricType {
+			name = strings.TrimSuffix(name, "_total")
+		}
+
+		current, ok := families[name]
+
+		if !ok {
+			families[name] = &family{name: name, metricType: metric.MType, metrics: []models.Metrics{metric}}
+			continue
+		}
+
+		switch {
+		case current.metricType == metric.MType:
+			current.metrics = append(current.metrics, metric)
+		case typeOrder[metric.MType] < typeOrder[current.metricType]:
+			families[name] = &family{name: name, metricType: metric.MType, metrics: []models.Metrics{metric}}
+		}
+	}
+
+	result := make([]*family, 0, len(families))
+
+	for _, item := range families {
+		sort.Slice(item.metrics, func(i, j int) bool {
+			return formatLabels(item.metrics[i].Labels, "", "") < formatLabels(item.metrics[j].Labels, "", "")
+		})
+
+		result = append(result, item)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].name < result[j].name
+	})
+
+	return result
+}
+
+// Write записывает метрики в указанном формате.
+func Write(w io.Writer, metrics []models.Metrics, format Format) error {
+	b := bufio.NewWriter(w)
+
+	for _, item := range groupFamilies(metrics, format) {
+		b.WriteString("# TYPE ")
+		b.WriteString(item.name)
+		b.WriteByte(' ')
+		b.WriteString(item.metricType)
+		b.WriteByte('\n')
+
+		for _, metric := range item.metrics {
+			writeMetric(b, item.name, metric, format)
+		}
+	}
+
+	if format == FormatOpenMetrics {
+		b.WriteString("# EOF\n")
+	}
+
+	return b.Flush()
+}
+
+func writeMetric(b *bufio.Writer, name string, metric models.Metrics, format Format) {
+	switch metric.MType {
+	case models.GaugeMetricType:
+		if metric.Value != nil {
+			writeSample(b, name, formatLabels(metric.Labels, "", ""), formatFloat(*metric.Value))
+		}
+	case models.CounterMetricType:
+		if metric.Delta != nil {
+			sampleName := name
+
+			if format == FormatOpenMetrics {
+				sampleName += "_total"
+			}
+
+			writeSample(b, sampleName, formatLabels(metric.Labels, "", ""), strconv.FormatInt(*metric.Delta, 10))
+		}
+	case models.HistogramMetricType:
+		if metric.Histogram != nil {
+			writeHistogram(b, name, metric)
+		}
+	}
+}
+
+// writeHistogram записывает корзины гистограммы с накопленным количеством значений, сумму и количество.
+func writeHistogram(b *bufio.Writer, name string, metric models.Metrics) {
+	var cumulative uint64
+
+	for i, count := range metric.Histogram.Counts {
+		cumulative += count
+		bound := "+Inf"
+
+		if i < len(metric.Histogram.Bounds) {
+			bound = formatFloat(metric.Histogram.Bounds[i])
+		}
+
+		writeSample(b, name+"_bucket", formatLabels(metric.Labels, "le", bound), strconv.FormatUint(cumulative, 10))
+	}
+
+	labels := formatLabels(metric.Labels, "", "")
+
+	writeSample(b, name+"_sum", labels, formatFloat(metric.Histogram.Sum))
+	writeSample(b, name+"_count", labels, strconv.FormatUint(metric.Histogram.Count, 10))
+}
+
+func writeSample(b *bufio.Writer, name, labels, value string) {
+	b.WriteString(name)
+	b.WriteString(labels)
+	b.WriteByte(' ')
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// formatLabels формирует набор меток, отсортированных по имени. Дополнительная метка extraName
+// добавляется в конец, если она задана.
+func formatLabels(labels map[string]string, extraName, extraValue string) string {
+	if len(labels) == 0 && extraName == "" {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	sanitized := make(map[string]string, len(labels))
+
+	for labelName, value := range labels {
+		labelName = services.SanitizeLabelName(labelName)
+
+		if _, ok := sanitized[labelName]; !ok {
+			names = append(names, labelName)
+		}
+
+		sanitized[labelName] = value
+	}
+
+	sort.Strings(names)
+
+	var b strings.Builder
+
+	b.WriteByte('{')
+
+	for i, labelName := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		writeLabel(&b, labelName, sanitized[labelName])
+	}
+
+	if extraName != "" {
+		if len(names) > 0 {
+			b.WriteByte(',')
+		}
+
+		writeLabel(&b, extraName, extraValue)
+	}
+
+	b.WriteByte('}')
+
+	return b.String()
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func writeLabel(b *strings.Builder, name, value string) {
+	b.WriteString(name)
+	b.WriteString(`="`)
+	b.WriteString(labelValueReplacer.Replace(value))
+	b.WriteByte('"')
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
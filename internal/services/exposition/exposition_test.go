@@ -0,0 +1,131 @@
+package exposition
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	testCases := []struct {
+		testName string
+		accept   string
+		expected Format
+	}{
+		{testName: "Should use text format by default", accept: "", expected: FormatText},
+		{testName: "Should use text format for any media type", accept: "*/*", expected: FormatText},
+		{testName: "Should use openmetrics when requested", accept: "application/openmetrics-text; version=1.0.0", expected: FormatOpenMetrics},
+		{
+			testName: "Should prefer format with higher quality",
+			accept:   "application/openmetrics-text;version=1.0.0;q=0.5,text/plain;version=0.0.4;q=0.9",
+			expected: FormatText,
+		},
+		{
+			testName: "Should use openmetrics when it has higher quality",
+			accept:   "application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5,*/*;q=0.1",
+			expected: FormatOpenMetrics,
+		},
+		{testName: "Should ignore openmetrics with zero quality", accept: "application/openmetrics-text;q=0", expected: FormatText},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Negotiate(tc.accept))
+		})
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "Alloc", expected: "Alloc"},
+		{name: "http.requests-total", expected: "http_requests_total"},
+		{name: "ns:metric", expected: "ns:metric"},
+		{name: "1st", expected: "_1st"},
+		{name: "метрика", expected: "_______"},
+		{name: "", expected: "_"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, SanitizeName(tc.name))
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	gauge := 1.5
+	negative := math.Inf(-1)
+	counter := int64(10)
+	otherCounter := int64(2)
+
+	metrics := []models.Metrics{
+		{ID: "requests_total", MType: models.CounterMetricType, Delta: &counter, Labels: map[string]string{"path": "/b"}},
+		{ID: "requests_total", MType: models.CounterMetricType, Delta: &otherCounter, Labels: map[string]string{"path": "/a"}},
+		{ID: "heap.alloc", MType: models.GaugeMetricType, Value: &gauge, Labels: map[string]string{"host": "a\"b\\c\nd"}},
+		{ID: "temperature", MType: models.GaugeMetricType, Value: &negative},
+		{ID: "heap_alloc", MType: models.CounterMetricType, Delta: &counter},
+		{
+			ID:    "latency",
+			MType: models.HistogramMetricType,
+			Histogram: &models.Histogram{
+				Bounds: []float64{0.1, 1},
+				Counts: []uint64{1, 2, 3},
+				Sum:    7.5,
+				Count:  6,
+			},
+			Labels: map[string]string{"service-name": "api"},
+		},
+	}
+
+	t.Run("Should write metrics in text format", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		require.NoError(t, Write(&buf, metrics, FormatText))
+
+		assert.Equal(t, `# TYPE heap_alloc counter
+heap_alloc 10
+# TYPE latency histogram
+latency_bucket{service_name="api",le="0.1"} 1
+latency_bucket{service_name="api",le="1"} 3
+latency_bucket{service_name="api",le="+Inf"} 6
+latency_sum{service_name="api"} 7.5
+latency_count{service_name="api"} 6
+# TYPE requests_total counter
+requests_total{path="/a"} 2
+requests_total{path="/b"} 10
+# TYPE temperature gauge
+temperature -Inf
+`, buf.String())
+	})
+
+	t.Run("Should write metrics in openmetrics format", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		require.NoError(t, Write(&buf, metrics[:4], FormatOpenMetrics))
+
+		assert.Equal(t, `# TYPE heap_alloc gauge
+heap_alloc{host="a\"b\\c\nd"} 1.5
+# TYPE requests counter
+requests_total{path="/a"} 2
+requests_total{path="/b"} 10
+# TYPE temperature gauge
+temperature -Inf
+# EOF
+`, buf.String())
+	})
+
+	t.Run("Should write only eof marker for empty openmetrics output", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		require.NoError(t, Write(&buf, nil, FormatOpenMetrics))
+
+		assert.Equal(t, "# EOF\n", buf.String())
+	})
+}
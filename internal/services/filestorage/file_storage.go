@@ -3,27 +3,34 @@ package filestorage
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/daremove/go-metrics-service/internal/logger"
+	"github.com/daremove/go-metrics-service/internal/models"
 	"github.com/daremove/go-metrics-service/internal/storage"
 	"go.uber.org/zap"
 )
 
 // backupFile структура для сериализации и десериализации данных метрик в файл.
 type backupFile struct {
-	Counters []storage.CounterMetric `json:"counters"`
-	Gauges   []storage.GaugeMetric   `json:"gauges"`
+	Counters   []storage.CounterMetric   `json:"counters"`
+	Gauges     []storage.GaugeMetric     `json:"gauges"`
+	Histograms []storage.HistogramMetric `json:"histograms"`
+	Sequence   uint64                    `json:"wal_sequence,omitempty"` // Номер последней записи журнала, вошедшей в снимок
 }
 
 // FileStorage реализует интерфейс Storage, предоставляя методы для работы с метриками, хранящимися в файле.
+// Каждое изменение дописывается в журнал (write-ahead log), а полный снимок данных записывается
+// только при сжатии журнала: периодически либо по достижении порога количества записей.
 type FileStorage struct {
 	Storage
-	storage Storage // Внутреннее хранилище для делегирования операций
-	config  Config  // Конфигурация хранилища
+	storage Storage          // Внутреннее хранилище для делегирования операций
+	config  Config           // Конфигурация хранилища
+	wal     *writeAheadLog   // Журнал изменений, nil если путь к файлу не задан
+	locks   *keyLocks        // Блокировки изменяемых метрик, упорядочивающие записи журнала одной метрики
+	now     func() time.Time // Источник текущего времени для записей журнала
 }
 
 // Storage интерфейс определяет методы, которые должны быть реализованы для работы с метриками.
@@ -34,68 +41,264 @@ type Storage interface {
 	GetCounterMetric(ctx context.Context, key string) (storage.CounterMetric, error)
 	GetCounterMetrics(ctx context.Context) ([]storage.CounterMetric, error)
 
+	GetHistogramMetric(ctx context.Context, key string) (storage.HistogramMetric, error)
+	GetHistogramMetrics(ctx context.Context) ([]storage.HistogramMetric, error)
+
+	ListMetrics(ctx context.Context, parameters storage.ListParameters) ([]storage.MetricRecord, error)
+
 	AddGaugeMetric(ctx context.Context, key string, value float64) error
 	AddCounterMetric(ctx context.Context, key string, value int64) error
+	AddHistogramMetric(ctx context.Context, key string, value storage.HistogramMetric) error
+
+	AddMetrics(ctx context.Context, gaugeMetrics []storage.GaugeMetric, counterMetrics []storage.CounterMetric, histogramMetrics []storage.HistogramMetric) error
+	RestoreMetrics(ctx context.Context, gaugeMetrics []storage.GaugeMetric, counterMetrics []storage.CounterMetric, histogramMetrics []storage.HistogramMetric) error
 
-	AddMetrics(ctx context.Context, gaugeMetrics []storage.GaugeMetric, counterMetrics []storage.CounterMetric) error
+	DeleteGaugeMetric(ctx context.Context, key string) error
+	DeleteCounterMetric(ctx context.Context, key string) error
+	DeleteHistogramMetric(ctx context.Context, key string) error
+	DeleteStaleMetric(ctx context.Context, metricType, key string, updatedAt time.Time) error
+	DeleteMetricsByPrefix(ctx context.Context, prefix string) (int, error)
+	ResetCounterMetric(ctx context.Context, key string) error
+
+	AddMetricSamples(ctx context.Context, samples []storage.MetricSample) error
+	GetMetricSamples(ctx context.Context, metricType, key string, from, to time.Time) ([]storage.MetricSample, error)
+	DeleteMetricSamplesBefore(ctx context.Context, before time.Time) (int, error)
 }
 
+// DefaultCompactRecords количество записей журнала, после которого выполняется его сжатие, если StoreInterval равен 0.
+const DefaultCompactRecords = 1000
+
 // Config структура конфигурации FileStorage.
 type Config struct {
-	StoreInterval   int    // Интервал автоматического сохранения данных в файл
-	FileStoragePath string // Путь к файлу хранения данных
+	StoreInterval   int    // Интервал сжатия журнала в снимок в секундах, 0 — сжатие по количеству записей
+	FileStoragePath string // Путь к файлу снимка данных, журнал хранится рядом с суффиксом .wal
 	Restore         bool   // Флаг, указывающий на необходимость восстановления данных из файла при инициализации
+	CompactRecords  int    // Количество записей журнала, после которого выполняется сжатие при StoreInterval = 0
+	SnapshotKeep    int    // Количество предыдущих снимков и частей журнала, к которым выполняется откат, если последний снимок поврежден
 }
 
-// AddGaugeMetric добавляет значение типа gauge в хранилище и выполняет бэкап данных при необходимости.
+// AddGaugeMetric добавляет значение типа gauge в хранилище и записывает изменение в журнал.
 func (fs FileStorage) AddGaugeMetric(ctx context.Context, key string, value float64) error {
-	if err := fs.storage.AddGaugeMetric(ctx, key, value); err != nil {
-		return err
-	}
+	return fs.write(ctx, walRecord{Operation: walOpAdd, Gauges: []storage.GaugeMetric{{Name: key, Value: value}}}, func() error {
+		return fs.storage.AddGaugeMetric(ctx, key, value)
+	})
+}
+
+// AddCounterMetric добавляет значение типа counter в хранилище и записывает изменение в журнал.
+func (fs FileStorage) AddCounterMetric(ctx context.Context, key string, value int64) error {
+	return fs.write(ctx, walRecord{Operation: walOpAdd, Counters: []storage.CounterMetric{{Name: key, Value: value}}}, func() error {
+		return fs.storage.AddCounterMetric(ctx, key, value)
+	})
+}
+
+// AddHistogramMetric добавляет значение типа histogram в хранилище и записывает изменение в журнал.
+func (fs FileStorage) AddHistogramMetric(ctx context.Context, key string, value storage.HistogramMetric) error {
+	value.Name = key
+
+	return fs.write(ctx, walRecord{Operation: walOpAdd, Histograms: []storage.HistogramMetric{value}}, func() error {
+		return fs.storage.AddHistogramMetric(ctx, key, value)
+	})
+}
+
+// AddMetrics добавляет несколько метрик в хранилище и записывает изменение в журнал одной записью.
+func (fs FileStorage) AddMetrics(ctx context.Context, gaugeMetrics []storage.GaugeMetric, counterMetrics []storage.CounterMetric, histogramMetrics []storage.HistogramMetric) error {
+	record := walRecord{Operation: walOpAdd, Gauges: gaugeMetrics, Counters: counterMetrics, Histograms: histogramMetrics}
+
+	return fs.write(ctx, record, func() error {
+		return fs.storage.AddMetrics(ctx, gaugeMetrics, counterMetrics, histogramMetrics)
+	})
+}
+
+// DeleteGaugeMetric удаляет метрику типа gauge из хранилища и записывает изменение в журнал.
+func (fs FileStorage) DeleteGaugeMetric(ctx context.Context, key string) error {
+	return fs.write(ctx, walRecord{Operation: walOpDelete, MetricType: models.GaugeMetricType, Key: key}, func() error {
+		return fs.storage.DeleteGaugeMetric(ctx, key)
+	})
+}
+
+// DeleteCounterMetric удаляет метрику типа counter из хранилища и записывает изменение в журнал.
+func (fs FileStorage) DeleteCounterMetric(ctx context.Context, key string) error {
+	return fs.write(ctx, walRecord{Operation: walOpDelete, MetricType: models.CounterMetricType, Key: key}, func() error {
+		return fs.storage.DeleteCounterMetric(ctx, key)
+	})
+}
+
+// DeleteHistogramMetric удаляет метрику типа histogram из хранилища и записывает изменение в журнал.
+func (fs FileStorage) DeleteHistogramMetric(ctx context.Context, key string) error {
+	return fs.write(ctx, walRecord{Operation: walOpDelete, MetricType: models.HistogramMetricType, Key: key}, func() error {
+		return fs.storage.DeleteHistogramMetric(ctx, key)
+	})
+}
+
+// DeleteStaleMetric удаляет метрику, если она не обновлялась после updatedAt, и записывает удаление в журнал.
+// Время обновления проверяется до записи в журнал под блокировкой метрики, поэтому в журнал попадает
+// только удаление, которое будет применено.
+func (fs FileStorage) DeleteStaleMetric(ctx context.Context, metricType, key string, updatedAt time.Time) error {
+	check := func() error {
+		current, err := fs.updatedAt(ctx, metricType, key)
+
+		if err != nil {
+			return err
+		}
+
+		if current.After(updatedAt) {
+			return storage.ErrDataNotFound
+		}
 
-	if fs.config.StoreInterval > 0 {
 		return nil
 	}
 
-	if err := backupData(ctx, fs.storage, fs.config.FileStoragePath); err != nil {
-		return fmt.Errorf("error has occurred during backup data: %s", err)
-	}
+	return fs.writeChecked(ctx, walRecord{Operation: walOpDelete, MetricType: metricType, Key: key}, check, func() error {
+		return fs.storage.DeleteStaleMetric(ctx, metricType, key, updatedAt)
+	})
+}
 
-	return nil
+// updatedAt возвращает время обновления метрики.
+func (fs FileStorage) updatedAt(ctx context.Context, metricType, key string) (time.Time, error) {
+	switch metricType {
+	case models.GaugeMetricType:
+		metric, err := fs.storage.GetGaugeMetric(ctx, key)
+		return metric.UpdatedAt, err
+	case models.CounterMetricType:
+		metric, err := fs.storage.GetCounterMetric(ctx, key)
+		return metric.UpdatedAt, err
+	case models.HistogramMetricType:
+		metric, err := fs.storage.GetHistogramMetric(ctx, key)
+		return metric.UpdatedAt, err
+	default:
+		return time.Time{}, storage.ErrDataNotFound
+	}
 }
 
-// AddCounterMetric добавляет значение типа counter в хранилище и выполняет бэкап данных при необходимости.
-func (fs FileStorage) AddCounterMetric(ctx context.Context, key string, value int64) error {
-	if err := fs.storage.AddCounterMetric(ctx, key, value); err != nil {
+// DeleteMetricsByPrefix удаляет метрики по префиксу ключа и записывает изменение в журнал.
+func (fs FileStorage) DeleteMetricsByPrefix(ctx context.Context, prefix string) (int, error) {
+	var deleted int
+
+	err := fs.write(ctx, walRecord{Operation: walOpDeletePrefix, Key: prefix}, func() error {
+		var err error
+		deleted, err = fs.storage.DeleteMetricsByPrefix(ctx, prefix)
+
 		return err
+	})
+
+	if err != nil {
+		return 0, err
 	}
 
-	if fs.config.StoreInterval > 0 {
-		return nil
+	return deleted, nil
+}
+
+// ResetCounterMetric обнуляет метрику типа counter и записывает изменение в журнал.
+func (fs FileStorage) ResetCounterMetric(ctx context.Context, key string) error {
+	return fs.write(ctx, walRecord{Operation: walOpReset, MetricType: models.CounterMetricType, Key: key}, func() error {
+		return fs.storage.ResetCounterMetric(ctx, key)
+	})
+}
+
+// write дописывает изменение в журнал и затем применяет его к хранилищу.
+func (fs FileStorage) write(ctx context.Context, record walRecord, apply func() error) error {
+	return fs.writeChecked(ctx, record, nil, apply)
+}
+
+// writeChecked дописывает изменение в журнал и затем применяет его к хранилищу. Изменение не применяется,
+// если его не удалось записать в журнал, а check, если задан, вызывается до записи и может отменить изменение.
+// На время записи и применения удерживаются блокировки изменяемых метрик, чтобы изменения одной метрики
+// применялись в порядке записей журнала; общая блокировка журнала удерживается только на время дописывания.
+// Изменение, записанное в журнал, но не примененное из-за ошибки, при восстановлении приводит к той же ошибке
+// и пропускается. Если StoreInterval равен 0, журнал сжимается в снимок по достижении порога количества записей.
+func (fs FileStorage) writeChecked(ctx context.Context, record walRecord, check, apply func() error) error {
+	if fs.wal == nil {
+		return apply()
+	}
+
+	var unlock func()
+
+	if keys, ok := record.keys(); ok {
+		unlock = fs.locks.lock(keys)
+	} else {
+		unlock = fs.locks.lockAll()
 	}
 
-	if err := backupData(ctx, fs.storage, fs.config.FileStoragePath); err != nil {
+	err := fs.appendAndApply(record, check, apply)
+	unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if err := fs.compactIfNeeded(ctx); err != nil {
 		return fmt.Errorf("error has occurred during backup data: %s", err)
 	}
 
 	return nil
 }
 
-// AddMetrics добавляет несколько метрик в хранилище и выполняет бэкап данных при необходимости.
-func (fs FileStorage) AddMetrics(ctx context.Context, gaugeMetrics []storage.GaugeMetric, counterMetrics []storage.CounterMetric) error {
-	if err := fs.storage.AddMetrics(ctx, gaugeMetrics, counterMetrics); err != nil {
-		return err
+// appendAndApply проверяет изменение, дописывает его в журнал и применяет.
+// Вызывающий код должен удерживать блокировки изменяемых метрик.
+func (fs FileStorage) appendAndApply(record walRecord, check, apply func() error) error {
+	if check != nil {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+
+	fs.wal.mu.Lock()
+	record.UpdatedAt = fs.now()
+	err := fs.wal.append(record)
+	fs.wal.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("error has occurred during write to log: %s", err)
 	}
 
+	return apply()
+}
+
+// compactIfNeeded сжимает журнал в снимок, если StoreInterval равен 0 и достигнут порог количества записей.
+// Порог проверяется повторно под блокировками, так как журнал мог уже сжать другой вызов.
+func (fs FileStorage) compactIfNeeded(ctx context.Context) error {
 	if fs.config.StoreInterval > 0 {
 		return nil
 	}
 
-	if err := backupData(ctx, fs.storage, fs.config.FileStoragePath); err != nil {
-		return fmt.Errorf("error has occurred during backup data: %s", err)
+	fs.wal.mu.Lock()
+	records := fs.wal.records
+	fs.wal.mu.Unlock()
+
+	if records < fs.compactRecords() {
+		return nil
 	}
 
-	return nil
+	unlock := fs.locks.lockAll()
+	defer unlock()
+
+	fs.wal.mu.Lock()
+	defer fs.wal.mu.Unlock()
+
+	if fs.wal.records < fs.compactRecords() {
+		return nil
+	}
+
+	return fs.compact(ctx)
+}
+
+func (fs FileStorage) compactRecords() int {
+	if fs.config.CompactRecords > 0 {
+		return fs.config.CompactRecords
+	}
+
+	return DefaultCompactRecords
+}
+
+// compact записывает снимок всех данных и начинает новый журнал.
+// Вызывающий код должен удерживать все блокировки метрик и блокировку журнала,
+// чтобы снимок содержал ровно изменения, записанные в журнал.
+func (fs FileStorage) compact(ctx context.Context) error {
+	if err := backupData(ctx, fs.storage, fs.config, fs.wal.sequence); err != nil {
+		return err
+	}
+
+	return fs.wal.reset()
 }
 
 // GetGaugeMetric извлекает метрику типа gauge из хранилища.
@@ -118,7 +321,38 @@ func (fs FileStorage) GetCounterMetrics(ctx context.Context) ([]storage.CounterM
 	return fs.storage.GetCounterMetrics(ctx)
 }
 
-func backupData(ctx context.Context, fs Storage, filePath string) error {
+// GetHistogramMetric извлекает метрику типа histogram из хранилища.
+func (fs FileStorage) GetHistogramMetric(ctx context.Context, key string) (storage.HistogramMetric, error) {
+	return fs.storage.GetHistogramMetric(ctx, key)
+}
+
+// GetHistogramMetrics извлекает все метрики типа histogram из хранилища.
+func (fs FileStorage) GetHistogramMetrics(ctx context.Context) ([]storage.HistogramMetric, error) {
+	return fs.storage.GetHistogramMetrics(ctx)
+}
+
+// ListMetrics возвращает страницу метрик всех типов, удовлетворяющих условиям.
+func (fs FileStorage) ListMetrics(ctx context.Context, parameters storage.ListParameters) ([]storage.MetricRecord, error) {
+	return fs.storage.ListMetrics(ctx, parameters)
+}
+
+// AddMetricSamples добавляет значения в историю метрик. История не попадает в файл бэкапа.
+func (fs FileStorage) AddMetricSamples(ctx context.Context, samples []storage.MetricSample) error {
+	return fs.storage.AddMetricSamples(ctx, samples)
+}
+
+// DeleteMetricSamplesBefore удаляет значения истории, сохраненные раньше before. История не попадает
+// в файл бэкапа, поэтому изменение не записывается в журнал.
+func (fs FileStorage) DeleteMetricSamplesBefore(ctx context.Context, before time.Time) (int, error) {
+	return fs.storage.DeleteMetricSamplesBefore(ctx, before)
+}
+
+// GetMetricSamples извлекает историю значений метрики в диапазоне [from, to].
+func (fs FileStorage) GetMetricSamples(ctx context.Context, metricType, key string, from, to time.Time) ([]storage.MetricSample, error) {
+	return fs.storage.GetMetricSamples(ctx, metricType, key, from, to)
+}
+
+func backupData(ctx context.Context, fs Storage, config Config, sequence uint64) error {
 	counterMetrics, err := fs.GetCounterMetrics(ctx)
 
 	if err != nil {
@@ -131,57 +365,165 @@ func backupData(ctx context.Context, fs Storage, filePath string) error {
 		return fmt.Errorf("cannot serialize data: %s", err)
 	}
 
-	serialisedData, err := json.Marshal(backupFile{
-		Counters: counterMetrics,
-		Gauges:   gaugeMetrics,
+	histogramMetrics, err := fs.GetHistogramMetrics(ctx)
+
+	if err != nil {
+		return fmt.Errorf("cannot serialize data: %s", err)
+	}
+
+	serialisedData, err := encodeSnapshot(backupFile{
+		Counters:   counterMetrics,
+		Gauges:     gaugeMetrics,
+		Histograms: histogramMetrics,
+		Sequence:   sequence,
 	})
 
 	if err != nil {
 		return fmt.Errorf("cannot serialize data: %s", err)
 	}
 
-	if err := os.WriteFile(filePath, serialisedData, 0666); err != nil {
+	if err := writeSnapshot(config.FileStoragePath, serialisedData, config.SnapshotKeep); err != nil {
 		return fmt.Errorf("cannot write data to file: %s", err)
 	}
 
 	return nil
 }
 
+// restoreSnapshot загружает последний корректный снимок данных в хранилище
+// и возвращает номер последней вошедшей в него записи журнала.
+// Метрики восстанавливаются с сохраненным в снимке временем обновления, чтобы устаревшие
+// до перезапуска метрики не получали заново полный срок хранения.
+func restoreSnapshot(ctx context.Context, storage Storage, config Config) (uint64, error) {
+	backupData, err := readSnapshot(config.FileStoragePath, config.SnapshotKeep)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if err := storage.RestoreMetrics(ctx, backupData.Gauges, backupData.Counters, backupData.Histograms); err != nil {
+		return 0, fmt.Errorf("cannot initialize data from file: %s", err)
+	}
+
+	return backupData.Sequence, nil
+}
+
+// replayRecord повторно применяет к хранилищу изменение из журнала.
+// Добавленные и обнуленные метрики получают время обновления из записи журнала.
+// Изменение записывается в журнал до применения, поэтому ошибки, с которыми оно не было применено, —
+// отсутствие удаляемой или обнуляемой метрики и несовпадение границ гистограммы — ошибками не считаются.
+func replayRecord(ctx context.Context, s Storage, record walRecord) error {
+	var err error
+
+	switch record.Operation {
+	case walOpAdd:
+		gauges, counters, histograms := record.metrics()
+		err = s.RestoreMetrics(ctx, gauges, counters, histograms)
+	case walOpDelete:
+		switch record.MetricType {
+		case models.GaugeMetricType:
+			err = s.DeleteGaugeMetric(ctx, record.Key)
+		case models.CounterMetricType:
+			err = s.DeleteCounterMetric(ctx, record.Key)
+		case models.HistogramMetricType:
+			err = s.DeleteHistogramMetric(ctx, record.Key)
+		default:
+			err = fmt.Errorf("metric type %s isn't supported", record.MetricType)
+		}
+	case walOpDeletePrefix:
+		_, err = s.DeleteMetricsByPrefix(ctx, record.Key)
+	case walOpReset:
+		err = s.ResetCounterMetric(ctx, record.Key)
+
+		if err == nil {
+			// Нулевое приращение выставляет обнуленному счетчику время обнуления из журнала.
+			err = s.RestoreMetrics(ctx, nil, []storage.CounterMetric{{Name: record.Key, UpdatedAt: record.UpdatedAt}}, nil)
+		}
+	default:
+		err = fmt.Errorf("operation %s isn't supported", record.Operation)
+	}
+
+	if err != nil && !errors.Is(err, storage.ErrDataNotFound) && !errors.Is(err, storage.ErrHistogramBoundsMismatch) {
+		return fmt.Errorf("cannot replay log record %d: %w", record.Sequence, err)
+	}
+
+	return nil
+}
+
 // New создает новый экземпляр FileStorage.
+// При восстановлении загружается снимок данных, после чего к нему применяются записи журнала,
+// не вошедшие в снимок, в том числе из предыдущих частей журнала, если снимок восстановлен из ротации.
+// Оборванная последняя запись журнала пропускается. Если записи журнала не продолжают снимок,
+// возвращается ошибка ErrLogGap, чтобы не восстановить счетчики с пропущенными приращениями.
 func New(ctx context.Context, storage Storage, config Config) (*FileStorage, error) {
 	fileStorage := &FileStorage{
 		storage: storage,
 		config:  config,
+		locks:   &keyLocks{},
+		now:     time.Now,
 	}
 
 	if config.FileStoragePath == "" {
 		return fileStorage, nil
 	}
 
+	walPath := config.FileStoragePath + walSuffix
+	removeTempSnapshots(config.FileStoragePath)
+
+	var (
+		sequence  uint64
+		validSize int64
+	)
+
 	if config.Restore {
-		data, err := os.ReadFile(config.FileStoragePath)
+		snapshotSequence, err := restoreSnapshot(ctx, storage, config)
 
-		if err == nil {
-			backupData := backupFile{}
-			err = json.Unmarshal(data, &backupData)
+		if err != nil {
+			return nil, err
+		}
+
+		records, size, err := readWALSegments(walPath, config.SnapshotKeep)
+
+		if err != nil {
+			return nil, fmt.Errorf("cannot read log: %w", err)
+		}
+
+		sequence = snapshotSequence
 
-			if err != nil {
-				return nil, fmt.Errorf("cannot deserialize data from file: %s", err)
+		for _, record := range records {
+			if record.Sequence <= snapshotSequence {
+				continue
 			}
 
-			for _, counter := range backupData.Counters {
-				if err = storage.AddCounterMetric(ctx, counter.Name, counter.Value); err != nil {
-					return nil, fmt.Errorf("cannot initialize counter data from file: %s", err)
-				}
+			if record.Sequence != sequence+1 {
+				return nil, fmt.Errorf("%w: records up to %d are restored, next log record is %d", ErrLogGap, sequence, record.Sequence)
 			}
 
-			for _, gauge := range backupData.Gauges {
-				if err = storage.AddGaugeMetric(ctx, gauge.Name, gauge.Value); err != nil {
-					return nil, fmt.Errorf("cannot initialize gauge data from file: %s", err)
-				}
+			if err := replayRecord(ctx, storage, record); err != nil {
+				return nil, err
 			}
-		} else if !os.IsNotExist(err) {
-			return nil, fmt.Errorf("cannot read file: %s", err)
+
+			sequence = record.Sequence
+		}
+
+		validSize = size
+	}
+
+	wal, err := openWAL(walPath, validSize, config.StoreInterval == 0, config.SnapshotKeep)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot open log: %s", err)
+	}
+
+	wal.sequence = sequence
+	fileStorage.wal = wal
+
+	if !config.Restore {
+		wal.mu.Lock()
+		err := fileStorage.compact(ctx)
+		wal.mu.Unlock()
+
+		if err != nil {
+			return nil, fmt.Errorf("error has occurred during backup data: %s", err)
 		}
 	}
 
@@ -201,7 +543,30 @@ func New(ctx context.Context, storage Storage, config Config) (*FileStorage, err
 	return fileStorage, nil
 }
 
-// BackupData выполняет бэкап всех данных в файл.
+// BackupData записывает снимок всех данных в файл и начинает новый журнал изменений.
 func (fs FileStorage) BackupData(ctx context.Context) error {
-	return backupData(ctx, fs.storage, fs.config.FileStoragePath)
+	if fs.wal == nil {
+		return backupData(ctx, fs.storage, fs.config, 0)
+	}
+
+	unlock := fs.locks.lockAll()
+	defer unlock()
+
+	fs.wal.mu.Lock()
+	defer fs.wal.mu.Unlock()
+
+	return fs.compact(ctx)
+}
+
+// Close записывает снимок данных и закрывает журнал изменений.
+func (fs FileStorage) Close(ctx context.Context) error {
+	if fs.wal == nil {
+		return nil
+	}
+
+	if err := fs.BackupData(ctx); err != nil {
+		return err
+	}
+
+	return fs.wal.close()
 }
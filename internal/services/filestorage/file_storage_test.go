@@ -4,18 +4,28 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/daremove/go-metrics-service/internal/models"
 	"github.com/daremove/go-metrics-service/internal/storage"
+	"github.com/daremove/go-metrics-service/internal/storage/memstorage"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type MockStorage struct {
-	gaugeMetrics   map[string]storage.GaugeMetric
-	counterMetrics map[string]storage.CounterMetric
-	returnError    bool
+	gaugeMetrics     map[string]storage.GaugeMetric
+	counterMetrics   map[string]storage.CounterMetric
+	histogramMetrics map[string]storage.HistogramMetric
+	samples          []storage.MetricSample
+	returnError      bool
 }
 
 func (m *MockStorage) GetGaugeMetric(ctx context.Context, key string) (storage.GaugeMetric, error) {
@@ -40,6 +50,24 @@ func (m *MockStorage) GetGaugeMetrics(ctx context.Context) ([]storage.GaugeMetri
 	return metrics, nil
 }
 
+func (m *MockStorage) ListMetrics(ctx context.Context, parameters storage.ListParameters) ([]storage.MetricRecord, error) {
+	if m.returnError {
+		return nil, errors.New("error")
+	}
+	var records []storage.MetricRecord
+	for _, metric := range m.gaugeMetrics {
+		if parameters.Matches("gauge", metric.Name) {
+			records = append(records, storage.MetricRecord{Type: "gauge", Name: metric.Name, Value: metric.Value, UpdatedAt: metric.UpdatedAt})
+		}
+	}
+	for _, metric := range m.counterMetrics {
+		if parameters.Matches("counter", metric.Name) {
+			records = append(records, storage.MetricRecord{Type: "counter", Name: metric.Name, Delta: metric.Value, UpdatedAt: metric.UpdatedAt})
+		}
+	}
+	return storage.SortRecords(records, parameters.Limit), nil
+}
+
 func (m *MockStorage) GetCounterMetric(ctx context.Context, key string) (storage.CounterMetric, error) {
 	if m.returnError {
 		return storage.CounterMetric{}, errors.New("error")
@@ -62,6 +90,28 @@ func (m *MockStorage) GetCounterMetrics(ctx context.Context) ([]storage.CounterM
 	return metrics, nil
 }
 
+func (m *MockStorage) GetHistogramMetric(ctx context.Context, key string) (storage.HistogramMetric, error) {
+	if m.returnError {
+		return storage.HistogramMetric{}, errors.New("error")
+	}
+	metric, ok := m.histogramMetrics[key]
+	if !ok {
+		return storage.HistogramMetric{}, errors.New("not found")
+	}
+	return metric, nil
+}
+
+func (m *MockStorage) GetHistogramMetrics(ctx context.Context) ([]storage.HistogramMetric, error) {
+	if m.returnError {
+		return nil, errors.New("error")
+	}
+	metrics := make([]storage.HistogramMetric, 0, len(m.histogramMetrics))
+	for _, metric := range m.histogramMetrics {
+		metrics = append(metrics, metric)
+	}
+	return metrics, nil
+}
+
 func (m *MockStorage) AddGaugeMetric(ctx context.Context, key string, value float64) error {
 	if m.returnError {
 		return errors.New("error")
@@ -78,7 +128,19 @@ func (m *MockStorage) AddCounterMetric(ctx context.Context, key string, value in
 	return nil
 }
 
-func (m *MockStorage) AddMetrics(ctx context.Context, gaugeMetrics []storage.GaugeMetric, counterMetrics []storage.CounterMetric) error {
+func (m *MockStorage) AddHistogramMetric(ctx context.Context, key string, value storage.HistogramMetric) error {
+	if m.returnError {
+		return errors.New("error")
+	}
+	if m.histogramMetrics == nil {
+		m.histogramMetrics = make(map[string]storage.HistogramMetric)
+	}
+	value.Name = key
+	m.histogramMetrics[key] = value
+	return nil
+}
+
+func (m *MockStorage) AddMetrics(ctx context.Context, gaugeMetrics []storage.GaugeMetric, counterMetrics []storage.CounterMetric, histogramMetrics []storage.HistogramMetric) error {
 	if m.returnError {
 		return errors.New("error")
 	}
@@ -88,9 +150,127 @@ func (m *MockStorage) AddMetrics(ctx context.Context, gaugeMetrics []storage.Gau
 	for _, metric := range counterMetrics {
 		m.counterMetrics[metric.Name] = metric
 	}
+	for _, metric := range histogramMetrics {
+		if err := m.AddHistogramMetric(ctx, metric.Name, metric); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MockStorage) RestoreMetrics(ctx context.Context, gaugeMetrics []storage.GaugeMetric, counterMetrics []storage.CounterMetric, histogramMetrics []storage.HistogramMetric) error {
+	return m.AddMetrics(ctx, gaugeMetrics, counterMetrics, histogramMetrics)
+}
+
+func (m *MockStorage) DeleteGaugeMetric(ctx context.Context, key string) error {
+	if _, ok := m.gaugeMetrics[key]; !ok {
+		return storage.ErrDataNotFound
+	}
+	delete(m.gaugeMetrics, key)
+	return nil
+}
+
+func (m *MockStorage) DeleteCounterMetric(ctx context.Context, key string) error {
+	if _, ok := m.counterMetrics[key]; !ok {
+		return storage.ErrDataNotFound
+	}
+	delete(m.counterMetrics, key)
+	return nil
+}
+
+func (m *MockStorage) DeleteHistogramMetric(ctx context.Context, key string) error {
+	if _, ok := m.histogramMetrics[key]; !ok {
+		return storage.ErrDataNotFound
+	}
+	delete(m.histogramMetrics, key)
+	return nil
+}
+
+func (m *MockStorage) DeleteStaleMetric(ctx context.Context, metricType, key string, updatedAt time.Time) error {
+	switch metricType {
+	case models.GaugeMetricType:
+		if metric, ok := m.gaugeMetrics[key]; ok && !metric.UpdatedAt.After(updatedAt) {
+			return m.DeleteGaugeMetric(ctx, key)
+		}
+	case models.CounterMetricType:
+		if metric, ok := m.counterMetrics[key]; ok && !metric.UpdatedAt.After(updatedAt) {
+			return m.DeleteCounterMetric(ctx, key)
+		}
+	case models.HistogramMetricType:
+		if metric, ok := m.histogramMetrics[key]; ok && !metric.UpdatedAt.After(updatedAt) {
+			return m.DeleteHistogramMetric(ctx, key)
+		}
+	}
+	return storage.ErrDataNotFound
+}
+
+func (m *MockStorage) DeleteMetricsByPrefix(ctx context.Context, prefix string) (int, error) {
+	deleted := 0
+	for key := range m.gaugeMetrics {
+		if strings.HasPrefix(key, prefix) {
+			delete(m.gaugeMetrics, key)
+			deleted++
+		}
+	}
+	for key := range m.counterMetrics {
+		if strings.HasPrefix(key, prefix) {
+			delete(m.counterMetrics, key)
+			deleted++
+		}
+	}
+	for key := range m.histogramMetrics {
+		if strings.HasPrefix(key, prefix) {
+			delete(m.histogramMetrics, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (m *MockStorage) ResetCounterMetric(ctx context.Context, key string) error {
+	if _, ok := m.counterMetrics[key]; !ok {
+		return storage.ErrDataNotFound
+	}
+	m.counterMetrics[key] = storage.CounterMetric{Name: key}
+	return nil
+}
+
+func (m *MockStorage) AddMetricSamples(ctx context.Context, samples []storage.MetricSample) error {
+	if m.returnError {
+		return errors.New("error")
+	}
+	m.samples = append(m.samples, samples...)
 	return nil
 }
 
+func (m *MockStorage) DeleteMetricSamplesBefore(ctx context.Context, before time.Time) (int, error) {
+	if m.returnError {
+		return 0, errors.New("error")
+	}
+	var kept []storage.MetricSample
+	for _, sample := range m.samples {
+		if !sample.Timestamp.Before(before) {
+			kept = append(kept, sample)
+		}
+	}
+	deleted := len(m.samples) - len(kept)
+	m.samples = kept
+	return deleted, nil
+}
+
+func (m *MockStorage) GetMetricSamples(ctx context.Context, metricType, key string, from, to time.Time) ([]storage.MetricSample, error) {
+	if m.returnError {
+		return nil, errors.New("error")
+	}
+	var result []storage.MetricSample
+	for _, sample := range m.samples {
+		if sample.Type == metricType && sample.Name == key && !sample.Timestamp.Before(from) && !sample.Timestamp.After(to) {
+			result = append(result, sample)
+		}
+	}
+	return result, nil
+}
+
 func TestFileStorage(t *testing.T) {
 	t.Run("Should successfully backup data", func(t *testing.T) {
 		mockStorage := &MockStorage{
@@ -107,8 +287,7 @@ func TestFileStorage(t *testing.T) {
 				},
 			},
 		}
-		config := Config{FileStoragePath: "test_backup.json", Restore: false}
-		defer os.Remove(config.FileStoragePath)
+		config := Config{FileStoragePath: filepath.Join(t.TempDir(), "test_backup.json"), Restore: false, CompactRecords: 1}
 		fs, err := New(context.Background(), mockStorage, config)
 		assert.NoError(t, err)
 		assert.NotNil(t, fs)
@@ -118,8 +297,7 @@ func TestFileStorage(t *testing.T) {
 
 		data, err := os.ReadFile(config.FileStoragePath)
 		assert.NoError(t, err)
-		var backupData backupFile
-		err = json.Unmarshal(data, &backupData)
+		backupData, err := decodeSnapshot(data)
 		assert.NoError(t, err)
 
 		assert.Len(t, backupData.Gauges, 1)
@@ -144,8 +322,7 @@ func TestFileStorage(t *testing.T) {
 				},
 			},
 		}
-		config := Config{FileStoragePath: "test_counter.json", Restore: false}
-		defer os.Remove(config.FileStoragePath)
+		config := Config{FileStoragePath: filepath.Join(t.TempDir(), "test_counter.json"), Restore: false, CompactRecords: 1}
 		fs, err := New(context.Background(), mockStorage, config)
 
 		assert.NoError(t, err)
@@ -170,8 +347,7 @@ func TestFileStorage(t *testing.T) {
 				},
 			},
 		}
-		config := Config{FileStoragePath: "test_gauge.json", Restore: false}
-		defer os.Remove(config.FileStoragePath)
+		config := Config{FileStoragePath: filepath.Join(t.TempDir(), "test_gauge.json"), Restore: false, CompactRecords: 1}
 		fs, err := New(context.Background(), mockStorage, config)
 
 		assert.NoError(t, err)
@@ -188,8 +364,7 @@ func TestFileStorage(t *testing.T) {
 			gaugeMetrics:   make(map[string]storage.GaugeMetric),
 			counterMetrics: make(map[string]storage.CounterMetric),
 		}
-		config := Config{FileStoragePath: "add_counter.json", Restore: false}
-		defer os.Remove(config.FileStoragePath)
+		config := Config{FileStoragePath: filepath.Join(t.TempDir(), "add_counter.json"), Restore: false, CompactRecords: 1}
 		fs, err := New(context.Background(), mockStorage, config)
 
 		assert.NoError(t, err)
@@ -200,8 +375,7 @@ func TestFileStorage(t *testing.T) {
 
 		data, err := os.ReadFile(config.FileStoragePath)
 		assert.NoError(t, err)
-		var backupData backupFile
-		err = json.Unmarshal(data, &backupData)
+		backupData, err := decodeSnapshot(data)
 		assert.NoError(t, err)
 
 		assert.Len(t, backupData.Counters, 1)
@@ -214,20 +388,18 @@ func TestFileStorage(t *testing.T) {
 			gaugeMetrics:   make(map[string]storage.GaugeMetric),
 			counterMetrics: make(map[string]storage.CounterMetric),
 		}
-		config := Config{FileStoragePath: "add_metics.json", Restore: false}
-		defer os.Remove(config.FileStoragePath)
+		config := Config{FileStoragePath: filepath.Join(t.TempDir(), "add_metics.json"), Restore: false, CompactRecords: 1}
 		fs, err := New(context.Background(), mockStorage, config)
 
 		assert.NoError(t, err)
 		assert.NotNil(t, fs)
 
-		err = fs.AddMetrics(context.Background(), []storage.GaugeMetric{{Name: "gauge", Value: 1.23}}, []storage.CounterMetric{{Name: "counter", Value: 456}})
+		err = fs.AddMetrics(context.Background(), []storage.GaugeMetric{{Name: "gauge", Value: 1.23}}, []storage.CounterMetric{{Name: "counter", Value: 456}}, nil)
 		assert.NoError(t, err)
 
 		data, err := os.ReadFile(config.FileStoragePath)
 		assert.NoError(t, err)
-		var backupData backupFile
-		err = json.Unmarshal(data, &backupData)
+		backupData, err := decodeSnapshot(data)
 		assert.NoError(t, err)
 
 		assert.Len(t, backupData.Counters, 1)
@@ -255,8 +427,7 @@ func TestFileStorage(t *testing.T) {
 			gaugeMetrics:   make(map[string]storage.GaugeMetric),
 			counterMetrics: make(map[string]storage.CounterMetric),
 		}
-		config := Config{FileStoragePath: "test_backup.json", Restore: false}
-		defer os.Remove(config.FileStoragePath)
+		config := Config{FileStoragePath: filepath.Join(t.TempDir(), "test_backup.json"), Restore: false, CompactRecords: 1}
 		fs, err := New(context.Background(), mockStorage, config)
 		assert.NoError(t, err)
 
@@ -265,8 +436,7 @@ func TestFileStorage(t *testing.T) {
 
 		data, err := os.ReadFile(config.FileStoragePath)
 		assert.NoError(t, err)
-		var backupData backupFile
-		err = json.Unmarshal(data, &backupData)
+		backupData, err := decodeSnapshot(data)
 		assert.NoError(t, err)
 		assert.Len(t, backupData.Gauges, 1)
 		assert.Equal(t, "test_gauge", backupData.Gauges[0].Name)
@@ -279,13 +449,10 @@ func TestFileStorage(t *testing.T) {
 			counterMetrics: make(map[string]storage.CounterMetric),
 			returnError:    true,
 		}
-		config := Config{FileStoragePath: "test_backup.json", Restore: false}
-		defer os.Remove(config.FileStoragePath)
+		config := Config{FileStoragePath: filepath.Join(t.TempDir(), "test_backup.json"), Restore: false, CompactRecords: 1}
 		fs, err := New(context.Background(), mockStorage, config)
-		assert.NoError(t, err)
-
-		err = fs.AddGaugeMetric(context.Background(), "test_gauge", 123.45)
 		assert.Error(t, err)
+		assert.Nil(t, fs)
 	})
 
 	t.Run("Should restore data from file", func(t *testing.T) {
@@ -296,11 +463,13 @@ func TestFileStorage(t *testing.T) {
 			Counters: []storage.CounterMetric{
 				{Name: "test_counter", Value: 678},
 			},
+			Histograms: []storage.HistogramMetric{
+				{Name: "test_histogram", Bounds: []float64{1}, Counts: []uint64{2, 1}, Sum: 3.5, Count: 3},
+			},
 		}
 		data, _ := json.Marshal(backupData)
-		filePath := "test_restore.json"
+		filePath := filepath.Join(t.TempDir(), "test_restore.json")
 		_ = os.WriteFile(filePath, data, 0666)
-		defer os.Remove(filePath)
 
 		mockStorage := &MockStorage{
 			gaugeMetrics:   make(map[string]storage.GaugeMetric),
@@ -320,6 +489,67 @@ func TestFileStorage(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, "test_counter", counter.Name)
 		assert.Equal(t, int64(678), counter.Value)
+
+		histogram, err := fs.GetHistogramMetric(context.Background(), "test_histogram")
+		assert.NoError(t, err)
+		assert.Equal(t, backupData.Histograms[0], histogram)
+	})
+
+	t.Run("Should add a histogram metric and backup data", func(t *testing.T) {
+		mockStorage := &MockStorage{
+			gaugeMetrics:   make(map[string]storage.GaugeMetric),
+			counterMetrics: make(map[string]storage.CounterMetric),
+		}
+		config := Config{FileStoragePath: filepath.Join(t.TempDir(), "add_histogram.json"), Restore: false, CompactRecords: 1}
+		fs, err := New(context.Background(), mockStorage, config)
+		assert.NoError(t, err)
+
+		err = fs.AddHistogramMetric(context.Background(), "histogram", storage.HistogramMetric{Bounds: []float64{1}, Counts: []uint64{0, 1}, Sum: 2, Count: 1})
+		assert.NoError(t, err)
+
+		data, err := os.ReadFile(config.FileStoragePath)
+		assert.NoError(t, err)
+		backupData, err := decodeSnapshot(data)
+		assert.NoError(t, err)
+		assert.Len(t, backupData.Histograms, 1)
+		assert.Equal(t, "histogram", backupData.Histograms[0].Name)
+		assert.Equal(t, uint64(1), backupData.Histograms[0].Count)
+	})
+
+	t.Run("Should backup data after metric is deleted", func(t *testing.T) {
+		mockStorage := &MockStorage{
+			gaugeMetrics: map[string]storage.GaugeMetric{
+				"gauge":       {Name: "gauge", Value: 1},
+				"other_gauge": {Name: "other_gauge", Value: 2},
+			},
+			counterMetrics: map[string]storage.CounterMetric{
+				"counter": {Name: "counter", Value: 5},
+			},
+		}
+		config := Config{FileStoragePath: filepath.Join(t.TempDir(), "delete_metric.json"), Restore: false, CompactRecords: 1}
+		fs, err := New(context.Background(), mockStorage, config)
+		assert.NoError(t, err)
+
+		assert.NoError(t, fs.DeleteGaugeMetric(context.Background(), "gauge"))
+		assert.NoError(t, fs.ResetCounterMetric(context.Background(), "counter"))
+		assert.ErrorIs(t, fs.DeleteCounterMetric(context.Background(), "unknown"), storage.ErrDataNotFound)
+
+		data, err := os.ReadFile(config.FileStoragePath)
+		assert.NoError(t, err)
+		backupData, err := decodeSnapshot(data)
+		assert.NoError(t, err)
+		assert.Equal(t, []storage.GaugeMetric{{Name: "other_gauge", Value: 2}}, backupData.Gauges)
+		assert.Equal(t, []storage.CounterMetric{{Name: "counter", Value: 0}}, backupData.Counters)
+
+		deleted, err := fs.DeleteMetricsByPrefix(context.Background(), "other_")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, deleted)
+
+		data, err = os.ReadFile(config.FileStoragePath)
+		assert.NoError(t, err)
+		backupData, err = decodeSnapshot(data)
+		assert.NoError(t, err)
+		assert.Empty(t, backupData.Gauges)
 	})
 
 	t.Run("Should return error if file does not exist during restore", func(t *testing.T) {
@@ -327,7 +557,7 @@ func TestFileStorage(t *testing.T) {
 			gaugeMetrics:   make(map[string]storage.GaugeMetric),
 			counterMetrics: make(map[string]storage.CounterMetric),
 		}
-		config := Config{FileStoragePath: "non_existent.json", Restore: true}
+		config := Config{FileStoragePath: filepath.Join(t.TempDir(), "non_existent.json"), Restore: true}
 		fs, err := New(context.Background(), mockStorage, config)
 		assert.NoError(t, err)
 		assert.NotNil(t, fs)
@@ -335,9 +565,8 @@ func TestFileStorage(t *testing.T) {
 
 	t.Run("Should return error if deserialization fails during restore", func(t *testing.T) {
 		invalidData := []byte(`invalid json`)
-		filePath := "test_invalid.json"
+		filePath := filepath.Join(t.TempDir(), "test_invalid.json")
 		_ = os.WriteFile(filePath, invalidData, 0666)
-		defer os.Remove(filePath)
 
 		mockStorage := &MockStorage{
 			gaugeMetrics:   make(map[string]storage.GaugeMetric),
@@ -349,3 +578,300 @@ func TestFileStorage(t *testing.T) {
 		assert.Nil(t, fs)
 	})
 }
+
+func TestFileStorage_WAL(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should restore data from snapshot and log", func(t *testing.T) {
+		config := Config{FileStoragePath: filepath.Join(t.TempDir(), "metrics.json"), StoreInterval: 0, Restore: false, CompactRecords: 100}
+
+		fs, err := New(ctx, memstorage.New(), config)
+		require.NoError(t, err)
+
+		require.NoError(t, fs.AddCounterMetric(ctx, "counter", 2))
+		require.NoError(t, fs.AddCounterMetric(ctx, "counter", 3))
+		require.NoError(t, fs.AddGaugeMetric(ctx, "gauge", 1.5))
+		require.NoError(t, fs.AddGaugeMetric(ctx, "agent_gauge", 1))
+		require.NoError(t, fs.DeleteGaugeMetric(ctx, "gauge"))
+		_, err = fs.DeleteMetricsByPrefix(ctx, "agent_")
+		require.NoError(t, err)
+		require.NoError(t, fs.AddCounterMetric(ctx, "reset_counter", 7))
+		require.NoError(t, fs.ResetCounterMetric(ctx, "reset_counter"))
+
+		data, err := os.ReadFile(config.FileStoragePath)
+		require.NoError(t, err)
+		snapshot, err := decodeSnapshot(data)
+		require.NoError(t, err)
+		assert.Empty(t, snapshot.Counters)
+		assert.Equal(t, uint64(0), snapshot.Sequence)
+
+		config.Restore = true
+		restored, err := New(ctx, memstorage.New(), config)
+		require.NoError(t, err)
+
+		counter, err := restored.GetCounterMetric(ctx, "counter")
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), counter.Value)
+
+		_, err = restored.GetGaugeMetric(ctx, "gauge")
+		assert.ErrorIs(t, err, storage.ErrDataNotFound)
+
+		gauges, err := restored.GetGaugeMetrics(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, gauges)
+
+		resetCounter, err := restored.GetCounterMetric(ctx, "reset_counter")
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), resetCounter.Value)
+	})
+
+	t.Run("Should restore update time from snapshot", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics.json")
+		updatedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+		data, _ := json.Marshal(backupFile{
+			Gauges:     []storage.GaugeMetric{{Name: "gauge", Value: 1, UpdatedAt: updatedAt}},
+			Counters:   []storage.CounterMetric{{Name: "counter", Value: 2, UpdatedAt: updatedAt}},
+			Histograms: []storage.HistogramMetric{{Name: "histogram", Bounds: []float64{1}, Counts: []uint64{1, 0}, Sum: 0.5, Count: 1, UpdatedAt: updatedAt}},
+		})
+		require.NoError(t, os.WriteFile(path, data, 0666))
+
+		fs, err := New(ctx, memstorage.New(), Config{FileStoragePath: path, Restore: true})
+		require.NoError(t, err)
+
+		gauge, err := fs.GetGaugeMetric(ctx, "gauge")
+		require.NoError(t, err)
+		assert.Equal(t, updatedAt, gauge.UpdatedAt)
+
+		counter, err := fs.GetCounterMetric(ctx, "counter")
+		require.NoError(t, err)
+		assert.Equal(t, updatedAt, counter.UpdatedAt)
+
+		histogram, err := fs.GetHistogramMetric(ctx, "histogram")
+		require.NoError(t, err)
+		assert.Equal(t, updatedAt, histogram.UpdatedAt)
+	})
+
+	t.Run("Should restore update time from log", func(t *testing.T) {
+		config := Config{FileStoragePath: filepath.Join(t.TempDir(), "metrics.json"), Restore: false, CompactRecords: 100}
+		updatedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+		fs, err := New(ctx, memstorage.New(), config)
+		require.NoError(t, err)
+		fs.now = func() time.Time { return updatedAt }
+
+		require.NoError(t, fs.AddGaugeMetric(ctx, "gauge", 1))
+		require.NoError(t, fs.AddMetrics(ctx, nil, []storage.CounterMetric{{Name: "counter", Value: 2}}, nil))
+		require.NoError(t, fs.AddCounterMetric(ctx, "reset_counter", 3))
+
+		fs.now = func() time.Time { return updatedAt.Add(time.Minute) }
+		require.NoError(t, fs.ResetCounterMetric(ctx, "reset_counter"))
+
+		config.Restore = true
+		restored, err := New(ctx, memstorage.New(), config)
+		require.NoError(t, err)
+
+		gauge, err := restored.GetGaugeMetric(ctx, "gauge")
+		require.NoError(t, err)
+		assert.Equal(t, updatedAt, gauge.UpdatedAt)
+
+		counter, err := restored.GetCounterMetric(ctx, "counter")
+		require.NoError(t, err)
+		assert.Equal(t, updatedAt, counter.UpdatedAt)
+
+		resetCounter, err := restored.GetCounterMetric(ctx, "reset_counter")
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), resetCounter.Value)
+		assert.Equal(t, updatedAt.Add(time.Minute), resetCounter.UpdatedAt)
+	})
+
+	t.Run("Should compact log into snapshot after threshold", func(t *testing.T) {
+		config := Config{FileStoragePath: filepath.Join(t.TempDir(), "metrics.json"), Restore: false, CompactRecords: 2}
+
+		fs, err := New(ctx, memstorage.New(), config)
+		require.NoError(t, err)
+
+		require.NoError(t, fs.AddCounterMetric(ctx, "counter", 1))
+		require.NoError(t, fs.AddCounterMetric(ctx, "counter", 1))
+		require.NoError(t, fs.AddCounterMetric(ctx, "counter", 1))
+
+		data, err := os.ReadFile(config.FileStoragePath)
+		require.NoError(t, err)
+		snapshot, err := decodeSnapshot(data)
+		require.NoError(t, err)
+		require.Len(t, snapshot.Counters, 1)
+		assert.Equal(t, int64(2), snapshot.Counters[0].Value)
+		assert.Equal(t, uint64(2), snapshot.Sequence)
+
+		records, _, err := readWAL(config.FileStoragePath + walSuffix)
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, uint64(3), records[0].Sequence)
+
+		config.Restore = true
+		restored, err := New(ctx, memstorage.New(), config)
+		require.NoError(t, err)
+
+		counter, err := restored.GetCounterMetric(ctx, "counter")
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), counter.Value)
+	})
+
+	t.Run("Should skip log records already included in snapshot", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics.json")
+		data, _ := json.Marshal(backupFile{Counters: []storage.CounterMetric{{Name: "counter", Value: 10}}, Sequence: 1})
+		require.NoError(t, os.WriteFile(path, data, 0666))
+		writeTestWAL(t, path+walSuffix,
+			walRecord{Operation: walOpAdd, Counters: []storage.CounterMetric{{Name: "counter", Value: 10}}},
+			walRecord{Operation: walOpAdd, Counters: []storage.CounterMetric{{Name: "counter", Value: 1}}},
+		)
+
+		fs, err := New(ctx, memstorage.New(), Config{FileStoragePath: path, Restore: true})
+		require.NoError(t, err)
+
+		counter, err := fs.GetCounterMetric(ctx, "counter")
+		require.NoError(t, err)
+		assert.Equal(t, int64(11), counter.Value)
+	})
+
+	t.Run("Should skip torn last record and continue log after it", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics.json")
+		data := writeTestWAL(t, path+walSuffix,
+			walRecord{Operation: walOpAdd, Gauges: []storage.GaugeMetric{{Name: "gauge", Value: 1}}},
+			walRecord{Operation: walOpAdd, Gauges: []storage.GaugeMetric{{Name: "gauge", Value: 2}}},
+		)
+		require.NoError(t, os.WriteFile(path+walSuffix, data[:len(data)-4], 0666))
+
+		config := Config{FileStoragePath: path, Restore: true}
+		fs, err := New(ctx, memstorage.New(), config)
+		require.NoError(t, err)
+
+		gauge, err := fs.GetGaugeMetric(ctx, "gauge")
+		require.NoError(t, err)
+		assert.Equal(t, 1.0, gauge.Value)
+
+		require.NoError(t, fs.AddGaugeMetric(ctx, "gauge", 3))
+
+		restored, err := New(ctx, memstorage.New(), config)
+		require.NoError(t, err)
+
+		gauge, err = restored.GetGaugeMetric(ctx, "gauge")
+		require.NoError(t, err)
+		assert.Equal(t, 3.0, gauge.Value)
+	})
+
+	t.Run("Should not apply change if it cannot be written to log", func(t *testing.T) {
+		config := Config{FileStoragePath: filepath.Join(t.TempDir(), "metrics.json"), Restore: false, CompactRecords: 100}
+
+		fs, err := New(ctx, memstorage.New(), config)
+		require.NoError(t, err)
+		require.NoError(t, fs.wal.file.Close())
+
+		assert.Error(t, fs.AddGaugeMetric(ctx, "gauge", 1))
+
+		_, err = fs.GetGaugeMetric(ctx, "gauge")
+		assert.ErrorIs(t, err, storage.ErrDataNotFound)
+	})
+
+	t.Run("Should restore changes that failed to apply the same way", func(t *testing.T) {
+		config := Config{FileStoragePath: filepath.Join(t.TempDir(), "metrics.json"), Restore: false, CompactRecords: 100}
+
+		fs, err := New(ctx, memstorage.New(), config)
+		require.NoError(t, err)
+
+		require.NoError(t, fs.AddHistogramMetric(ctx, "histogram", storage.HistogramMetric{Bounds: []float64{1}, Counts: []uint64{1, 0}, Sum: 0.5, Count: 1}))
+		assert.ErrorIs(t, fs.AddMetrics(ctx, []storage.GaugeMetric{{Name: "gauge", Value: 1}}, nil, []storage.HistogramMetric{{Name: "histogram", Bounds: []float64{2}, Counts: []uint64{1, 0}, Sum: 1, Count: 1}}), storage.ErrHistogramBoundsMismatch)
+		assert.ErrorIs(t, fs.DeleteCounterMetric(ctx, "unknown"), storage.ErrDataNotFound)
+		require.NoError(t, fs.AddGaugeMetric(ctx, "other", 2))
+
+		config.Restore = true
+		restored, err := New(ctx, memstorage.New(), config)
+		require.NoError(t, err)
+
+		_, err = restored.GetGaugeMetric(ctx, "gauge")
+		assert.ErrorIs(t, err, storage.ErrDataNotFound)
+
+		histogram, err := restored.GetHistogramMetric(ctx, "histogram")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), histogram.Count)
+
+		other, err := restored.GetGaugeMetric(ctx, "other")
+		require.NoError(t, err)
+		assert.Equal(t, 2.0, other.Value)
+	})
+
+	t.Run("Should not log stale delete of updated metric", func(t *testing.T) {
+		config := Config{FileStoragePath: filepath.Join(t.TempDir(), "metrics.json"), Restore: false, CompactRecords: 100}
+
+		fs, err := New(ctx, memstorage.New(), config)
+		require.NoError(t, err)
+		require.NoError(t, fs.AddGaugeMetric(ctx, "gauge", 1))
+
+		gauge, err := fs.GetGaugeMetric(ctx, "gauge")
+		require.NoError(t, err)
+
+		err = fs.DeleteStaleMetric(ctx, models.GaugeMetricType, "gauge", gauge.UpdatedAt.Add(-time.Second))
+		assert.ErrorIs(t, err, storage.ErrDataNotFound)
+
+		records, _, err := readWAL(config.FileStoragePath + walSuffix)
+		require.NoError(t, err)
+		assert.Len(t, records, 1)
+
+		require.NoError(t, fs.DeleteStaleMetric(ctx, models.GaugeMetricType, "gauge", gauge.UpdatedAt))
+
+		_, err = fs.GetGaugeMetric(ctx, "gauge")
+		assert.ErrorIs(t, err, storage.ErrDataNotFound)
+	})
+
+	t.Run("Should restore concurrent changes", func(t *testing.T) {
+		config := Config{FileStoragePath: filepath.Join(t.TempDir(), "metrics.json"), Restore: false, CompactRecords: 50}
+
+		fs, err := New(ctx, memstorage.New(), config)
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+
+			go func(i int) {
+				defer wg.Done()
+
+				for j := 0; j < 100; j++ {
+					assert.NoError(t, fs.AddCounterMetric(ctx, "shared", 1))
+					assert.NoError(t, fs.AddGaugeMetric(ctx, fmt.Sprintf("gauge_%d", i), float64(j)))
+				}
+			}(i)
+		}
+
+		wg.Wait()
+
+		config.Restore = true
+		restored, err := New(ctx, memstorage.New(), config)
+		require.NoError(t, err)
+
+		counter, err := restored.GetCounterMetric(ctx, "shared")
+		require.NoError(t, err)
+		assert.Equal(t, int64(800), counter.Value)
+
+		for i := 0; i < 8; i++ {
+			gauge, err := restored.GetGaugeMetric(ctx, fmt.Sprintf("gauge_%d", i))
+			require.NoError(t, err)
+			assert.Equal(t, 99.0, gauge.Value)
+		}
+	})
+
+	t.Run("Should return error if log is corrupted in the middle", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics.json")
+		data := writeTestWAL(t, path+walSuffix,
+			walRecord{Operation: walOpAdd, Gauges: []storage.GaugeMetric{{Name: "gauge", Value: 1}}},
+			walRecord{Operation: walOpAdd, Gauges: []storage.GaugeMetric{{Name: "gauge", Value: 2}}},
+		)
+		data[12] = 'x'
+		require.NoError(t, os.WriteFile(path+walSuffix, data, 0666))
+
+		fs, err := New(ctx, memstorage.New(), Config{FileStoragePath: path, Restore: true})
+
+		assert.ErrorIs(t, err, ErrCorruptedLog)
+		assert.Nil(t, fs)
+	})
+}
@@ -0,0 +1,76 @@
+package filestorage
+
+import (
+	"sort"
+	"sync"
+)
+
+// lockStripes количество блокировок, по которым распределяются ключи метрик.
+const lockStripes = 64
+
+// keyLocks распределяет ключи метрик по фиксированному набору блокировок: изменения разных метрик
+// записываются в журнал и применяются параллельно, а изменения одной метрики — в порядке записей журнала.
+type keyLocks struct {
+	stripes [lockStripes]sync.Mutex
+}
+
+// stripe возвращает номер блокировки ключа по хешу FNV-1a.
+func stripe(key string) int {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+
+	hash := uint32(offset32)
+
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= prime32
+	}
+
+	return int(hash % lockStripes)
+}
+
+// lock захватывает блокировки ключей в порядке возрастания номеров, чтобы изменения с пересекающимися
+// наборами ключей не приводили к взаимной блокировке, и возвращает функцию их освобождения.
+func (l *keyLocks) lock(keys []string) func() {
+	seen := make(map[int]struct{}, len(keys))
+	indexes := make([]int, 0, len(keys))
+
+	for _, key := range keys {
+		index := stripe(key)
+
+		if _, ok := seen[index]; ok {
+			continue
+		}
+
+		seen[index] = struct{}{}
+		indexes = append(indexes, index)
+	}
+
+	sort.Ints(indexes)
+
+	for _, index := range indexes {
+		l.stripes[index].Lock()
+	}
+
+	return func() {
+		for i := len(indexes) - 1; i >= 0; i-- {
+			l.stripes[indexes[i]].Unlock()
+		}
+	}
+}
+
+// lockAll захватывает все блокировки и возвращает функцию их освобождения.
+// Используется изменениями, затрагивающими заранее неизвестный набор ключей, и сжатием журнала.
+func (l *keyLocks) lockAll() func() {
+	for i := range l.stripes {
+		l.stripes[i].Lock()
+	}
+
+	return func() {
+		for i := len(l.stripes) - 1; i >= 0; i-- {
+			l.stripes[i].Unlock()
+		}
+	}
+}
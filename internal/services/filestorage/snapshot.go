@@ -0,0 +1,225 @@
+package filestorage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/daremove/go-metrics-service/internal/logger"
+)
+
+// snapshotVersion текущая версия формата файла снимка.
+const snapshotVersion = 1
+
+// ErrSnapshotChecksum ошибка, возникающая если контрольная сумма снимка не совпадает с его содержимым.
+var ErrSnapshotChecksum = errors.New("snapshot checksum mismatch")
+
+// snapshotEnvelope оболочка файла снимка с версией формата и контрольной суммой данных.
+type snapshotEnvelope struct {
+	Version  int             `json:"version"`  // Версия формата
+	Checksum string          `json:"checksum"` // SHA-256 от поля data в шестнадцатеричном виде
+	Data     json.RawMessage `json:"data"`     // Сериализованный backupFile
+}
+
+// encodeSnapshot сериализует данные снимка вместе с версией формата и контрольной суммой.
+func encodeSnapshot(data backupFile) ([]byte, error) {
+	payload, err := json.Marshal(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	checksum := sha256.Sum256(payload)
+
+	return json.Marshal(snapshotEnvelope{
+		Version:  snapshotVersion,
+		Checksum: hex.EncodeToString(checksum[:]),
+		Data:     payload,
+	})
+}
+
+// decodeSnapshot разбирает файл снимка и проверяет его контрольную сумму.
+// Файлы без версии считаются снимками старого формата и читаются без проверки.
+func decodeSnapshot(content []byte) (backupFile, error) {
+	var envelope snapshotEnvelope
+
+	if err := json.Unmarshal(content, &envelope); err != nil {
+		return backupFile{}, fmt.Errorf("cannot deserialize data from file: %s", err)
+	}
+
+	var data backupFile
+
+	switch envelope.Version {
+	case 0:
+		if err := json.Unmarshal(content, &data); err != nil {
+			return backupFile{}, fmt.Errorf("cannot deserialize data from file: %s", err)
+		}
+
+		return data, nil
+	case snapshotVersion:
+	default:
+		return backupFile{}, fmt.Errorf("snapshot version %d isn't supported", envelope.Version)
+	}
+
+	checksum := sha256.Sum256(envelope.Data)
+
+	if hex.EncodeToString(checksum[:]) != envelope.Checksum {
+		return backupFile{}, ErrSnapshotChecksum
+	}
+
+	if err := json.Unmarshal(envelope.Data, &data); err != nil {
+		return backupFile{}, fmt.Errorf("cannot deserialize data from file: %s", err)
+	}
+
+	return data, nil
+}
+
+// rotatedPath возвращает путь к предыдущей версии файла снимка или журнала с номером index, начиная с 1.
+func rotatedPath(path string, index int) string {
+	return path + "." + strconv.Itoa(index)
+}
+
+// writeSnapshot атомарно записывает снимок: данные записываются во временный файл рядом с целевым,
+// сбрасываются на диск и переименовываются. Перед заменой предыдущий снимок сдвигается
+// в ротацию, где хранится не более keep прошлых снимков.
+func writeSnapshot(path string, content []byte, keep int) error {
+	dir := filepath.Dir(path)
+	file, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+
+	if err != nil {
+		return err
+	}
+
+	tempPath := file.Name()
+
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		os.Remove(tempPath)
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tempPath)
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	if err := rotateFiles(path, keep); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+// rotateFiles сдвигает текущую и предыдущие версии файла снимка или журнала на одну позицию,
+// отбрасывая самую старую.
+func rotateFiles(path string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	for i := keep; i > 0; i-- {
+		from := path
+
+		if i > 1 {
+			from = rotatedPath(path, i-1)
+		}
+
+		if err := os.Rename(from, rotatedPath(path, i)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncDir сбрасывает на диск содержимое каталога, чтобы переименование файла пережило сбой.
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	return file.Sync()
+}
+
+// removeTempSnapshots удаляет временные файлы снимков, оставшиеся после аварийного завершения.
+func removeTempSnapshots(path string) {
+	matches, err := filepath.Glob(path + ".tmp-*")
+
+	if err != nil {
+		return
+	}
+
+	for _, match := range matches {
+		os.Remove(match)
+	}
+}
+
+// readSnapshot читает последний снимок, прошедший проверку. Если текущий снимок поврежден,
+// используются предыдущие снимки из ротации, начиная с самого нового.
+// Если ни одного снимка нет, возвращается пустой снимок.
+func readSnapshot(path string, keep int) (backupFile, error) {
+	var lastErr error
+
+	for i := 0; i <= keep; i++ {
+		candidate := path
+
+		if i > 0 {
+			candidate = rotatedPath(path, i)
+		}
+
+		content, err := os.ReadFile(candidate)
+
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			lastErr = fmt.Errorf("cannot read file: %s", err)
+			logger.Log.Warn("cannot read snapshot", zap.String("path", candidate), zap.Error(err))
+			continue
+		}
+
+		data, err := decodeSnapshot(content)
+
+		if err != nil {
+			lastErr = err
+			logger.Log.Warn("snapshot verification failed", zap.String("path", candidate), zap.Error(err))
+			continue
+		}
+
+		if lastErr != nil {
+			logger.Log.Warn("data was restored from previous snapshot", zap.String("path", candidate))
+		}
+
+		return data, nil
+	}
+
+	if lastErr != nil {
+		return backupFile{}, lastErr
+	}
+
+	return backupFile{}, nil
+}
@@ -0,0 +1,199 @@
+package filestorage
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/daremove/go-metrics-service/internal/storage"
+	"github.com/daremove/go-metrics-service/internal/storage/memstorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestSnapshot(t *testing.T, path string, data backupFile) []byte {
+	content, err := encodeSnapshot(data)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, content, 0666))
+
+	return content
+}
+
+func TestSnapshotEncoding(t *testing.T) {
+	data := backupFile{
+		Counters: []storage.CounterMetric{{Name: "counter", Value: 5}},
+		Gauges:   []storage.GaugeMetric{{Name: "gauge", Value: 1.5}},
+		Sequence: 3,
+	}
+
+	t.Run("Should decode encoded snapshot", func(t *testing.T) {
+		content, err := encodeSnapshot(data)
+		require.NoError(t, err)
+
+		result, err := decodeSnapshot(content)
+
+		require.NoError(t, err)
+		assert.Equal(t, data, result)
+	})
+
+	t.Run("Should read snapshot without version", func(t *testing.T) {
+		result, err := decodeSnapshot([]byte(`{"counters":[{"name":"counter","value":5}],"gauges":[],"histograms":[]}`))
+
+		require.NoError(t, err)
+		assert.Equal(t, []storage.CounterMetric{{Name: "counter", Value: 5}}, result.Counters)
+	})
+
+	t.Run("Should return error if checksum doesn't match", func(t *testing.T) {
+		content, err := encodeSnapshot(data)
+		require.NoError(t, err)
+
+		content = bytes.Replace(content, []byte(`"value":5`), []byte(`"value":6`), 1)
+		_, err = decodeSnapshot(content)
+
+		assert.ErrorIs(t, err, ErrSnapshotChecksum)
+	})
+
+	t.Run("Should return error if version isn't supported", func(t *testing.T) {
+		_, err := decodeSnapshot([]byte(`{"version":99,"checksum":"00","data":{}}`))
+
+		assert.Error(t, err)
+	})
+}
+
+func TestWriteSnapshot(t *testing.T) {
+	t.Run("Should rotate previous snapshots", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics.json")
+
+		for i := 1; i <= 4; i++ {
+			content, err := encodeSnapshot(backupFile{Sequence: uint64(i)})
+			require.NoError(t, err)
+			require.NoError(t, writeSnapshot(path, content, 2))
+		}
+
+		for index, expected := range map[string]uint64{path: 4, rotatedPath(path, 1): 3, rotatedPath(path, 2): 2} {
+			content, err := os.ReadFile(index)
+			require.NoError(t, err)
+
+			data, err := decodeSnapshot(content)
+			require.NoError(t, err)
+			assert.Equal(t, expected, data.Sequence)
+		}
+
+		_, err := os.Stat(rotatedPath(path, 3))
+		assert.True(t, os.IsNotExist(err))
+
+		matches, err := filepath.Glob(path + ".tmp-*")
+		require.NoError(t, err)
+		assert.Empty(t, matches)
+	})
+
+	t.Run("Should replace snapshot without rotation", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics.json")
+
+		require.NoError(t, writeSnapshot(path, []byte("first"), 0))
+		require.NoError(t, writeSnapshot(path, []byte("second"), 0))
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "second", string(content))
+
+		_, err = os.Stat(rotatedPath(path, 1))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestReadSnapshot(t *testing.T) {
+	t.Run("Should fall back to previous snapshot if the newest one is truncated", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics.json")
+		writeTestSnapshot(t, rotatedPath(path, 2), backupFile{Sequence: 1})
+		writeTestSnapshot(t, rotatedPath(path, 1), backupFile{Sequence: 2})
+		content := writeTestSnapshot(t, path, backupFile{Sequence: 3})
+		require.NoError(t, os.WriteFile(path, content[:len(content)/2], 0666))
+
+		data, err := readSnapshot(path, 2)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), data.Sequence)
+	})
+
+	t.Run("Should not use snapshots beyond configured rotation", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics.json")
+		writeTestSnapshot(t, rotatedPath(path, 1), backupFile{Sequence: 1})
+		require.NoError(t, os.WriteFile(path, []byte("{"), 0666))
+
+		_, err := readSnapshot(path, 0)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("Should return error if all snapshots fail verification", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics.json")
+		require.NoError(t, os.WriteFile(path, []byte("{"), 0666))
+		require.NoError(t, os.WriteFile(rotatedPath(path, 1), []byte(`{"version":1,"checksum":"00","data":{}}`), 0666))
+
+		_, err := readSnapshot(path, 1)
+
+		assert.ErrorIs(t, err, ErrSnapshotChecksum)
+	})
+
+	t.Run("Should return empty snapshot if there are no files", func(t *testing.T) {
+		data, err := readSnapshot(filepath.Join(t.TempDir(), "metrics.json"), 2)
+
+		require.NoError(t, err)
+		assert.Equal(t, backupFile{}, data)
+	})
+}
+
+func TestFileStorage_SnapshotRecovery(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should restore from previous snapshot and remove temporary files", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics.json")
+		config := Config{FileStoragePath: path, SnapshotKeep: 1}
+
+		fs, err := New(ctx, memstorage.New(), config)
+		require.NoError(t, err)
+		require.NoError(t, fs.AddCounterMetric(ctx, "counter", 1))
+		require.NoError(t, fs.BackupData(ctx))
+		require.NoError(t, fs.AddCounterMetric(ctx, "counter", 1))
+		require.NoError(t, fs.Close(ctx))
+
+		require.NoError(t, os.WriteFile(path, []byte(`{"version":1,"checks`), 0666))
+		require.NoError(t, os.WriteFile(path+".tmp-123", []byte("partial"), 0666))
+
+		config.Restore = true
+		restored, err := New(ctx, memstorage.New(), config)
+		require.NoError(t, err)
+
+		counter, err := restored.GetCounterMetric(ctx, "counter")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), counter.Value)
+
+		_, err = os.Stat(path + ".tmp-123")
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("Should return error if log doesn't continue previous snapshot", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics.json")
+		config := Config{FileStoragePath: path, SnapshotKeep: 1}
+
+		fs, err := New(ctx, memstorage.New(), config)
+		require.NoError(t, err)
+		require.NoError(t, fs.AddCounterMetric(ctx, "counter", 1))
+		require.NoError(t, fs.BackupData(ctx))
+		require.NoError(t, fs.AddCounterMetric(ctx, "counter", 1))
+		require.NoError(t, fs.BackupData(ctx))
+		require.NoError(t, fs.AddCounterMetric(ctx, "counter", 1))
+
+		require.NoError(t, os.WriteFile(path, []byte(`{"version":1,"checks`), 0666))
+		require.NoError(t, os.Remove(rotatedPath(path+walSuffix, 1)))
+
+		config.Restore = true
+		restored, err := New(ctx, memstorage.New(), config)
+
+		assert.ErrorIs(t, err, ErrLogGap)
+		assert.Nil(t, restored)
+	})
+}
@@ -0,0 +1,330 @@
+package filestorage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/daremove/go-metrics-service/internal/storage"
+)
+
+// ErrCorruptedLog ошибка, возникающая если поврежденная запись журнала находится не в его конце.
+var ErrCorruptedLog = errors.New("write-ahead log is corrupted")
+
+// ErrLogGap ошибка, возникающая если записи журнала не продолжают восстановленный снимок,
+// например когда снимок восстановлен из ротации, а нужная часть журнала уже удалена.
+var ErrLogGap = errors.New("write-ahead log doesn't continue snapshot")
+
+// Операции, записываемые в журнал.
+const (
+	walOpAdd          = "add"
+	walOpDelete       = "delete"
+	walOpDeletePrefix = "delete_prefix"
+	walOpReset        = "reset"
+)
+
+// walSuffix добавляется к пути файла хранения для получения пути журнала.
+const walSuffix = ".wal"
+
+// walRecord описывает одно изменение хранилища.
+type walRecord struct {
+	Sequence   uint64                    `json:"seq"`                  // Порядковый номер записи
+	Operation  string                    `json:"op"`                   // Операция
+	MetricType string                    `json:"type,omitempty"`       // Тип метрики для удаления и обнуления
+	Key        string                    `json:"key,omitempty"`        // Ключ метрики либо префикс ключей
+	Gauges     []storage.GaugeMetric     `json:"gauges,omitempty"`     // Добавленные метрики типа gauge
+	Counters   []storage.CounterMetric   `json:"counters,omitempty"`   // Добавленные приращения метрик типа counter
+	Histograms []storage.HistogramMetric `json:"histograms,omitempty"` // Добавленные метрики типа histogram
+	UpdatedAt  time.Time                 `json:"updated_at"`           // Время изменения, восстанавливаемое как время обновления метрик
+}
+
+// metrics возвращает копии добавленных записью метрик с временем обновления записи.
+func (record walRecord) metrics() ([]storage.GaugeMetric, []storage.CounterMetric, []storage.HistogramMetric) {
+	gauges := append([]storage.GaugeMetric(nil), record.Gauges...)
+	counters := append([]storage.CounterMetric(nil), record.Counters...)
+	histograms := append([]storage.HistogramMetric(nil), record.Histograms...)
+
+	for i := range gauges {
+		gauges[i].UpdatedAt = record.UpdatedAt
+	}
+
+	for i := range counters {
+		counters[i].UpdatedAt = record.UpdatedAt
+	}
+
+	for i := range histograms {
+		histograms[i].UpdatedAt = record.UpdatedAt
+	}
+
+	return gauges, counters, histograms
+}
+
+// keys возвращает ключи метрик, которые изменяет запись. Для удаления по префиксу набор ключей
+// заранее неизвестен, поэтому возвращается false.
+func (record walRecord) keys() ([]string, bool) {
+	if record.Operation == walOpDeletePrefix {
+		return nil, false
+	}
+
+	if record.Operation != walOpAdd {
+		return []string{record.Key}, true
+	}
+
+	keys := make([]string, 0, len(record.Gauges)+len(record.Counters)+len(record.Histograms))
+
+	for _, metric := range record.Gauges {
+		keys = append(keys, metric.Name)
+	}
+
+	for _, metric := range record.Counters {
+		keys = append(keys, metric.Name)
+	}
+
+	for _, metric := range record.Histograms {
+		keys = append(keys, metric.Name)
+	}
+
+	return keys, true
+}
+
+// encodeWALRecord сериализует запись в строку вида "<crc32> <json>\n".
+// Контрольная сумма позволяет обнаружить запись, оборванную при аварийном завершении.
+func encodeWALRecord(record walRecord) ([]byte, error) {
+	data, err := json.Marshal(record)
+
+	if err != nil {
+		return nil, err
+	}
+
+	line := make([]byte, 0, len(data)+10)
+	line = append(line, fmt.Sprintf("%08x ", crc32.ChecksumIEEE(data))...)
+	line = append(line, data...)
+	line = append(line, '\n')
+
+	return line, nil
+}
+
+// decodeWALRecord разбирает строку журнала без завершающего перевода строки.
+func decodeWALRecord(line []byte) (walRecord, error) {
+	checksum, data, ok := bytes.Cut(line, []byte{' '})
+
+	if !ok {
+		return walRecord{}, fmt.Errorf("record has no checksum")
+	}
+
+	expected, err := strconv.ParseUint(string(checksum), 16, 32)
+
+	if err != nil {
+		return walRecord{}, fmt.Errorf("record checksum is invalid: %w", err)
+	}
+
+	if crc32.ChecksumIEEE(data) != uint32(expected) {
+		return walRecord{}, fmt.Errorf("record checksum mismatch")
+	}
+
+	var record walRecord
+
+	if err := json.Unmarshal(data, &record); err != nil {
+		return walRecord{}, fmt.Errorf("record cannot be decoded: %w", err)
+	}
+
+	return record, nil
+}
+
+// readWAL читает записи журнала и возвращает их вместе с размером корректной части файла.
+// Оборванная или поврежденная последняя запись пропускается, повреждение в середине журнала
+// приводит к ошибке ErrCorruptedLog. Отсутствующий журнал считается пустым.
+func readWAL(path string) ([]walRecord, int64, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+
+		return nil, 0, err
+	}
+
+	defer file.Close()
+
+	var (
+		records   []walRecord
+		validSize int64
+		broken    error
+	)
+
+	reader := bufio.NewReader(file)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+
+		if len(line) == 0 && errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, 0, err
+		}
+
+		if broken != nil {
+			return nil, 0, fmt.Errorf("%w: record after offset %d: %s", ErrCorruptedLog, validSize, broken)
+		}
+
+		if line[len(line)-1] != '\n' {
+			broken = fmt.Errorf("record is incomplete")
+			continue
+		}
+
+		record, decodeErr := decodeWALRecord(line[:len(line)-1])
+
+		if decodeErr != nil {
+			broken = decodeErr
+			continue
+		}
+
+		records = append(records, record)
+		validSize += int64(len(line))
+	}
+
+	return records, validSize, nil
+}
+
+// readWALSegments читает записи предыдущих частей журнала, начиная с самой старой, и текущего журнала.
+// Возвращает размер корректной части текущего журнала.
+func readWALSegments(path string, keep int) ([]walRecord, int64, error) {
+	var records []walRecord
+
+	for i := keep; i > 0; i-- {
+		segment, _, err := readWAL(rotatedPath(path, i))
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		records = append(records, segment...)
+	}
+
+	current, validSize, err := readWAL(path)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return append(records, current...), validSize, nil
+}
+
+// writeAheadLog журнал изменений хранилища, в который записи только дописываются.
+// При сжатии журнал не очищается, а сдвигается в ротацию вместе со снимками: предыдущие части
+// нужны, чтобы восстановиться из предыдущего снимка, если последний поврежден.
+type writeAheadLog struct {
+	mu       sync.Mutex
+	file     *os.File
+	path     string // Путь к текущему журналу
+	keep     int    // Количество хранимых предыдущих частей журнала
+	sequence uint64 // Номер последней записанной записи
+	records  int    // Количество записей в журнале с момента последнего сжатия
+	sync     bool   // Сбрасывать ли каждую запись на диск
+}
+
+// openWAL открывает журнал для дописывания, отбрасывая все после validSize.
+// При сжатии хранится не более keep предыдущих частей журнала.
+func openWAL(path string, validSize int64, sync bool, keep int) (*writeAheadLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0666)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := file.Truncate(validSize); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if _, err := file.Seek(validSize, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &writeAheadLog{file: file, path: path, keep: keep, sync: sync}, nil
+}
+
+// append присваивает записи очередной номер и дописывает ее в журнал.
+// Вызывающий код должен удерживать блокировку mu.
+func (w *writeAheadLog) append(record walRecord) error {
+	record.Sequence = w.sequence + 1
+	line, err := encodeWALRecord(record)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.file.Write(line); err != nil {
+		return err
+	}
+
+	if w.sync {
+		if err := w.file.Sync(); err != nil {
+			return err
+		}
+	}
+
+	w.sequence = record.Sequence
+	w.records++
+
+	return nil
+}
+
+// reset начинает новый журнал после того, как записи текущего попали в снимок.
+// Текущий журнал сдвигается в ротацию, если предыдущие части хранятся, иначе очищается.
+// Вызывающий код должен удерживать блокировку mu.
+func (w *writeAheadLog) reset() error {
+	if w.keep <= 0 {
+		if err := w.file.Truncate(0); err != nil {
+			return err
+		}
+
+		if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		w.records = 0
+
+		return nil
+	}
+
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+
+	if err := rotateFiles(w.path, w.keep); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+
+	if err != nil {
+		return err
+	}
+
+	w.file = file
+	w.records = 0
+
+	return syncDir(filepath.Dir(w.path))
+}
+
+// close закрывает файл журнала.
+func (w *writeAheadLog) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.file.Close()
+}
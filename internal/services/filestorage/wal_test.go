@@ -0,0 +1,152 @@
+package filestorage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestWAL(t *testing.T, path string, records ...walRecord) []byte {
+	var data []byte
+
+	for i, record := range records {
+		record.Sequence = uint64(i + 1)
+		line, err := encodeWALRecord(record)
+		require.NoError(t, err)
+		data = append(data, line...)
+	}
+
+	require.NoError(t, os.WriteFile(path, data, 0666))
+
+	return data
+}
+
+func TestReadWAL(t *testing.T) {
+	records := []walRecord{
+		{Operation: walOpAdd, Counters: []storage.CounterMetric{{Name: "counter", Value: 1}}},
+		{Operation: walOpDelete, MetricType: models.GaugeMetricType, Key: "gauge"},
+	}
+
+	t.Run("Should read all records", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics.json.wal")
+		data := writeTestWAL(t, path, records...)
+
+		result, size, err := readWAL(path)
+
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, uint64(1), result[0].Sequence)
+		assert.Equal(t, records[0].Counters, result[0].Counters)
+		assert.Equal(t, "gauge", result[1].Key)
+		assert.Equal(t, int64(len(data)), size)
+	})
+
+	t.Run("Should treat missing log as empty", func(t *testing.T) {
+		result, size, err := readWAL(filepath.Join(t.TempDir(), "missing.wal"))
+
+		require.NoError(t, err)
+		assert.Empty(t, result)
+		assert.Equal(t, int64(0), size)
+	})
+
+	t.Run("Should skip torn last record", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics.json.wal")
+		data := writeTestWAL(t, path, records...)
+		firstSize := len(data) - len(mustEncode(t, walRecord{Sequence: 2, Operation: walOpDelete, MetricType: models.GaugeMetricType, Key: "gauge"}))
+		require.NoError(t, os.WriteFile(path, data[:len(data)-5], 0666))
+
+		result, size, err := readWAL(path)
+
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, int64(firstSize), size)
+	})
+
+	t.Run("Should skip last record with invalid checksum", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics.json.wal")
+		data := writeTestWAL(t, path, records...)
+		data[len(data)-3] = 'x'
+		require.NoError(t, os.WriteFile(path, data, 0666))
+
+		result, _, err := readWAL(path)
+
+		require.NoError(t, err)
+		assert.Len(t, result, 1)
+	})
+
+	t.Run("Should return error if corrupted record is followed by others", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics.json.wal")
+		data := writeTestWAL(t, path, records...)
+		data[12] = 'x'
+		require.NoError(t, os.WriteFile(path, data, 0666))
+
+		_, _, err := readWAL(path)
+
+		assert.ErrorIs(t, err, ErrCorruptedLog)
+	})
+}
+
+func TestWriteAheadLog(t *testing.T) {
+	t.Run("Should append records after valid part and reset log", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics.json.wal")
+		data := writeTestWAL(t, path, walRecord{Operation: walOpReset, MetricType: models.CounterMetricType, Key: "counter"})
+		require.NoError(t, os.WriteFile(path, append(data, []byte("0000")...), 0666))
+
+		wal, err := openWAL(path, int64(len(data)), true, 0)
+		require.NoError(t, err)
+		defer wal.close()
+
+		wal.sequence = 1
+		require.NoError(t, wal.append(walRecord{Operation: walOpDeletePrefix, Key: "agent_"}))
+
+		result, _, err := readWAL(path)
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, uint64(2), result[1].Sequence)
+		assert.Equal(t, 1, wal.records)
+
+		require.NoError(t, wal.reset())
+
+		result, size, err := readWAL(path)
+		require.NoError(t, err)
+		assert.Empty(t, result)
+		assert.Equal(t, int64(0), size)
+		assert.Equal(t, uint64(2), wal.sequence)
+	})
+}
+
+func TestWriteAheadLog_Reset(t *testing.T) {
+	t.Run("Should move log to rotation and read all parts", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics.json.wal")
+
+		wal, err := openWAL(path, 0, true, 1)
+		require.NoError(t, err)
+		defer wal.close()
+
+		require.NoError(t, wal.append(walRecord{Operation: walOpDeletePrefix, Key: "first_"}))
+		require.NoError(t, wal.reset())
+		require.NoError(t, wal.append(walRecord{Operation: walOpDeletePrefix, Key: "second_"}))
+		require.NoError(t, wal.reset())
+		require.NoError(t, wal.append(walRecord{Operation: walOpDeletePrefix, Key: "third_"}))
+		assert.Equal(t, 1, wal.records)
+
+		records, size, err := readWALSegments(path, 1)
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		assert.Equal(t, uint64(2), records[0].Sequence)
+		assert.Equal(t, uint64(3), records[1].Sequence)
+		assert.Equal(t, int64(len(mustEncode(t, records[1]))), size)
+	})
+}
+
+func mustEncode(t *testing.T, record walRecord) []byte {
+	line, err := encodeWALRecord(record)
+	require.NoError(t, err)
+
+	return line
+}
@@ -0,0 +1,330 @@
+// Package graphite предоставляет прием метрик по текстовому протоколу Graphite через TCP:
+// каждая строка "path value timestamp" сохраняется как gauge либо, по правилам для путей, как счетчик.
+package graphite
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/daremove/go-metrics-service/internal/logger"
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/services"
+)
+
+// Значения по умолчанию для настроек приема.
+const (
+	DefaultMaxConnections = 100
+	DefaultIdleTimeout    = time.Minute
+	DefaultMaxLineLength  = 4096
+)
+
+// shutdownReadTimeout время, в течение которого при остановке дочитываются уже отправленные данные.
+const shutdownReadTimeout = 100 * time.Millisecond
+
+// ErrServerClosed ошибка, возвращаемая Serve после вызова Shutdown.
+var ErrServerClosed = errors.New("graphite server closed")
+
+// Config содержит настройки приема метрик Graphite.
+type Config struct {
+	Address        string        // Адрес TCP, например ":2003"; пустой — прием отключен
+	CounterRules   []string      // Шаблоны путей, значения которых сохраняются как прирост счетчика
+	MaxConnections int           // Максимальное количество одновременных соединений
+	IdleTimeout    time.Duration // Время бездействия, после которого соединение закрывается
+	MaxLineLength  int           // Максимальная длина строки; более длинные строки пропускаются
+}
+
+// Saver определяет метод сохранения метрик.
+type Saver interface {
+	SaveModels(ctx context.Context, parameters []models.Metrics) error
+}
+
+// Server принимает соединения по протоколу Graphite и сохраняет полученные значения.
+// Строки, прочитанные из соединения за одну операцию чтения, сохраняются одним пакетом.
+type Server struct {
+	config    Config
+	saver     Saver
+	slots     chan struct{}
+	mu        sync.Mutex
+	listener  net.Listener
+	conns     map[net.Conn]struct{}
+	closed    bool
+	wg        sync.WaitGroup
+	malformed atomic.Uint64
+}
+
+// New создает новый экземпляр Server.
+func New(config Config, saver Saver) *Server {
+	if config.MaxConnections <= 0 {
+		config.MaxConnections = DefaultMaxConnections
+	}
+
+	if config.IdleTimeout <= 0 {
+		config.IdleTimeout = DefaultIdleTimeout
+	}
+
+	if config.MaxLineLength <= 0 {
+		config.MaxLineLength = DefaultMaxLineLength
+	}
+
+	return &Server{
+		config: config,
+		saver:  saver,
+		slots:  make(chan struct{}, config.MaxConnections),
+		conns:  map[net.Conn]struct{}{},
+	}
+}
+
+// ListenAndServe открывает TCP-порт из настроек и принимает соединения до вызова Shutdown.
+func (s *Server) ListenAndServe() error {
+	listener, err := net.Listen("tcp", s.config.Address)
+
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(listener)
+}
+
+// Serve принимает соединения до вызова Shutdown. Соединения сверх лимита сразу закрываются.
+func (s *Server) Serve(listener net.Listener) error {
+	s.mu.Lock()
+
+	if s.closed {
+		s.mu.Unlock()
+		listener.Close()
+
+		return ErrServerClosed
+	}
+
+	s.listener = listener
+	s.mu.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+
+			if closed {
+				return ErrServerClosed
+			}
+
+			return err
+		}
+
+		select {
+		case s.slots <- struct{}{}:
+		default:
+			logger.Log.Warn("graphite connection was rejected due to connection limit", zap.String("remote", conn.RemoteAddr().String()))
+			conn.Close()
+			continue
+		}
+
+		if !s.track(conn) {
+			<-s.slots
+			conn.Close()
+
+			return ErrServerClosed
+		}
+
+		go s.handle(conn)
+	}
+}
+
+// track регистрирует соединение, если сервер не остановлен.
+func (s *Server) track(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false
+	}
+
+	s.conns[conn] = struct{}{}
+	s.wg.Add(1)
+
+	return true
+}
+
+// handle читает строки из соединения до его закрытия, истечения времени бездействия или остановки сервера.
+func (s *Server) handle(conn net.Conn) {
+	defer func() {
+		conn.Close()
+
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+
+		<-s.slots
+		s.wg.Done()
+	}()
+
+	reader := bufio.NewReaderSize(conn, s.config.MaxLineLength)
+
+	var batch []models.Metrics
+
+	for {
+		if err := s.extendDeadline(conn); err != nil {
+			logger.Log.Warn("error set graphite connection deadline", zap.Error(err))
+			break
+		}
+
+		line, err := reader.ReadSlice('\n')
+
+		if errors.Is(err, bufio.ErrBufferFull) {
+			s.malformed.Add(1)
+			logger.Log.Warn("too long graphite line was skipped", zap.Int("max_line_length", s.config.MaxLineLength))
+
+			err = skipLine(reader)
+		} else if metric, ok := s.parse(string(line)); ok {
+			batch = append(batch, metric)
+		}
+
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) && !isTimeout(err) {
+				logger.Log.Warn("error read graphite connection", zap.Error(err))
+			}
+
+			break
+		}
+
+		if reader.Buffered() == 0 {
+			batch = s.flush(batch)
+		}
+	}
+
+	s.flush(batch)
+}
+
+// skipLine пропускает остаток строки, которая не поместилась в буфер чтения.
+func skipLine(reader *bufio.Reader) error {
+	for {
+		if _, err := reader.ReadSlice('\n'); !errors.Is(err, bufio.ErrBufferFull) {
+			return err
+		}
+	}
+}
+
+// extendDeadline продлевает время ожидания данных. После остановки сервера время ожидания
+// не продлевается, чтобы соединение закрылось после чтения уже отправленных данных.
+func (s *Server) extendDeadline(conn net.Conn) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	return conn.SetReadDeadline(time.Now().Add(s.config.IdleTimeout))
+}
+
+// parse разбирает строку. Некорректные строки учитываются в счетчике и записываются в лог.
+func (s *Server) parse(line string) (models.Metrics, bool) {
+	line = strings.TrimSpace(line)
+
+	if line == "" {
+		return models.Metrics{}, false
+	}
+
+	sample, err := ParseLine(line)
+
+	if err != nil {
+		s.malformed.Add(1)
+		logger.Log.Warn("malformed graphite line was skipped", zap.Error(err))
+
+		return models.Metrics{}, false
+	}
+
+	if metricType(s.config.CounterRules, sample.Path) == models.CounterMetricType {
+		delta, ok := services.CounterDelta(sample.Value)
+
+		if !ok {
+			s.malformed.Add(1)
+			logger.Log.Warn("malformed graphite line was skipped", zap.Error(fmt.Errorf("%w: %q counter value is out of range", ErrMalformedLine, line)))
+
+			return models.Metrics{}, false
+		}
+
+		return models.Metrics{ID: sample.Path, MType: models.CounterMetricType, Delta: &delta, Labels: sample.Labels}, true
+	}
+
+	value := sample.Value
+
+	return models.Metrics{ID: sample.Path, MType: models.GaugeMetricType, Value: &value, Labels: sample.Labels}, true
+}
+
+// flush сохраняет накопленные значения и возвращает пустой пакет.
+func (s *Server) flush(batch []models.Metrics) []models.Metrics {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	if err := s.saver.SaveModels(context.Background(), batch); err != nil {
+		logger.Log.Error("error save graphite metrics", zap.Error(err))
+	}
+
+	return nil
+}
+
+// Malformed возвращает количество пропущенных некорректных строк.
+func (s *Server) Malformed() uint64 {
+	return s.malformed.Load()
+}
+
+// Shutdown прекращает прием соединений, дочитывает уже отправленные в открытые соединения данные
+// и ожидает их сохранения. Если контекст завершится раньше, оставшиеся соединения закрываются принудительно.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+
+	if s.listener != nil {
+		s.listener.Close()
+	}
+
+	for conn := range s.conns {
+		_ = conn.SetReadDeadline(time.Now().Add(shutdownReadTimeout))
+	}
+
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+
+		for conn := range s.conns {
+			conn.Close()
+		}
+
+		s.mu.Unlock()
+
+		return ctx.Err()
+	}
+}
+
+// isTimeout сообщает, вызвана ли ошибка истечением времени ожидания.
+func isTimeout(err error) bool {
+	var netErr net.Error
+
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,172 @@
+package graphite
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+)
+
+type saverMock struct {
+	mu    sync.Mutex
+	saved []models.Metrics
+}
+
+func (m *saverMock) SaveModels(_ context.Context, parameters []models.Metrics) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.saved = append(m.saved, parameters...)
+
+	return nil
+}
+
+func (m *saverMock) metrics() []models.Metrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]models.Metrics(nil), m.saved...)
+}
+
+func startServer(t *testing.T, config Config, saver Saver) (*Server, string, chan error) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := New(config, saver)
+	done := make(chan error, 1)
+
+	go func() {
+		done <- server.Serve(listener)
+	}()
+
+	return server, listener.Addr().String(), done
+}
+
+func TestServer(t *testing.T) {
+	t.Run("Should save gauges and counters", func(t *testing.T) {
+		saver := &saverMock{}
+		server, address, done := startServer(t, Config{CounterRules: []string{"jobs.*.runs"}}, saver)
+
+		conn, err := net.Dial("tcp", address)
+		require.NoError(t, err)
+
+		_, err = conn.Write([]byte("jobs.backup.duration 12.5 1700000000\njobs.backup.runs 1 1700000000\njobs.backup.runs 1e300 1700000000\nbroken\n"))
+		require.NoError(t, err)
+		conn.Close()
+
+		require.Eventually(t, func() bool {
+			return len(saver.metrics()) == 2
+		}, time.Second, 10*time.Millisecond)
+
+		duration, runs := 12.5, int64(1)
+
+		assert.Equal(t, []models.Metrics{
+			{ID: "jobs.backup.duration", MType: models.GaugeMetricType, Value: &duration},
+			{ID: "jobs.backup.runs", MType: models.CounterMetricType, Delta: &runs},
+		}, saver.metrics())
+		assert.Equal(t, uint64(2), server.Malformed())
+
+		require.NoError(t, server.Shutdown(context.Background()))
+		assert.ErrorIs(t, <-done, ErrServerClosed)
+	})
+
+	t.Run("Should skip lines longer than limit", func(t *testing.T) {
+		saver := &saverMock{}
+		server, address, done := startServer(t, Config{MaxLineLength: 64}, saver)
+
+		conn, err := net.Dial("tcp", address)
+		require.NoError(t, err)
+
+		_, err = conn.Write([]byte("load." + strings.Repeat("a", 200) + " 1\nload 2\n"))
+		require.NoError(t, err)
+		conn.Close()
+
+		require.Eventually(t, func() bool {
+			return len(saver.metrics()) == 1
+		}, time.Second, 10*time.Millisecond)
+
+		value := 2.0
+
+		assert.Equal(t, []models.Metrics{{ID: "load", MType: models.GaugeMetricType, Value: &value}}, saver.metrics())
+		assert.Equal(t, uint64(1), server.Malformed())
+
+		require.NoError(t, server.Shutdown(context.Background()))
+		assert.ErrorIs(t, <-done, ErrServerClosed)
+	})
+
+	t.Run("Should reject connections over limit", func(t *testing.T) {
+		saver := &saverMock{}
+		server, address, _ := startServer(t, Config{MaxConnections: 1}, saver)
+		defer server.Shutdown(context.Background())
+
+		first, err := net.Dial("tcp", address)
+		require.NoError(t, err)
+		defer first.Close()
+
+		_, err = first.Write([]byte("load 1\n"))
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			return len(saver.metrics()) == 1
+		}, time.Second, 10*time.Millisecond)
+
+		second, err := net.Dial("tcp", address)
+		require.NoError(t, err)
+		defer second.Close()
+
+		require.NoError(t, second.SetReadDeadline(time.Now().Add(time.Second)))
+
+		_, err = second.Read(make([]byte, 1))
+		assert.Error(t, err)
+	})
+
+	t.Run("Should close idle connections", func(t *testing.T) {
+		server, address, _ := startServer(t, Config{IdleTimeout: 50 * time.Millisecond}, &saverMock{})
+		defer server.Shutdown(context.Background())
+
+		conn, err := net.Dial("tcp", address)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+
+		_, err = conn.Read(make([]byte, 1))
+		require.Error(t, err)
+		assert.False(t, isTimeout(err))
+	})
+
+	t.Run("Should save received lines on shutdown", func(t *testing.T) {
+		saver := &saverMock{}
+		server, address, done := startServer(t, Config{}, saver)
+
+		conn, err := net.Dial("tcp", address)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, err = conn.Write([]byte("load 3 1700000000"))
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			server.mu.Lock()
+			defer server.mu.Unlock()
+
+			return len(server.conns) == 1
+		}, time.Second, 10*time.Millisecond)
+
+		require.NoError(t, server.Shutdown(context.Background()))
+		assert.ErrorIs(t, <-done, ErrServerClosed)
+
+		value := 3.0
+
+		assert.Equal(t, []models.Metrics{{ID: "load", MType: models.GaugeMetricType, Value: &value}}, saver.metrics())
+	})
+}
@@ -0,0 +1,141 @@
+package graphite
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/services"
+)
+
+// ErrMalformedLine ошибка, возникающая при разборе некорректной строки протокола Graphite.
+var ErrMalformedLine = errors.New("graphite line is malformed")
+
+// Sample описывает одно значение из строки протокола Graphite.
+type Sample struct {
+	Path   string            // Путь метрики
+	Value  float64           // Значение
+	Labels map[string]string // Метки из тегов вида "path;tag=value"
+}
+
+// ParseLine разбирает строку вида "path[;tag=value...] value [timestamp]".
+// Время точки проверяется, но не сохраняется: метрики хранят только последнее значение.
+// Зарезервированные символы в пути заменяются на "_".
+func ParseLine(line string) (Sample, error) {
+	fields := strings.Fields(line)
+
+	if len(fields) < 2 || len(fields) > 3 {
+		return Sample{}, fmt.Errorf("%w: %q must be in format \"path value timestamp\"", ErrMalformedLine, line)
+	}
+
+	name, tags, _ := strings.Cut(fields[0], ";")
+
+	if name == "" {
+		return Sample{}, fmt.Errorf("%w: %q has no metric path", ErrMalformedLine, line)
+	}
+
+	sample := Sample{Path: services.SanitizeMetricName(name)}
+
+	if tags != "" {
+		labels, err := parseTags(tags)
+
+		if err != nil {
+			return Sample{}, fmt.Errorf("%w: %q: %v", ErrMalformedLine, line, err)
+		}
+
+		sample.Labels = labels
+	}
+
+	value, err := strconv.ParseFloat(fields[1], 64)
+
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return Sample{}, fmt.Errorf("%w: %q has invalid value", ErrMalformedLine, line)
+	}
+
+	sample.Value = value
+
+	if len(fields) == 3 && fields[2] != "N" && fields[2] != "-1" {
+		if _, err := strconv.ParseFloat(fields[2], 64); err != nil {
+			return Sample{}, fmt.Errorf("%w: %q has invalid timestamp", ErrMalformedLine, line)
+		}
+	}
+
+	return sample, nil
+}
+
+// parseTags разбирает теги Graphite вида "tag=value;tag=value" в метки.
+func parseTags(value string) (map[string]string, error) {
+	labels := map[string]string{}
+
+	for _, tag := range strings.Split(value, ";") {
+		name, tagValue, ok := strings.Cut(tag, "=")
+
+		if !ok || tagValue == "" {
+			return nil, fmt.Errorf("tag %q must be in format tag=value", tag)
+		}
+
+		if err := services.ValidateLabelName(name); err != nil {
+			return nil, err
+		}
+
+		labels[name] = tagValue
+	}
+
+	return labels, nil
+}
+
+// ParseCounterRules разбирает шаблоны путей, значения которых сохраняются как счетчики,
+// в формате "stats.counts.*,*.requests". Шаблоны сопоставляются с путем по сегментам,
+// разделенным точкой, в сегменте допускаются символы "*", "?" и "[...]".
+func ParseCounterRules(value string) ([]string, error) {
+	var result []string
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+
+		if item == "" {
+			continue
+		}
+
+		if _, err := path.Match(item, ""); err != nil {
+			return nil, fmt.Errorf("counter rule %q is invalid: %w", item, err)
+		}
+
+		result = append(result, item)
+	}
+
+	return result, nil
+}
+
+// metricType определяет тип метрики по правилам для счетчиков.
+func metricType(rules []string, metricPath string) string {
+	for _, rule := range rules {
+		if matchPath(rule, metricPath) {
+			return models.CounterMetricType
+		}
+	}
+
+	return models.GaugeMetricType
+}
+
+// matchPath сопоставляет путь с шаблоном по сегментам.
+func matchPath(pattern, metricPath string) bool {
+	patternSegments := strings.Split(pattern, ".")
+	pathSegments := strings.Split(metricPath, ".")
+
+	if len(patternSegments) != len(pathSegments) {
+		return false
+	}
+
+	for i, segment := range patternSegments {
+		if ok, _ := path.Match(segment, pathSegments[i]); !ok {
+			return false
+		}
+	}
+
+	return true
+}
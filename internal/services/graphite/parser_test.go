@@ -0,0 +1,121 @@
+package graphite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+)
+
+func TestParseLine(t *testing.T) {
+	testCases := []struct {
+		testName string
+		line     string
+		expected Sample
+		hasError bool
+	}{
+		{
+			testName: "Should parse line with timestamp",
+			line:     "jobs.backup.duration 12.5 1700000000",
+			expected: Sample{Path: "jobs.backup.duration", Value: 12.5},
+		},
+		{
+			testName: "Should parse line without timestamp",
+			line:     "jobs.backup.size 1024",
+			expected: Sample{Path: "jobs.backup.size", Value: 1024},
+		},
+		{
+			testName: "Should parse line with current time marker",
+			line:     "jobs.backup.size 1024 -1",
+			expected: Sample{Path: "jobs.backup.size", Value: 1024},
+		},
+		{
+			testName: "Should parse tags as labels",
+			line:     "disk.used;host=a;mount=/var 75 1700000000",
+			expected: Sample{Path: "disk.used", Value: 75, Labels: map[string]string{"host": "a", "mount": "/var"}},
+		},
+		{
+			testName: "Should replace reserved characters in path",
+			line:     "cpu{host=\"a\"} 1",
+			expected: Sample{Path: "cpu_host__a__", Value: 1},
+		},
+		{
+			testName: "Should return error if value is missing",
+			line:     "jobs.backup.size",
+			hasError: true,
+		},
+		{
+			testName: "Should return error if value is invalid",
+			line:     "jobs.backup.size big 1700000000",
+			hasError: true,
+		},
+		{
+			testName: "Should return error if timestamp is invalid",
+			line:     "jobs.backup.size 1 yesterday",
+			hasError: true,
+		},
+		{
+			testName: "Should return error if there are extra fields",
+			line:     "jobs.backup.size 1 1700000000 extra",
+			hasError: true,
+		},
+		{
+			testName: "Should return error if tag is invalid",
+			line:     "disk.used;host 75",
+			hasError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			result, err := ParseLine(tc.line)
+
+			if tc.hasError {
+				require.ErrorIs(t, err, ErrMalformedLine)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestParseCounterRules(t *testing.T) {
+	t.Run("Should parse rules", func(t *testing.T) {
+		rules, err := ParseCounterRules("stats.counts.*, *.requests ,")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"stats.counts.*", "*.requests"}, rules)
+	})
+
+	t.Run("Should return error for invalid pattern", func(t *testing.T) {
+		_, err := ParseCounterRules("stats.[counts")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestMetricType(t *testing.T) {
+	rules := []string{"stats.counts.*", "*.requests", "jobs.?.runs"}
+
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{path: "stats.counts.logins", expected: models.CounterMetricType},
+		{path: "api.requests", expected: models.CounterMetricType},
+		{path: "jobs.a.runs", expected: models.CounterMetricType},
+		{path: "stats.counts.logins.failed", expected: models.GaugeMetricType},
+		{path: "stats.gauges.load", expected: models.GaugeMetricType},
+		{path: "jobs.ab.runs", expected: models.GaugeMetricType},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.expected, metricType(rules, tc.path))
+		})
+	}
+}
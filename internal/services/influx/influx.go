@@ -0,0 +1,202 @@
+// Package influx принимает данные в формате протокола InfluxDB (line protocol) и преобразует их в модели метрик.
+package influx
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/services"
+	"github.com/daremove/go-metrics-service/internal/storage"
+)
+
+// DefaultCounterSuffix суффикс имени целочисленного поля, по которому оно считается счетчиком, используемый по умолчанию.
+const DefaultCounterSuffix = "_total"
+
+// Config содержит настройки приема данных InfluxDB.
+type Config struct {
+	CounterSuffix string // Суффикс имени целочисленного поля, по которому оно сохраняется как счетчик
+}
+
+// Saver определяет метод сохранения метрик, необходимый для приема данных.
+type Saver interface {
+	SaveModels(ctx context.Context, parameters []models.Metrics) error
+}
+
+// counterTTL время, после которого забывается последнее значение счетчика, не получавшего новых данных.
+const counterTTL = time.Hour
+
+// counterState последнее полученное значение счетчика и время его получения.
+type counterState struct {
+	value int64
+	seen  time.Time
+}
+
+// Receiver преобразует точки InfluxDB в модели метрик. Каждое поле сохраняется отдельной метрикой
+// с именем "measurement_field", теги сохраняются как метки.
+// Целочисленные поля с суффиксом из настроек сохраняются как счетчики: источники передают их накопленным
+// значением, поэтому Receiver запоминает последнее значение и сохраняет только прирост.
+// Остальные поля сохраняются как gauge.
+// Значения счетчиков, не обновлявшихся дольше counterTTL, удаляются, чтобы исчезнувшие ряды не копились в памяти.
+type Receiver struct {
+	config   Config
+	mu       sync.Mutex
+	counters map[string]counterState
+	ttl      time.Duration
+	swept    time.Time
+	now      func() time.Time
+}
+
+// New создает новый экземпляр Receiver.
+func New(config Config) *Receiver {
+	if config.CounterSuffix == "" {
+		config.CounterSuffix = DefaultCounterSuffix
+	}
+
+	return &Receiver{
+		config:   config,
+		counters: make(map[string]counterState),
+		ttl:      counterTTL,
+		now:      time.Now,
+	}
+}
+
+// Write сохраняет точки. Если одна метрика встречается несколько раз, из значений gauge сохраняется
+// самое позднее, из значений счетчика — суммарный прирост.
+// Запомненные значения счетчиков обновляются только после успешного сохранения.
+func (r *Receiver) Write(ctx context.Context, saver Saver, points []Point) error {
+	points = append([]Point(nil), points...)
+
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var keys []string
+
+	gauges := make(map[string]models.Metrics)
+	deltas := make(map[string]models.Metrics)
+	counters := make(map[string]int64)
+
+	for _, point := range points {
+		labels := toLabels(point.Tags)
+
+		for _, field := range point.Fields {
+			name := services.SanitizeMetricName(point.Measurement + "_" + field.Key)
+			key := storage.SeriesKey(name, labels)
+
+			if !field.Integer || !strings.HasSuffix(field.Key, r.config.CounterSuffix) {
+				value := field.Value
+
+				if _, ok := gauges[key]; !ok {
+					keys = append(keys, models.GaugeMetricType+"/"+key)
+				}
+
+				gauges[key] = models.Metrics{ID: name, MType: models.GaugeMetricType, Value: &value, Labels: labels}
+				continue
+			}
+
+			// Разобранные строки не содержат целых значений вне диапазона int64,
+			// но точки могут быть переданы и без разбора.
+			value, ok := services.CounterDelta(field.Value)
+
+			if !ok {
+				continue
+			}
+
+			last, ok := counters[key]
+
+			if !ok {
+				var state counterState
+				state, ok = r.counters[key]
+				last = state.value
+			}
+
+			increase := value
+
+			if ok && value >= last {
+				increase = value - last
+			}
+
+			counters[key] = value
+
+			item, exists := deltas[key]
+
+			if !exists {
+				var delta int64
+
+				item = models.Metrics{ID: name, MType: models.CounterMetricType, Delta: &delta, Labels: labels}
+				deltas[key] = item
+				keys = append(keys, models.CounterMetricType+"/"+key)
+			}
+
+			*item.Delta += increase
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	result := make([]models.Metrics, 0, len(keys))
+
+	for _, item := range keys {
+		metricType, key, _ := strings.Cut(item, "/")
+
+		if metricType == models.CounterMetricType {
+			result = append(result, deltas[key])
+		} else {
+			result = append(result, gauges[key])
+		}
+	}
+
+	if err := saver.SaveModels(ctx, result); err != nil {
+		return err
+	}
+
+	now := r.now()
+
+	for key, value := range counters {
+		r.counters[key] = counterState{value: value, seen: now}
+	}
+
+	r.evictCounters(now)
+
+	return nil
+}
+
+// evictCounters удаляет значения счетчиков, не обновлявшихся дольше ttl.
+// Проверка выполняется не чаще одного раза за ttl.
+func (r *Receiver) evictCounters(now time.Time) {
+	if now.Sub(r.swept) < r.ttl {
+		return
+	}
+
+	for key, state := range r.counters {
+		if now.Sub(state.seen) > r.ttl {
+			delete(r.counters, key)
+		}
+	}
+
+	r.swept = now
+}
+
+// toLabels преобразует теги в метки. Символы, недопустимые в имени метки, заменяются на "_".
+func toLabels(tags map[string]string) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	result := make(map[string]string, len(tags))
+
+	for name, value := range tags {
+		result[services.SanitizeLabelName(name)] = value
+	}
+
+	return result
+}
@@ -0,0 +1,146 @@
+package influx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+)
+
+type saverMock struct {
+	saved [][]models.Metrics
+	err   error
+}
+
+func (m *saverMock) SaveModels(_ context.Context, parameters []models.Metrics) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	m.saved = append(m.saved, parameters)
+
+	return nil
+}
+
+func gauge(name string, value float64, labels map[string]string) models.Metrics {
+	return models.Metrics{ID: name, MType: models.GaugeMetricType, Value: &value, Labels: labels}
+}
+
+func counter(name string, delta int64, labels map[string]string) models.Metrics {
+	return models.Metrics{ID: name, MType: models.CounterMetricType, Delta: &delta, Labels: labels}
+}
+
+func parse(t *testing.T, body string) []Point {
+	t.Helper()
+
+	points, errs := Parse([]byte(body), time.Second, time.Unix(0, 0))
+	require.Empty(t, errs)
+
+	return points
+}
+
+func TestReceiver_Write(t *testing.T) {
+	t.Run("Should map fields to gauges and counters", func(t *testing.T) {
+		receiver := New(Config{})
+		saver := &saverMock{}
+
+		require.NoError(t, receiver.Write(context.Background(), saver, parse(t, "cpu,host=a usage=0.5,requests_total=10i,threads=4i 1")))
+
+		labels := map[string]string{"host": "a"}
+
+		assert.Equal(t, [][]models.Metrics{{
+			gauge("cpu_usage", 0.5, labels),
+			counter("cpu_requests_total", 10, labels),
+			gauge("cpu_threads", 4, labels),
+		}}, saver.saved)
+	})
+
+	t.Run("Should use configured counter suffix", func(t *testing.T) {
+		receiver := New(Config{CounterSuffix: "_count"})
+		saver := &saverMock{}
+
+		require.NoError(t, receiver.Write(context.Background(), saver, parse(t, "http requests_count=3i,requests_total=5i")))
+
+		assert.Equal(t, [][]models.Metrics{{counter("http_requests_count", 3, nil), gauge("http_requests_total", 5, nil)}}, saver.saved)
+	})
+
+	t.Run("Should save counter increase and latest gauge value", func(t *testing.T) {
+		receiver := New(Config{})
+		saver := &saverMock{}
+
+		require.NoError(t, receiver.Write(context.Background(), saver, parse(t, "app hits_total=10i,load=1 1")))
+		require.NoError(t, receiver.Write(context.Background(), saver, parse(t, "app hits_total=18i,load=3 3\napp hits_total=15i,load=2 2")))
+		require.NoError(t, receiver.Write(context.Background(), saver, parse(t, "app hits_total=4i 4")))
+
+		assert.Equal(t, [][]models.Metrics{
+			{counter("app_hits_total", 10, nil), gauge("app_load", 1, nil)},
+			{counter("app_hits_total", 8, nil), gauge("app_load", 3, nil)},
+			{counter("app_hits_total", 4, nil)},
+		}, saver.saved)
+	})
+
+	t.Run("Should not remember counters if save failed", func(t *testing.T) {
+		receiver := New(Config{})
+		saver := &saverMock{err: errors.New("storage is unavailable")}
+
+		require.Error(t, receiver.Write(context.Background(), saver, parse(t, "app hits_total=10i")))
+
+		saver.err = nil
+		require.NoError(t, receiver.Write(context.Background(), saver, parse(t, "app hits_total=12i")))
+
+		assert.Equal(t, [][]models.Metrics{{counter("app_hits_total", 12, nil)}}, saver.saved)
+	})
+
+	t.Run("Should forget counters that weren't updated longer than ttl", func(t *testing.T) {
+		receiver := New(Config{})
+		saver := &saverMock{}
+		now := time.Unix(0, 0)
+		receiver.now = func() time.Time { return now }
+
+		require.NoError(t, receiver.Write(context.Background(), saver, parse(t, "app hits_total=10i")))
+
+		now = now.Add(counterTTL / 2)
+		require.NoError(t, receiver.Write(context.Background(), saver, parse(t, "app errors_total=1i")))
+
+		now = now.Add(counterTTL)
+		require.NoError(t, receiver.Write(context.Background(), saver, parse(t, "app errors_total=3i")))
+
+		assert.NotContains(t, receiver.counters, "app_hits_total")
+		assert.Contains(t, receiver.counters, "app_errors_total")
+
+		require.NoError(t, receiver.Write(context.Background(), saver, parse(t, "app hits_total=12i")))
+		assert.Equal(t, []models.Metrics{counter("app_hits_total", 12, nil)}, saver.saved[3])
+	})
+
+	t.Run("Should convert tag names to label names", func(t *testing.T) {
+		receiver := New(Config{})
+		saver := &saverMock{}
+
+		require.NoError(t, receiver.Write(context.Background(), saver, parse(t, "net,if-name=eth0,1zone=a rx=1")))
+
+		assert.Equal(t, [][]models.Metrics{{gauge("net_rx", 1, map[string]string{"if_name": "eth0", "_1zone": "a"})}}, saver.saved)
+	})
+
+	t.Run("Should replace reserved characters in metric names", func(t *testing.T) {
+		receiver := New(Config{})
+		saver := &saverMock{}
+
+		require.NoError(t, receiver.Write(context.Background(), saver, parse(t, `cpu{host="a"} usage=1`)))
+
+		assert.Equal(t, [][]models.Metrics{{gauge("cpu_host__a___usage", 1, nil)}}, saver.saved)
+	})
+
+	t.Run("Should not save empty data", func(t *testing.T) {
+		receiver := New(Config{})
+		saver := &saverMock{}
+
+		require.NoError(t, receiver.Write(context.Background(), saver, parse(t, `app status="ok"`)))
+
+		assert.Empty(t, saver.saved)
+	})
+}
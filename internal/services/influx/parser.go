@@ -0,0 +1,289 @@
+package influx
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/daremove/go-metrics-service/internal/services"
+)
+
+// ErrInvalidLine ошибка, возникающая при разборе некорректной строки протокола InfluxDB.
+var ErrInvalidLine = errors.New("line protocol is invalid")
+
+// ErrInvalidPrecision ошибка, возникающая при указании неизвестной точности временных меток.
+var ErrInvalidPrecision = errors.New("precision is invalid")
+
+// LineError описывает ошибку разбора строки с ее номером.
+type LineError struct {
+	Line int   // Номер строки, начиная с 1
+	Err  error // Причина ошибки
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// Field описывает числовое поле точки.
+type Field struct {
+	Key     string  // Имя поля
+	Value   float64 // Значение поля
+	Integer bool    // Признак целочисленного поля: "5i" или "5u"
+}
+
+// Point описывает одну строку протокола InfluxDB.
+type Point struct {
+	Measurement string            // Имя измерения
+	Tags        map[string]string // Теги
+	Fields      []Field           // Числовые поля; строковые поля не сохраняются и пропускаются
+	Timestamp   time.Time         // Время точки; если не задано — время получения запроса
+}
+
+// ParsePrecision возвращает единицу временных меток для значения параметра precision.
+// Пустое значение соответствует наносекундам.
+func ParsePrecision(value string) (time.Duration, error) {
+	switch value {
+	case "", "ns", "n":
+		return time.Nanosecond, nil
+	case "us", "u":
+		return time.Microsecond, nil
+	case "ms":
+		return time.Millisecond, nil
+	case "s":
+		return time.Second, nil
+	default:
+		return 0, fmt.Errorf("%w: %q, expected ns, us, ms or s", ErrInvalidPrecision, value)
+	}
+}
+
+// Parse разбирает тело запроса в формате протокола InfluxDB. Пустые строки и комментарии пропускаются.
+// Некорректные строки не прерывают разбор: для каждой возвращается LineError.
+func Parse(body []byte, precision time.Duration, now time.Time) ([]Point, []error) {
+	var (
+		points []Point
+		errs   []error
+	)
+
+	for i, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		point, err := ParseLine(line, precision, now)
+
+		if err != nil {
+			errs = append(errs, &LineError{Line: i + 1, Err: err})
+			continue
+		}
+
+		points = append(points, point)
+	}
+
+	return points, errs
+}
+
+// ParseLine разбирает строку вида "measurement[,tag=value...] field=value[,field=value...] [timestamp]".
+func ParseLine(line string, precision time.Duration, now time.Time) (Point, error) {
+	measurement, i := scan(line, 0, ", ")
+
+	if measurement == "" {
+		return Point{}, fmt.Errorf("%w: measurement is missing", ErrInvalidLine)
+	}
+
+	point := Point{Measurement: measurement, Timestamp: now}
+
+	for i < len(line) && line[i] == ',' {
+		var key, value string
+
+		key, i = scan(line, i+1, "=, ")
+
+		if i >= len(line) || line[i] != '=' || key == "" {
+			return Point{}, fmt.Errorf("%w: tag %q has no value", ErrInvalidLine, key)
+		}
+
+		value, i = scan(line, i+1, ", ")
+
+		if value == "" {
+			return Point{}, fmt.Errorf("%w: tag %q has empty value", ErrInvalidLine, key)
+		}
+
+		if point.Tags == nil {
+			point.Tags = map[string]string{}
+		}
+
+		point.Tags[key] = value
+	}
+
+	if i >= len(line) || line[i] != ' ' {
+		return Point{}, fmt.Errorf("%w: fields are missing", ErrInvalidLine)
+	}
+
+	i = skipSpaces(line, i)
+	fields := 0
+
+	for {
+		var (
+			key   string
+			field Field
+			ok    bool
+			err   error
+		)
+
+		key, i = scan(line, i, "=, ")
+
+		if i >= len(line) || line[i] != '=' || key == "" {
+			return Point{}, fmt.Errorf("%w: field %q has no value", ErrInvalidLine, key)
+		}
+
+		field, ok, i, err = scanFieldValue(line, i+1)
+
+		if err != nil {
+			return Point{}, fmt.Errorf("%w: field %q: %v", ErrInvalidLine, key, err)
+		}
+
+		fields++
+
+		if ok {
+			field.Key = key
+			point.Fields = append(point.Fields, field)
+		}
+
+		if i >= len(line) || line[i] != ',' {
+			break
+		}
+
+		i++
+	}
+
+	if fields == 0 {
+		return Point{}, fmt.Errorf("%w: fields are missing", ErrInvalidLine)
+	}
+
+	if i < len(line) {
+		value := strings.TrimSpace(line[i:])
+		timestamp, err := strconv.ParseInt(value, 10, 64)
+
+		if err != nil {
+			return Point{}, fmt.Errorf("%w: timestamp %q is invalid", ErrInvalidLine, value)
+		}
+
+		point.Timestamp = time.Unix(0, timestamp*int64(precision))
+	}
+
+	return point, nil
+}
+
+// scan читает значение до одного из символов stops с учетом экранирования обратной косой чертой.
+// Возвращает значение без экранирования и позицию символа-разделителя.
+func scan(line string, i int, stops string) (string, int) {
+	var b strings.Builder
+
+	for ; i < len(line); i++ {
+		c := line[i]
+
+		if c == '\\' && i+1 < len(line) && (strings.IndexByte(stops, line[i+1]) >= 0 || line[i+1] == '\\' || line[i+1] == '=') {
+			i++
+			b.WriteByte(line[i])
+			continue
+		}
+
+		if strings.IndexByte(stops, c) >= 0 {
+			break
+		}
+
+		b.WriteByte(c)
+	}
+
+	return b.String(), i
+}
+
+// skipSpaces пропускает пробелы, начиная с позиции i.
+func skipSpaces(line string, i int) int {
+	for i < len(line) && line[i] == ' ' {
+		i++
+	}
+
+	return i
+}
+
+// scanFieldValue разбирает значение поля. Строковые значения проверяются, но не возвращаются,
+// так как не могут быть сохранены как метрика. Логические значения сохраняются как 1 и 0.
+func scanFieldValue(line string, i int) (Field, bool, int, error) {
+	if i < len(line) && line[i] == '"' {
+		for i++; i < len(line); i++ {
+			switch line[i] {
+			case '\\':
+				i++
+			case '"':
+				return Field{}, false, skipSpaces(line, i+1), nil
+			}
+		}
+
+		return Field{}, false, i, fmt.Errorf("string value isn't terminated")
+	}
+
+	start := i
+
+	for i < len(line) && line[i] != ',' && line[i] != ' ' {
+		i++
+	}
+
+	raw := line[start:i]
+	next := i
+
+	if next < len(line) && line[next] == ' ' {
+		next = skipSpaces(line, next)
+	}
+
+	switch raw {
+	case "t", "T", "true", "True", "TRUE":
+		return Field{Value: 1}, true, next, nil
+	case "f", "F", "false", "False", "FALSE":
+		return Field{Value: 0}, true, next, nil
+	}
+
+	if strings.HasSuffix(raw, "i") {
+		value, err := strconv.ParseInt(strings.TrimSuffix(raw, "i"), 10, 64)
+
+		if err != nil {
+			return Field{}, false, next, fmt.Errorf("integer value %q is invalid", raw)
+		}
+
+		if _, ok := services.CounterDelta(float64(value)); !ok {
+			return Field{}, false, next, fmt.Errorf("integer value %q is out of range", raw)
+		}
+
+		return Field{Value: float64(value), Integer: true}, true, next, nil
+	}
+
+	if strings.HasSuffix(raw, "u") {
+		value, err := strconv.ParseUint(strings.TrimSuffix(raw, "u"), 10, 64)
+
+		if err != nil {
+			return Field{}, false, next, fmt.Errorf("unsigned value %q is invalid", raw)
+		}
+
+		if _, ok := services.CounterDelta(float64(value)); !ok {
+			return Field{}, false, next, fmt.Errorf("unsigned value %q is out of range", raw)
+		}
+
+		return Field{Value: float64(value), Integer: true}, true, next, nil
+	}
+
+	value, err := strconv.ParseFloat(raw, 64)
+
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return Field{}, false, next, fmt.Errorf("value %q is invalid", raw)
+	}
+
+	return Field{Value: value}, true, next, nil
+}
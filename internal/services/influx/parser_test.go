@@ -0,0 +1,162 @@
+package influx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	now := time.Unix(100, 0)
+
+	testCases := []struct {
+		testName  string
+		line      string
+		precision time.Duration
+		expected  Point
+		hasError  bool
+	}{
+		{
+			testName:  "Should parse line with tags, fields and timestamp",
+			line:      "cpu,host=a,dc=eu usage=0.5,requests_total=10i 1700000000",
+			precision: time.Second,
+			expected: Point{
+				Measurement: "cpu",
+				Tags:        map[string]string{"host": "a", "dc": "eu"},
+				Fields:      []Field{{Key: "usage", Value: 0.5}, {Key: "requests_total", Value: 10, Integer: true}},
+				Timestamp:   time.Unix(1700000000, 0),
+			},
+		},
+		{
+			testName:  "Should use current time if timestamp is missing",
+			line:      "mem used=1024u",
+			precision: time.Nanosecond,
+			expected:  Point{Measurement: "mem", Fields: []Field{{Key: "used", Value: 1024, Integer: true}}, Timestamp: now},
+		},
+		{
+			testName:  "Should apply precision to timestamp",
+			line:      "mem used=1 1500",
+			precision: time.Millisecond,
+			expected:  Point{Measurement: "mem", Fields: []Field{{Key: "used", Value: 1}}, Timestamp: time.Unix(1, 500*int64(time.Millisecond))},
+		},
+		{
+			testName:  "Should unescape names and skip string fields",
+			line:      `disk\ io,path=/var\,log read=1,status="ok, fine",healthy=true`,
+			precision: time.Nanosecond,
+			expected: Point{
+				Measurement: "disk io",
+				Tags:        map[string]string{"path": "/var,log"},
+				Fields:      []Field{{Key: "read", Value: 1}, {Key: "healthy", Value: 1}},
+				Timestamp:   now,
+			},
+		},
+		{
+			testName:  "Should return error if fields are missing",
+			line:      "cpu,host=a",
+			precision: time.Nanosecond,
+			hasError:  true,
+		},
+		{
+			testName:  "Should return error if field value is invalid",
+			line:      "cpu usage=abc",
+			precision: time.Nanosecond,
+			hasError:  true,
+		},
+		{
+			testName:  "Should return error if integer value is invalid",
+			line:      "cpu usage=1.5i",
+			precision: time.Nanosecond,
+			hasError:  true,
+		},
+		{
+			testName:  "Should return error if integer value is out of counter range",
+			line:      "cpu requests_total=9223372036854775807i",
+			precision: time.Nanosecond,
+			hasError:  true,
+		},
+		{
+			testName:  "Should return error if unsigned value is out of counter range",
+			line:      "cpu requests_total=18446744073709551615u",
+			precision: time.Nanosecond,
+			hasError:  true,
+		},
+		{
+			testName:  "Should return error if string value isn't terminated",
+			line:      `cpu status="ok`,
+			precision: time.Nanosecond,
+			hasError:  true,
+		},
+		{
+			testName:  "Should return error if timestamp is invalid",
+			line:      "cpu usage=1 yesterday",
+			precision: time.Nanosecond,
+			hasError:  true,
+		},
+		{
+			testName:  "Should return error if tag has no value",
+			line:      "cpu,host usage=1",
+			precision: time.Nanosecond,
+			hasError:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			result, err := ParseLine(tc.line, tc.precision, now)
+
+			if tc.hasError {
+				require.ErrorIs(t, err, ErrInvalidLine)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Run("Should return valid points and errors with line numbers", func(t *testing.T) {
+		points, errs := Parse([]byte("# comment\ncpu usage=1\n\ncpu\ncpu usage=2\n"), time.Nanosecond, time.Unix(0, 0))
+
+		require.Len(t, points, 2)
+		require.Len(t, errs, 1)
+
+		var lineErr *LineError
+
+		require.ErrorAs(t, errs[0], &lineErr)
+		assert.Equal(t, 4, lineErr.Line)
+		assert.ErrorIs(t, errs[0], ErrInvalidLine)
+	})
+}
+
+func TestParsePrecision(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected time.Duration
+		hasError bool
+	}{
+		{value: "", expected: time.Nanosecond},
+		{value: "ns", expected: time.Nanosecond},
+		{value: "us", expected: time.Microsecond},
+		{value: "ms", expected: time.Millisecond},
+		{value: "s", expected: time.Second},
+		{value: "h", hasError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			result, err := ParsePrecision(tc.value)
+
+			if tc.hasError {
+				require.ErrorIs(t, err, ErrInvalidPrecision)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
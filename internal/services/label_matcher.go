@@ -0,0 +1,153 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Операторы сравнения значений меток.
+const (
+	MatchEqual     = "="
+	MatchNotEqual  = "!="
+	MatchRegexp    = "=~"
+	MatchNotRegexp = "!~"
+)
+
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// LabelMatcher описывает условие фильтрации метрик по значению метки.
+type LabelMatcher struct {
+	Name  string         // Имя метки
+	Op    string         // Оператор сравнения
+	Value string         // Значение либо регулярное выражение
+	re    *regexp.Regexp // Скомпилированное регулярное выражение для операторов =~ и !~
+}
+
+// ValidateLabelName проверяет, что имя метки состоит из допустимых символов.
+func ValidateLabelName(name string) error {
+	if !labelNamePattern.MatchString(name) {
+		return fmt.Errorf("label name %q is invalid", name)
+	}
+
+	return nil
+}
+
+// SanitizeLabelName приводит имя к допустимому имени метки [a-zA-Z_][a-zA-Z0-9_]*: недопустимые символы
+// заменяются на "_", перед именем, начинающимся с цифры, добавляется "_", а пустое имя заменяется на "_".
+func SanitizeLabelName(name string) string {
+	if name == "" {
+		return "_"
+	}
+
+	if ValidateLabelName(name) == nil {
+		return name
+	}
+
+	var b strings.Builder
+
+	b.Grow(len(name) + 1)
+
+	for i, r := range name {
+		switch {
+		case i == 0 && r >= '0' && r <= '9':
+			b.WriteByte('_')
+			b.WriteRune(r)
+		case r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+
+	return b.String()
+}
+
+// NewLabelMatcher создает условие фильтрации по метке.
+// Регулярные выражения должны совпадать со значением метки целиком.
+func NewLabelMatcher(name, op, value string) (LabelMatcher, error) {
+	if err := ValidateLabelName(name); err != nil {
+		return LabelMatcher{}, err
+	}
+
+	matcher := LabelMatcher{Name: name, Op: op, Value: value}
+
+	switch op {
+	case MatchEqual, MatchNotEqual:
+	case MatchRegexp, MatchNotRegexp:
+		re, err := regexp.Compile("^(?:" + value + ")$")
+
+		if err != nil {
+			return LabelMatcher{}, fmt.Errorf("label matcher %s has invalid regexp: %w", name, err)
+		}
+
+		matcher.re = re
+	default:
+		return LabelMatcher{}, fmt.Errorf("label matcher operator %q isn't supported", op)
+	}
+
+	return matcher, nil
+}
+
+// ParseLabelMatcher разбирает условие фильтрации вида host="a", env=~"prod.*", env!="dev" или host!~"b.*".
+func ParseLabelMatcher(expression string) (LabelMatcher, error) {
+	expression = strings.TrimSpace(expression)
+	index := strings.IndexAny(expression, "=!")
+
+	if index <= 0 {
+		return LabelMatcher{}, fmt.Errorf("label matcher %q is invalid", expression)
+	}
+
+	name := strings.TrimSpace(expression[:index])
+	rest := expression[index:]
+	op := MatchEqual
+
+	for _, candidate := range []string{MatchRegexp, MatchNotRegexp, MatchNotEqual} {
+		if strings.HasPrefix(rest, candidate) {
+			op = candidate
+			break
+		}
+	}
+
+	if op == MatchEqual && !strings.HasPrefix(rest, MatchEqual) {
+		return LabelMatcher{}, fmt.Errorf("label matcher %q is invalid", expression)
+	}
+
+	value, err := strconv.Unquote(strings.TrimSpace(rest[len(op):]))
+
+	if err != nil {
+		return LabelMatcher{}, fmt.Errorf("label matcher %q must have quoted value", expression)
+	}
+
+	return NewLabelMatcher(name, op, value)
+}
+
+// Matches проверяет, удовлетворяет ли набор меток условию. Отсутствующая метка считается пустой.
+func (m LabelMatcher) Matches(labels map[string]string) bool {
+	value := labels[m.Name]
+
+	switch m.Op {
+	case MatchEqual:
+		return value == m.Value
+	case MatchNotEqual:
+		return value != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(value)
+	case MatchNotRegexp:
+		return !m.re.MatchString(value)
+	default:
+		return false
+	}
+}
+
+// MatchLabels проверяет, удовлетворяет ли набор меток всем условиям.
+func MatchLabels(labels map[string]string, matchers []LabelMatcher) bool {
+	for _, matcher := range matchers {
+		if !matcher.Matches(labels) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLabelMatcher(t *testing.T) {
+	testCases := []struct {
+		expression string
+		labels     map[string]string
+		matches    bool
+	}{
+		{expression: `host="a"`, labels: map[string]string{"host": "a"}, matches: true},
+		{expression: `host="a"`, labels: map[string]string{"host": "b"}, matches: false},
+		{expression: `host!="a"`, labels: map[string]string{"host": "b"}, matches: true},
+		{expression: `env=~"prod.*"`, labels: map[string]string{"env": "production"}, matches: true},
+		{expression: `env=~"prod"`, labels: map[string]string{"env": "production"}, matches: false},
+		{expression: `env!~"prod.*"`, labels: map[string]string{"env": "dev"}, matches: true},
+		{expression: `env=""`, labels: map[string]string{}, matches: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run("Should match "+tc.expression, func(t *testing.T) {
+			matcher, err := ParseLabelMatcher(tc.expression)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.matches, matcher.Matches(tc.labels))
+		})
+	}
+
+	t.Run("Should return error for invalid expressions", func(t *testing.T) {
+		for _, expression := range []string{`host`, `host=a`, `1host="a"`, `env=~"("`, `host<"a"`} {
+			_, err := ParseLabelMatcher(expression)
+
+			assert.Error(t, err, expression)
+		}
+	})
+}
+
+func TestMatchLabels(t *testing.T) {
+	host, _ := NewLabelMatcher("host", MatchEqual, "a")
+	env, _ := NewLabelMatcher("env", MatchRegexp, "prod.*")
+
+	t.Run("Should require all matchers", func(t *testing.T) {
+		assert.True(t, MatchLabels(map[string]string{"host": "a", "env": "prod"}, []LabelMatcher{host, env}))
+		assert.False(t, MatchLabels(map[string]string{"host": "a", "env": "dev"}, []LabelMatcher{host, env}))
+	})
+
+	t.Run("Should match everything without matchers", func(t *testing.T) {
+		assert.True(t, MatchLabels(nil, nil))
+	})
+}
+
+func TestSanitizeLabelName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "host", expected: "host"},
+		{name: "service.name", expected: "service_name"},
+		{name: "if-name", expected: "if_name"},
+		{name: "1zone", expected: "_1zone"},
+		{name: "ns:metric", expected: "ns_metric"},
+		{name: "метка", expected: "_____"},
+		{name: "", expected: "_"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, SanitizeLabelName(tc.name))
+		})
+	}
+}
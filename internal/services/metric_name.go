@@ -0,0 +1,35 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// reservedMetricNameChars символы, которые используются в ключе хранения метрики с метками
+// и поэтому не допускаются в имени метрики.
+const reservedMetricNameChars = "{}=\","
+
+// ValidateMetricName проверяет, что имя метрики не содержит символов, зарезервированных
+// для ключа хранения с метками: иначе имя вида cpu{host="a"} совпало бы с ключом метрики cpu с меткой host.
+func ValidateMetricName(name string) error {
+	if strings.ContainsAny(name, reservedMetricNameChars) {
+		return fmt.Errorf("metric name %q must not contain any of %s", name, reservedMetricNameChars)
+	}
+
+	return nil
+}
+
+// SanitizeMetricName заменяет в имени метрики зарезервированные символы на "_".
+func SanitizeMetricName(name string) string {
+	if !strings.ContainsAny(name, reservedMetricNameChars) {
+		return name
+	}
+
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(reservedMetricNameChars, r) {
+			return '_'
+		}
+
+		return r
+	}, name)
+}
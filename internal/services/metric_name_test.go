@@ -0,0 +1,39 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateMetricName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		hasError bool
+	}{
+		{name: "cpu"},
+		{name: "jobs.backup-duration:seconds"},
+		{name: `cpu{host="a"}`, hasError: true},
+		{name: "a{b}", hasError: true},
+		{name: "a=b", hasError: true},
+		{name: "a,b", hasError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateMetricName(tc.name)
+
+			if tc.hasError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSanitizeMetricName(t *testing.T) {
+	assert.Equal(t, "cpu", SanitizeMetricName("cpu"))
+	assert.Equal(t, "cpu_host__a__", SanitizeMetricName(`cpu{host="a"}`))
+}
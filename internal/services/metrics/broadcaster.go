@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/services"
+)
+
+// DefaultSubscriberBuffer размер буфера событий подписчика по умолчанию.
+const DefaultSubscriberBuffer = 256
+
+// Broadcaster рассылает события изменения метрик всем подписчикам.
+// Рассылка никогда не блокируется: подписчик, буфер которого переполнен, отключается,
+// а его канал событий закрывается.
+type Broadcaster struct {
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	bufferSize  int
+}
+
+// subscriber описывает подписку на события изменения метрик.
+type subscriber struct {
+	events     chan models.MetricEvent
+	parameters services.MetricStreamParameters
+}
+
+// NewBroadcaster создает рассыльщик событий с указанным размером буфера подписчика.
+// Если размер не задан, используется DefaultSubscriberBuffer.
+func NewBroadcaster(bufferSize int) *Broadcaster {
+	if bufferSize <= 0 {
+		bufferSize = DefaultSubscriberBuffer
+	}
+
+	return &Broadcaster{
+		subscribers: map[*subscriber]struct{}{},
+		bufferSize:  bufferSize,
+	}
+}
+
+// Subscribe регистрирует подписчика и возвращает канал событий, удовлетворяющих условиям,
+// и функцию отмены подписки. Канал закрывается при отмене подписки или при отключении
+// подписчика, не успевающего читать события. Функцию отмены можно вызывать повторно.
+func (b *Broadcaster) Subscribe(parameters services.MetricStreamParameters) (<-chan models.MetricEvent, func()) {
+	s := &subscriber{
+		events:     make(chan models.MetricEvent, b.bufferSize),
+		parameters: parameters,
+	}
+
+	b.mu.Lock()
+	b.subscribers[s] = struct{}{}
+	b.mu.Unlock()
+
+	return s.events, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		b.remove(s)
+	}
+}
+
+// Publish рассылает события подписчикам, условиям которых они удовлетворяют.
+func (b *Broadcaster) Publish(events ...models.MetricEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for s := range b.subscribers {
+		for _, event := range events {
+			if !s.matches(event) {
+				continue
+			}
+
+			select {
+			case s.events <- event:
+			default:
+				b.remove(s)
+			}
+
+			if _, ok := b.subscribers[s]; !ok {
+				break
+			}
+		}
+	}
+}
+
+// HasSubscribers сообщает, есть ли активные подписчики.
+func (b *Broadcaster) HasSubscribers() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.subscribers) > 0
+}
+
+// remove удаляет подписчика и закрывает его канал событий. Вызывается под блокировкой.
+func (b *Broadcaster) remove(s *subscriber) {
+	if _, ok := b.subscribers[s]; !ok {
+		return
+	}
+
+	delete(b.subscribers, s)
+	close(s.events)
+}
+
+// matches проверяет, удовлетворяет ли событие условиям подписки.
+func (s *subscriber) matches(event models.MetricEvent) bool {
+	if len(s.parameters.Types) > 0 {
+		found := false
+
+		for _, metricType := range s.parameters.Types {
+			if metricType == event.Metric.MType {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if s.parameters.Pattern != nil && !s.parameters.Pattern.MatchString(event.Metric.ID) {
+		return false
+	}
+
+	return services.MatchLabels(event.Metric.Labels, s.parameters.Matchers)
+}
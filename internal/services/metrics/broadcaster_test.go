@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func drainEvents(events <-chan models.MetricEvent) []models.MetricEvent {
+	var result []models.MetricEvent
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return result
+			}
+
+			result = append(result, event)
+		default:
+			return result
+		}
+	}
+}
+
+func TestBroadcaster(t *testing.T) {
+	gaugeEvent := models.MetricEvent{Action: models.MetricUpdateAction, Metric: models.Metrics{ID: "cpu", MType: models.GaugeMetricType, Labels: map[string]string{"host": "a"}}}
+	counterEvent := models.MetricEvent{Action: models.MetricUpdateAction, Metric: models.Metrics{ID: "requests", MType: models.CounterMetricType}}
+	otherGaugeEvent := models.MetricEvent{Action: models.MetricDeleteAction, Metric: models.Metrics{ID: "memory", MType: models.GaugeMetricType}}
+
+	matcher, err := services.ParseLabelMatcher(`host="a"`)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		testName   string
+		parameters services.MetricStreamParameters
+		expected   []models.MetricEvent
+	}{
+		{
+			testName: "Should deliver all events without filters",
+			expected: []models.MetricEvent{gaugeEvent, counterEvent, otherGaugeEvent},
+		},
+		{
+			testName:   "Should filter events by type",
+			parameters: services.MetricStreamParameters{Types: []string{models.CounterMetricType}},
+			expected:   []models.MetricEvent{counterEvent},
+		},
+		{
+			testName:   "Should filter events by name pattern",
+			parameters: services.MetricStreamParameters{Pattern: regexp.MustCompile("^(cpu|memory)$")},
+			expected:   []models.MetricEvent{gaugeEvent, otherGaugeEvent},
+		},
+		{
+			testName:   "Should filter events by labels",
+			parameters: services.MetricStreamParameters{Matchers: []services.LabelMatcher{matcher}},
+			expected:   []models.MetricEvent{gaugeEvent},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			broadcaster := NewBroadcaster(0)
+			events, unsubscribe := broadcaster.Subscribe(tc.parameters)
+			defer unsubscribe()
+
+			broadcaster.Publish(gaugeEvent, counterEvent, otherGaugeEvent)
+
+			assert.Equal(t, tc.expected, drainEvents(events))
+		})
+	}
+
+	t.Run("Should drop subscriber which buffer is full without blocking others", func(t *testing.T) {
+		broadcaster := NewBroadcaster(1)
+		slow, unsubscribeSlow := broadcaster.Subscribe(services.MetricStreamParameters{})
+		defer unsubscribeSlow()
+		fast, unsubscribeFast := broadcaster.Subscribe(services.MetricStreamParameters{})
+		defer unsubscribeFast()
+
+		broadcaster.Publish(gaugeEvent)
+		assert.Equal(t, []models.MetricEvent{gaugeEvent}, drainEvents(fast))
+
+		broadcaster.Publish(counterEvent)
+		assert.Equal(t, []models.MetricEvent{counterEvent}, drainEvents(fast))
+
+		assert.Equal(t, []models.MetricEvent{gaugeEvent}, drainEvents(slow))
+		_, ok := <-slow
+		assert.False(t, ok)
+		assert.True(t, broadcaster.HasSubscribers())
+	})
+
+	t.Run("Should close events channel on unsubscribe", func(t *testing.T) {
+		broadcaster := NewBroadcaster(0)
+		events, unsubscribe := broadcaster.Subscribe(services.MetricStreamParameters{})
+
+		unsubscribe()
+		unsubscribe()
+
+		_, ok := <-events
+		assert.False(t, ok)
+		assert.False(t, broadcaster.HasSubscribers())
+
+		broadcaster.Publish(gaugeEvent)
+	})
+}
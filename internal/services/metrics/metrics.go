@@ -3,18 +3,47 @@ package metrics
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
+	"time"
 
+	"go.uber.org/zap"
+
+	"github.com/daremove/go-metrics-service/internal/logger"
 	"github.com/daremove/go-metrics-service/internal/models"
 	"github.com/daremove/go-metrics-service/internal/services"
+	"github.com/daremove/go-metrics-service/internal/services/staleness"
 	"github.com/daremove/go-metrics-service/internal/storage"
 )
 
+// ErrHistogramValueFormat ошибка, возникающая при попытке сохранить гистограмму из строкового значения.
+var ErrHistogramValueFormat = errors.New("histogram metric can be saved only as json model")
+
+// ErrInvalidHistogram ошибка, возникающая при сохранении некорректной гистограммы.
+var ErrInvalidHistogram = errors.New("histogram is invalid")
+
+// ErrEmptyPrefix ошибка, возникающая при попытке удалить метрики по пустому префиксу.
+var ErrEmptyPrefix = errors.New("prefix must not be empty")
+
+// ErrResetNotSupported ошибка, возникающая при попытке обнулить метрику, не являющуюся счетчиком.
+var ErrResetNotSupported = errors.New("only counter metrics can be reset")
+
 // Metrics предоставляет методы для управления метриками через определенное хранилище.
 type Metrics struct {
-	storage Storage
+	storage     Storage
+	staleness   staleness.Policy
+	broadcaster *Broadcaster
+	now         func() time.Time
+}
+
+// Config содержит настройки сервиса метрик.
+type Config struct {
+	Staleness        staleness.Policy // Время жизни метрик без обновлений, устаревшие метрики скрываются из ответов
+	SubscriberBuffer int              // Размер буфера событий подписчика, 0 — DefaultSubscriberBuffer
 }
 
 // Storage определяет интерфейс для механизмов хранения, используемых системой метрик.
@@ -23,94 +52,343 @@ type Storage interface {
 	GetGaugeMetrics(ctx context.Context) ([]storage.GaugeMetric, error)
 	GetCounterMetric(ctx context.Context, key string) (storage.CounterMetric, error)
 	GetCounterMetrics(ctx context.Context) ([]storage.CounterMetric, error)
+	GetHistogramMetric(ctx context.Context, key string) (storage.HistogramMetric, error)
+	GetHistogramMetrics(ctx context.Context) ([]storage.HistogramMetric, error)
+	ListMetrics(ctx context.Context, parameters storage.ListParameters) ([]storage.MetricRecord, error)
 	AddGaugeMetric(ctx context.Context, key string, value float64) error
 	AddCounterMetric(ctx context.Context, key string, value int64) error
-	AddMetrics(ctx context.Context, gaugeMetrics []storage.GaugeMetric, counterMetrics []storage.CounterMetric) error
+	AddHistogramMetric(ctx context.Context, key string, value storage.HistogramMetric) error
+	AddMetrics(ctx context.Context, gaugeMetrics []storage.GaugeMetric, counterMetrics []storage.CounterMetric, histogramMetrics []storage.HistogramMetric) error
+	DeleteGaugeMetric(ctx context.Context, key string) error
+	DeleteCounterMetric(ctx context.Context, key string) error
+	DeleteHistogramMetric(ctx context.Context, key string) error
+	DeleteStaleMetric(ctx context.Context, metricType, key string, updatedAt time.Time) error
+	DeleteMetricsByPrefix(ctx context.Context, prefix string) (int, error)
+	ResetCounterMetric(ctx context.Context, key string) error
+	AddMetricSamples(ctx context.Context, samples []storage.MetricSample) error
+	GetMetricSamples(ctx context.Context, metricType, key string, from, to time.Time) ([]storage.MetricSample, error)
+	DeleteMetricSamplesBefore(ctx context.Context, before time.Time) (int, error)
 }
 
 // New создает новый экземпляр Metrics.
 func New(storage Storage) *Metrics {
+	return NewWithConfig(storage, Config{})
+}
+
+// NewWithConfig создает новый экземпляр Metrics с указанными настройками.
+func NewWithConfig(storage Storage, config Config) *Metrics {
 	return &Metrics{
-		storage,
+		storage:     storage,
+		staleness:   config.Staleness,
+		broadcaster: NewBroadcaster(config.SubscriberBuffer),
+		now:         time.Now,
+	}
+}
+
+// Subscribe подписывает на события изменения метрик, удовлетворяющие условиям.
+// Возвращает канал событий и функцию отмены подписки.
+func (m *Metrics) Subscribe(parameters services.MetricStreamParameters) (<-chan models.MetricEvent, func()) {
+	return m.broadcaster.Subscribe(parameters)
+}
+
+// publishUpdates рассылает подписчикам события обновления сохраненных метрик.
+func (m *Metrics) publishUpdates(parameters ...models.Metrics) {
+	events := make([]models.MetricEvent, 0, len(parameters))
+	updatedAt := toUpdatedAt(m.now())
+
+	for _, parameter := range parameters {
+		metric := models.Metrics{ID: parameter.ID, MType: parameter.MType, Labels: parameter.Labels, UpdatedAt: updatedAt}
+
+		switch parameter.MType {
+		case models.GaugeMetricType:
+			metric.Value = parameter.Value
+		case models.CounterMetricType:
+			metric.Delta = parameter.Delta
+		case models.HistogramMetricType:
+			metric.Histogram = parameter.Histogram
+		}
+
+		events = append(events, models.MetricEvent{Action: models.MetricUpdateAction, Metric: metric})
+	}
+
+	m.broadcaster.Publish(events...)
+}
+
+// publishKeys рассылает подписчикам события об изменении метрик с указанными ключами хранения.
+func (m *Metrics) publishKeys(action, metricType string, keys ...string) {
+	events := make([]models.MetricEvent, 0, len(keys))
+
+	for _, key := range keys {
+		metric, err := newModel(metricType, key, m.now())
+
+		if err != nil {
+			continue
+		}
+
+		events = append(events, models.MetricEvent{Action: action, Metric: metric})
 	}
+
+	m.broadcaster.Publish(events...)
+}
+
+// isStale проверяет, истекло ли время жизни метрики с указанным ключом.
+func (m *Metrics) isStale(key string, updatedAt time.Time) bool {
+	return m.staleness.IsStale(key, updatedAt, m.now())
 }
 
 // Save сохраняет одиночную метрику на основе предоставленных параметров.
 func (m *Metrics) Save(ctx context.Context, parameters services.MetricSaveParameters) error {
+	if err := services.ValidateMetricName(parameters.MetricName); err != nil {
+		return &services.ValidationError{Err: err}
+	}
+
 	switch parameters.MetricType {
 	case models.GaugeMetricType:
 		v, err := strconv.ParseFloat(parameters.MetricValue, 64)
 
 		if err != nil {
-			return err
+			return &services.ValidationError{Err: err}
 		}
 
 		if err := m.storage.AddGaugeMetric(ctx, parameters.MetricName, v); err != nil {
 			return err
 		}
+
+		m.publishUpdates(models.Metrics{ID: parameters.MetricName, MType: models.GaugeMetricType, Value: &v})
+
+		m.recordSamples(ctx, newSample(models.GaugeMetricType, parameters.MetricName, v))
+
+		return nil
 	case models.CounterMetricType:
 		v, err := strconv.ParseInt(parameters.MetricValue, 10, 64)
 
 		if err != nil {
-			return err
+			return &services.ValidationError{Err: err}
 		}
 
 		if err := m.storage.AddCounterMetric(ctx, parameters.MetricName, v); err != nil {
 			return err
 		}
+
+		m.publishUpdates(models.Metrics{ID: parameters.MetricName, MType: models.CounterMetricType, Delta: &v})
+
+		m.recordSamples(ctx, newSample(models.CounterMetricType, parameters.MetricName, float64(v)))
+
+		return nil
+	case models.HistogramMetricType:
+		return &services.ValidationError{Err: ErrHistogramValueFormat}
 	default:
-		return fmt.Errorf("metrict type %s isn't defined", parameters.MetricType)
+		return &services.ValidationError{Err: fmt.Errorf("metrict type %s isn't defined", parameters.MetricType)}
 	}
-
-	return nil
 }
 
 // SaveModel сохраняет модель метрики.
 func (m *Metrics) SaveModel(ctx context.Context, parameters models.Metrics) error {
+	key, err := seriesKey(parameters)
+
+	if err != nil {
+		return err
+	}
+
 	switch parameters.MType {
 	case models.GaugeMetricType:
-		if err := m.storage.AddGaugeMetric(ctx, parameters.ID, *parameters.Value); err != nil {
+		if err := m.storage.AddGaugeMetric(ctx, key, *parameters.Value); err != nil {
 			return err
 		}
+
+		m.publishUpdates(parameters)
+
+		m.recordSamples(ctx, newSample(models.GaugeMetricType, key, *parameters.Value))
+
+		return nil
 	case models.CounterMetricType:
-		if err := m.storage.AddCounterMetric(ctx, parameters.ID, *parameters.Delta); err != nil {
+		if err := m.storage.AddCounterMetric(ctx, key, *parameters.Delta); err != nil {
 			return err
 		}
+
+		m.publishUpdates(parameters)
+
+		m.recordSamples(ctx, newSample(models.CounterMetricType, key, float64(*parameters.Delta)))
+
+		return nil
+	case models.HistogramMetricType:
+		histogram, err := toHistogramMetric(key, parameters)
+
+		if err != nil {
+			return err
+		}
+
+		if err := m.storage.AddHistogramMetric(ctx, key, histogram); err != nil {
+			return storageError(err)
+		}
+
+		m.publishUpdates(parameters)
+
+		m.recordSamples(ctx, newSample(models.HistogramMetricType, key, float64(histogram.Count)))
+
+		return nil
 	default:
-		return fmt.Errorf("metrict type %s isn't defined", parameters.MType)
+		return &services.ValidationError{Err: fmt.Errorf("metrict type %s isn't defined", parameters.MType)}
 	}
-
-	return nil
 }
 
 // SaveModels сохраняет массив метрик.
 func (m *Metrics) SaveModels(ctx context.Context, parameters []models.Metrics) error {
 	gaugeMetrics := make([]storage.GaugeMetric, 0, len(parameters))
 	counterMetrics := make([]storage.CounterMetric, 0, len(parameters))
+	histogramMetrics := make([]storage.HistogramMetric, 0)
+	samples := make([]storage.MetricSample, 0, len(parameters))
 
 	for _, parameter := range parameters {
+		key, err := seriesKey(parameter)
+
+		if err != nil {
+			return err
+		}
+
 		switch parameter.MType {
 		case models.GaugeMetricType:
-			gaugeMetrics = append(gaugeMetrics, storage.GaugeMetric{Name: parameter.ID, Value: *parameter.Value})
+			gaugeMetrics = append(gaugeMetrics, storage.GaugeMetric{Name: key, Value: *parameter.Value})
+			samples = append(samples, newSample(models.GaugeMetricType, key, *parameter.Value))
 		case models.CounterMetricType:
-			counterMetrics = append(counterMetrics, storage.CounterMetric{Name: parameter.ID, Value: *parameter.Delta})
+			counterMetrics = append(counterMetrics, storage.CounterMetric{Name: key, Value: *parameter.Delta})
+			samples = append(samples, newSample(models.CounterMetricType, key, float64(*parameter.Delta)))
+		case models.HistogramMetricType:
+			histogram, err := toHistogramMetric(key, parameter)
+
+			if err != nil {
+				return err
+			}
+
+			histogramMetrics = append(histogramMetrics, histogram)
+			samples = append(samples, newSample(models.HistogramMetricType, key, float64(histogram.Count)))
 		default:
-			return fmt.Errorf("metrict type %s isn't defined", parameter.MType)
+			return &services.ValidationError{Err: fmt.Errorf("metrict type %s isn't defined", parameter.MType)}
 		}
 	}
 
-	if err := m.storage.AddMetrics(ctx, gaugeMetrics, counterMetrics); err != nil {
-		return err
+	if err := m.storage.AddMetrics(ctx, gaugeMetrics, counterMetrics, histogramMetrics); err != nil {
+		return storageError(err)
 	}
 
+	m.publishUpdates(parameters...)
+
+	m.recordSamples(ctx, samples...)
+
 	return nil
 }
 
+// storageError возвращает ошибку несовпадения границ гистограммы как ошибку проверки параметров:
+// ее вызывают данные клиента, а не сбой хранилища.
+func storageError(err error) error {
+	if errors.Is(err, storage.ErrHistogramBoundsMismatch) {
+		return &services.ValidationError{Err: err}
+	}
+
+	return err
+}
+
+// seriesKey проверяет имя метрики и имена меток и формирует ключ хранения метрики.
+func seriesKey(parameters models.Metrics) (string, error) {
+	if err := services.ValidateMetricName(parameters.ID); err != nil {
+		return "", &services.ValidationError{Err: err}
+	}
+
+	for name := range parameters.Labels {
+		if err := services.ValidateLabelName(name); err != nil {
+			return "", &services.ValidationError{Err: err}
+		}
+	}
+
+	return storage.SeriesKey(parameters.ID, parameters.Labels), nil
+}
+
+// toHistogramMetric проверяет корректность гистограммы и преобразует ее в структуру хранилища.
+func toHistogramMetric(key string, parameters models.Metrics) (storage.HistogramMetric, error) {
+	histogram := parameters.Histogram
+
+	if histogram == nil {
+		return storage.HistogramMetric{}, &services.ValidationError{Err: fmt.Errorf("histogram %s: %w", parameters.ID, ErrInvalidHistogram)}
+	}
+
+	if len(histogram.Counts) != len(histogram.Bounds)+1 {
+		return storage.HistogramMetric{}, &services.ValidationError{Err: fmt.Errorf("histogram %s must have %d counts: %w", parameters.ID, len(histogram.Bounds)+1, ErrInvalidHistogram)}
+	}
+
+	for i := 1; i < len(histogram.Bounds); i++ {
+		if histogram.Bounds[i] <= histogram.Bounds[i-1] {
+			return storage.HistogramMetric{}, &services.ValidationError{Err: fmt.Errorf("histogram %s bounds must be increasing: %w", parameters.ID, ErrInvalidHistogram)}
+		}
+	}
+
+	var count uint64
+
+	for _, c := range histogram.Counts {
+		count += c
+	}
+
+	if count != histogram.Count {
+		return storage.HistogramMetric{}, &services.ValidationError{Err: fmt.Errorf("histogram %s count doesn't match bucket counts: %w", parameters.ID, ErrInvalidHistogram)}
+	}
+
+	return storage.HistogramMetric{
+		Name:   key,
+		Bounds: histogram.Bounds,
+		Counts: histogram.Counts,
+		Sum:    histogram.Sum,
+		Count:  histogram.Count,
+	}, nil
+}
+
+func toHistogramModel(histogram storage.HistogramMetric) *models.Histogram {
+	return &models.Histogram{
+		Bounds: histogram.Bounds,
+		Counts: histogram.Counts,
+		Sum:    histogram.Sum,
+		Count:  histogram.Count,
+	}
+}
+
+func newSample(metricType, name string, value float64) storage.MetricSample {
+	return storage.MetricSample{
+		Name:      name,
+		Type:      metricType,
+		Value:     value,
+		Timestamp: time.Now().UTC(),
+	}
+}
+
+// recordSamples сохраняет значения в историю метрик. К этому моменту метрики уже сохранены,
+// поэтому ошибка записи истории только записывается в лог: если бы запрос завершился ошибкой,
+// повтор запроса клиентом учел бы приращения счетчиков дважды.
+func (m *Metrics) recordSamples(ctx context.Context, samples ...storage.MetricSample) {
+	if len(samples) == 0 {
+		return
+	}
+
+	if err := m.storage.AddMetricSamples(ctx, samples); err != nil {
+		logger.Log.Error("cannot record metric history", zap.Int("samples", len(samples)), zap.Error(err))
+	}
+}
+
 // Get возвращает значение метрики по указанным параметрам.
+// Если заданы условия по меткам, среди метрик с указанным именем должна найтись ровно одна подходящая.
 func (m *Metrics) Get(ctx context.Context, parameters services.MetricGetParameters) (string, error) {
+	key := parameters.MetricName
+
+	if len(parameters.Matchers) > 0 {
+		v, err := m.findSeriesKey(ctx, parameters.MetricType, parameters.MetricName, parameters.Matchers)
+
+		if err != nil {
+			return "", err
+		}
+
+		key = v
+	}
+
 	switch parameters.MetricType {
 	case models.GaugeMetricType:
-		value, err := m.storage.GetGaugeMetric(ctx, parameters.MetricName)
+		value, err := m.storage.GetGaugeMetric(ctx, key)
 
 		if err != nil {
 			if errors.Is(err, storage.ErrDataNotFound) {
@@ -120,9 +398,13 @@ func (m *Metrics) Get(ctx context.Context, parameters services.MetricGetParamete
 			return "", err
 		}
 
+		if m.isStale(key, value.UpdatedAt) {
+			return "", services.ErrMetricNotFound
+		}
+
 		return fmt.Sprintf("%g", value.Value), nil
 	case models.CounterMetricType:
-		value, err := m.storage.GetCounterMetric(ctx, parameters.MetricName)
+		value, err := m.storage.GetCounterMetric(ctx, key)
 
 		if err != nil {
 			if errors.Is(err, storage.ErrDataNotFound) {
@@ -132,7 +414,33 @@ func (m *Metrics) Get(ctx context.Context, parameters services.MetricGetParamete
 			return "", err
 		}
 
+		if m.isStale(key, value.UpdatedAt) {
+			return "", services.ErrMetricNotFound
+		}
+
 		return fmt.Sprintf("%v", value.Value), err
+	case models.HistogramMetricType:
+		value, err := m.storage.GetHistogramMetric(ctx, key)
+
+		if err != nil {
+			if errors.Is(err, storage.ErrDataNotFound) {
+				return "", services.ErrMetricNotFound
+			}
+
+			return "", err
+		}
+
+		if m.isStale(key, value.UpdatedAt) {
+			return "", services.ErrMetricNotFound
+		}
+
+		data, err := json.Marshal(toHistogramModel(value))
+
+		if err != nil {
+			return "", err
+		}
+
+		return string(data), nil
 	default:
 		return "", services.ErrMetricNotFound
 	}
@@ -140,9 +448,23 @@ func (m *Metrics) Get(ctx context.Context, parameters services.MetricGetParamete
 
 // GetModel возвращает полную модель метрики.
 func (m *Metrics) GetModel(ctx context.Context, parameters models.Metrics) (models.Metrics, error) {
+	key, err := seriesKey(parameters)
+
+	if err != nil {
+		return models.Metrics{}, err
+	}
+
+	result := models.Metrics{
+		ID:     parameters.ID,
+		MType:  parameters.MType,
+		Labels: parameters.Labels,
+	}
+
+	var updatedAt time.Time
+
 	switch parameters.MType {
 	case models.GaugeMetricType:
-		value, err := m.storage.GetGaugeMetric(ctx, parameters.ID)
+		value, err := m.storage.GetGaugeMetric(ctx, key)
 
 		if err != nil {
 			if errors.Is(err, storage.ErrDataNotFound) {
@@ -152,13 +474,10 @@ func (m *Metrics) GetModel(ctx context.Context, parameters models.Metrics) (mode
 			return models.Metrics{}, err
 		}
 
-		return models.Metrics{
-			ID:    parameters.ID,
-			MType: parameters.MType,
-			Value: &value.Value,
-		}, nil
+		result.Value = &value.Value
+		updatedAt = value.UpdatedAt
 	case models.CounterMetricType:
-		value, err := m.storage.GetCounterMetric(ctx, parameters.ID)
+		value, err := m.storage.GetCounterMetric(ctx, key)
 
 		if err != nil {
 			if errors.Is(err, storage.ErrDataNotFound) {
@@ -168,18 +487,216 @@ func (m *Metrics) GetModel(ctx context.Context, parameters models.Metrics) (mode
 			return models.Metrics{}, err
 		}
 
-		return models.Metrics{
-			ID:    parameters.ID,
-			MType: parameters.MType,
-			Delta: &value.Value,
-		}, nil
+		result.Delta = &value.Value
+		updatedAt = value.UpdatedAt
+	case models.HistogramMetricType:
+		value, err := m.storage.GetHistogramMetric(ctx, key)
+
+		if err != nil {
+			if errors.Is(err, storage.ErrDataNotFound) {
+				return models.Metrics{}, services.ErrMetricNotFound
+			}
+
+			return models.Metrics{}, err
+		}
+
+		result.Histogram = toHistogramModel(value)
+		updatedAt = value.UpdatedAt
 	default:
 		return models.Metrics{}, services.ErrMetricNotFound
 	}
+
+	if m.isStale(key, updatedAt) {
+		return models.Metrics{}, services.ErrMetricNotFound
+	}
+
+	result.UpdatedAt = toUpdatedAt(updatedAt)
+
+	return result, nil
+}
+
+// GetAll извлекает все метрики из хранилища, отбирает подходящие под условия по меткам
+// и формирует список для отображения.
+func (m *Metrics) GetAll(ctx context.Context, matchers []services.LabelMatcher) ([]services.MetricEntry, error) {
+	data, err := m.GetAllModels(ctx, matchers)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]services.MetricEntry, 0, len(data))
+
+	for _, item := range data {
+		entry := services.MetricEntry{Name: storage.SeriesKey(item.ID, item.Labels), Value: formatValue(item)}
+
+		if item.UpdatedAt != nil {
+			entry.UpdatedAt = *item.UpdatedAt
+		}
+
+		result = append(result, entry)
+	}
+
+	return result, nil
 }
 
-// GetAll извлекает все метрики из хранилища и формирует список для отображения.
-func (m *Metrics) GetAll(ctx context.Context) ([]services.MetricEntry, error) {
+// GetAllModels извлекает все актуальные метрики в виде моделей, метки которых удовлетворяют всем условиям matchers.
+func (m *Metrics) GetAllModels(ctx context.Context, matchers []services.LabelMatcher) ([]models.Metrics, error) {
+	data, err := m.listModels(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.Metrics, 0, len(data))
+
+	for _, item := range data {
+		if services.MatchLabels(item.Labels, matchers) {
+			result = append(result, item)
+		}
+	}
+
+	return result, nil
+}
+
+// Размер страницы списка метрик.
+const (
+	DefaultListLimit = 100  // Используется, если размер страницы не задан
+	MaxListLimit     = 1000 // Ограничивает размер страницы сверху
+)
+
+// listCursor описывает последнюю метрику страницы, после которой начинается следующая.
+type listCursor struct {
+	Name string `json:"n"`
+	Type string `json:"t"`
+}
+
+func encodeCursor(record storage.MetricRecord) string {
+	data, _ := json.Marshal(listCursor{Name: record.Name, Type: record.Type})
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (listCursor, error) {
+	var cursor listCursor
+
+	if value == "" {
+		return cursor, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+
+	if err != nil {
+		return listCursor{}, services.ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Name == "" {
+		return listCursor{}, services.ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+// List возвращает страницу метрик, отобранных по типу, префиксу и регулярному выражению для имени
+// и условиям по меткам. Хранилище читается страницами, пока не наберется нужное количество метрик,
+// поэтому фильтры, не поддерживаемые хранилищем, не приводят к неполным страницам.
+func (m *Metrics) List(ctx context.Context, parameters services.MetricListParameters) (models.MetricList, error) {
+	cursor, err := decodeCursor(parameters.Cursor)
+
+	if err != nil {
+		return models.MetricList{}, err
+	}
+
+	switch {
+	case parameters.Limit <= 0:
+		parameters.Limit = DefaultListLimit
+	case parameters.Limit > MaxListLimit:
+		parameters.Limit = MaxListLimit
+	}
+
+	storageParameters := storage.ListParameters{
+		Types:     parameters.Types,
+		Prefix:    parameters.Prefix,
+		AfterName: cursor.Name,
+		AfterType: cursor.Type,
+		Limit:     parameters.Limit + 1,
+	}
+
+	result := models.MetricList{Metrics: make([]models.Metrics, 0, parameters.Limit)}
+
+	var lastRecord storage.MetricRecord
+
+	for {
+		records, err := m.storage.ListMetrics(ctx, storageParameters)
+
+		if err != nil {
+			return models.MetricList{}, err
+		}
+
+		for _, record := range records {
+			storageParameters.AfterName, storageParameters.AfterType = record.Name, record.Type
+
+			if m.isStale(record.Name, record.UpdatedAt) {
+				continue
+			}
+
+			model, err := recordModel(record)
+
+			if err != nil {
+				logger.Log.Warn("metric with invalid series key was skipped", zap.String("key", record.Name), zap.Error(err))
+				continue
+			}
+
+			if parameters.Pattern != nil && !parameters.Pattern.MatchString(model.ID) {
+				continue
+			}
+
+			if !services.MatchLabels(model.Labels, parameters.Matchers) {
+				continue
+			}
+
+			if len(result.Metrics) == parameters.Limit {
+				result.NextCursor = encodeCursor(lastRecord)
+
+				return result, nil
+			}
+
+			result.Metrics = append(result.Metrics, model)
+			lastRecord = record
+		}
+
+		if len(records) < storageParameters.Limit {
+			return result, nil
+		}
+	}
+}
+
+// recordModel создает модель метрики из записи, полученной при постраничном чтении хранилища.
+func recordModel(record storage.MetricRecord) (models.Metrics, error) {
+	model, err := newModel(record.Type, record.Name, record.UpdatedAt)
+
+	if err != nil {
+		return models.Metrics{}, err
+	}
+
+	switch record.Type {
+	case models.GaugeMetricType:
+		value := record.Value
+		model.Value = &value
+	case models.CounterMetricType:
+		delta := record.Delta
+		model.Delta = &delta
+	case models.HistogramMetricType:
+		if record.Histogram != nil {
+			model.Histogram = toHistogramModel(*record.Histogram)
+		}
+	}
+
+	return model, nil
+}
+
+// listModels извлекает все метрики из хранилища в виде моделей с разобранными метками.
+// Устаревшие метрики в результат не попадают.
+func (m *Metrics) listModels(ctx context.Context) ([]models.Metrics, error) {
 	gaugeMetrics, err := m.storage.GetGaugeMetrics(ctx)
 
 	if err != nil {
@@ -192,14 +709,321 @@ func (m *Metrics) GetAll(ctx context.Context) ([]services.MetricEntry, error) {
 		return nil, err
 	}
 
-	result := make([]services.MetricEntry, 0, len(gaugeMetrics)+len(counterMetrics))
+	histogramMetrics, err := m.storage.GetHistogramMetrics(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.Metrics, 0, len(gaugeMetrics)+len(counterMetrics)+len(histogramMetrics))
 
 	for _, item := range gaugeMetrics {
-		result = append(result, services.MetricEntry{Name: item.Name, Value: fmt.Sprintf("%g", item.Value)})
+		if m.isStale(item.Name, item.UpdatedAt) {
+			continue
+		}
+
+		model, err := newModel(models.GaugeMetricType, item.Name, item.UpdatedAt)
+
+		if err != nil {
+			logger.Log.Warn("metric with invalid series key was skipped", zap.String("key", item.Name), zap.Error(err))
+			continue
+		}
+
+		value := item.Value
+		model.Value = &value
+		result = append(result, model)
 	}
 
 	for _, item := range counterMetrics {
-		result = append(result, services.MetricEntry{Name: item.Name, Value: fmt.Sprintf("%v", item.Value)})
+		if m.isStale(item.Name, item.UpdatedAt) {
+			continue
+		}
+
+		model, err := newModel(models.CounterMetricType, item.Name, item.UpdatedAt)
+
+		if err != nil {
+			logger.Log.Warn("metric with invalid series key was skipped", zap.String("key", item.Name), zap.Error(err))
+			continue
+		}
+
+		delta := item.Value
+		model.Delta = &delta
+		result = append(result, model)
+	}
+
+	for _, item := range histogramMetrics {
+		if m.isStale(item.Name, item.UpdatedAt) {
+			continue
+		}
+
+		model, err := newModel(models.HistogramMetricType, item.Name, item.UpdatedAt)
+
+		if err != nil {
+			logger.Log.Warn("metric with invalid series key was skipped", zap.String("key", item.Name), zap.Error(err))
+			continue
+		}
+
+		model.Histogram = toHistogramModel(item)
+		result = append(result, model)
+	}
+
+	return result, nil
+}
+
+// findSeriesKeys ищет ключи хранения метрик с указанным типом и именем, удовлетворяющих условиям по меткам.
+func (m *Metrics) findSeriesKeys(ctx context.Context, metricType, name string, matchers []services.LabelMatcher) ([]string, error) {
+	data, err := m.listModels(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+
+	for _, item := range data {
+		if item.MType == metricType && item.ID == name && services.MatchLabels(item.Labels, matchers) {
+			keys = append(keys, storage.SeriesKey(item.ID, item.Labels))
+		}
+	}
+
+	return keys, nil
+}
+
+// findSeriesKey ищет ключ хранения единственной метрики с указанным типом и именем,
+// удовлетворяющей условиям по меткам.
+func (m *Metrics) findSeriesKey(ctx context.Context, metricType, name string, matchers []services.LabelMatcher) (string, error) {
+	keys, err := m.findSeriesKeys(ctx, metricType, name, matchers)
+
+	if err != nil {
+		return "", err
+	}
+
+	switch len(keys) {
+	case 0:
+		return "", services.ErrMetricNotFound
+	case 1:
+		return keys[0], nil
+	default:
+		return "", fmt.Errorf("%d metrics match %s: %w", len(keys), name, services.ErrMetricAmbiguous)
+	}
+}
+
+// newModel создает модель метрики из ключа хранения.
+func newModel(metricType, key string, updatedAt time.Time) (models.Metrics, error) {
+	name, labels, err := storage.ParseSeriesKey(key)
+
+	if err != nil {
+		return models.Metrics{}, err
+	}
+
+	return models.Metrics{ID: name, MType: metricType, Labels: labels, UpdatedAt: toUpdatedAt(updatedAt)}, nil
+}
+
+// toUpdatedAt возвращает время обновления для модели, нулевое время в модель не попадает.
+func toUpdatedAt(updatedAt time.Time) *time.Time {
+	if updatedAt.IsZero() {
+		return nil
+	}
+
+	return &updatedAt
+}
+
+// formatValue возвращает текстовое представление значения метрики.
+func formatValue(metric models.Metrics) string {
+	switch {
+	case metric.Value != nil:
+		return fmt.Sprintf("%g", *metric.Value)
+	case metric.Delta != nil:
+		return fmt.Sprintf("%v", *metric.Delta)
+	case metric.Histogram != nil:
+		return fmt.Sprintf("count=%d sum=%g", metric.Histogram.Count, metric.Histogram.Sum)
+	default:
+		return ""
+	}
+}
+
+// Delete удаляет метрику и ее историю. Если заданы условия по меткам,
+// удаляются все метрики с указанным именем, которые им удовлетворяют.
+func (m *Metrics) Delete(ctx context.Context, parameters services.MetricGetParameters) error {
+	keys := []string{parameters.MetricName}
+
+	if len(parameters.Matchers) > 0 {
+		v, err := m.findSeriesKeys(ctx, parameters.MetricType, parameters.MetricName, parameters.Matchers)
+
+		if err != nil {
+			return err
+		}
+
+		if len(v) == 0 {
+			return services.ErrMetricNotFound
+		}
+
+		keys = v
+	}
+
+	var deleteMetric func(ctx context.Context, key string) error
+
+	switch parameters.MetricType {
+	case models.GaugeMetricType:
+		deleteMetric = m.storage.DeleteGaugeMetric
+	case models.CounterMetricType:
+		deleteMetric = m.storage.DeleteCounterMetric
+	case models.HistogramMetricType:
+		deleteMetric = m.storage.DeleteHistogramMetric
+	default:
+		return services.ErrMetricNotFound
+	}
+
+	for _, key := range keys {
+		if err := deleteMetric(ctx, key); err != nil {
+			if errors.Is(err, storage.ErrDataNotFound) {
+				return services.ErrMetricNotFound
+			}
+
+			return err
+		}
+
+		m.publishKeys(models.MetricDeleteAction, parameters.MetricType, key)
+	}
+
+	return nil
+}
+
+// DeleteStaleMetric удаляет метрику, если она не обновлялась после updatedAt, и оповещает подписчиков об удалении.
+// Если метрика обновлена позже или уже удалена, возвращается storage.ErrDataNotFound.
+func (m *Metrics) DeleteStaleMetric(ctx context.Context, metricType, key string, updatedAt time.Time) error {
+	if err := m.storage.DeleteStaleMetric(ctx, metricType, key, updatedAt); err != nil {
+		return err
+	}
+
+	m.publishKeys(models.MetricDeleteAction, metricType, key)
+
+	return nil
+}
+
+// DeleteByPrefix удаляет метрики всех типов, имя которых начинается с префикса, и возвращает их количество.
+func (m *Metrics) DeleteByPrefix(ctx context.Context, prefix string) (int, error) {
+	if prefix == "" {
+		return 0, &services.ValidationError{Err: ErrEmptyPrefix}
+	}
+
+	// Ключи удаляемых метрик нужны только для событий, поэтому запрашиваются лишь при наличии подписчиков.
+	var records []storage.MetricRecord
+
+	if m.broadcaster.HasSubscribers() {
+		v, err := m.storage.ListMetrics(ctx, storage.ListParameters{Prefix: prefix})
+
+		if err != nil {
+			return 0, err
+		}
+
+		records = v
+	}
+
+	count, err := m.storage.DeleteMetricsByPrefix(ctx, prefix)
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, record := range records {
+		m.publishKeys(models.MetricDeleteAction, record.Type, record.Name)
+	}
+
+	return count, nil
+}
+
+// ResetCounter обнуляет значение метрики типа counter.
+func (m *Metrics) ResetCounter(ctx context.Context, parameters services.MetricGetParameters) error {
+	if parameters.MetricType != models.CounterMetricType {
+		return &services.ValidationError{Err: fmt.Errorf("metric type %s: %w", parameters.MetricType, ErrResetNotSupported)}
+	}
+
+	key := parameters.MetricName
+
+	if len(parameters.Matchers) > 0 {
+		v, err := m.findSeriesKey(ctx, parameters.MetricType, parameters.MetricName, parameters.Matchers)
+
+		if err != nil {
+			return err
+		}
+
+		key = v
+	}
+
+	if err := m.storage.ResetCounterMetric(ctx, key); err != nil {
+		if errors.Is(err, storage.ErrDataNotFound) {
+			return services.ErrMetricNotFound
+		}
+
+		return err
+	}
+
+	m.publishKeys(models.MetricResetAction, models.CounterMetricType, key)
+
+	return nil
+}
+
+// GetHistory возвращает историю значений метрики за период [From, To].
+// Если задан шаг, значения агрегируются по интервалам: для gauge берется последнее значение
+// интервала, для counter и histogram — сумма приращений.
+func (m *Metrics) GetHistory(ctx context.Context, parameters services.MetricHistoryParameters) (models.MetricHistory, error) {
+	switch parameters.MetricType {
+	case models.GaugeMetricType, models.CounterMetricType, models.HistogramMetricType:
+	default:
+		return models.MetricHistory{}, services.ErrMetricNotFound
+	}
+
+	if parameters.To.Before(parameters.From) {
+		return models.MetricHistory{}, &services.ValidationError{Err: fmt.Errorf("period start %s is after period end %s", parameters.From, parameters.To)}
+	}
+
+	if parameters.Step < 0 {
+		return models.MetricHistory{}, &services.ValidationError{Err: fmt.Errorf("step %s must be positive", parameters.Step)}
+	}
+
+	samples, err := m.storage.GetMetricSamples(ctx, parameters.MetricType, parameters.MetricName, parameters.From, parameters.To)
+
+	if err != nil {
+		return models.MetricHistory{}, err
+	}
+
+	result := models.MetricHistory{
+		ID:     parameters.MetricName,
+		MType:  parameters.MetricType,
+		From:   parameters.From,
+		To:     parameters.To,
+		Points: make([]models.MetricPoint, 0, len(samples)),
+	}
+
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].Timestamp.Before(samples[j].Timestamp)
+	})
+
+	if parameters.Step == 0 {
+		for _, sample := range samples {
+			result.Points = append(result.Points, models.MetricPoint{Timestamp: sample.Timestamp, Value: sample.Value})
+		}
+
+		return result, nil
+	}
+
+	result.Step = parameters.Step.String()
+
+	for _, sample := range samples {
+		bucket := parameters.From.Add(sample.Timestamp.Sub(parameters.From).Truncate(parameters.Step))
+		last := len(result.Points) - 1
+
+		if last < 0 || !result.Points[last].Timestamp.Equal(bucket) {
+			result.Points = append(result.Points, models.MetricPoint{Timestamp: bucket, Value: sample.Value})
+			continue
+		}
+
+		if parameters.MetricType == models.GaugeMetricType {
+			result.Points[last].Value = sample.Value
+		} else {
+			result.Points[last].Value += sample.Value
+		}
 	}
 
 	return result, nil
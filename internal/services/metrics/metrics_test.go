@@ -2,10 +2,15 @@ package metrics
 
 import (
 	"context"
+	"errors"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/daremove/go-metrics-service/internal/models"
 	"github.com/daremove/go-metrics-service/internal/services"
+	"github.com/daremove/go-metrics-service/internal/services/staleness"
+	"github.com/daremove/go-metrics-service/internal/storage"
 	"github.com/daremove/go-metrics-service/internal/storage/memstorage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -92,6 +97,30 @@ func TestMetrics_Save(t *testing.T) {
 	}
 }
 
+// historyFailingStorage хранилище, в котором не удается сохранить историю значений.
+type historyFailingStorage struct {
+	*memstorage.MemStorage
+}
+
+func (s historyFailingStorage) AddMetricSamples(context.Context, []storage.MetricSample) error {
+	return &storage.UnavailableError{Err: errors.New("connection refused")}
+}
+
+func TestMetrics_SaveHistoryFailure(t *testing.T) {
+	store := historyFailingStorage{MemStorage: memstorage.New()}
+	metricsService := New(store)
+	delta := int64(2)
+
+	require.NoError(t, metricsService.Save(context.TODO(), services.MetricSaveParameters{MetricType: models.CounterMetricType, MetricName: "requests", MetricValue: "1"}))
+	require.NoError(t, metricsService.SaveModel(context.TODO(), models.Metrics{ID: "requests", MType: models.CounterMetricType, Delta: &delta}))
+	require.NoError(t, metricsService.SaveModels(context.TODO(), []models.Metrics{{ID: "requests", MType: models.CounterMetricType, Delta: &delta}}))
+
+	value, err := store.GetCounterMetric(context.TODO(), "requests")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), value.Value)
+}
+
 func TestMetrics_SaveModel(t *testing.T) {
 	var deltaMock int64 = 100
 	var valueMock = 1.1
@@ -154,6 +183,77 @@ func TestMetrics_SaveModel(t *testing.T) {
 	}
 }
 
+func TestMetrics_SaveHistogram(t *testing.T) {
+	storeMock := memstorage.New()
+	metricsService := New(storeMock)
+
+	t.Run("Should save and merge histogram metric type", func(t *testing.T) {
+		histogram := models.Metrics{
+			ID:        "latency",
+			MType:     models.HistogramMetricType,
+			Histogram: &models.Histogram{Bounds: []float64{0.1, 1}, Counts: []uint64{1, 2, 0}, Sum: 1.05, Count: 3},
+		}
+
+		require.NoError(t, metricsService.SaveModel(context.TODO(), histogram))
+		require.NoError(t, metricsService.SaveModels(context.TODO(), []models.Metrics{histogram}))
+
+		value, err := metricsService.GetModel(context.TODO(), models.Metrics{ID: "latency", MType: models.HistogramMetricType})
+
+		require.NoError(t, err)
+		assert.Equal(t, &models.Histogram{Bounds: []float64{0.1, 1}, Counts: []uint64{2, 4, 0}, Sum: 2.1, Count: 6}, value.Histogram)
+
+		text, err := metricsService.Get(context.TODO(), services.MetricGetParameters{MetricType: models.HistogramMetricType, MetricName: "latency"})
+
+		require.NoError(t, err)
+		assert.Equal(t, `{"bounds":[0.1,1],"counts":[2,4,0],"sum":2.1,"count":6}`, text)
+	})
+
+	t.Run("Should return error if histogram is invalid", func(t *testing.T) {
+		testCases := []*models.Histogram{
+			nil,
+			{Bounds: []float64{1}, Counts: []uint64{1}, Count: 1},
+			{Bounds: []float64{1, 1}, Counts: []uint64{1, 0, 0}, Count: 1},
+			{Bounds: []float64{1}, Counts: []uint64{1, 0}, Count: 2},
+		}
+
+		for _, histogram := range testCases {
+			err := metricsService.SaveModel(context.TODO(), models.Metrics{ID: "invalid", MType: models.HistogramMetricType, Histogram: histogram})
+
+			assert.ErrorIs(t, err, ErrInvalidHistogram)
+			assert.ErrorIs(t, err, services.ErrInvalidParameters)
+		}
+	})
+
+	t.Run("Should return validation error if histogram bounds differ from saved", func(t *testing.T) {
+		histogram := models.Metrics{
+			ID:        "latency",
+			MType:     models.HistogramMetricType,
+			Histogram: &models.Histogram{Bounds: []float64{5}, Counts: []uint64{1, 0}, Sum: 1, Count: 1},
+		}
+
+		err := metricsService.SaveModel(context.TODO(), histogram)
+
+		assert.ErrorIs(t, err, storage.ErrHistogramBoundsMismatch)
+		assert.ErrorIs(t, err, services.ErrInvalidParameters)
+
+		err = metricsService.SaveModels(context.TODO(), []models.Metrics{histogram})
+
+		assert.ErrorIs(t, err, storage.ErrHistogramBoundsMismatch)
+		assert.ErrorIs(t, err, services.ErrInvalidParameters)
+	})
+
+	t.Run("Should return error if histogram is saved from string value", func(t *testing.T) {
+		err := metricsService.Save(context.TODO(), services.MetricSaveParameters{
+			MetricType:  models.HistogramMetricType,
+			MetricName:  "latency",
+			MetricValue: "1",
+		})
+
+		assert.ErrorIs(t, err, ErrHistogramValueFormat)
+		assert.ErrorIs(t, err, services.ErrInvalidParameters)
+	})
+}
+
 func TestMetrics_SaveModels(t *testing.T) {
 	var deltaMock int64 = 100
 	var valueMock = 1.1
@@ -217,12 +317,397 @@ func TestMetrics_GetAll(t *testing.T) {
 	metricsService := New(memstorage.NewWithPrefilledData(map[string]float64{"first": 1.11234}, map[string]int64{"second": 1}))
 
 	t.Run("Should return all metrics", func(t *testing.T) {
-		result, err := metricsService.GetAll(context.TODO())
+		result, err := metricsService.GetAll(context.TODO(), nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"first": "1.11234", "second": "1"}, entryValues(result))
+	})
+}
+
+func TestMetrics_List(t *testing.T) {
+	metricsService := New(memstorage.NewWithPrefilledData(
+		map[string]float64{"cpu_load": 1.5, "cpu_temp": 60, "mem_free": 100, `disk_free{host="a"}`: 10, `disk_free{host="b"}`: 20},
+		map[string]int64{"cpu_load": 3, "requests": 7},
+	))
+
+	listIDs := func(list models.MetricList) []string {
+		var result []string
+
+		for _, item := range list.Metrics {
+			result = append(result, item.MType+":"+storage.SeriesKey(item.ID, item.Labels))
+		}
+
+		return result
+	}
+
+	t.Run("Should walk through all pages", func(t *testing.T) {
+		var (
+			result []string
+			cursor string
+			pages  int
+		)
+
+		for {
+			list, err := metricsService.List(context.TODO(), services.MetricListParameters{Limit: 3, Cursor: cursor})
+			require.NoError(t, err)
+
+			result = append(result, listIDs(list)...)
+			pages++
+
+			if list.NextCursor == "" {
+				break
+			}
+
+			cursor = list.NextCursor
+		}
+
+		assert.Equal(t, 3, pages)
+		assert.Equal(t, []string{
+			"counter:cpu_load", "gauge:cpu_load", "gauge:cpu_temp",
+			`gauge:disk_free{host="a"}`, `gauge:disk_free{host="b"}`, "gauge:mem_free",
+			"counter:requests",
+		}, result)
+	})
+
+	t.Run("Should filter by type, prefix, pattern and labels", func(t *testing.T) {
+		list, err := metricsService.List(context.TODO(), services.MetricListParameters{
+			Types:  []string{models.GaugeMetricType},
+			Prefix: "cpu_",
+			Limit:  10,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"gauge:cpu_load", "gauge:cpu_temp"}, listIDs(list))
+		assert.Empty(t, list.NextCursor)
+
+		list, err = metricsService.List(context.TODO(), services.MetricListParameters{
+			Pattern: regexp.MustCompile(`_free$`),
+			Limit:   1,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{`gauge:disk_free{host="a"}`}, listIDs(list))
+
+		list, err = metricsService.List(context.TODO(), services.MetricListParameters{
+			Pattern: regexp.MustCompile(`_free$`),
+			Cursor:  list.NextCursor,
+			Limit:   1,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{`gauge:disk_free{host="b"}`}, listIDs(list))
+
+		matcher, err := services.ParseLabelMatcher(`host="b"`)
+		require.NoError(t, err)
+
+		list, err = metricsService.List(context.TODO(), services.MetricListParameters{Matchers: []services.LabelMatcher{matcher}, Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []string{`gauge:disk_free{host="b"}`}, listIDs(list))
+		assert.Empty(t, list.NextCursor)
+	})
+
+	t.Run("Should return error for invalid cursor", func(t *testing.T) {
+		_, err := metricsService.List(context.TODO(), services.MetricListParameters{Cursor: "not a cursor"})
+
+		assert.ErrorIs(t, err, services.ErrInvalidCursor)
+	})
+}
+
+// entryValues возвращает значения записей метрик по именам без учета времени обновления.
+func entryValues(entries []services.MetricEntry) map[string]string {
+	result := make(map[string]string, len(entries))
+
+	for _, entry := range entries {
+		result[entry.Name] = entry.Value
+	}
+
+	return result
+}
+
+func TestMetrics_Labels(t *testing.T) {
+	var (
+		valueA = 1.5
+		valueB = 2.5
+	)
+
+	metricsService := New(memstorage.New())
+
+	err := metricsService.SaveModels(context.TODO(), []models.Metrics{
+		{ID: "cpu", MType: models.GaugeMetricType, Value: &valueA, Labels: map[string]string{"host": "a", "env": "production"}},
+		{ID: "cpu", MType: models.GaugeMetricType, Value: &valueB, Labels: map[string]string{"host": "b", "env": "dev"}},
+	})
+	require.NoError(t, err)
+
+	t.Run("Should return model by exact labels", func(t *testing.T) {
+		value, err := metricsService.GetModel(context.TODO(), models.Metrics{ID: "cpu", MType: models.GaugeMetricType, Labels: map[string]string{"env": "dev", "host": "b"}})
+
+		require.NoError(t, err)
+		assert.Equal(t, valueB, *value.Value)
+		assert.Equal(t, map[string]string{"env": "dev", "host": "b"}, value.Labels)
+	})
+
+	t.Run("Should return not found if labels are different", func(t *testing.T) {
+		_, err := metricsService.GetModel(context.TODO(), models.Metrics{ID: "cpu", MType: models.GaugeMetricType})
+
+		assert.Equal(t, services.ErrMetricNotFound, err)
+	})
+
+	t.Run("Should filter all metrics by label matchers", func(t *testing.T) {
+		matcher, err := services.ParseLabelMatcher(`env=~"prod.*"`)
+		require.NoError(t, err)
+
+		result, err := metricsService.GetAll(context.TODO(), []services.LabelMatcher{matcher})
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{`cpu{env="production",host="a"}`: "1.5"}, entryValues(result))
+	})
+
+	t.Run("Should return value by label matchers", func(t *testing.T) {
+		matcher, err := services.ParseLabelMatcher(`host="b"`)
+		require.NoError(t, err)
+
+		value, err := metricsService.Get(context.TODO(), services.MetricGetParameters{
+			MetricType: models.GaugeMetricType,
+			MetricName: "cpu",
+			Matchers:   []services.LabelMatcher{matcher},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "2.5", value)
+	})
+
+	t.Run("Should return error if several metrics match", func(t *testing.T) {
+		matcher, err := services.ParseLabelMatcher(`host=~".*"`)
+		require.NoError(t, err)
+
+		_, err = metricsService.Get(context.TODO(), services.MetricGetParameters{
+			MetricType: models.GaugeMetricType,
+			MetricName: "cpu",
+			Matchers:   []services.LabelMatcher{matcher},
+		})
+
+		assert.ErrorIs(t, err, services.ErrMetricAmbiguous)
+	})
+
+	t.Run("Should return error if label name is invalid", func(t *testing.T) {
+		err := metricsService.SaveModel(context.TODO(), models.Metrics{ID: "cpu", MType: models.GaugeMetricType, Value: &valueA, Labels: map[string]string{"host-name": "a"}})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("Should return error if metric name looks like series key", func(t *testing.T) {
+		assert.ErrorIs(t, metricsService.SaveModel(context.TODO(), models.Metrics{ID: `cpu{host="a"}`, MType: models.GaugeMetricType, Value: &valueA}), services.ErrInvalidParameters)
+		assert.Error(t, metricsService.SaveModels(context.TODO(), []models.Metrics{{ID: "a{b}", MType: models.GaugeMetricType, Value: &valueA}}))
+		assert.Error(t, metricsService.Save(context.TODO(), services.MetricSaveParameters{MetricType: models.GaugeMetricType, MetricName: "a=b", MetricValue: "1"}))
+	})
+}
+
+func TestMetrics_InvalidSeriesKey(t *testing.T) {
+	value := 1.5
+	store := memstorage.New()
+
+	require.NoError(t, store.AddGaugeMetric(context.TODO(), "a{b}", 1))
+
+	metricsService := New(store)
+	require.NoError(t, metricsService.SaveModel(context.TODO(), models.Metrics{ID: "cpu", MType: models.GaugeMetricType, Value: &value}))
+
+	t.Run("Should skip invalid keys when listing all metrics", func(t *testing.T) {
+		result, err := metricsService.GetAll(context.TODO(), nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"cpu": "1.5"}, entryValues(result))
+	})
+
+	t.Run("Should skip invalid keys when listing metrics page", func(t *testing.T) {
+		result, err := metricsService.List(context.TODO(), services.MetricListParameters{})
 
 		require.NoError(t, err)
-		assert.Equal(t, 2, len(result))
-		assert.Contains(t, result, services.MetricEntry{Name: "first", Value: "1.11234"})
-		assert.Contains(t, result, services.MetricEntry{Name: "second", Value: "1"})
+		require.Len(t, result.Metrics, 1)
+		assert.Equal(t, "cpu", result.Metrics[0].ID)
+	})
+}
+
+func TestMetrics_Delete(t *testing.T) {
+	var (
+		valueA = 1.5
+		valueB = 2.5
+	)
+
+	metricsService := New(memstorage.NewWithPrefilledData(map[string]float64{"gauge": 1.1, "job_gauge": 2}, map[string]int64{"counter": 5, "job_counter": 1}))
+
+	err := metricsService.SaveModels(context.TODO(), []models.Metrics{
+		{ID: "cpu", MType: models.GaugeMetricType, Value: &valueA, Labels: map[string]string{"host": "a"}},
+		{ID: "cpu", MType: models.GaugeMetricType, Value: &valueB, Labels: map[string]string{"host": "b"}},
+	})
+	require.NoError(t, err)
+
+	t.Run("Should delete metric", func(t *testing.T) {
+		err := metricsService.Delete(context.TODO(), services.MetricGetParameters{MetricType: models.GaugeMetricType, MetricName: "gauge"})
+		require.NoError(t, err)
+
+		_, err = metricsService.Get(context.TODO(), services.MetricGetParameters{MetricType: models.GaugeMetricType, MetricName: "gauge"})
+		assert.Equal(t, services.ErrMetricNotFound, err)
+	})
+
+	t.Run("Should return not found if deleted metric doesn't exist", func(t *testing.T) {
+		err := metricsService.Delete(context.TODO(), services.MetricGetParameters{MetricType: models.CounterMetricType, MetricName: "unknown"})
+
+		assert.Equal(t, services.ErrMetricNotFound, err)
+	})
+
+	t.Run("Should delete all metrics matched by labels", func(t *testing.T) {
+		matcher, err := services.ParseLabelMatcher(`host=~"a|b"`)
+		require.NoError(t, err)
+
+		err = metricsService.Delete(context.TODO(), services.MetricGetParameters{
+			MetricType: models.GaugeMetricType,
+			MetricName: "cpu",
+			Matchers:   []services.LabelMatcher{matcher},
+		})
+		require.NoError(t, err)
+
+		result, err := metricsService.GetAll(context.TODO(), []services.LabelMatcher{matcher})
+		require.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("Should delete metrics by prefix", func(t *testing.T) {
+		deleted, err := metricsService.DeleteByPrefix(context.TODO(), "job_")
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, deleted)
+	})
+
+	t.Run("Should return error if prefix is empty", func(t *testing.T) {
+		_, err := metricsService.DeleteByPrefix(context.TODO(), "")
+
+		assert.ErrorIs(t, err, ErrEmptyPrefix)
+	})
+
+	t.Run("Should reset counter", func(t *testing.T) {
+		err := metricsService.ResetCounter(context.TODO(), services.MetricGetParameters{MetricType: models.CounterMetricType, MetricName: "counter"})
+		require.NoError(t, err)
+
+		value, err := metricsService.Get(context.TODO(), services.MetricGetParameters{MetricType: models.CounterMetricType, MetricName: "counter"})
+		require.NoError(t, err)
+		assert.Equal(t, "0", value)
+	})
+
+	t.Run("Should return error if reset metric isn't counter", func(t *testing.T) {
+		err := metricsService.ResetCounter(context.TODO(), services.MetricGetParameters{MetricType: models.GaugeMetricType, MetricName: "cpu"})
+
+		assert.ErrorIs(t, err, ErrResetNotSupported)
+	})
+
+	t.Run("Should return not found if reset counter doesn't exist", func(t *testing.T) {
+		err := metricsService.ResetCounter(context.TODO(), services.MetricGetParameters{MetricType: models.CounterMetricType, MetricName: "unknown"})
+
+		assert.Equal(t, services.ErrMetricNotFound, err)
+	})
+}
+
+func TestMetrics_Subscribe(t *testing.T) {
+	var (
+		value = 1.5
+		delta = int64(3)
+		now   = time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	)
+
+	metricsService := New(memstorage.NewWithPrefilledData(map[string]float64{"job_gauge": 2}, map[string]int64{"counter": 5}))
+	metricsService.now = func() time.Time { return now }
+
+	events, unsubscribe := metricsService.Subscribe(services.MetricStreamParameters{})
+	defer unsubscribe()
+
+	counters, unsubscribeCounters := metricsService.Subscribe(services.MetricStreamParameters{Types: []string{models.CounterMetricType}})
+	defer unsubscribeCounters()
+
+	require.NoError(t, metricsService.Save(context.TODO(), services.MetricSaveParameters{MetricType: models.CounterMetricType, MetricName: "counter", MetricValue: "2"}))
+	require.NoError(t, metricsService.SaveModels(context.TODO(), []models.Metrics{
+		{ID: "cpu", MType: models.GaugeMetricType, Value: &value, Labels: map[string]string{"host": "a"}},
+		{ID: "counter", MType: models.CounterMetricType, Delta: &delta},
+	}))
+	require.NoError(t, metricsService.ResetCounter(context.TODO(), services.MetricGetParameters{MetricType: models.CounterMetricType, MetricName: "counter"}))
+
+	matcher, err := services.ParseLabelMatcher(`host="a"`)
+	require.NoError(t, err)
+	require.NoError(t, metricsService.Delete(context.TODO(), services.MetricGetParameters{MetricType: models.GaugeMetricType, MetricName: "cpu", Matchers: []services.LabelMatcher{matcher}}))
+
+	deleted, err := metricsService.DeleteByPrefix(context.TODO(), "job_")
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	assert.Error(t, metricsService.Save(context.TODO(), services.MetricSaveParameters{MetricType: models.GaugeMetricType, MetricName: "cpu", MetricValue: "string"}))
+
+	savedDelta := int64(2)
+	expected := []models.MetricEvent{
+		{Action: models.MetricUpdateAction, Metric: models.Metrics{ID: "counter", MType: models.CounterMetricType, Delta: &savedDelta, UpdatedAt: &now}},
+		{Action: models.MetricUpdateAction, Metric: models.Metrics{ID: "cpu", MType: models.GaugeMetricType, Value: &value, Labels: map[string]string{"host": "a"}, UpdatedAt: &now}},
+		{Action: models.MetricUpdateAction, Metric: models.Metrics{ID: "counter", MType: models.CounterMetricType, Delta: &delta, UpdatedAt: &now}},
+		{Action: models.MetricResetAction, Metric: models.Metrics{ID: "counter", MType: models.CounterMetricType, UpdatedAt: &now}},
+		{Action: models.MetricDeleteAction, Metric: models.Metrics{ID: "cpu", MType: models.GaugeMetricType, Labels: map[string]string{"host": "a"}, UpdatedAt: &now}},
+		{Action: models.MetricDeleteAction, Metric: models.Metrics{ID: "job_gauge", MType: models.GaugeMetricType, UpdatedAt: &now}},
+	}
+
+	assert.Equal(t, expected, drainEvents(events))
+	assert.Equal(t, []models.MetricEvent{expected[0], expected[2], expected[3]}, drainEvents(counters))
+}
+
+func TestMetrics_DeleteStaleMetric(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	memStore := memstorage.New()
+
+	metricsService := New(memStore)
+	metricsService.now = func() time.Time { return now }
+
+	require.NoError(t, memStore.AddGaugeMetric(context.TODO(), "gauge", 1))
+
+	gauge, err := memStore.GetGaugeMetric(context.TODO(), "gauge")
+	require.NoError(t, err)
+
+	events, unsubscribe := metricsService.Subscribe(services.MetricStreamParameters{})
+	defer unsubscribe()
+
+	err = metricsService.DeleteStaleMetric(context.TODO(), models.GaugeMetricType, "gauge", gauge.UpdatedAt.Add(-time.Second))
+	assert.ErrorIs(t, err, storage.ErrDataNotFound)
+
+	require.NoError(t, metricsService.DeleteStaleMetric(context.TODO(), models.GaugeMetricType, "gauge", gauge.UpdatedAt))
+
+	expected := []models.MetricEvent{
+		{Action: models.MetricDeleteAction, Metric: models.Metrics{ID: "gauge", MType: models.GaugeMetricType, UpdatedAt: &now}},
+	}
+
+	assert.Equal(t, expected, drainEvents(events))
+}
+
+func TestMetrics_Staleness(t *testing.T) {
+	metricsService := NewWithConfig(memstorage.NewWithPrefilledData(map[string]float64{"host_load": 1.5, "static_version": 2}, map[string]int64{}), Config{
+		Staleness: staleness.Policy{TTL: time.Minute, PrefixTTL: map[string]time.Duration{"static_": 0}},
+	})
+	metricsService.now = func() time.Time { return time.Now().Add(time.Hour) }
+
+	t.Run("Should hide stale metrics from list", func(t *testing.T) {
+		result, err := metricsService.GetAll(context.TODO(), nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"static_version": "2"}, entryValues(result))
+	})
+
+	t.Run("Should return not found for stale metric", func(t *testing.T) {
+		_, err := metricsService.Get(context.TODO(), services.MetricGetParameters{MetricType: models.GaugeMetricType, MetricName: "host_load"})
+		assert.Equal(t, services.ErrMetricNotFound, err)
+
+		_, err = metricsService.GetModel(context.TODO(), models.Metrics{ID: "host_load", MType: models.GaugeMetricType})
+		assert.Equal(t, services.ErrMetricNotFound, err)
+	})
+
+	t.Run("Should show metric again after update", func(t *testing.T) {
+		metricsService.now = time.Now
+		value := 3.5
+
+		require.NoError(t, metricsService.SaveModel(context.TODO(), models.Metrics{ID: "host_load", MType: models.GaugeMetricType, Value: &value}))
+
+		result, err := metricsService.GetModel(context.TODO(), models.Metrics{ID: "host_load", MType: models.GaugeMetricType})
+		require.NoError(t, err)
+		assert.Equal(t, value, *result.Value)
+		assert.NotNil(t, result.UpdatedAt)
 	})
 }
 
@@ -330,12 +815,151 @@ func TestMetrics_GetModel(t *testing.T) {
 			require.Equal(t, tc.expectedError, err)
 
 			if err == nil {
+				require.NotNil(t, value.UpdatedAt)
+				value.UpdatedAt = nil
 				assert.Equal(t, tc.expectedValue, value)
 			}
 		})
 	}
 }
 
+func TestMetrics_GetHistory(t *testing.T) {
+	var (
+		from  = time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+		to    = from.Add(time.Minute)
+		store = memstorage.New()
+	)
+
+	err := store.AddMetricSamples(context.TODO(), []storage.MetricSample{
+		{Name: "gauge", Type: models.GaugeMetricType, Value: 1, Timestamp: from.Add(5 * time.Second)},
+		{Name: "gauge", Type: models.GaugeMetricType, Value: 2, Timestamp: from.Add(20 * time.Second)},
+		{Name: "gauge", Type: models.GaugeMetricType, Value: 3, Timestamp: from.Add(40 * time.Second)},
+		{Name: "gauge", Type: models.GaugeMetricType, Value: 4, Timestamp: to.Add(time.Second)},
+		{Name: "counter", Type: models.CounterMetricType, Value: 1, Timestamp: from.Add(5 * time.Second)},
+		{Name: "counter", Type: models.CounterMetricType, Value: 2, Timestamp: from.Add(20 * time.Second)},
+		{Name: "counter", Type: models.CounterMetricType, Value: 3, Timestamp: from.Add(40 * time.Second)},
+	})
+	require.NoError(t, err)
+
+	metricsService := New(store)
+
+	t.Run("Should return raw samples within period", func(t *testing.T) {
+		result, err := metricsService.GetHistory(context.TODO(), services.MetricHistoryParameters{
+			MetricType: models.GaugeMetricType,
+			MetricName: "gauge",
+			From:       from,
+			To:         to,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []models.MetricPoint{
+			{Timestamp: from.Add(5 * time.Second), Value: 1},
+			{Timestamp: from.Add(20 * time.Second), Value: 2},
+			{Timestamp: from.Add(40 * time.Second), Value: 3},
+		}, result.Points)
+	})
+
+	t.Run("Should keep last gauge value within step", func(t *testing.T) {
+		result, err := metricsService.GetHistory(context.TODO(), services.MetricHistoryParameters{
+			MetricType: models.GaugeMetricType,
+			MetricName: "gauge",
+			From:       from,
+			To:         to,
+			Step:       30 * time.Second,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "30s", result.Step)
+		assert.Equal(t, []models.MetricPoint{
+			{Timestamp: from, Value: 2},
+			{Timestamp: from.Add(30 * time.Second), Value: 3},
+		}, result.Points)
+	})
+
+	t.Run("Should sum counter increments within step", func(t *testing.T) {
+		result, err := metricsService.GetHistory(context.TODO(), services.MetricHistoryParameters{
+			MetricType: models.CounterMetricType,
+			MetricName: "counter",
+			From:       from,
+			To:         to,
+			Step:       30 * time.Second,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []models.MetricPoint{
+			{Timestamp: from, Value: 3},
+			{Timestamp: from.Add(30 * time.Second), Value: 3},
+		}, result.Points)
+	})
+
+	t.Run("Should return error if period is invalid", func(t *testing.T) {
+		_, err := metricsService.GetHistory(context.TODO(), services.MetricHistoryParameters{
+			MetricType: models.GaugeMetricType,
+			MetricName: "gauge",
+			From:       to,
+			To:         from,
+		})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("Should return not found error if metric type isn't defined", func(t *testing.T) {
+		_, err := metricsService.GetHistory(context.TODO(), services.MetricHistoryParameters{
+			MetricType: "test",
+			MetricName: "gauge",
+			From:       from,
+			To:         to,
+		})
+
+		assert.Equal(t, services.ErrMetricNotFound, err)
+	})
+
+	t.Run("Should record history on save", func(t *testing.T) {
+		start := time.Now().UTC()
+
+		require.NoError(t, metricsService.Save(context.TODO(), services.MetricSaveParameters{
+			MetricType:  models.CounterMetricType,
+			MetricName:  "saved",
+			MetricValue: "5",
+		}))
+
+		result, err := metricsService.GetHistory(context.TODO(), services.MetricHistoryParameters{
+			MetricType: models.CounterMetricType,
+			MetricName: "saved",
+			From:       start,
+			To:         time.Now().UTC(),
+		})
+
+		require.NoError(t, err)
+		require.Len(t, result.Points, 1)
+		assert.Equal(t, float64(5), result.Points[0].Value)
+	})
+
+	t.Run("Should record observation count of histogram on save", func(t *testing.T) {
+		start := time.Now().UTC()
+
+		for i := 0; i < 2; i++ {
+			require.NoError(t, metricsService.SaveModel(context.TODO(), models.Metrics{
+				ID:        "latency",
+				MType:     models.HistogramMetricType,
+				Histogram: &models.Histogram{Bounds: []float64{1}, Counts: []uint64{1, 2}, Sum: 3, Count: 3},
+			}))
+		}
+
+		result, err := metricsService.GetHistory(context.TODO(), services.MetricHistoryParameters{
+			MetricType: models.HistogramMetricType,
+			MetricName: "latency",
+			From:       start,
+			To:         time.Now().UTC(),
+			Step:       time.Hour,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, result.Points, 1)
+		assert.Equal(t, float64(6), result.Points[0].Value)
+	})
+}
+
 func TestMetrics_IsCounterMetricType(t *testing.T) {
 	t.Run("Should return true if metric name is counter metric type", func(t *testing.T) {
 		assert.True(t, IsCounterMetricType("PollCount"))
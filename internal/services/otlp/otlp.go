@@ -0,0 +1,370 @@
+// Package otlp принимает метрики в формате OpenTelemetry (OTLP/HTTP) и преобразует их в модели метрик.
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"mime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+	otlppb "github.com/daremove/go-metrics-service/internal/proto/otlp"
+	"github.com/daremove/go-metrics-service/internal/services"
+	"github.com/daremove/go-metrics-service/internal/storage"
+)
+
+// ErrInvalidRequest ошибка, возникающая при получении некорректного запроса OTLP.
+var ErrInvalidRequest = errors.New("otlp request is invalid")
+
+// ErrUnsupportedContentType ошибка, возникающая при получении запроса в неподдерживаемом формате.
+var ErrUnsupportedContentType = errors.New("otlp content type is unsupported")
+
+// Форматы тела запроса OTLP/HTTP.
+const (
+	ProtobufContentType = "application/x-protobuf"
+	JSONContentType     = "application/json"
+)
+
+// Saver определяет метод сохранения метрик, необходимый для приема данных.
+type Saver interface {
+	SaveModels(ctx context.Context, parameters []models.Metrics) error
+}
+
+// counterTTL время, после которого забывается последнее значение счетчика, не получавшего новых данных.
+const counterTTL = time.Hour
+
+// counterState описывает последнее полученное накопленное значение счетчика.
+type counterState struct {
+	start uint64    // Время начала накопления, при его изменении счетчик считается перезапущенным
+	value float64   // Накопленное значение
+	seen  time.Time // Время получения значения
+}
+
+// Receiver преобразует запросы OTLP в модели метрик.
+// Точки Gauge сохраняются как gauge. Точки монотонных Sum сохраняются как счетчики: для накопительной
+// темпоральности Receiver запоминает последнее значение каждого ряда и сохраняет только прирост,
+// первое значение сохраняется целиком. Немонотонные накопительные Sum описывают текущее значение
+// и сохраняются как gauge. Атрибуты ресурса и точки сохраняются как метки.
+// Значения счетчиков, не обновлявшихся дольше counterTTL, удаляются, чтобы исчезнувшие ряды не копились в памяти.
+type Receiver struct {
+	mu       sync.Mutex
+	counters map[string]counterState
+	ttl      time.Duration
+	swept    time.Time
+	now      func() time.Time
+}
+
+// New создает новый экземпляр Receiver.
+func New() *Receiver {
+	return &Receiver{
+		counters: make(map[string]counterState),
+		ttl:      counterTTL,
+		now:      time.Now,
+	}
+}
+
+// Decode разбирает тело запроса в формате protobuf или JSON в зависимости от типа содержимого.
+func Decode(body []byte, contentType string) (*otlppb.ExportMetricsServiceRequest, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+
+	if err != nil {
+		mediaType = ProtobufContentType
+	}
+
+	var request otlppb.ExportMetricsServiceRequest
+
+	switch mediaType {
+	case ProtobufContentType:
+		err = proto.Unmarshal(body, &request)
+	case JSONContentType:
+		err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(body, &request)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedContentType, mediaType)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+
+	return &request, nil
+}
+
+// Encode формирует ответ в том же формате, что и запрос, и возвращает его тип содержимого.
+func Encode(response *otlppb.ExportMetricsServiceResponse, contentType string) ([]byte, string, error) {
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == JSONContentType {
+		data, err := protojson.Marshal(response)
+
+		return data, JSONContentType, err
+	}
+
+	data, err := proto.Marshal(response)
+
+	return data, ProtobufContentType, err
+}
+
+// point описывает точку данных, приведенную к модели метрик.
+type point struct {
+	name     string
+	labels   map[string]string
+	dataType string
+	counter  bool   // Признак накопительного счетчика, для которого сохраняется прирост
+	start    uint64 // Время начала накопления
+	time     uint64
+	value    float64
+}
+
+// Write сохраняет метрики запроса. Из значений gauge сохраняется самое позднее,
+// из значений счетчика — суммарный прирост. Запомненные значения счетчиков обновляются
+// только после успешного сохранения.
+func (r *Receiver) Write(ctx context.Context, saver Saver, request *otlppb.ExportMetricsServiceRequest) error {
+	points := collectPoints(request)
+
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].time < points[j].time
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var keys []string
+
+	result := make(map[string]models.Metrics)
+	counters := make(map[string]counterState)
+
+	for _, item := range points {
+		key := item.dataType + "/" + storage.SeriesKey(item.name, item.labels)
+
+		var increase int64
+
+		if item.dataType == models.CounterMetricType {
+			// Значения счетчиков проверены при разборе, но разность значений с разными знаками
+			// может не поместиться в int64, такие точки пропускаются.
+			v, ok := r.counterIncrease(counters, key, item)
+
+			if !ok {
+				continue
+			}
+
+			increase = v
+		}
+
+		metric, ok := result[key]
+
+		if !ok {
+			metric = models.Metrics{ID: item.name, MType: item.dataType, Labels: item.labels}
+			keys = append(keys, key)
+		}
+
+		switch item.dataType {
+		case models.CounterMetricType:
+			if metric.Delta == nil {
+				metric.Delta = new(int64)
+			}
+
+			*metric.Delta += increase
+		default:
+			value := item.value
+			metric.Value = &value
+		}
+
+		result[key] = metric
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	metrics := make([]models.Metrics, 0, len(keys))
+
+	for _, key := range keys {
+		metrics = append(metrics, result[key])
+	}
+
+	if err := saver.SaveModels(ctx, metrics); err != nil {
+		return err
+	}
+
+	now := r.now()
+
+	for key, value := range counters {
+		value.seen = now
+		r.counters[key] = value
+	}
+
+	r.evictCounters(now)
+
+	return nil
+}
+
+// counterIncrease вычисляет прирост счетчика по точке и запоминает ее значение в counters.
+// Для накопительного счетчика приростом считается разность с предыдущим значением того же
+// периода накопления, вычисленная до преобразования в int64. Возвращает false, если прирост
+// не помещается в int64.
+func (r *Receiver) counterIncrease(counters map[string]counterState, key string, item point) (int64, bool) {
+	if !item.counter {
+		return services.CounterDelta(item.value)
+	}
+
+	increase, ok := services.CounterDelta(item.value)
+	last, known := counters[key]
+
+	if !known {
+		last, known = r.counters[key]
+	}
+
+	if known && last.start == item.start && item.value >= last.value {
+		increase, ok = services.CounterDelta(item.value - last.value)
+	}
+
+	if ok {
+		counters[key] = counterState{start: item.start, value: item.value}
+	}
+
+	return increase, ok
+}
+
+// evictCounters удаляет значения счетчиков, не обновлявшихся дольше ttl.
+// Проверка выполняется не чаще одного раза за ttl.
+func (r *Receiver) evictCounters(now time.Time) {
+	if now.Sub(r.swept) < r.ttl {
+		return
+	}
+
+	for key, state := range r.counters {
+		if now.Sub(state.seen) > r.ttl {
+			delete(r.counters, key)
+		}
+	}
+
+	r.swept = now
+}
+
+// collectPoints извлекает из запроса точки данных, которые можно сохранить.
+// Точки без значения, точки неподдерживаемых типов и значения счетчиков вне диапазона int64 пропускаются.
+func collectPoints(request *otlppb.ExportMetricsServiceRequest) []point {
+	var result []point
+
+	for _, resourceMetrics := range request.GetResourceMetrics() {
+		resourceLabels := attributesToLabels(nil, resourceMetrics.GetResource().GetAttributes())
+
+		for _, scopeMetrics := range resourceMetrics.GetScopeMetrics() {
+			for _, metric := range scopeMetrics.GetMetrics() {
+				var (
+					dataPoints []*otlppb.NumberDataPoint
+					dataType   = models.GaugeMetricType
+					counter    bool
+				)
+
+				switch data := metric.GetData().(type) {
+				case *otlppb.Metric_Gauge:
+					dataPoints = data.Gauge.GetDataPoints()
+				case *otlppb.Metric_Sum:
+					dataPoints = data.Sum.GetDataPoints()
+					cumulative := data.Sum.GetAggregationTemporality() == otlppb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+
+					if data.Sum.GetIsMonotonic() || !cumulative {
+						dataType = models.CounterMetricType
+						counter = cumulative
+					}
+				default:
+					continue
+				}
+
+				for _, dataPoint := range dataPoints {
+					value, ok := pointValue(dataPoint)
+
+					if !ok || metric.GetName() == "" {
+						continue
+					}
+
+					// Значения счетчиков вне диапазона int64 переполнились бы при сохранении прироста.
+					if _, valid := services.CounterDelta(value); dataType == models.CounterMetricType && !valid {
+						continue
+					}
+
+					result = append(result, point{
+						name:     services.SanitizeMetricName(metric.GetName()),
+						labels:   attributesToLabels(resourceLabels, dataPoint.GetAttributes()),
+						dataType: dataType,
+						counter:  counter,
+						start:    dataPoint.GetStartTimeUnixNano(),
+						time:     dataPoint.GetTimeUnixNano(),
+						value:    value,
+					})
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// pointValue возвращает значение точки, если оно было зафиксировано.
+func pointValue(dataPoint *otlppb.NumberDataPoint) (float64, bool) {
+	if dataPoint.GetFlags()&uint32(otlppb.DataPointFlags_DATA_POINT_FLAGS_NO_RECORDED_VALUE_MASK) != 0 {
+		return 0, false
+	}
+
+	switch value := dataPoint.GetValue().(type) {
+	case *otlppb.NumberDataPoint_AsDouble:
+		if math.IsNaN(value.AsDouble) || math.IsInf(value.AsDouble, 0) {
+			return 0, false
+		}
+
+		return value.AsDouble, true
+	case *otlppb.NumberDataPoint_AsInt:
+		return float64(value.AsInt), true
+	default:
+		return 0, false
+	}
+}
+
+// attributesToLabels добавляет к меткам base атрибуты со скалярными значениями.
+// Символы, недопустимые в имени метки, например точки в "service.name", заменяются на "_".
+func attributesToLabels(base map[string]string, attributes []*otlppb.KeyValue) map[string]string {
+	result := make(map[string]string, len(base)+len(attributes))
+
+	for name, value := range base {
+		result[name] = value
+	}
+
+	for _, attribute := range attributes {
+		value, ok := attributeValue(attribute.GetValue())
+
+		if !ok || attribute.GetKey() == "" {
+			continue
+		}
+
+		result[services.SanitizeLabelName(attribute.GetKey())] = value
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+
+	return result
+}
+
+// attributeValue возвращает строковое представление скалярного значения атрибута.
+func attributeValue(value *otlppb.AnyValue) (string, bool) {
+	switch v := value.GetValue().(type) {
+	case *otlppb.AnyValue_StringValue:
+		return v.StringValue, true
+	case *otlppb.AnyValue_BoolValue:
+		return strconv.FormatBool(v.BoolValue), true
+	case *otlppb.AnyValue_IntValue:
+		return strconv.FormatInt(v.IntValue, 10), true
+	case *otlppb.AnyValue_DoubleValue:
+		return strconv.FormatFloat(v.DoubleValue, 'g', -1, 64), true
+	default:
+		return "", false
+	}
+}
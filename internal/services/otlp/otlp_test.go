@@ -0,0 +1,237 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+	otlppb "github.com/daremove/go-metrics-service/internal/proto/otlp"
+)
+
+type saverMock struct {
+	saved [][]models.Metrics
+	err   error
+}
+
+func (m *saverMock) SaveModels(_ context.Context, parameters []models.Metrics) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	m.saved = append(m.saved, parameters)
+
+	return nil
+}
+
+func gauge(name string, value float64, labels map[string]string) models.Metrics {
+	return models.Metrics{ID: name, MType: models.GaugeMetricType, Value: &value, Labels: labels}
+}
+
+func counter(name string, delta int64, labels map[string]string) models.Metrics {
+	return models.Metrics{ID: name, MType: models.CounterMetricType, Delta: &delta, Labels: labels}
+}
+
+func stringAttribute(key, value string) *otlppb.KeyValue {
+	return &otlppb.KeyValue{Key: key, Value: &otlppb.AnyValue{Value: &otlppb.AnyValue_StringValue{StringValue: value}}}
+}
+
+func doublePoint(value float64, start, time uint64, attributes ...*otlppb.KeyValue) *otlppb.NumberDataPoint {
+	return &otlppb.NumberDataPoint{
+		Attributes:        attributes,
+		StartTimeUnixNano: start,
+		TimeUnixNano:      time,
+		Value:             &otlppb.NumberDataPoint_AsDouble{AsDouble: value},
+	}
+}
+
+func intPoint(value int64, start, time uint64, attributes ...*otlppb.KeyValue) *otlppb.NumberDataPoint {
+	return &otlppb.NumberDataPoint{
+		Attributes:        attributes,
+		StartTimeUnixNano: start,
+		TimeUnixNano:      time,
+		Value:             &otlppb.NumberDataPoint_AsInt{AsInt: value},
+	}
+}
+
+func sumMetric(name string, temporality otlppb.AggregationTemporality, monotonic bool, points ...*otlppb.NumberDataPoint) *otlppb.Metric {
+	return &otlppb.Metric{Name: name, Data: &otlppb.Metric_Sum{Sum: &otlppb.Sum{
+		DataPoints:             points,
+		AggregationTemporality: temporality,
+		IsMonotonic:            monotonic,
+	}}}
+}
+
+func gaugeMetric(name string, points ...*otlppb.NumberDataPoint) *otlppb.Metric {
+	return &otlppb.Metric{Name: name, Data: &otlppb.Metric_Gauge{Gauge: &otlppb.Gauge{DataPoints: points}}}
+}
+
+func request(resource []*otlppb.KeyValue, metrics ...*otlppb.Metric) *otlppb.ExportMetricsServiceRequest {
+	return &otlppb.ExportMetricsServiceRequest{ResourceMetrics: []*otlppb.ResourceMetrics{{
+		Resource:     &otlppb.Resource{Attributes: resource},
+		ScopeMetrics: []*otlppb.ScopeMetrics{{Metrics: metrics}},
+	}}}
+}
+
+const cumulative = otlppb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+
+const delta = otlppb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+
+func TestDecode(t *testing.T) {
+	expected := request(
+		[]*otlppb.KeyValue{stringAttribute("service.name", "checkout")},
+		sumMetric("http.requests", cumulative, true, intPoint(5, 1, 2)),
+	)
+
+	data, err := proto.Marshal(expected)
+	require.NoError(t, err)
+
+	t.Run("Should decode protobuf", func(t *testing.T) {
+		result, err := Decode(data, "application/x-protobuf")
+
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(expected, result))
+	})
+
+	t.Run("Should decode json", func(t *testing.T) {
+		body := `{"resourceMetrics":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"checkout"}}]},
+			"scopeMetrics":[{"scope":{"name":"sdk"},"metrics":[{"name":"http.requests","sum":{"aggregationTemporality":2,"isMonotonic":true,
+			"dataPoints":[{"startTimeUnixNano":"1","timeUnixNano":"2","asInt":"5"}]}},
+			{"name":"latency","histogram":{"dataPoints":[{"count":"1"}]}}]}]}]}`
+
+		result, err := Decode([]byte(body), "application/json; charset=utf-8")
+
+		require.NoError(t, err)
+		require.Len(t, result.GetResourceMetrics()[0].GetScopeMetrics()[0].GetMetrics(), 2)
+		assert.True(t, proto.Equal(expected.GetResourceMetrics()[0].GetScopeMetrics()[0].GetMetrics()[0], result.GetResourceMetrics()[0].GetScopeMetrics()[0].GetMetrics()[0]))
+	})
+
+	t.Run("Should return error for invalid body", func(t *testing.T) {
+		_, err := Decode([]byte("{"), "application/json")
+
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+	})
+
+	t.Run("Should return error for unsupported content type", func(t *testing.T) {
+		_, err := Decode(data, "text/plain")
+
+		assert.ErrorIs(t, err, ErrUnsupportedContentType)
+	})
+}
+
+func TestReceiver_Write(t *testing.T) {
+	resource := []*otlppb.KeyValue{stringAttribute("service.name", "checkout")}
+
+	t.Run("Should map gauges and sums with resource attributes", func(t *testing.T) {
+		receiver := New()
+		saver := &saverMock{}
+
+		require.NoError(t, receiver.Write(context.Background(), saver, request(resource,
+			gaugeMetric("memory.usage", doublePoint(512.5, 0, 1, stringAttribute("host", "a"))),
+			sumMetric("http.requests", cumulative, true, intPoint(5, 1, 1)),
+			sumMetric("queue.size", cumulative, false, intPoint(7, 1, 1)),
+		)))
+
+		assert.Equal(t, [][]models.Metrics{{
+			gauge("memory.usage", 512.5, map[string]string{"service_name": "checkout", "host": "a"}),
+			counter("http.requests", 5, map[string]string{"service_name": "checkout"}),
+			gauge("queue.size", 7, map[string]string{"service_name": "checkout"}),
+		}}, saver.saved)
+	})
+
+	t.Run("Should compute deltas for cumulative sums", func(t *testing.T) {
+		receiver := New()
+		saver := &saverMock{}
+
+		require.NoError(t, receiver.Write(context.Background(), saver, request(nil, sumMetric("requests", cumulative, true, intPoint(10, 1, 1)))))
+		require.NoError(t, receiver.Write(context.Background(), saver, request(nil, sumMetric("requests", cumulative, true, intPoint(18, 1, 3), intPoint(15, 1, 2)))))
+		require.NoError(t, receiver.Write(context.Background(), saver, request(nil, sumMetric("requests", cumulative, true, intPoint(4, 5, 6)))))
+
+		assert.Equal(t, [][]models.Metrics{
+			{counter("requests", 10, nil)},
+			{counter("requests", 8, nil)},
+			{counter("requests", 4, nil)},
+		}, saver.saved)
+	})
+
+	t.Run("Should save delta sums as is", func(t *testing.T) {
+		receiver := New()
+		saver := &saverMock{}
+
+		require.NoError(t, receiver.Write(context.Background(), saver, request(nil, sumMetric("requests", delta, true, intPoint(3, 1, 2), intPoint(4, 2, 3)))))
+		require.NoError(t, receiver.Write(context.Background(), saver, request(nil, sumMetric("requests", delta, true, intPoint(3, 3, 4)))))
+
+		assert.Equal(t, [][]models.Metrics{{counter("requests", 7, nil)}, {counter("requests", 3, nil)}}, saver.saved)
+	})
+
+	t.Run("Should skip counter values outside int64 range", func(t *testing.T) {
+		receiver := New()
+		saver := &saverMock{}
+
+		require.NoError(t, receiver.Write(context.Background(), saver, request(nil,
+			sumMetric("requests", cumulative, true, doublePoint(1.5, 1, 1), doublePoint(1e300, 1, 2), doublePoint(3.9, 1, 3)),
+			sumMetric("errors", delta, true, doublePoint(-1e300, 1, 2)),
+			gaugeMetric("load", doublePoint(1e300, 0, 1)),
+		)))
+
+		assert.Equal(t, [][]models.Metrics{{counter("requests", 3, nil), gauge("load", 1e300, nil)}}, saver.saved)
+	})
+
+	t.Run("Should not remember counters if save failed", func(t *testing.T) {
+		receiver := New()
+		saver := &saverMock{err: errors.New("storage is unavailable")}
+
+		require.Error(t, receiver.Write(context.Background(), saver, request(nil, sumMetric("requests", cumulative, true, intPoint(10, 1, 1)))))
+
+		saver.err = nil
+		require.NoError(t, receiver.Write(context.Background(), saver, request(nil, sumMetric("requests", cumulative, true, intPoint(12, 1, 2)))))
+
+		assert.Equal(t, [][]models.Metrics{{counter("requests", 12, nil)}}, saver.saved)
+	})
+
+	t.Run("Should forget counters that weren't updated longer than ttl", func(t *testing.T) {
+		receiver := New()
+		saver := &saverMock{}
+		now := time.Unix(0, 0)
+		receiver.now = func() time.Time { return now }
+
+		require.NoError(t, receiver.Write(context.Background(), saver, request(nil, sumMetric("requests", cumulative, true, intPoint(10, 1, 1)))))
+
+		now = now.Add(counterTTL / 2)
+		require.NoError(t, receiver.Write(context.Background(), saver, request(nil, sumMetric("errors", cumulative, true, intPoint(1, 1, 1)))))
+
+		now = now.Add(counterTTL)
+		require.NoError(t, receiver.Write(context.Background(), saver, request(nil, sumMetric("errors", cumulative, true, intPoint(3, 1, 2)))))
+
+		assert.NotContains(t, receiver.counters, models.CounterMetricType+"/requests")
+		assert.Contains(t, receiver.counters, models.CounterMetricType+"/errors")
+
+		require.NoError(t, receiver.Write(context.Background(), saver, request(nil, sumMetric("requests", cumulative, true, intPoint(12, 1, 2)))))
+		assert.Equal(t, []models.Metrics{counter("requests", 12, nil)}, saver.saved[3])
+	})
+
+	t.Run("Should skip points without recorded value", func(t *testing.T) {
+		receiver := New()
+		saver := &saverMock{}
+		point := doublePoint(1, 0, 1)
+		point.Flags = uint32(otlppb.DataPointFlags_DATA_POINT_FLAGS_NO_RECORDED_VALUE_MASK)
+
+		require.NoError(t, receiver.Write(context.Background(), saver, request(nil, gaugeMetric("load", point))))
+
+		assert.Empty(t, saver.saved)
+	})
+
+	t.Run("Should replace reserved characters in metric names", func(t *testing.T) {
+		receiver := New()
+		saver := &saverMock{}
+
+		require.NoError(t, receiver.Write(context.Background(), saver, request(nil, gaugeMetric(`load{host="a"}`, doublePoint(1, 0, 1)))))
+
+		assert.Equal(t, [][]models.Metrics{{gauge("load_host__a__", 1, nil)}}, saver.saved)
+	})
+}
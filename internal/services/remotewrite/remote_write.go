@@ -0,0 +1,348 @@
+// Package remotewrite принимает данные в формате Prometheus remote_write и преобразует их в модели метрик.
+package remotewrite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/proto/prompb"
+	"github.com/daremove/go-metrics-service/internal/services"
+	"github.com/daremove/go-metrics-service/internal/storage"
+)
+
+// ErrInvalidRequest ошибка, возникающая при получении некорректного запроса remote_write.
+var ErrInvalidRequest = errors.New("remote write request is invalid")
+
+// ErrRequestTooLarge ошибка, возникающая когда распакованный запрос remote_write превышает MaxDecodedSize.
+var ErrRequestTooLarge = errors.New("remote write request is too large")
+
+// MaxDecodedSize максимальный размер запроса remote_write после распаковки snappy.
+const MaxDecodedSize = 32 << 20
+
+// metricNameLabel метка, в которой Prometheus передает имя метрики.
+const metricNameLabel = "__name__"
+
+// DefaultTypeRules правила определения типа метрики по суффиксу имени, используемые по умолчанию.
+var DefaultTypeRules = map[string]string{
+	"_total": models.CounterMetricType,
+}
+
+// Config содержит настройки приема данных remote_write.
+type Config struct {
+	TypeRules   map[string]string // Тип метрики по суффиксу имени, используется самый длинный подходящий суффикс
+	DefaultType string            // Тип метрики, если не подошло ни одно правило и нет метаданных
+}
+
+// Saver определяет метод сохранения метрик, необходимый для приема данных.
+type Saver interface {
+	SaveModels(ctx context.Context, parameters []models.Metrics) error
+}
+
+// counterTTL время, после которого забывается последнее значение счетчика, не получавшего новых данных.
+const counterTTL = time.Hour
+
+// counterState последнее полученное значение счетчика и время его получения.
+type counterState struct {
+	value float64
+	seen  time.Time
+}
+
+// Receiver преобразует запросы remote_write в модели метрик.
+// Prometheus передает счетчики накопленным значением, поэтому Receiver запоминает последнее значение
+// каждого счетчика и сохраняет только его прирост. Первое значение счетчика сохраняется целиком.
+// Значения счетчиков, не обновлявшихся дольше counterTTL, удаляются, чтобы исчезнувшие ряды не копились в памяти.
+type Receiver struct {
+	config   Config
+	mu       sync.Mutex
+	counters map[string]counterState
+	ttl      time.Duration
+	swept    time.Time
+	now      func() time.Time
+}
+
+// New создает новый экземпляр Receiver.
+func New(config Config) *Receiver {
+	if config.TypeRules == nil {
+		config.TypeRules = DefaultTypeRules
+	}
+
+	if config.DefaultType == "" {
+		config.DefaultType = models.GaugeMetricType
+	}
+
+	return &Receiver{
+		config:   config,
+		counters: make(map[string]counterState),
+		ttl:      counterTTL,
+		now:      time.Now,
+	}
+}
+
+// ParseTypeRules разбирает правила определения типа в формате "_total=counter,_ratio=gauge".
+func ParseTypeRules(value string) (map[string]string, error) {
+	result := map[string]string{}
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+
+		if item == "" {
+			continue
+		}
+
+		suffix, metricType, ok := strings.Cut(item, "=")
+
+		if !ok || suffix == "" {
+			return nil, fmt.Errorf("type rule %q must be in format suffix=type", item)
+		}
+
+		if metricType != models.CounterMetricType && metricType != models.GaugeMetricType {
+			return nil, fmt.Errorf("type rule %q: metric type %q isn't supported", item, metricType)
+		}
+
+		result[suffix] = metricType
+	}
+
+	return result, nil
+}
+
+// Decode распаковывает и разбирает тело запроса remote_write, сжатое snappy.
+// Размер распакованных данных проверяется по заголовку до выделения памяти под них.
+func Decode(body []byte) (*prompb.WriteRequest, error) {
+	size, err := snappy.DecodedLen(body)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+
+	if size > MaxDecodedSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds limit of %d bytes", ErrRequestTooLarge, size, MaxDecodedSize)
+	}
+
+	data, err := snappy.Decode(nil, body)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+
+	var request prompb.WriteRequest
+
+	if err := proto.Unmarshal(data, &request); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+
+	return &request, nil
+}
+
+// Write сохраняет временные ряды запроса. Из значений gauge сохраняется последнее,
+// из значений счетчика — прирост относительно предыдущего полученного значения.
+// Запомненные значения счетчиков обновляются только после успешного сохранения.
+func (r *Receiver) Write(ctx context.Context, saver Saver, request *prompb.WriteRequest) error {
+	metadata := metadataTypes(request.GetMetadata())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]models.Metrics, 0, len(request.GetTimeseries()))
+	counters := make(map[string]float64)
+
+	for _, series := range request.GetTimeseries() {
+		name, labels, err := parseLabels(series.GetLabels())
+
+		if err != nil {
+			return err
+		}
+
+		samples := sortedSamples(series.GetSamples())
+
+		if len(samples) == 0 {
+			continue
+		}
+
+		switch r.metricType(name, metadata) {
+		case models.CounterMetricType:
+			key := storage.SeriesKey(name, labels)
+			last, ok := counters[key]
+
+			if !ok {
+				var state counterState
+				state, ok = r.counters[key]
+				last = state.value
+			}
+
+			var (
+				delta    int64
+				accepted bool
+			)
+
+			// Значения, прирост от которых не помещается в int64, пропускаются:
+			// при преобразовании они переполнились бы в огромное отрицательное приращение.
+			for _, sample := range samples {
+				increase, valid := counterIncrease(last, ok, sample.GetValue())
+
+				if !valid || overflows(delta, increase) {
+					continue
+				}
+
+				delta += increase
+				last, ok, accepted = sample.GetValue(), true, true
+			}
+
+			if !accepted {
+				continue
+			}
+
+			counters[key] = last
+			result = append(result, models.Metrics{ID: name, MType: models.CounterMetricType, Delta: &delta, Labels: labels})
+		default:
+			value := samples[len(samples)-1].GetValue()
+			result = append(result, models.Metrics{ID: name, MType: models.GaugeMetricType, Value: &value, Labels: labels})
+		}
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+
+	if err := saver.SaveModels(ctx, result); err != nil {
+		return err
+	}
+
+	now := r.now()
+
+	for key, value := range counters {
+		r.counters[key] = counterState{value: value, seen: now}
+	}
+
+	r.evictCounters(now)
+
+	return nil
+}
+
+// evictCounters удаляет значения счетчиков, не обновлявшихся дольше ttl.
+// Проверка выполняется не чаще одного раза за ttl.
+func (r *Receiver) evictCounters(now time.Time) {
+	if now.Sub(r.swept) < r.ttl {
+		return
+	}
+
+	for key, state := range r.counters {
+		if now.Sub(state.seen) > r.ttl {
+			delete(r.counters, key)
+		}
+	}
+
+	r.swept = now
+}
+
+// metricType определяет тип метрики по метаданным, а при их отсутствии — по правилам для суффикса имени.
+func (r *Receiver) metricType(name string, metadata map[string]string) string {
+	if metricType, ok := metadata[name]; ok {
+		return metricType
+	}
+
+	if metricType, ok := metadata[strings.TrimSuffix(name, "_total")]; ok && metricType == models.CounterMetricType {
+		return metricType
+	}
+
+	metricType := r.config.DefaultType
+	matched := -1
+
+	for suffix, suffixType := range r.config.TypeRules {
+		if strings.HasSuffix(name, suffix) && len(suffix) > matched {
+			metricType = suffixType
+			matched = len(suffix)
+		}
+	}
+
+	return metricType
+}
+
+// metadataTypes возвращает типы семейств метрик, которые можно сохранить без потери смысла.
+func metadataTypes(metadata []*prompb.MetricMetadata) map[string]string {
+	result := make(map[string]string, len(metadata))
+
+	for _, item := range metadata {
+		switch item.GetType() {
+		case prompb.MetricMetadata_COUNTER:
+			result[item.GetMetricFamilyName()] = models.CounterMetricType
+		case prompb.MetricMetadata_GAUGE:
+			result[item.GetMetricFamilyName()] = models.GaugeMetricType
+		}
+	}
+
+	return result
+}
+
+// parseLabels извлекает имя метрики и остальные метки временного ряда.
+// Зарезервированные символы в имени заменяются, чтобы ряд не конфликтовал с ключами хранилища.
+func parseLabels(labels []*prompb.Label) (string, map[string]string, error) {
+	var name string
+
+	result := make(map[string]string, len(labels))
+
+	for _, label := range labels {
+		if label.GetName() == metricNameLabel {
+			name = label.GetValue()
+			continue
+		}
+
+		result[label.GetName()] = label.GetValue()
+	}
+
+	if name == "" {
+		return "", nil, fmt.Errorf("%w: time series without %s label", ErrInvalidRequest, metricNameLabel)
+	}
+
+	if len(result) == 0 {
+		result = nil
+	}
+
+	return services.SanitizeMetricName(name), result, nil
+}
+
+// sortedSamples возвращает значения ряда по возрастанию времени без NaN, которыми Prometheus
+// помечает исчезнувшие ряды, и без бесконечностей, которые нельзя сохранить ни в gauge, ни в counter.
+func sortedSamples(samples []*prompb.Sample) []*prompb.Sample {
+	result := make([]*prompb.Sample, 0, len(samples))
+
+	for _, sample := range samples {
+		if value := sample.GetValue(); !math.IsNaN(value) && !math.IsInf(value, 0) {
+			result = append(result, sample)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].GetTimestamp() < result[j].GetTimestamp()
+	})
+
+	return result
+}
+
+// counterIncrease вычисляет прирост счетчика. Уменьшение значения означает перезапуск источника,
+// в этом случае приростом считается новое значение целиком. Разность вычисляется до преобразования
+// в int64, чтобы не отбрасывать дробные части значений по отдельности. Возвращает false,
+// если прирост не помещается в int64.
+func counterIncrease(last float64, known bool, value float64) (int64, bool) {
+	if !known || value < last {
+		return services.CounterDelta(value)
+	}
+
+	return services.CounterDelta(value - last)
+}
+
+// overflows сообщает, переполняет ли сумма a и b диапазон int64.
+func overflows(a, b int64) bool {
+	sum := a + b
+
+	return (b > 0 && sum < a) || (b < 0 && sum > a)
+}
@@ -0,0 +1,275 @@
+package remotewrite
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/proto/prompb"
+)
+
+type saverMock struct {
+	saved [][]models.Metrics
+	err   error
+}
+
+func (s *saverMock) SaveModels(_ context.Context, parameters []models.Metrics) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	s.saved = append(s.saved, parameters)
+
+	return nil
+}
+
+func newSeries(name string, labels map[string]string, values ...float64) *prompb.TimeSeries {
+	series := &prompb.TimeSeries{Labels: []*prompb.Label{{Name: metricNameLabel, Value: name}}}
+
+	for labelName, labelValue := range labels {
+		series.Labels = append(series.Labels, &prompb.Label{Name: labelName, Value: labelValue})
+	}
+
+	for i, value := range values {
+		series.Samples = append(series.Samples, &prompb.Sample{Value: value, Timestamp: int64(i)})
+	}
+
+	return series
+}
+
+func int64Ptr(value int64) *int64 {
+	return &value
+}
+
+func float64Ptr(value float64) *float64 {
+	return &value
+}
+
+func TestParseTypeRules(t *testing.T) {
+	testCases := []struct {
+		testName    string
+		value       string
+		expected    map[string]string
+		expectedErr bool
+	}{
+		{testName: "Should parse empty rules", value: "", expected: map[string]string{}},
+		{
+			testName: "Should parse several rules",
+			value:    "_total=counter, _ratio=gauge",
+			expected: map[string]string{"_total": models.CounterMetricType, "_ratio": models.GaugeMetricType},
+		},
+		{testName: "Should return error for rule without type", value: "_total", expectedErr: true},
+		{testName: "Should return error for unsupported type", value: "_bucket=histogram", expectedErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			result, err := ParseTypeRules(tc.value)
+
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	t.Run("Should decode snappy compressed request", func(t *testing.T) {
+		data, err := proto.Marshal(&prompb.WriteRequest{Timeseries: []*prompb.TimeSeries{newSeries("up", nil, 1)}})
+
+		require.NoError(t, err)
+
+		request, err := Decode(snappy.Encode(nil, data))
+
+		require.NoError(t, err)
+		require.Len(t, request.GetTimeseries(), 1)
+		assert.Equal(t, "up", request.GetTimeseries()[0].GetLabels()[0].GetValue())
+	})
+
+	t.Run("Should return error for uncompressed data", func(t *testing.T) {
+		_, err := Decode([]byte("not snappy"))
+
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+	})
+
+	t.Run("Should reject request with too large decoded size", func(t *testing.T) {
+		body := binary.AppendUvarint(nil, MaxDecodedSize+1)
+
+		_, err := Decode(append(body, 0))
+
+		assert.ErrorIs(t, err, ErrRequestTooLarge)
+	})
+}
+
+func TestReceiver_Write(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should infer metric types and save last gauge value", func(t *testing.T) {
+		saver := &saverMock{}
+		receiver := New(Config{})
+
+		err := receiver.Write(ctx, saver, &prompb.WriteRequest{
+			Timeseries: []*prompb.TimeSeries{
+				newSeries("http_requests_total", map[string]string{"path": "/"}, 3, 5),
+				newSeries("temperature", nil, 20.5, 21.5, math.NaN()),
+				newSeries("errors", nil, 2),
+			},
+			Metadata: []*prompb.MetricMetadata{{Type: prompb.MetricMetadata_COUNTER, MetricFamilyName: "errors"}},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, saver.saved, 1)
+		assert.Equal(t, []models.Metrics{
+			{ID: "http_requests_total", MType: models.CounterMetricType, Delta: int64Ptr(5), Labels: map[string]string{"path": "/"}},
+			{ID: "temperature", MType: models.GaugeMetricType, Value: float64Ptr(21.5)},
+			{ID: "errors", MType: models.CounterMetricType, Delta: int64Ptr(2)},
+		}, saver.saved[0])
+	})
+
+	t.Run("Should save counter increase between requests", func(t *testing.T) {
+		saver := &saverMock{}
+		receiver := New(Config{})
+
+		for _, value := range []float64{10, 15, 4} {
+			err := receiver.Write(ctx, saver, &prompb.WriteRequest{
+				Timeseries: []*prompb.TimeSeries{newSeries("jobs_total", nil, value)},
+			})
+
+			require.NoError(t, err)
+		}
+
+		require.Len(t, saver.saved, 3)
+		assert.Equal(t, int64(10), *saver.saved[0][0].Delta)
+		assert.Equal(t, int64(5), *saver.saved[1][0].Delta)
+		assert.Equal(t, int64(4), *saver.saved[2][0].Delta)
+	})
+
+	t.Run("Should compute counter increase from fractional values", func(t *testing.T) {
+		saver := &saverMock{}
+		receiver := New(Config{})
+
+		for _, value := range []float64{1.5, 2.4} {
+			require.NoError(t, receiver.Write(ctx, saver, &prompb.WriteRequest{
+				Timeseries: []*prompb.TimeSeries{newSeries("jobs_total", nil, value)},
+			}))
+		}
+
+		require.Len(t, saver.saved, 2)
+		assert.Equal(t, int64(1), *saver.saved[0][0].Delta)
+		assert.Equal(t, int64(0), *saver.saved[1][0].Delta)
+	})
+
+	t.Run("Should skip non-finite and out of range values", func(t *testing.T) {
+		saver := &saverMock{}
+		receiver := New(Config{})
+
+		err := receiver.Write(ctx, saver, &prompb.WriteRequest{
+			Timeseries: []*prompb.TimeSeries{
+				newSeries("jobs_total", nil, 10, math.Inf(1), 1e300, 12),
+				newSeries("huge_total", nil, 1e300),
+				newSeries("overflow_total", nil, 1<<62+1<<61, 1<<61, 1<<62+1<<61+1024),
+				newSeries("temperature", nil, 20.5, math.Inf(-1)),
+				newSeries("infinity", nil, math.Inf(1)),
+			},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, saver.saved, 1)
+		assert.Equal(t, []models.Metrics{
+			{ID: "jobs_total", MType: models.CounterMetricType, Delta: int64Ptr(12)},
+			{ID: "overflow_total", MType: models.CounterMetricType, Delta: int64Ptr(1<<62 + 1<<61 + 1024)},
+			{ID: "temperature", MType: models.GaugeMetricType, Value: float64Ptr(20.5)},
+		}, saver.saved[0])
+	})
+
+	t.Run("Should keep previous counter value when saving fails", func(t *testing.T) {
+		saver := &saverMock{}
+		receiver := New(Config{})
+		request := func(value float64) *prompb.WriteRequest {
+			return &prompb.WriteRequest{Timeseries: []*prompb.TimeSeries{newSeries("jobs_total", nil, value)}}
+		}
+
+		require.NoError(t, receiver.Write(ctx, saver, request(10)))
+
+		saver.err = errors.New("storage is down")
+		assert.Error(t, receiver.Write(ctx, saver, request(12)))
+
+		saver.err = nil
+		require.NoError(t, receiver.Write(ctx, saver, request(12)))
+
+		require.Len(t, saver.saved, 2)
+		assert.Equal(t, int64(2), *saver.saved[1][0].Delta)
+	})
+
+	t.Run("Should forget counters that weren't updated longer than ttl", func(t *testing.T) {
+		saver := &saverMock{}
+		receiver := New(Config{})
+		now := time.Unix(0, 0)
+		receiver.now = func() time.Time { return now }
+		request := func(name string, value float64) *prompb.WriteRequest {
+			return &prompb.WriteRequest{Timeseries: []*prompb.TimeSeries{newSeries(name, nil, value)}}
+		}
+
+		require.NoError(t, receiver.Write(ctx, saver, request("jobs_total", 10)))
+
+		now = now.Add(counterTTL / 2)
+		require.NoError(t, receiver.Write(ctx, saver, request("errors_total", 1)))
+
+		now = now.Add(counterTTL)
+		require.NoError(t, receiver.Write(ctx, saver, request("errors_total", 3)))
+
+		assert.NotContains(t, receiver.counters, "jobs_total")
+		assert.Contains(t, receiver.counters, "errors_total")
+
+		require.NoError(t, receiver.Write(ctx, saver, request("jobs_total", 12)))
+		assert.Equal(t, int64(12), *saver.saved[3][0].Delta)
+	})
+
+	t.Run("Should use configured type rules", func(t *testing.T) {
+		saver := &saverMock{}
+		receiver := New(Config{TypeRules: map[string]string{"_seconds": models.CounterMetricType}, DefaultType: models.GaugeMetricType})
+
+		err := receiver.Write(ctx, saver, &prompb.WriteRequest{
+			Timeseries: []*prompb.TimeSeries{
+				newSeries("uptime_seconds", nil, 7),
+				newSeries("requests_total", nil, 3),
+			},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, models.CounterMetricType, saver.saved[0][0].MType)
+		assert.Equal(t, models.GaugeMetricType, saver.saved[0][1].MType)
+	})
+
+	t.Run("Should replace reserved characters in metric name", func(t *testing.T) {
+		saver := &saverMock{}
+
+		err := New(Config{}).Write(ctx, saver, &prompb.WriteRequest{
+			Timeseries: []*prompb.TimeSeries{newSeries(`cpu{host="a"}`, nil, 1)},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "cpu_host__a__", saver.saved[0][0].ID)
+	})
+
+	t.Run("Should return error for series without name", func(t *testing.T) {
+		err := New(Config{}).Write(ctx, &saverMock{}, &prompb.WriteRequest{
+			Timeseries: []*prompb.TimeSeries{{Samples: []*prompb.Sample{{Value: 1}}}},
+		})
+
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+	})
+}
@@ -0,0 +1,67 @@
+// Package retention предоставляет фоновое удаление значений истории метрик старше срока хранения.
+package retention
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/daremove/go-metrics-service/internal/logger"
+)
+
+// Значения по умолчанию для хранения истории метрик.
+const (
+	DefaultRetention     = 7 * 24 * time.Hour
+	DefaultPruneInterval = 10 * time.Minute
+)
+
+// Storage определяет метод хранилища, необходимый для удаления истории метрик.
+type Storage interface {
+	DeleteMetricSamplesBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+// Pruner периодически удаляет из хранилища значения истории метрик старше срока хранения.
+type Pruner struct {
+	storage   Storage
+	retention time.Duration
+	interval  time.Duration
+	now       func() time.Time
+}
+
+// NewPruner создает новый экземпляр Pruner.
+func NewPruner(storage Storage, retention, interval time.Duration) *Pruner {
+	return &Pruner{
+		storage:   storage,
+		retention: retention,
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+// Prune удаляет значения истории старше срока хранения и возвращает их количество.
+func (p *Pruner) Prune(ctx context.Context) (int, error) {
+	return p.storage.DeleteMetricSamplesBefore(ctx, p.now().Add(-p.retention))
+}
+
+// Run удаляет устаревшие значения истории сразу и затем с заданным интервалом до завершения контекста.
+func (p *Pruner) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := p.Prune(ctx)
+
+		if err != nil {
+			logger.Log.Error("error prune metric history", zap.Error(err))
+		} else if deleted > 0 {
+			logger.Log.Info("metric history was pruned", zap.Int("count", deleted))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
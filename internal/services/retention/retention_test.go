@@ -0,0 +1,47 @@
+package retention
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/storage"
+	"github.com/daremove/go-metrics-service/internal/storage/memstorage"
+)
+
+func TestPruner_Prune(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		now   = time.Date(2024, 1, 8, 10, 0, 0, 0, time.UTC)
+		store = memstorage.New()
+	)
+
+	require.NoError(t, store.AddMetricSamples(ctx, []storage.MetricSample{
+		{Name: "load", Type: models.GaugeMetricType, Value: 1, Timestamp: now.Add(-48 * time.Hour)},
+		{Name: "load", Type: models.GaugeMetricType, Value: 2, Timestamp: now.Add(-time.Hour)},
+		{Name: "requests", Type: models.CounterMetricType, Value: 3, Timestamp: now.Add(-25 * time.Hour)},
+	}))
+
+	pruner := NewPruner(store, 24*time.Hour, time.Minute)
+	pruner.now = func() time.Time { return now }
+
+	deleted, err := pruner.Prune(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	load, err := store.GetMetricSamples(ctx, models.GaugeMetricType, "load", time.Time{}, now)
+
+	require.NoError(t, err)
+	require.Len(t, load, 1)
+	assert.Equal(t, float64(2), load[0].Value)
+
+	requests, err := store.GetMetricSamples(ctx, models.CounterMetricType, "requests", time.Time{}, now)
+
+	require.NoError(t, err)
+	assert.Empty(t, requests)
+}
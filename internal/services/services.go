@@ -2,15 +2,50 @@
 // используемых для управления метрическими данными в приложении.
 package services
 
-import "errors"
+import (
+	"errors"
+	"regexp"
+	"time"
+)
 
 // ErrMetricNotFound ошибка, возвращаемая когда данные метрики не найдены.
 var ErrMetricNotFound = errors.New("metric data is not found")
 
+// ErrMetricAmbiguous ошибка, возвращаемая когда условиям поиска соответствует несколько метрик.
+var ErrMetricAmbiguous = errors.New("more than one metric matches parameters")
+
+// ErrInvalidCursor ошибка, возвращаемая когда курсор страницы не удалось разобрать.
+var ErrInvalidCursor = errors.New("cursor is invalid")
+
+// ErrInvalidParameters ошибка, возвращаемая когда параметры запроса к сервису не прошли проверку.
+var ErrInvalidParameters = errors.New("parameters are invalid")
+
+// ValidationError ошибка проверки параметров запроса к сервису. Текст ошибки совпадает
+// с текстом исходной ошибки, а errors.Is сопоставляет ее с ErrInvalidParameters.
+type ValidationError struct {
+	Err error // Исходная ошибка проверки
+}
+
+// Error возвращает текст исходной ошибки.
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap возвращает исходную ошибку проверки.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Is позволяет сравнивать ошибку с ErrInvalidParameters с помощью errors.Is.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidParameters
+}
+
 // MetricEntry представляет базовую запись метрики с именем и значением.
 type MetricEntry struct {
-	Name  string // Имя метрики
-	Value string // Значение метрики
+	Name      string    // Имя метрики
+	Value     string    // Значение метрики
+	UpdatedAt time.Time // Время последнего обновления метрики
 }
 
 // MetricSaveParameters содержит параметры для сохранения метрики.
@@ -22,6 +57,33 @@ type MetricSaveParameters struct {
 
 // MetricGetParameters содержит параметры для получения метрики.
 type MetricGetParameters struct {
-	MetricType string // Тип метрики
-	MetricName string // Имя метрики
+	MetricType string         // Тип метрики
+	MetricName string         // Имя метрики
+	Matchers   []LabelMatcher // Условия по меткам метрики
+}
+
+// MetricHistoryParameters содержит параметры для получения истории значений метрики.
+type MetricHistoryParameters struct {
+	MetricType string        // Тип метрики
+	MetricName string        // Имя метрики
+	From       time.Time     // Начало периода
+	To         time.Time     // Окончание периода
+	Step       time.Duration // Шаг агрегации значений, 0 — без агрегации
+}
+
+// MetricListParameters содержит параметры постраничного получения метрик.
+type MetricListParameters struct {
+	Types    []string       // Типы метрик, пустой список — все типы
+	Prefix   string         // Префикс имени метрики
+	Pattern  *regexp.Regexp // Регулярное выражение для имени метрики, nil — без фильтрации
+	Matchers []LabelMatcher // Условия по меткам метрики
+	Cursor   string         // Курсор страницы из предыдущего ответа, пустой — первая страница
+	Limit    int            // Максимальное количество метрик на странице
+}
+
+// MetricStreamParameters содержит условия отбора событий изменения метрик.
+type MetricStreamParameters struct {
+	Types    []string       // Типы метрик, пустой список — все типы
+	Pattern  *regexp.Regexp // Регулярное выражение для имени метрики, nil — без фильтрации
+	Matchers []LabelMatcher // Условия по меткам метрики
 }
@@ -0,0 +1,236 @@
+// Package staleness предоставляет политику устаревания метрик, которые перестали обновляться,
+// и фоновую очистку хранилища от таких метрик.
+package staleness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/daremove/go-metrics-service/internal/logger"
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/storage"
+)
+
+// Режимы обработки устаревших метрик.
+const (
+	ModeHide  = "hide"  // Устаревшие метрики скрываются из ответов, но остаются в хранилище
+	ModePurge = "purge" // Устаревшие метрики удаляются из хранилища
+)
+
+// Policy описывает время жизни метрик без обновлений.
+type Policy struct {
+	TTL       time.Duration            // Время жизни по умолчанию, 0 — метрики не устаревают
+	PrefixTTL map[string]time.Duration // Время жизни метрик, имя которых начинается с префикса
+}
+
+// Config содержит настройки обработки устаревших метрик.
+type Config struct {
+	Policy        Policy        // Время жизни метрик
+	Mode          string        // Режим обработки устаревших метрик: ModeHide или ModePurge
+	SweepInterval time.Duration // Интервал удаления устаревших метрик в режиме ModePurge
+}
+
+// Enabled сообщает, задано ли хотя бы одно время жизни.
+func (p Policy) Enabled() bool {
+	return p.TTL > 0 || len(p.PrefixTTL) > 0
+}
+
+// TTLFor возвращает время жизни метрики с указанным ключом.
+// Используется самый длинный подходящий префикс, при его отсутствии — время жизни по умолчанию.
+func (p Policy) TTLFor(key string) time.Duration {
+	ttl := p.TTL
+	matched := -1
+
+	for prefix, prefixTTL := range p.PrefixTTL {
+		if strings.HasPrefix(key, prefix) && len(prefix) > matched {
+			ttl = prefixTTL
+			matched = len(prefix)
+		}
+	}
+
+	return ttl
+}
+
+// IsStale проверяет, истекло ли время жизни метрики к моменту now.
+// Метрики без времени обновления не считаются устаревшими.
+func (p Policy) IsStale(key string, updatedAt, now time.Time) bool {
+	ttl := p.TTLFor(key)
+
+	if ttl <= 0 || updatedAt.IsZero() {
+		return false
+	}
+
+	return now.Sub(updatedAt) > ttl
+}
+
+// ParsePrefixTTL разбирает время жизни по префиксам в формате "prefix=duration,prefix=duration",
+// например: "agent_=5m,batch_=24h".
+func ParsePrefixTTL(value string) (map[string]time.Duration, error) {
+	result := map[string]time.Duration{}
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+
+		if item == "" {
+			continue
+		}
+
+		prefix, rawTTL, ok := strings.Cut(item, "=")
+
+		if !ok || prefix == "" {
+			return nil, fmt.Errorf("prefix ttl %q must be in format prefix=duration", item)
+		}
+
+		ttl, err := time.ParseDuration(rawTTL)
+
+		if err != nil {
+			return nil, fmt.Errorf("prefix ttl %q is invalid: %w", item, err)
+		}
+
+		result[prefix] = ttl
+	}
+
+	return result, nil
+}
+
+// Storage определяет методы хранилища, необходимые для очистки устаревших метрик.
+type Storage interface {
+	GetGaugeMetrics(ctx context.Context) ([]storage.GaugeMetric, error)
+	GetCounterMetrics(ctx context.Context) ([]storage.CounterMetric, error)
+	GetHistogramMetrics(ctx context.Context) ([]storage.HistogramMetric, error)
+}
+
+// Deleter определяет метод удаления метрики, которая не обновлялась после отбора на удаление.
+// Реализация должна проверять время обновления атомарно с удалением и возвращать storage.ErrDataNotFound,
+// если метрика обновлена позже или уже удалена.
+type Deleter interface {
+	DeleteStaleMetric(ctx context.Context, metricType, key string, updatedAt time.Time) error
+}
+
+// Sweeper периодически удаляет из хранилища метрики, время жизни которых истекло.
+type Sweeper struct {
+	storage  Storage
+	deleter  Deleter
+	policy   Policy
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewSweeper создает новый экземпляр Sweeper. Метрики читаются из storage, а удаляются через deleter,
+// чтобы удаление было видно подписчикам на изменения метрик.
+func NewSweeper(storage Storage, deleter Deleter, policy Policy, interval time.Duration) *Sweeper {
+	return &Sweeper{
+		storage:  storage,
+		deleter:  deleter,
+		policy:   policy,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+type staleMetric struct {
+	metricType string
+	key        string
+	updatedAt  time.Time
+}
+
+// Sweep удаляет устаревшие метрики всех типов и возвращает их количество.
+func (s *Sweeper) Sweep(ctx context.Context) (int, error) {
+	stale, err := s.findStale(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+
+	for _, item := range stale {
+		err := s.deleter.DeleteStaleMetric(ctx, item.metricType, item.key, item.updatedAt)
+
+		if err != nil {
+			if errors.Is(err, storage.ErrDataNotFound) {
+				continue
+			}
+
+			return deleted, fmt.Errorf("cannot delete stale %s metric %s: %w", item.metricType, item.key, err)
+		}
+
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// findStale собирает устаревшие метрики всех типов.
+func (s *Sweeper) findStale(ctx context.Context) ([]staleMetric, error) {
+	now := s.now()
+
+	gaugeMetrics, err := s.storage.GetGaugeMetrics(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	counterMetrics, err := s.storage.GetCounterMetrics(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	histogramMetrics, err := s.storage.GetHistogramMetrics(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []staleMetric
+
+	for _, item := range gaugeMetrics {
+		if s.policy.IsStale(item.Name, item.UpdatedAt, now) {
+			result = append(result, staleMetric{models.GaugeMetricType, item.Name, item.UpdatedAt})
+		}
+	}
+
+	for _, item := range counterMetrics {
+		if s.policy.IsStale(item.Name, item.UpdatedAt, now) {
+			result = append(result, staleMetric{models.CounterMetricType, item.Name, item.UpdatedAt})
+		}
+	}
+
+	for _, item := range histogramMetrics {
+		if s.policy.IsStale(item.Name, item.UpdatedAt, now) {
+			result = append(result, staleMetric{models.HistogramMetricType, item.Name, item.UpdatedAt})
+		}
+	}
+
+	return result, nil
+}
+
+// Run запускает периодическую очистку до отмены контекста.
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.Sweep(ctx)
+
+			if err != nil {
+				logger.Log.Error("error sweep stale metrics", zap.Error(err))
+				continue
+			}
+
+			if deleted > 0 {
+				logger.Log.Info("stale metrics were purged", zap.Int("count", deleted))
+			}
+		}
+	}
+}
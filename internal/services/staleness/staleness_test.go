@@ -0,0 +1,165 @@
+package staleness
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/daremove/go-metrics-service/internal/storage"
+	"github.com/daremove/go-metrics-service/internal/storage/memstorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy(t *testing.T) {
+	policy := Policy{
+		TTL: time.Minute,
+		PrefixTTL: map[string]time.Duration{
+			"agent_":      5 * time.Minute,
+			"agent_disk_": time.Hour,
+			"static_":     0,
+		},
+	}
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		testName  string
+		key       string
+		updatedAt time.Time
+		expected  bool
+	}{
+		{
+			testName:  "Should use default ttl",
+			key:       "cpu",
+			updatedAt: now.Add(-2 * time.Minute),
+			expected:  true,
+		},
+		{
+			testName:  "Should use prefix ttl",
+			key:       "agent_cpu",
+			updatedAt: now.Add(-2 * time.Minute),
+			expected:  false,
+		},
+		{
+			testName:  "Should use the longest prefix",
+			key:       "agent_disk_free",
+			updatedAt: now.Add(-30 * time.Minute),
+			expected:  false,
+		},
+		{
+			testName:  "Should never expire metrics with zero prefix ttl",
+			key:       "static_version",
+			updatedAt: now.Add(-24 * time.Hour),
+			expected:  false,
+		},
+		{
+			testName:  "Should not expire metrics without update time",
+			key:       "cpu",
+			updatedAt: time.Time{},
+			expected:  false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			assert.Equal(t, tc.expected, policy.IsStale(tc.key, tc.updatedAt, now))
+		})
+	}
+}
+
+func TestParsePrefixTTL(t *testing.T) {
+	t.Run("Should parse prefix ttl list", func(t *testing.T) {
+		result, err := ParsePrefixTTL("agent_=5m, batch_=24h,")
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]time.Duration{"agent_": 5 * time.Minute, "batch_": 24 * time.Hour}, result)
+	})
+
+	t.Run("Should return error if item format is invalid", func(t *testing.T) {
+		_, err := ParsePrefixTTL("agent_")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("Should return error if duration is invalid", func(t *testing.T) {
+		_, err := ParsePrefixTTL("agent_=soon")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestSweeper(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should purge stale metrics of all types", func(t *testing.T) {
+		memStore := memstorage.New()
+
+		require.NoError(t, memStore.AddGaugeMetric(ctx, "gauge", 1))
+		require.NoError(t, memStore.AddCounterMetric(ctx, "counter", 1))
+		require.NoError(t, memStore.AddHistogramMetric(ctx, "histogram", storage.HistogramMetric{Bounds: []float64{1}, Counts: []uint64{1, 0}, Sum: 0.5, Count: 1}))
+		require.NoError(t, memStore.AddGaugeMetric(ctx, "static_gauge", 1))
+
+		sweeper := NewSweeper(memStore, memStore, Policy{TTL: time.Minute, PrefixTTL: map[string]time.Duration{"static_": 0}}, time.Second)
+		sweeper.now = func() time.Time { return time.Now().Add(time.Hour) }
+
+		deleted, err := sweeper.Sweep(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, deleted)
+
+		gauges, err := memStore.GetGaugeMetrics(ctx)
+		require.NoError(t, err)
+		require.Len(t, gauges, 1)
+		assert.Equal(t, "static_gauge", gauges[0].Name)
+	})
+
+	t.Run("Should keep fresh metrics", func(t *testing.T) {
+		memStore := memstorage.New()
+
+		require.NoError(t, memStore.AddGaugeMetric(ctx, "gauge", 1))
+
+		deleted, err := NewSweeper(memStore, memStore, Policy{TTL: time.Minute}, time.Second).Sweep(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, deleted)
+	})
+	t.Run("Should keep metric updated after it was found stale", func(t *testing.T) {
+		memStore := memstorage.New()
+
+		require.NoError(t, memStore.AddGaugeMetric(ctx, "gauge", 1))
+		require.NoError(t, memStore.AddGaugeMetric(ctx, "other", 1))
+
+		deleter := updatingDeleter{MemStorage: memStore, key: "gauge"}
+		sweeper := NewSweeper(memStore, deleter, Policy{TTL: time.Minute}, time.Second)
+		sweeper.now = func() time.Time { return time.Now().Add(time.Hour) }
+
+		deleted, err := sweeper.Sweep(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, deleted)
+
+		gauges, err := memStore.GetGaugeMetrics(ctx)
+		require.NoError(t, err)
+		require.Len(t, gauges, 1)
+		assert.Equal(t, "gauge", gauges[0].Name)
+		assert.Equal(t, 2.0, gauges[0].Value)
+	})
+}
+
+// updatingDeleter обновляет метрику перед удалением, имитируя запись между ее отбором и удалением.
+type updatingDeleter struct {
+	*memstorage.MemStorage
+	key string
+}
+
+func (d updatingDeleter) DeleteStaleMetric(ctx context.Context, metricType, key string, updatedAt time.Time) error {
+	if key == d.key {
+		time.Sleep(time.Millisecond)
+
+		if err := d.AddGaugeMetric(ctx, key, 2); err != nil {
+			return err
+		}
+	}
+
+	return d.MemStorage.DeleteStaleMetric(ctx, metricType, key, updatedAt)
+}
@@ -0,0 +1,112 @@
+package statsd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/daremove/go-metrics-service/internal/services"
+)
+
+// Типы метрик протокола StatsD.
+const (
+	CounterType = "c"
+	GaugeType   = "g"
+)
+
+// ErrMalformedLine ошибка, возникающая при разборе некорректной строки протокола StatsD.
+var ErrMalformedLine = errors.New("statsd line is malformed")
+
+// Sample описывает одно значение из строки протокола StatsD.
+type Sample struct {
+	Name     string            // Имя метрики
+	Type     string            // Тип метрики: CounterType или GaugeType
+	Value    float64           // Значение; для счетчика уже поделено на частоту выборки
+	Relative bool              // Признак относительного изменения gauge: "+3" или "-3"
+	Labels   map[string]string // Метки из тегов вида "|#tag:value"
+}
+
+// ParseLine разбирает строку вида "name:value|type[|@rate][|#tag:value,...]".
+// Для gauge значение со знаком "+" или "-" означает изменение текущего значения.
+// Зарезервированные символы в имени метрики заменяются на "_". Значения NaN и Inf, а также
+// значения счетчика, не помещающиеся в int64, считаются некорректными.
+func ParseLine(line string) (Sample, error) {
+	name, rest, ok := strings.Cut(line, ":")
+
+	if !ok || name == "" {
+		return Sample{}, fmt.Errorf("%w: %q has no metric name", ErrMalformedLine, line)
+	}
+
+	parts := strings.Split(rest, "|")
+
+	if len(parts) < 2 {
+		return Sample{}, fmt.Errorf("%w: %q has no metric type", ErrMalformedLine, line)
+	}
+
+	sample := Sample{Name: services.SanitizeMetricName(name), Type: parts[1]}
+	rate := 1.0
+
+	for _, part := range parts[2:] {
+		switch {
+		case strings.HasPrefix(part, "@"):
+			v, err := strconv.ParseFloat(part[1:], 64)
+
+			if err != nil || v <= 0 || v > 1 {
+				return Sample{}, fmt.Errorf("%w: %q has invalid sample rate", ErrMalformedLine, line)
+			}
+
+			rate = v
+		case strings.HasPrefix(part, "#"):
+			labels, err := parseTags(part[1:])
+
+			if err != nil {
+				return Sample{}, fmt.Errorf("%w: %q: %v", ErrMalformedLine, line, err)
+			}
+
+			sample.Labels = labels
+		default:
+			return Sample{}, fmt.Errorf("%w: %q has unknown section %q", ErrMalformedLine, line, part)
+		}
+	}
+
+	value, err := strconv.ParseFloat(parts[0], 64)
+
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return Sample{}, fmt.Errorf("%w: %q has invalid value", ErrMalformedLine, line)
+	}
+
+	switch sample.Type {
+	case CounterType:
+		sample.Value = value / rate
+
+		if _, ok := services.CounterDelta(sample.Value); !ok {
+			return Sample{}, fmt.Errorf("%w: %q has counter value out of range", ErrMalformedLine, line)
+		}
+	case GaugeType:
+		sample.Value = value
+		sample.Relative = strings.HasPrefix(parts[0], "+") || strings.HasPrefix(parts[0], "-")
+	default:
+		return Sample{}, fmt.Errorf("%w: %q has unsupported type %q", ErrMalformedLine, line, sample.Type)
+	}
+
+	return sample, nil
+}
+
+// parseTags разбирает теги DogStatsD вида "tag:value,tag:value" в метки.
+func parseTags(value string) (map[string]string, error) {
+	labels := map[string]string{}
+
+	for _, tag := range strings.Split(value, ",") {
+		name, tagValue, _ := strings.Cut(tag, ":")
+
+		if err := services.ValidateLabelName(name); err != nil {
+			return nil, err
+		}
+
+		labels[name] = tagValue
+	}
+
+	return labels, nil
+}
@@ -0,0 +1,122 @@
+package statsd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	testCases := []struct {
+		testName string
+		line     string
+		expected Sample
+		hasError bool
+	}{
+		{
+			testName: "Should parse counter",
+			line:     "requests:1|c",
+			expected: Sample{Name: "requests", Type: CounterType, Value: 1},
+		},
+		{
+			testName: "Should scale counter by sample rate",
+			line:     "requests:2|c|@0.5",
+			expected: Sample{Name: "requests", Type: CounterType, Value: 4},
+		},
+		{
+			testName: "Should parse gauge",
+			line:     "temperature:42.5|g",
+			expected: Sample{Name: "temperature", Type: GaugeType, Value: 42.5},
+		},
+		{
+			testName: "Should parse relative gauge increment",
+			line:     "connections:+3|g",
+			expected: Sample{Name: "connections", Type: GaugeType, Value: 3, Relative: true},
+		},
+		{
+			testName: "Should parse relative gauge decrement",
+			line:     "connections:-2|g",
+			expected: Sample{Name: "connections", Type: GaugeType, Value: -2, Relative: true},
+		},
+		{
+			testName: "Should parse tags as labels",
+			line:     "requests:1|c|#host:a,dc:eu",
+			expected: Sample{Name: "requests", Type: CounterType, Value: 1, Labels: map[string]string{"host": "a", "dc": "eu"}},
+		},
+		{
+			testName: "Should replace reserved characters in name",
+			line:     "cpu{host=a}:1|c",
+			expected: Sample{Name: "cpu_host_a_", Type: CounterType, Value: 1},
+		},
+		{
+			testName: "Should return error if name is missing",
+			line:     ":1|c",
+			hasError: true,
+		},
+		{
+			testName: "Should return error if type is missing",
+			line:     "requests:1",
+			hasError: true,
+		},
+		{
+			testName: "Should return error if value is invalid",
+			line:     "requests:abc|c",
+			hasError: true,
+		},
+		{
+			testName: "Should return error if counter value is NaN",
+			line:     "requests:NaN|c",
+			hasError: true,
+		},
+		{
+			testName: "Should return error if counter value is infinite",
+			line:     "requests:Inf|c",
+			hasError: true,
+		},
+		{
+			testName: "Should return error if counter value is out of int64 range",
+			line:     "requests:1e300|c",
+			hasError: true,
+		},
+		{
+			testName: "Should return error if scaled counter value is out of int64 range",
+			line:     "requests:9e18|c|@0.5",
+			hasError: true,
+		},
+		{
+			testName: "Should return error if gauge value is infinite",
+			line:     "temperature:Inf|g",
+			hasError: true,
+		},
+		{
+			testName: "Should return error if type is unsupported",
+			line:     "latency:320|ms",
+			hasError: true,
+		},
+		{
+			testName: "Should return error if sample rate is out of range",
+			line:     "requests:1|c|@2",
+			hasError: true,
+		},
+		{
+			testName: "Should return error if tag name is invalid",
+			line:     "requests:1|c|#1host:a",
+			hasError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			result, err := ParseLine(tc.line)
+
+			if tc.hasError {
+				require.ErrorIs(t, err, ErrMalformedLine)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
@@ -0,0 +1,409 @@
+// Package statsd предоставляет прием метрик по протоколу StatsD через UDP:
+// строки протокола разбираются, агрегируются в памяти и сохраняются через сервис метрик
+// раз в интервал сброса.
+package statsd
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/daremove/go-metrics-service/internal/logger"
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/services"
+	"github.com/daremove/go-metrics-service/internal/storage"
+)
+
+// DefaultFlushInterval интервал сброса агрегированных значений по умолчанию.
+const DefaultFlushInterval = 10 * time.Second
+
+// DefaultMaxSeries максимальное количество агрегируемых метрик по умолчанию.
+const DefaultMaxSeries = 10000
+
+// seriesTTL время, после которого из агрегатора удаляется метрика, не получавшая новых значений.
+const seriesTTL = time.Hour
+
+// maxPacketSize максимальный размер UDP-пакета.
+const maxPacketSize = 65535
+
+// Config содержит настройки приема метрик StatsD.
+type Config struct {
+	Address       string        // Адрес UDP, например ":8125"; пустой — прием отключен
+	FlushInterval time.Duration // Интервал сохранения агрегированных значений
+	MaxSeries     int           // Максимальное количество агрегируемых метрик; значения новых метрик сверх него отбрасываются
+}
+
+// Saver определяет метод сохранения метрик.
+type Saver interface {
+	SaveModels(ctx context.Context, parameters []models.Metrics) error
+}
+
+// series описывает агрегированное значение одной метрики.
+type series struct {
+	name    string
+	labels  map[string]string
+	value   float64   // Накопленное приращение счетчика либо текущее значение gauge
+	dirty   bool      // Признак изменения gauge с момента последнего сохранения
+	version uint64    // Номер изменения gauge, по которому сброс определяет, менялось ли значение во время сохранения
+	seen    time.Time // Время получения последнего значения
+}
+
+// flushedCounter описывает сохраняемое приращение счетчика.
+type flushedCounter struct {
+	key   string
+	item  *series
+	delta int64
+}
+
+// flushedGauge описывает сохраняемое значение gauge.
+type flushedGauge struct {
+	item    *series
+	version uint64
+}
+
+// Aggregator накапливает значения StatsD между сохранениями.
+// Счетчики суммируются, дробная часть суммы переносится на следующий интервал.
+// Значения gauge сохраняются между интервалами, чтобы относительные изменения применялись к последнему значению.
+// Счетчики без несохраненного остатка удаляются после сброса. Сохраненные gauge и счетчики с дробным
+// остатком, не получавшие значений дольше seriesTTL, тоже удаляются, чтобы исчезнувшие метрики
+// не занимали место в ограничении. Количество метрик ограничено maxSeries:
+// значения новых метрик сверх ограничения отбрасываются.
+type Aggregator struct {
+	mu        sync.Mutex
+	flushMu   sync.Mutex
+	counters  map[string]*series
+	gauges    map[string]*series
+	maxSeries int
+	malformed atomic.Uint64
+	dropped   atomic.Uint64
+	ttl       time.Duration
+	now       func() time.Time
+}
+
+// NewAggregator создает новый экземпляр Aggregator, агрегирующий не больше maxSeries метрик.
+func NewAggregator(maxSeries int) *Aggregator {
+	return &Aggregator{
+		counters:  map[string]*series{},
+		gauges:    map[string]*series{},
+		maxSeries: maxSeries,
+		ttl:       seriesTTL,
+		now:       time.Now,
+	}
+}
+
+// AddPacket разбирает пакет, содержащий строки протокола StatsD, и добавляет значения.
+// Некорректные строки пропускаются, учитываются в счетчике и записываются в лог.
+func (a *Aggregator) AddPacket(packet []byte) {
+	for _, line := range strings.Split(string(packet), "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" {
+			continue
+		}
+
+		sample, err := ParseLine(line)
+
+		if err != nil {
+			a.malformed.Add(1)
+			logger.Log.Warn("malformed statsd line was skipped", zap.Error(err))
+			continue
+		}
+
+		a.Add(sample)
+	}
+}
+
+// Add добавляет значение. Значение, с которым накопленное приращение счетчика перестает помещаться
+// в int64 либо значение gauge становится бесконечным, отбрасывается.
+func (a *Aggregator) Add(sample Sample) {
+	key := storage.SeriesKey(sample.Name, sample.Labels)
+	now := a.now()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch sample.Type {
+	case CounterType:
+		item, ok := a.counters[key]
+
+		if !ok {
+			if !a.reserve(key) {
+				return
+			}
+
+			item = &series{name: sample.Name, labels: sample.Labels}
+			a.counters[key] = item
+		}
+
+		if _, ok := services.CounterDelta(item.value + sample.Value); !ok {
+			a.overflow(key)
+			return
+		}
+
+		item.value += sample.Value
+		item.seen = now
+	case GaugeType:
+		item, ok := a.gauges[key]
+
+		if !ok {
+			if !a.reserve(key) {
+				return
+			}
+
+			item = &series{name: sample.Name, labels: sample.Labels}
+			a.gauges[key] = item
+		}
+
+		value := sample.Value
+
+		if sample.Relative {
+			value += item.value
+		}
+
+		if math.IsInf(value, 0) {
+			a.overflow(key)
+			return
+		}
+
+		item.value = value
+		item.dirty = true
+		item.version++
+		item.seen = now
+	}
+}
+
+// overflow учитывает значение, переполнившее накопленное значение метрики, в счетчике отброшенных
+// и записывает его в лог.
+func (a *Aggregator) overflow(key string) {
+	a.dropped.Add(1)
+	logger.Log.Warn("statsd value overflows accumulated value, value was dropped", zap.String("key", key))
+}
+
+// reserve проверяет, можно ли добавить новую метрику. Если ограничение достигнуто,
+// значение учитывается в счетчике отброшенных и записывается в лог.
+func (a *Aggregator) reserve(key string) bool {
+	if len(a.counters)+len(a.gauges) < a.maxSeries {
+		return true
+	}
+
+	a.dropped.Add(1)
+	logger.Log.Warn("statsd series limit is reached, value was dropped", zap.String("key", key), zap.Int("max_series", a.maxSeries))
+
+	return false
+}
+
+// Malformed возвращает количество пропущенных некорректных строк.
+func (a *Aggregator) Malformed() uint64 {
+	return a.malformed.Load()
+}
+
+// Dropped возвращает количество значений, отброшенных из-за ограничения количества метрик
+// или переполнения накопленного значения.
+func (a *Aggregator) Dropped() uint64 {
+	return a.dropped.Load()
+}
+
+// Flush сохраняет накопленные значения. Блокировка удерживается только при отборе и обновлении
+// значений, поэтому пакеты принимаются и во время сохранения. Если сохранить не удалось, значения
+// остаются в агрегаторе и будут сохранены при следующем сбросе.
+func (a *Aggregator) Flush(ctx context.Context, saver Saver) error {
+	a.flushMu.Lock()
+	defer a.flushMu.Unlock()
+
+	metrics, counters, gauges := a.collect()
+
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	if err := saver.SaveModels(ctx, metrics); err != nil {
+		return err
+	}
+
+	a.commit(counters, gauges)
+
+	return nil
+}
+
+// collect удаляет давно не обновлявшиеся метрики и отбирает значения для сохранения:
+// целую часть накопленных приращений счетчиков и изменившиеся значения gauge.
+func (a *Aggregator) collect() ([]models.Metrics, []flushedCounter, []flushedGauge) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.evictSeries(a.now())
+
+	var (
+		metrics  []models.Metrics
+		counters []flushedCounter
+		gauges   []flushedGauge
+	)
+
+	for key, item := range a.counters {
+		delta := int64(math.Trunc(item.value))
+
+		if delta == 0 {
+			continue
+		}
+
+		metrics = append(metrics, models.Metrics{ID: item.name, MType: models.CounterMetricType, Delta: &delta, Labels: item.labels})
+		counters = append(counters, flushedCounter{key: key, item: item, delta: delta})
+	}
+
+	for _, item := range a.gauges {
+		if !item.dirty {
+			continue
+		}
+
+		value := item.value
+		metrics = append(metrics, models.Metrics{ID: item.name, MType: models.GaugeMetricType, Value: &value, Labels: item.labels})
+		gauges = append(gauges, flushedGauge{item: item, version: item.version})
+	}
+
+	sort.Slice(metrics, func(i, j int) bool {
+		if metrics[i].MType != metrics[j].MType {
+			return metrics[i].MType < metrics[j].MType
+		}
+
+		return storage.SeriesKey(metrics[i].ID, metrics[i].Labels) < storage.SeriesKey(metrics[j].ID, metrics[j].Labels)
+	})
+
+	return metrics, counters, gauges
+}
+
+// evictSeries удаляет счетчики с дробным остатком и сохраненные gauge, не получавшие значений дольше ttl.
+// Несохраненные значения не удаляются, относительное изменение удаленного gauge применяется к нулю.
+func (a *Aggregator) evictSeries(now time.Time) {
+	for key, item := range a.counters {
+		if now.Sub(item.seen) > a.ttl && math.Abs(item.value) < 1 {
+			delete(a.counters, key)
+		}
+	}
+
+	for key, item := range a.gauges {
+		if now.Sub(item.seen) > a.ttl && !item.dirty {
+			delete(a.gauges, key)
+		}
+	}
+}
+
+// commit вычитает сохраненные приращения счетчиков, удаляя счетчики без остатка,
+// и снимает признак изменения с gauge, которые не менялись во время сохранения.
+func (a *Aggregator) commit(counters []flushedCounter, gauges []flushedGauge) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, flushed := range counters {
+		flushed.item.value -= float64(flushed.delta)
+
+		if flushed.item.value == 0 && a.counters[flushed.key] == flushed.item {
+			delete(a.counters, flushed.key)
+		}
+	}
+
+	for _, flushed := range gauges {
+		if flushed.item.version == flushed.version {
+			flushed.item.dirty = false
+		}
+	}
+}
+
+// Listener принимает пакеты StatsD по UDP и периодически сохраняет агрегированные значения.
+type Listener struct {
+	config     Config
+	saver      Saver
+	aggregator *Aggregator
+}
+
+// New создает новый экземпляр Listener.
+func New(config Config, saver Saver) *Listener {
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultFlushInterval
+	}
+
+	if config.MaxSeries <= 0 {
+		config.MaxSeries = DefaultMaxSeries
+	}
+
+	return &Listener{
+		config:     config,
+		saver:      saver,
+		aggregator: NewAggregator(config.MaxSeries),
+	}
+}
+
+// ListenAndServe открывает UDP-порт из настроек и принимает пакеты до отмены контекста.
+func (l *Listener) ListenAndServe(ctx context.Context) error {
+	conn, err := net.ListenPacket("udp", l.config.Address)
+
+	if err != nil {
+		return err
+	}
+
+	return l.Serve(ctx, conn)
+}
+
+// Serve принимает пакеты из соединения до отмены контекста. При остановке соединение закрывается,
+// а накопленные значения сохраняются.
+func (l *Listener) Serve(ctx context.Context, conn net.PacketConn) error {
+	done := make(chan error, 1)
+
+	go func() {
+		buf := make([]byte, maxPacketSize)
+
+		for {
+			n, _, err := conn.ReadFrom(buf)
+
+			if err != nil {
+				done <- err
+				return
+			}
+
+			l.aggregator.AddPacket(buf[:n])
+		}
+	}()
+
+	ticker := time.NewTicker(l.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+			<-done
+			l.flush(context.WithoutCancel(ctx))
+
+			return nil
+		case err := <-done:
+			conn.Close()
+			l.flush(context.WithoutCancel(ctx))
+
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return err
+		case <-ticker.C:
+			l.flush(ctx)
+		}
+	}
+}
+
+// Malformed возвращает количество пропущенных некорректных строк.
+func (l *Listener) Malformed() uint64 {
+	return l.aggregator.Malformed()
+}
+
+func (l *Listener) flush(ctx context.Context) {
+	if err := l.aggregator.Flush(ctx, l.saver); err != nil {
+		logger.Log.Error("error save statsd metrics", zap.Error(err))
+	}
+}
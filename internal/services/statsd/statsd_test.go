@@ -0,0 +1,275 @@
+package statsd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+)
+
+type saverMock struct {
+	mu     sync.Mutex
+	saved  [][]models.Metrics
+	err    error
+	onSave func()
+}
+
+func (m *saverMock) SaveModels(_ context.Context, parameters []models.Metrics) error {
+	if m.onSave != nil {
+		m.onSave()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return m.err
+	}
+
+	m.saved = append(m.saved, parameters)
+
+	return nil
+}
+
+func (m *saverMock) batches() [][]models.Metrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([][]models.Metrics(nil), m.saved...)
+}
+
+func counter(name string, delta int64) models.Metrics {
+	return models.Metrics{ID: name, MType: models.CounterMetricType, Delta: &delta}
+}
+
+func gauge(name string, value float64) models.Metrics {
+	return models.Metrics{ID: name, MType: models.GaugeMetricType, Value: &value}
+}
+
+func TestAggregator(t *testing.T) {
+	t.Run("Should aggregate counters and gauges between flushes", func(t *testing.T) {
+		aggregator := NewAggregator(DefaultMaxSeries)
+		saver := &saverMock{}
+
+		aggregator.AddPacket([]byte("requests:1|c\nrequests:2|c|@0.5\nconnections:10|g\nconnections:+3|g\n"))
+
+		require.NoError(t, aggregator.Flush(context.Background(), saver))
+		assert.Equal(t, [][]models.Metrics{{counter("requests", 5), gauge("connections", 13)}}, saver.batches())
+	})
+
+	t.Run("Should apply relative gauge to last known value", func(t *testing.T) {
+		aggregator := NewAggregator(DefaultMaxSeries)
+		saver := &saverMock{}
+
+		aggregator.AddPacket([]byte("connections:10|g"))
+		require.NoError(t, aggregator.Flush(context.Background(), saver))
+
+		aggregator.AddPacket([]byte("connections:-4|g"))
+		require.NoError(t, aggregator.Flush(context.Background(), saver))
+
+		assert.Equal(t, [][]models.Metrics{{gauge("connections", 10)}, {gauge("connections", 6)}}, saver.batches())
+	})
+
+	t.Run("Should not save unchanged values", func(t *testing.T) {
+		aggregator := NewAggregator(DefaultMaxSeries)
+		saver := &saverMock{}
+
+		aggregator.AddPacket([]byte("requests:1|c\nconnections:10|g"))
+		require.NoError(t, aggregator.Flush(context.Background(), saver))
+		require.NoError(t, aggregator.Flush(context.Background(), saver))
+
+		assert.Len(t, saver.batches(), 1)
+	})
+
+	t.Run("Should carry fractional counter remainder to next flush", func(t *testing.T) {
+		aggregator := NewAggregator(DefaultMaxSeries)
+		saver := &saverMock{}
+
+		aggregator.AddPacket([]byte("requests:1|c|@0.4"))
+		require.NoError(t, aggregator.Flush(context.Background(), saver))
+
+		aggregator.AddPacket([]byte("requests:1|c|@0.4"))
+		require.NoError(t, aggregator.Flush(context.Background(), saver))
+
+		assert.Equal(t, [][]models.Metrics{{counter("requests", 2)}, {counter("requests", 3)}}, saver.batches())
+	})
+
+	t.Run("Should keep values if save failed", func(t *testing.T) {
+		aggregator := NewAggregator(DefaultMaxSeries)
+		saver := &saverMock{err: errors.New("storage is unavailable")}
+
+		aggregator.AddPacket([]byte("requests:3|c"))
+		require.Error(t, aggregator.Flush(context.Background(), saver))
+
+		saver.err = nil
+		aggregator.AddPacket([]byte("requests:2|c"))
+		require.NoError(t, aggregator.Flush(context.Background(), saver))
+
+		assert.Equal(t, [][]models.Metrics{{counter("requests", 5)}}, saver.batches())
+	})
+
+	t.Run("Should accept values while saving", func(t *testing.T) {
+		aggregator := NewAggregator(DefaultMaxSeries)
+		saver := &saverMock{}
+		saver.onSave = func() {
+			saver.onSave = nil
+			aggregator.AddPacket([]byte("requests:2|c\nconnections:7|g"))
+		}
+
+		aggregator.AddPacket([]byte("requests:1|c\nconnections:5|g"))
+		require.NoError(t, aggregator.Flush(context.Background(), saver))
+		require.NoError(t, aggregator.Flush(context.Background(), saver))
+
+		assert.Equal(t, [][]models.Metrics{
+			{counter("requests", 1), gauge("connections", 5)},
+			{counter("requests", 2), gauge("connections", 7)},
+		}, saver.batches())
+	})
+
+	t.Run("Should drop values of new series over limit", func(t *testing.T) {
+		aggregator := NewAggregator(2)
+		saver := &saverMock{}
+
+		aggregator.AddPacket([]byte("requests:1|c\nconnections:5|g\nerrors:1|c\nrequests:1|c"))
+		require.NoError(t, aggregator.Flush(context.Background(), saver))
+
+		assert.Equal(t, uint64(1), aggregator.Dropped())
+		assert.Equal(t, [][]models.Metrics{{counter("requests", 2), gauge("connections", 5)}}, saver.batches())
+
+		aggregator.AddPacket([]byte("errors:1|c"))
+		require.NoError(t, aggregator.Flush(context.Background(), saver))
+
+		assert.Equal(t, uint64(1), aggregator.Dropped())
+		assert.Equal(t, []models.Metrics{counter("errors", 1)}, saver.batches()[1])
+	})
+
+	t.Run("Should drop values overflowing accumulated value", func(t *testing.T) {
+		aggregator := NewAggregator(DefaultMaxSeries)
+		saver := &saverMock{}
+
+		aggregator.AddPacket([]byte("requests:9e18|c\nrequests:9e18|c\nconnections:1e308|g\nconnections:+1e308|g"))
+		require.NoError(t, aggregator.Flush(context.Background(), saver))
+
+		assert.Equal(t, uint64(2), aggregator.Dropped())
+		assert.Equal(t, [][]models.Metrics{{counter("requests", 9e18), gauge("connections", 1e308)}}, saver.batches())
+	})
+
+	t.Run("Should evict series without new values after TTL", func(t *testing.T) {
+		aggregator := NewAggregator(2)
+		saver := &saverMock{}
+		now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+		aggregator.now = func() time.Time { return now }
+
+		aggregator.AddPacket([]byte("requests:1|c|@0.4\nconnections:5|g"))
+		require.NoError(t, aggregator.Flush(context.Background(), saver))
+
+		now = now.Add(seriesTTL / 2)
+		require.NoError(t, aggregator.Flush(context.Background(), saver))
+		assert.Len(t, aggregator.gauges, 1)
+		assert.Len(t, aggregator.counters, 1)
+
+		now = now.Add(seriesTTL)
+		require.NoError(t, aggregator.Flush(context.Background(), saver))
+		assert.Empty(t, aggregator.gauges)
+		assert.Empty(t, aggregator.counters)
+
+		aggregator.AddPacket([]byte("errors:1|c\nqueue:3|g"))
+		require.NoError(t, aggregator.Flush(context.Background(), saver))
+
+		assert.Equal(t, uint64(0), aggregator.Dropped())
+		assert.Equal(t, []models.Metrics{counter("errors", 1), gauge("queue", 3)}, saver.batches()[1])
+	})
+
+	t.Run("Should count malformed lines and keep valid ones", func(t *testing.T) {
+		aggregator := NewAggregator(DefaultMaxSeries)
+		saver := &saverMock{}
+
+		aggregator.AddPacket([]byte("requests:1|c\nbroken\nlatency:3|ms\n\nrequests:1|c"))
+		require.NoError(t, aggregator.Flush(context.Background(), saver))
+
+		assert.Equal(t, uint64(2), aggregator.Malformed())
+		assert.Equal(t, [][]models.Metrics{{counter("requests", 2)}}, saver.batches())
+	})
+
+	t.Run("Should keep labels from tags", func(t *testing.T) {
+		aggregator := NewAggregator(DefaultMaxSeries)
+		saver := &saverMock{}
+
+		aggregator.AddPacket([]byte("requests:1|c|#host:a\nrequests:1|c|#host:b\nrequests:1|c|#host:a"))
+		require.NoError(t, aggregator.Flush(context.Background(), saver))
+
+		first, second := counter("requests", 2), counter("requests", 1)
+		first.Labels = map[string]string{"host": "a"}
+		second.Labels = map[string]string{"host": "b"}
+
+		assert.Equal(t, [][]models.Metrics{{first, second}}, saver.batches())
+	})
+}
+
+func TestListener_Serve(t *testing.T) {
+	t.Run("Should receive packets over UDP and flush on shutdown", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		saver := &saverMock{}
+		listener := New(Config{FlushInterval: time.Hour}, saver)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+
+		go func() {
+			done <- listener.Serve(ctx, conn)
+		}()
+
+		client, err := net.Dial("udp", conn.LocalAddr().String())
+		require.NoError(t, err)
+		defer client.Close()
+
+		_, err = client.Write([]byte("requests:1|c\nconnections:7|g"))
+		require.NoError(t, err)
+		_, err = client.Write([]byte("requests:2|c\nbroken"))
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			return listener.Malformed() == 1
+		}, time.Second, 10*time.Millisecond)
+
+		cancel()
+		require.NoError(t, <-done)
+
+		assert.Equal(t, [][]models.Metrics{{counter("requests", 3), gauge("connections", 7)}}, saver.batches())
+	})
+
+	t.Run("Should flush periodically", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		saver := &saverMock{}
+		listener := New(Config{FlushInterval: 20 * time.Millisecond}, saver)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go func() {
+			_ = listener.Serve(ctx, conn)
+		}()
+
+		client, err := net.Dial("udp", conn.LocalAddr().String())
+		require.NoError(t, err)
+		defer client.Close()
+
+		_, err = client.Write([]byte("requests:4|c"))
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			return len(saver.batches()) == 1
+		}, time.Second, 10*time.Millisecond)
+
+		assert.Equal(t, []models.Metrics{counter("requests", 4)}, saver.batches()[0])
+	})
+}
@@ -0,0 +1,70 @@
+package webhook
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// DeliveryRecord описывает результат доставки группы уведомлений получателю.
+type DeliveryRecord struct {
+	Time          time.Time      `json:"time"`                  // Время начала доставки
+	Receiver      string         `json:"receiver"`              // Имя получателя
+	URL           string         `json:"url"`                   // Адрес получателя
+	Attempts      int            `json:"attempts"`              // Количество выполненных попыток
+	StatusCode    int            `json:"status_code,omitempty"` // Код ответа последней попытки
+	Success       bool           `json:"success"`               // Признак успешной доставки
+	Error         string         `json:"error,omitempty"`       // Ошибка последней попытки
+	Notifications []Notification `json:"notifications"`         // Доставляемые уведомления
+}
+
+// DeliveryLog определяет журнал доставки уведомлений.
+type DeliveryLog interface {
+	Append(record DeliveryRecord) error
+}
+
+// FileDeliveryLog сохраняет записи журнала доставки в файл, по одной JSON-записи на строку.
+type FileDeliveryLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileDeliveryLog открывает файл журнала доставки для дозаписи, создавая его при необходимости.
+func NewFileDeliveryLog(path string) (*FileDeliveryLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileDeliveryLog{file: file}, nil
+}
+
+// Append дописывает запись в журнал.
+func (l *FileDeliveryLog) Append(record DeliveryRecord) error {
+	data, err := json.Marshal(record)
+
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, err = l.file.Write(append(data, '\n'))
+
+	return err
+}
+
+// Close закрывает файл журнала.
+func (l *FileDeliveryLog) Close() error {
+	return l.file.Close()
+}
+
+// nopDeliveryLog используется, если журнал доставки не задан.
+type nopDeliveryLog struct{}
+
+func (nopDeliveryLog) Append(DeliveryRecord) error {
+	return nil
+}
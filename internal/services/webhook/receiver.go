@@ -0,0 +1,244 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"text/template"
+	"time"
+
+	"github.com/daremove/go-metrics-service/internal/middlewares/dataintergity"
+	"github.com/daremove/go-metrics-service/internal/utils"
+)
+
+// DefaultTemplate шаблон тела запроса, используемый, если у получателя шаблон не задан.
+const DefaultTemplate = `{"receiver":{{json .Receiver}},"notifications":{{json .Notifications}}}`
+
+// Значения по умолчанию для настроек получателя.
+const (
+	defaultMaxRetries      = 3
+	defaultBackoff         = time.Second
+	defaultTimeout         = 5 * time.Second
+	defaultDeliveryTimeout = 30 * time.Second
+)
+
+// ReceiverConfig описывает получателя уведомлений в конфигурационном файле.
+type ReceiverConfig struct {
+	Name       string `json:"name"`        // Имя получателя
+	URL        string `json:"url"`         // Адрес, на который отправляются уведомления
+	SigningKey string `json:"signing_key"` // Ключ подписи тела запроса, пустой — без подписи
+	Template   string `json:"template"`    // Шаблон тела запроса text/template, по умолчанию DefaultTemplate
+	MaxRetries *int   `json:"max_retries"` // Количество повторных попыток, по умолчанию 3
+	Backoff    string `json:"backoff"`     // Задержка перед первым повтором, удваивается с каждой попыткой, по умолчанию 1s
+	Timeout    string `json:"timeout"`     // Время ожидания ответа, по умолчанию 5s
+
+	DeliveryTimeout string `json:"delivery_timeout"` // Время доставки группы уведомлений с учетом повторов, по умолчанию 30s
+}
+
+// Payload содержит данные, доступные в шаблоне тела запроса.
+type Payload struct {
+	Receiver      string         // Имя получателя
+	Notifications []Notification // Сгруппированные уведомления
+}
+
+// receiver отправляет уведомления на один адрес.
+type receiver struct {
+	name       string
+	url        string
+	signingKey string
+	template   *template.Template
+	maxRetries int
+	backoff    time.Duration
+	client     *http.Client
+
+	deliveryTimeout time.Duration
+}
+
+// newReceiver проверяет конфигурацию получателя и создает его.
+func newReceiver(config ReceiverConfig) (*receiver, error) {
+	if config.Name == "" || config.URL == "" {
+		return nil, fmt.Errorf("webhook receiver must have name and url")
+	}
+
+	text := config.Template
+
+	if text == "" {
+		text = DefaultTemplate
+	}
+
+	tmpl, err := template.New(config.Name).Funcs(template.FuncMap{"json": toJSON}).Parse(text)
+
+	if err != nil {
+		return nil, fmt.Errorf("webhook receiver %s has invalid template: %w", config.Name, err)
+	}
+
+	result := &receiver{
+		name:       config.Name,
+		url:        config.URL,
+		signingKey: config.SigningKey,
+		template:   tmpl,
+		maxRetries: defaultMaxRetries,
+		backoff:    defaultBackoff,
+		client:     &http.Client{Timeout: defaultTimeout},
+
+		deliveryTimeout: defaultDeliveryTimeout,
+	}
+
+	if config.MaxRetries != nil {
+		if *config.MaxRetries < 0 {
+			return nil, fmt.Errorf("webhook receiver %s max retries must not be negative", config.Name)
+		}
+
+		result.maxRetries = *config.MaxRetries
+	}
+
+	durations := []struct {
+		name   string
+		value  string
+		target *time.Duration
+	}{
+		{"backoff", config.Backoff, &result.backoff},
+		{"timeout", config.Timeout, &result.client.Timeout},
+		{"delivery timeout", config.DeliveryTimeout, &result.deliveryTimeout},
+	}
+
+	for _, item := range durations {
+		if item.value == "" {
+			continue
+		}
+
+		duration, err := time.ParseDuration(item.value)
+
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("webhook receiver %s %s must be positive duration", config.Name, item.name)
+		}
+
+		*item.target = duration
+	}
+
+	return result, nil
+}
+
+// toJSON кодирует значение в JSON для использования в шаблоне.
+func toJSON(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// render формирует тело запроса по шаблону получателя.
+func (r *receiver) render(notifications []Notification) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := r.template.Execute(&buf, Payload{Receiver: r.name, Notifications: notifications}); err != nil {
+		return nil, fmt.Errorf("cannot render webhook payload for %s: %w", r.name, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// deliver отправляет уведомления, повторяя попытку с экспоненциальной задержкой
+// при сетевых ошибках, ответах 5xx и 429, но не дольше времени доставки получателя.
+// Возвращает запись журнала доставки и признак того, что неудачную доставку имеет смысл
+// повторить позже: ответы 4xx, кроме 429, и ошибки шаблона повтором не исправляются.
+func (r *receiver) deliver(ctx context.Context, notifications []Notification, now time.Time) (DeliveryRecord, bool) {
+	record := DeliveryRecord{
+		Time:          now,
+		Receiver:      r.name,
+		URL:           r.url,
+		Notifications: notifications,
+	}
+
+	body, err := r.render(notifications)
+
+	if err != nil {
+		record.Error = err.Error()
+		return record, false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.deliveryTimeout)
+	defer cancel()
+
+	backoff := r.backoff
+
+	for attempt := 0; attempt <= r.maxRetries; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(backoff)
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				record.Error = ctx.Err().Error()
+				return record, true
+			case <-timer.C:
+			}
+
+			backoff *= 2
+		}
+
+		record.Attempts++
+
+		statusCode, err := r.send(ctx, body)
+		record.StatusCode = statusCode
+
+		if err != nil {
+			record.Error = err.Error()
+			continue
+		}
+
+		switch {
+		case statusCode >= 200 && statusCode < 300:
+			record.Success = true
+			record.Error = ""
+			return record, false
+		case statusCode == http.StatusTooManyRequests || statusCode >= 500:
+			record.Error = fmt.Sprintf("unexpected status code %d", statusCode)
+		default:
+			record.Error = fmt.Sprintf("unexpected status code %d", statusCode)
+			return record, false
+		}
+	}
+
+	return record, true
+}
+
+// send выполняет один запрос к получателю.
+func (r *receiver) send(ctx context.Context, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if r.signingKey != "" {
+		signedBody, err := utils.SignData(body, r.signingKey)
+
+		if err != nil {
+			return 0, err
+		}
+
+		req.Header.Set(dataintergity.HeaderKeyHash, hex.EncodeToString(signedBody))
+	}
+
+	res, err := r.client.Do(req)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer res.Body.Close()
+
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	return res.StatusCode, nil
+}
@@ -0,0 +1,167 @@
+package webhook
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/storage"
+)
+
+// Условия наблюдения за метрикой.
+const (
+	ConditionAbove         = "above"          // Значение gauge больше порога
+	ConditionBelow         = "below"          // Значение gauge меньше порога
+	ConditionNotIncreasing = "not_increasing" // Counter не растет в течение заданного времени
+)
+
+// Состояния наблюдения, передаваемые в уведомлениях.
+const (
+	StateFiring   = "firing"   // Значение пересекло порог
+	StateResolved = "resolved" // Значение вернулось в норму
+)
+
+// WatchConfig описывает наблюдение за метрикой в конфигурационном файле.
+type WatchConfig struct {
+	Name      string   `json:"name"`      // Имя наблюдения
+	Metric    string   `json:"metric"`    // Имя метрики, при необходимости с метками: cpu{host="a"}
+	Type      string   `json:"type"`      // Тип метрики: "gauge" или "counter"
+	Condition string   `json:"condition"` // Условие: "above", "below" или "not_increasing"
+	Threshold float64  `json:"threshold"` // Порог для условий above и below
+	For       string   `json:"for"`       // Время без роста счетчика для условия not_increasing, например "1m"
+	Receivers []string `json:"receivers"` // Имена получателей, пустой список — все получатели
+}
+
+// watch описывает разобранное наблюдение и его текущее состояние.
+type watch struct {
+	name         string
+	key          string
+	metricType   string
+	condition    string
+	threshold    float64
+	window       time.Duration
+	receivers    []string
+	firing       bool
+	lastIncrease time.Time
+}
+
+// newWatch проверяет конфигурацию наблюдения и создает его.
+func newWatch(config WatchConfig, receivers map[string]*receiver, startedAt time.Time) (*watch, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("webhook watch for metric %q must have name", config.Metric)
+	}
+
+	name, labels, err := storage.ParseSeriesKey(config.Metric)
+
+	if err != nil || name == "" {
+		return nil, fmt.Errorf("webhook watch %s has invalid metric %q", config.Name, config.Metric)
+	}
+
+	result := &watch{
+		name:         config.Name,
+		key:          storage.SeriesKey(name, labels),
+		metricType:   config.Type,
+		condition:    config.Condition,
+		threshold:    config.Threshold,
+		receivers:    config.Receivers,
+		lastIncrease: startedAt,
+	}
+
+	switch {
+	case config.Type == models.GaugeMetricType && (config.Condition == ConditionAbove || config.Condition == ConditionBelow):
+	case config.Type == models.CounterMetricType && config.Condition == ConditionNotIncreasing:
+		window, err := time.ParseDuration(config.For)
+
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("webhook watch %s must have positive duration in field for", config.Name)
+		}
+
+		result.window = window
+	default:
+		return nil, fmt.Errorf("webhook watch %s: condition %q isn't supported for metric type %q", config.Name, config.Condition, config.Type)
+	}
+
+	if len(result.receivers) == 0 {
+		for name := range receivers {
+			result.receivers = append(result.receivers, name)
+		}
+
+		sort.Strings(result.receivers)
+	}
+
+	for _, name := range result.receivers {
+		if _, ok := receivers[name]; !ok {
+			return nil, fmt.Errorf("webhook watch %s refers to unknown receiver %q", config.Name, name)
+		}
+	}
+
+	return result, nil
+}
+
+// observe обрабатывает новое значение метрики и возвращает уведомление, если состояние изменилось.
+func (w *watch) observe(metric models.Metrics, now time.Time) (Notification, bool) {
+	switch w.condition {
+	case ConditionAbove, ConditionBelow:
+		if metric.Value == nil {
+			return Notification{}, false
+		}
+
+		value := *metric.Value
+		crossed := value > w.threshold
+
+		if w.condition == ConditionBelow {
+			crossed = value < w.threshold
+		}
+
+		return w.transition(crossed, value, now)
+	case ConditionNotIncreasing:
+		if metric.Delta == nil {
+			return Notification{}, false
+		}
+
+		if *metric.Delta > 0 {
+			w.lastIncrease = now
+			return w.transition(false, float64(*metric.Delta), now)
+		}
+
+		return w.check(now)
+	default:
+		return Notification{}, false
+	}
+}
+
+// check проверяет условия, зависящие от времени, и возвращает уведомление, если состояние изменилось.
+func (w *watch) check(now time.Time) (Notification, bool) {
+	if w.condition != ConditionNotIncreasing || w.firing || now.Sub(w.lastIncrease) < w.window {
+		return Notification{}, false
+	}
+
+	return w.transition(true, 0, now)
+}
+
+// transition меняет состояние наблюдения и формирует уведомление о пересечении порога.
+func (w *watch) transition(firing bool, value float64, now time.Time) (Notification, bool) {
+	if firing == w.firing {
+		return Notification{}, false
+	}
+
+	w.firing = firing
+
+	state := StateResolved
+
+	if firing {
+		state = StateFiring
+	}
+
+	return Notification{
+		Watch:     w.name,
+		Metric:    w.key,
+		Type:      w.metricType,
+		Condition: w.condition,
+		Threshold: w.threshold,
+		State:     state,
+		Value:     value,
+		Timestamp: now,
+	}, true
+}
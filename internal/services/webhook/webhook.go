@@ -0,0 +1,390 @@
+// Package webhook предоставляет наблюдение за пересечением метриками пороговых значений
+// и доставку уведомлений об этом на webhook-получатели с группировкой, ограничением частоты,
+// подписью, повторными попытками и журналом доставки.
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/daremove/go-metrics-service/internal/logger"
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/services"
+	"github.com/daremove/go-metrics-service/internal/storage"
+)
+
+// Значения по умолчанию для группировки уведомлений.
+const (
+	defaultGroupWait   = 10 * time.Second
+	defaultMinInterval = time.Minute
+)
+
+// checkInterval интервал проверки условий, зависящих от времени, и отправки накопленных уведомлений.
+const checkInterval = time.Second
+
+// maxDeliveryFailures количество неудачных доставок подряд, после которого уведомление наблюдения
+// отбрасывается, а не возвращается в очередь.
+const maxDeliveryFailures = 5
+
+// Notification описывает пересечение метрикой порогового значения.
+type Notification struct {
+	Watch     string    `json:"watch"`     // Имя наблюдения
+	Metric    string    `json:"metric"`    // Ключ метрики
+	Type      string    `json:"type"`      // Тип метрики
+	Condition string    `json:"condition"` // Условие наблюдения
+	Threshold float64   `json:"threshold"` // Порог наблюдения
+	State     string    `json:"state"`     // Состояние: "firing" или "resolved"
+	Value     float64   `json:"value"`     // Значение gauge либо приращение counter, вызвавшее переход
+	Timestamp time.Time `json:"timestamp"` // Время перехода
+}
+
+// Config содержит настройки уведомлений.
+type Config struct {
+	Receivers   []ReceiverConfig `json:"receivers"`    // Получатели уведомлений
+	Watches     []WatchConfig    `json:"watches"`      // Наблюдения за метриками
+	GroupWait   string           `json:"group_wait"`   // Время накопления уведомлений перед отправкой, по умолчанию 10s
+	MinInterval string           `json:"min_interval"` // Минимальный интервал между отправками одному получателю, по умолчанию 1m
+	DeliveryLog string           `json:"delivery_log"` // Путь к файлу журнала доставки, пустой — журнал не ведется
+}
+
+// Enabled сообщает, задано ли хотя бы одно наблюдение.
+func (c Config) Enabled() bool {
+	return len(c.Watches) > 0
+}
+
+// Source определяет источник событий изменения метрик.
+type Source interface {
+	Subscribe(parameters services.MetricStreamParameters) (<-chan models.MetricEvent, func())
+}
+
+// group накапливает уведомления для одного получателя.
+type group struct {
+	pending   map[string]Notification // Последнее уведомление каждого наблюдения, ожидающее отправки
+	since     time.Time               // Время появления первого ожидающего уведомления
+	lastSent  time.Time               // Время последней отправки
+	delivered map[string]string       // Последнее доставленное состояние каждого наблюдения
+	failures  map[string]int          // Количество неудачных доставок подряд каждого наблюдения
+}
+
+// delivery описывает группу уведомлений, готовую к отправке.
+type delivery struct {
+	receiver      *receiver
+	notifications []Notification
+}
+
+// Notifier отслеживает наблюдения за метриками и отправляет уведомления получателям.
+type Notifier struct {
+	mu          sync.Mutex
+	receivers   map[string]*receiver
+	watches     map[string][]*watch
+	groups      map[string]*group
+	groupWait   time.Duration
+	minInterval time.Duration
+	deliveryLog DeliveryLog
+	now         func() time.Time
+}
+
+// New проверяет конфигурацию и создает экземпляр Notifier.
+// Если журнал доставки не задан, записи журнала не сохраняются.
+func New(config Config, deliveryLog DeliveryLog) (*Notifier, error) {
+	if deliveryLog == nil {
+		deliveryLog = nopDeliveryLog{}
+	}
+
+	n := &Notifier{
+		receivers:   map[string]*receiver{},
+		watches:     map[string][]*watch{},
+		groups:      map[string]*group{},
+		groupWait:   defaultGroupWait,
+		minInterval: defaultMinInterval,
+		deliveryLog: deliveryLog,
+		now:         time.Now,
+	}
+
+	durations := []struct {
+		name   string
+		value  string
+		target *time.Duration
+	}{
+		{"group wait", config.GroupWait, &n.groupWait},
+		{"min interval", config.MinInterval, &n.minInterval},
+	}
+
+	for _, item := range durations {
+		if item.value == "" {
+			continue
+		}
+
+		duration, err := time.ParseDuration(item.value)
+
+		if err != nil || duration < 0 {
+			return nil, fmt.Errorf("webhook %s must be non-negative duration", item.name)
+		}
+
+		*item.target = duration
+	}
+
+	for _, receiverConfig := range config.Receivers {
+		r, err := newReceiver(receiverConfig)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := n.receivers[r.name]; ok {
+			return nil, fmt.Errorf("webhook receiver name %q is duplicated", r.name)
+		}
+
+		n.receivers[r.name] = r
+		n.groups[r.name] = &group{pending: map[string]Notification{}, delivered: map[string]string{}, failures: map[string]int{}}
+	}
+
+	startedAt := n.now()
+	names := map[string]struct{}{}
+
+	for _, watchConfig := range config.Watches {
+		w, err := newWatch(watchConfig, n.receivers, startedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := names[w.name]; ok {
+			return nil, fmt.Errorf("webhook watch name %q is duplicated", w.name)
+		}
+
+		names[w.name] = struct{}{}
+		n.watches[watchKey(w.metricType, w.key)] = append(n.watches[watchKey(w.metricType, w.key)], w)
+	}
+
+	return n, nil
+}
+
+// watchKey формирует ключ поиска наблюдений по типу и ключу метрики.
+func watchKey(metricType, key string) string {
+	return metricType + "/" + key
+}
+
+// Observe обрабатывает событие изменения метрики.
+func (n *Notifier) Observe(event models.MetricEvent) {
+	if event.Action != models.MetricUpdateAction {
+		return
+	}
+
+	now := n.now()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, w := range n.watches[watchKey(event.Metric.MType, storage.SeriesKey(event.Metric.ID, event.Metric.Labels))] {
+		if notification, ok := w.observe(event.Metric, now); ok {
+			n.enqueue(w.receivers, notification, now)
+		}
+	}
+}
+
+// Check проверяет условия, зависящие от времени, например отсутствие роста счетчика.
+func (n *Notifier) Check() {
+	now := n.now()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, watches := range n.watches {
+		for _, w := range watches {
+			if notification, ok := w.check(now); ok {
+				n.enqueue(w.receivers, notification, now)
+			}
+		}
+	}
+}
+
+// enqueue добавляет уведомление в группы получателей. Более новое уведомление наблюдения
+// заменяет ожидающее, поэтому частые переходы одного наблюдения не приводят к множеству отправок.
+func (n *Notifier) enqueue(receivers []string, notification Notification, now time.Time) {
+	for _, name := range receivers {
+		g := n.groups[name]
+
+		if len(g.pending) == 0 {
+			g.since = now
+		}
+
+		g.pending[notification.Watch] = notification
+	}
+}
+
+// Flush отправляет накопленные уведомления получателям, для которых истекло время группировки
+// и минимальный интервал между отправками. Получателям уведомления отправляются параллельно,
+// чтобы недоступный получатель не задерживал остальных. Уведомления, не изменившие последнее
+// доставленное состояние наблюдения, отбрасываются. При неудачной доставке уведомления возвращаются
+// в очередь, если повтор имеет смысл и число неудачных доставок не превысило maxDeliveryFailures.
+func (n *Notifier) Flush(ctx context.Context) {
+	now := n.now()
+	deliveries := n.collect(now)
+
+	var wg sync.WaitGroup
+
+	for _, item := range deliveries {
+		wg.Add(1)
+
+		go func(item delivery) {
+			defer wg.Done()
+
+			record, retry := item.receiver.deliver(ctx, item.notifications, now)
+
+			if err := n.deliveryLog.Append(record); err != nil {
+				logger.Log.Error("error append webhook delivery log", zap.Error(err))
+			}
+
+			if !record.Success {
+				logger.Log.Error("webhook delivery failed", zap.String("receiver", record.Receiver), zap.Int("attempts", record.Attempts), zap.String("error", record.Error))
+			}
+
+			n.complete(item, record.Success, retry)
+		}(item)
+	}
+
+	wg.Wait()
+}
+
+// collect забирает из групп уведомления, готовые к отправке.
+func (n *Notifier) collect(now time.Time) []delivery {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	names := make([]string, 0, len(n.groups))
+
+	for name := range n.groups {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var result []delivery
+
+	for _, name := range names {
+		g := n.groups[name]
+
+		for watchName, notification := range g.pending {
+			delivered, ok := g.delivered[watchName]
+
+			if !ok {
+				delivered = StateResolved
+			}
+
+			if notification.State == delivered {
+				delete(g.pending, watchName)
+			}
+		}
+
+		if len(g.pending) == 0 {
+			continue
+		}
+
+		if now.Sub(g.since) < n.groupWait || (!g.lastSent.IsZero() && now.Sub(g.lastSent) < n.minInterval) {
+			continue
+		}
+
+		notifications := make([]Notification, 0, len(g.pending))
+
+		for _, notification := range g.pending {
+			notifications = append(notifications, notification)
+		}
+
+		sort.Slice(notifications, func(i, j int) bool {
+			return notifications[i].Watch < notifications[j].Watch
+		})
+
+		g.pending = map[string]Notification{}
+		g.lastSent = now
+		result = append(result, delivery{receiver: n.receivers[name], notifications: notifications})
+	}
+
+	return result
+}
+
+// complete фиксирует результат доставки группы уведомлений. Неудачно доставленные уведомления
+// возвращаются в очередь, если retry истинно, наблюдение не получило более нового уведомления
+// и число неудачных доставок подряд не достигло maxDeliveryFailures; иначе они отбрасываются.
+func (n *Notifier) complete(item delivery, success, retry bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	g := n.groups[item.receiver.name]
+
+	for _, notification := range item.notifications {
+		if success {
+			g.delivered[notification.Watch] = notification.State
+			delete(g.failures, notification.Watch)
+			continue
+		}
+
+		g.failures[notification.Watch]++
+
+		if !retry || g.failures[notification.Watch] >= maxDeliveryFailures {
+			logger.Log.Warn("webhook notification was dropped", zap.String("receiver", item.receiver.name), zap.String("watch", notification.Watch), zap.Int("failures", g.failures[notification.Watch]))
+			delete(g.failures, notification.Watch)
+			continue
+		}
+
+		if _, ok := g.pending[notification.Watch]; ok {
+			continue
+		}
+
+		if len(g.pending) == 0 {
+			g.since = notification.Timestamp
+		}
+
+		g.pending[notification.Watch] = notification
+	}
+}
+
+// Run подписывается на изменения метрик и обрабатывает их до отмены контекста.
+// Если источник отключил подписку из-за переполнения буфера, подписка возобновляется.
+func (n *Notifier) Run(ctx context.Context, source Source) {
+	events, unsubscribe := source.Subscribe(services.MetricStreamParameters{})
+	defer func() {
+		unsubscribe()
+	}()
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	flushed := make(chan struct{}, 1)
+	flushing := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				logger.Log.Warn("webhook notifier subscription was dropped, resubscribing")
+				events, unsubscribe = source.Subscribe(services.MetricStreamParameters{})
+				continue
+			}
+
+			n.Observe(event)
+		case <-ticker.C:
+			n.Check()
+
+			if flushing {
+				continue
+			}
+
+			flushing = true
+
+			go func() {
+				n.Flush(ctx)
+				flushed <- struct{}{}
+			}()
+		case <-flushed:
+			flushing = false
+		}
+	}
+}
@@ -0,0 +1,470 @@
+package webhook
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daremove/go-metrics-service/internal/middlewares/dataintergity"
+	"github.com/daremove/go-metrics-service/internal/models"
+	"github.com/daremove/go-metrics-service/internal/services"
+	"github.com/daremove/go-metrics-service/internal/utils"
+)
+
+type webhookRequest struct {
+	body      []byte
+	signature string
+}
+
+type webhookReceiverMock struct {
+	mu       sync.Mutex
+	requests []webhookRequest
+	statuses []int
+}
+
+func (m *webhookReceiverMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests = append(m.requests, webhookRequest{body: body, signature: r.Header.Get(dataintergity.HeaderKeyHash)})
+	status := http.StatusOK
+
+	if len(m.statuses) > 0 {
+		status, m.statuses = m.statuses[0], m.statuses[1:]
+	}
+
+	w.WriteHeader(status)
+}
+
+func (m *webhookReceiverMock) received() []webhookRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]webhookRequest(nil), m.requests...)
+}
+
+// deliveryLogMock сохраняет записи журнала доставки в памяти.
+type deliveryLogMock struct {
+	mu      sync.Mutex
+	records []DeliveryRecord
+}
+
+func (m *deliveryLogMock) Append(record DeliveryRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.records = append(m.records, record)
+
+	return nil
+}
+
+func (m *deliveryLogMock) all() []DeliveryRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]DeliveryRecord(nil), m.records...)
+}
+
+func gaugeEvent(name string, value float64) models.MetricEvent {
+	return models.MetricEvent{Action: models.MetricUpdateAction, Metric: models.Metrics{ID: name, MType: models.GaugeMetricType, Value: &value}}
+}
+
+func counterEvent(name string, delta int64) models.MetricEvent {
+	return models.MetricEvent{Action: models.MetricUpdateAction, Metric: models.Metrics{ID: name, MType: models.CounterMetricType, Delta: &delta}}
+}
+
+func newTestNotifier(t *testing.T, config Config, deliveryLog DeliveryLog) (*Notifier, *time.Time) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	notifier, err := New(config, deliveryLog)
+	require.NoError(t, err)
+
+	notifier.now = func() time.Time { return now }
+
+	for _, watches := range notifier.watches {
+		for _, w := range watches {
+			w.lastIncrease = now
+		}
+	}
+
+	return notifier, &now
+}
+
+func decodeNotifications(t *testing.T, body []byte) []Notification {
+	var payload struct {
+		Receiver      string         `json:"receiver"`
+		Notifications []Notification `json:"notifications"`
+	}
+
+	require.NoError(t, json.Unmarshal(body, &payload))
+
+	return payload.Notifications
+}
+
+func TestNotifier(t *testing.T) {
+	t.Run("Should deliver signed notification when gauge crosses threshold", func(t *testing.T) {
+		receiverMock := &webhookReceiverMock{}
+		server := httptest.NewServer(receiverMock)
+		defer server.Close()
+
+		notifier, now := newTestNotifier(t, Config{
+			Receivers: []ReceiverConfig{{Name: "ops", URL: server.URL, SigningKey: "secret"}},
+			Watches:   []WatchConfig{{Name: "heap", Metric: "HeapAlloc", Type: models.GaugeMetricType, Condition: ConditionAbove, Threshold: 100}},
+			GroupWait: "10s",
+		}, nil)
+
+		notifier.Observe(gaugeEvent("HeapAlloc", 50))
+		notifier.Observe(gaugeEvent("HeapAlloc", 150))
+		notifier.Flush(context.TODO())
+		assert.Empty(t, receiverMock.received())
+
+		*now = now.Add(10 * time.Second)
+		notifier.Flush(context.TODO())
+
+		requests := receiverMock.received()
+		require.Len(t, requests, 1)
+
+		signature, err := utils.SignData(requests[0].body, "secret")
+		require.NoError(t, err)
+		assert.Equal(t, hex.EncodeToString(signature), requests[0].signature)
+
+		notifications := decodeNotifications(t, requests[0].body)
+		require.Len(t, notifications, 1)
+		assert.Equal(t, "heap", notifications[0].Watch)
+		assert.Equal(t, StateFiring, notifications[0].State)
+		assert.Equal(t, 150.0, notifications[0].Value)
+	})
+
+	t.Run("Should group flapping gauge and respect min interval", func(t *testing.T) {
+		receiverMock := &webhookReceiverMock{}
+		server := httptest.NewServer(receiverMock)
+		defer server.Close()
+
+		notifier, now := newTestNotifier(t, Config{
+			Receivers:   []ReceiverConfig{{Name: "ops", URL: server.URL}},
+			Watches:     []WatchConfig{{Name: "temp", Metric: `temp{room="a"}`, Type: models.GaugeMetricType, Condition: ConditionBelow, Threshold: 10}},
+			GroupWait:   "0s",
+			MinInterval: "1m",
+		}, nil)
+
+		tempEvent := func(value float64) models.MetricEvent {
+			event := gaugeEvent("temp", value)
+			event.Metric.Labels = map[string]string{"room": "a"}
+
+			return event
+		}
+
+		notifier.Observe(tempEvent(5))
+		notifier.Flush(context.TODO())
+		require.Len(t, receiverMock.received(), 1)
+
+		for i := 0; i < 5; i++ {
+			*now = now.Add(time.Second)
+			notifier.Observe(tempEvent(20))
+			notifier.Flush(context.TODO())
+			notifier.Observe(tempEvent(5))
+			notifier.Flush(context.TODO())
+		}
+
+		assert.Len(t, receiverMock.received(), 1)
+
+		notifier.Observe(tempEvent(20))
+		notifier.Flush(context.TODO())
+		assert.Len(t, receiverMock.received(), 1)
+
+		*now = now.Add(time.Minute)
+		notifier.Flush(context.TODO())
+
+		requests := receiverMock.received()
+		require.Len(t, requests, 2)
+
+		notifications := decodeNotifications(t, requests[1].body)
+		require.Len(t, notifications, 1)
+		assert.Equal(t, StateResolved, notifications[0].State)
+		assert.Equal(t, `temp{room="a"}`, notifications[0].Metric)
+	})
+
+	t.Run("Should notify when counter is not increasing", func(t *testing.T) {
+		receiverMock := &webhookReceiverMock{}
+		server := httptest.NewServer(receiverMock)
+		defer server.Close()
+
+		notifier, now := newTestNotifier(t, Config{
+			Receivers:   []ReceiverConfig{{Name: "ops", URL: server.URL, Template: `{{range .Notifications}}{{.Watch}}:{{.State}};{{end}}`}},
+			Watches:     []WatchConfig{{Name: "poll", Metric: "PollCount", Type: models.CounterMetricType, Condition: ConditionNotIncreasing, For: "1m"}},
+			GroupWait:   "0s",
+			MinInterval: "0s",
+		}, nil)
+
+		*now = now.Add(30 * time.Second)
+		notifier.Observe(counterEvent("PollCount", 1))
+		notifier.Observe(counterEvent("PollCount", 0))
+		notifier.Check()
+		notifier.Flush(context.TODO())
+		assert.Empty(t, receiverMock.received())
+
+		*now = now.Add(time.Minute)
+		notifier.Check()
+		notifier.Flush(context.TODO())
+
+		*now = now.Add(time.Second)
+		notifier.Observe(counterEvent("PollCount", 2))
+		notifier.Flush(context.TODO())
+
+		requests := receiverMock.received()
+		require.Len(t, requests, 2)
+		assert.Equal(t, "poll:firing;", string(requests[0].body))
+		assert.Equal(t, "poll:resolved;", string(requests[1].body))
+	})
+
+	t.Run("Should retry delivery and persist delivery log", func(t *testing.T) {
+		receiverMock := &webhookReceiverMock{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+		server := httptest.NewServer(receiverMock)
+		defer server.Close()
+
+		path := filepath.Join(t.TempDir(), "deliveries.log")
+		deliveryLog, err := NewFileDeliveryLog(path)
+		require.NoError(t, err)
+		defer deliveryLog.Close()
+
+		retries := 2
+		notifier, _ := newTestNotifier(t, Config{
+			Receivers: []ReceiverConfig{{Name: "ops", URL: server.URL, MaxRetries: &retries, Backoff: "1ms"}},
+			Watches:   []WatchConfig{{Name: "heap", Metric: "HeapAlloc", Type: models.GaugeMetricType, Condition: ConditionAbove, Threshold: 100}},
+			GroupWait: "0s",
+		}, deliveryLog)
+
+		notifier.Observe(gaugeEvent("HeapAlloc", 150))
+		notifier.Flush(context.TODO())
+
+		assert.Len(t, receiverMock.received(), 3)
+
+		file, err := os.Open(path)
+		require.NoError(t, err)
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		require.True(t, scanner.Scan())
+
+		var record DeliveryRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		assert.Equal(t, "ops", record.Receiver)
+		assert.Equal(t, 3, record.Attempts)
+		assert.Equal(t, http.StatusOK, record.StatusCode)
+		assert.True(t, record.Success)
+		assert.Len(t, record.Notifications, 1)
+		assert.False(t, scanner.Scan())
+	})
+
+	t.Run("Should requeue notifications after failed delivery", func(t *testing.T) {
+		receiverMock := &webhookReceiverMock{statuses: []int{http.StatusServiceUnavailable}}
+		server := httptest.NewServer(receiverMock)
+		defer server.Close()
+
+		retries := 0
+		notifier, now := newTestNotifier(t, Config{
+			Receivers:   []ReceiverConfig{{Name: "ops", URL: server.URL, MaxRetries: &retries}},
+			Watches:     []WatchConfig{{Name: "heap", Metric: "HeapAlloc", Type: models.GaugeMetricType, Condition: ConditionAbove, Threshold: 100}},
+			GroupWait:   "0s",
+			MinInterval: "1m",
+		}, nil)
+
+		notifier.Observe(gaugeEvent("HeapAlloc", 150))
+		notifier.Flush(context.TODO())
+		require.Len(t, receiverMock.received(), 1)
+
+		*now = now.Add(time.Minute)
+		notifier.Flush(context.TODO())
+
+		requests := receiverMock.received()
+		require.Len(t, requests, 2)
+		assert.Equal(t, requests[0].body, requests[1].body)
+	})
+
+	t.Run("Should drop notifications rejected by receiver", func(t *testing.T) {
+		receiverMock := &webhookReceiverMock{statuses: []int{http.StatusBadRequest}}
+		server := httptest.NewServer(receiverMock)
+		defer server.Close()
+
+		notifier, now := newTestNotifier(t, Config{
+			Receivers:   []ReceiverConfig{{Name: "ops", URL: server.URL}},
+			Watches:     []WatchConfig{{Name: "heap", Metric: "HeapAlloc", Type: models.GaugeMetricType, Condition: ConditionAbove, Threshold: 100}},
+			GroupWait:   "0s",
+			MinInterval: "1m",
+		}, nil)
+
+		notifier.Observe(gaugeEvent("HeapAlloc", 150))
+		notifier.Flush(context.TODO())
+
+		*now = now.Add(time.Minute)
+		notifier.Flush(context.TODO())
+
+		assert.Len(t, receiverMock.received(), 1)
+	})
+
+	t.Run("Should drop notifications after too many failed deliveries", func(t *testing.T) {
+		statuses := make([]int, maxDeliveryFailures+2)
+
+		for i := range statuses {
+			statuses[i] = http.StatusServiceUnavailable
+		}
+
+		receiverMock := &webhookReceiverMock{statuses: statuses}
+		server := httptest.NewServer(receiverMock)
+		defer server.Close()
+
+		retries := 0
+		notifier, now := newTestNotifier(t, Config{
+			Receivers:   []ReceiverConfig{{Name: "ops", URL: server.URL, MaxRetries: &retries}},
+			Watches:     []WatchConfig{{Name: "heap", Metric: "HeapAlloc", Type: models.GaugeMetricType, Condition: ConditionAbove, Threshold: 100}},
+			GroupWait:   "0s",
+			MinInterval: "0s",
+		}, nil)
+
+		notifier.Observe(gaugeEvent("HeapAlloc", 150))
+
+		for i := 0; i < maxDeliveryFailures+2; i++ {
+			notifier.Flush(context.TODO())
+			*now = now.Add(time.Second)
+		}
+
+		assert.Len(t, receiverMock.received(), maxDeliveryFailures)
+	})
+
+	t.Run("Should deliver to receivers concurrently", func(t *testing.T) {
+		fastReceived := make(chan struct{})
+		fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			close(fastReceived)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer fast.Close()
+
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			select {
+			case <-fastReceived:
+				w.WriteHeader(http.StatusOK)
+			case <-time.After(time.Second):
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer slow.Close()
+
+		deliveryLog := &deliveryLogMock{}
+		retries := 0
+		notifier, _ := newTestNotifier(t, Config{
+			Receivers: []ReceiverConfig{
+				{Name: "a-slow", URL: slow.URL, MaxRetries: &retries, DeliveryTimeout: "500ms"},
+				{Name: "b-fast", URL: fast.URL, MaxRetries: &retries},
+			},
+			Watches:   []WatchConfig{{Name: "heap", Metric: "HeapAlloc", Type: models.GaugeMetricType, Condition: ConditionAbove, Threshold: 100}},
+			GroupWait: "0s",
+		}, deliveryLog)
+
+		notifier.Observe(gaugeEvent("HeapAlloc", 150))
+		notifier.Flush(context.TODO())
+
+		records := deliveryLog.all()
+		require.Len(t, records, 2)
+
+		for _, record := range records {
+			assert.True(t, record.Success, record.Receiver)
+		}
+	})
+
+	t.Run("Should stop delivery after delivery timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			<-release
+		}))
+		defer server.Close()
+		defer close(release)
+
+		deliveryLog := &deliveryLogMock{}
+		notifier, _ := newTestNotifier(t, Config{
+			Receivers: []ReceiverConfig{{Name: "ops", URL: server.URL, Backoff: "1ms", DeliveryTimeout: "50ms"}},
+			Watches:   []WatchConfig{{Name: "heap", Metric: "HeapAlloc", Type: models.GaugeMetricType, Condition: ConditionAbove, Threshold: 100}},
+			GroupWait: "0s",
+		}, deliveryLog)
+
+		notifier.Observe(gaugeEvent("HeapAlloc", 150))
+		notifier.Flush(context.TODO())
+
+		records := deliveryLog.all()
+		require.Len(t, records, 1)
+		assert.False(t, records[0].Success)
+		assert.Len(t, notifier.groups["ops"].pending, 1)
+	})
+
+	t.Run("Should process events from source", func(t *testing.T) {
+		receiverMock := &webhookReceiverMock{}
+		server := httptest.NewServer(receiverMock)
+		defer server.Close()
+
+		notifier, err := New(Config{
+			Receivers: []ReceiverConfig{{Name: "ops", URL: server.URL}},
+			Watches:   []WatchConfig{{Name: "heap", Metric: "HeapAlloc", Type: models.GaugeMetricType, Condition: ConditionAbove, Threshold: 100}},
+			GroupWait: "0s",
+		}, nil)
+		require.NoError(t, err)
+
+		source := sourceMock{events: make(chan models.MetricEvent, 1)}
+		source.events <- gaugeEvent("HeapAlloc", 150)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go notifier.Run(ctx, source)
+
+		assert.Eventually(t, func() bool {
+			return len(receiverMock.received()) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+type sourceMock struct {
+	events chan models.MetricEvent
+}
+
+func (m sourceMock) Subscribe(_ services.MetricStreamParameters) (<-chan models.MetricEvent, func()) {
+	return m.events, func() {}
+}
+
+func TestNew(t *testing.T) {
+	receivers := []ReceiverConfig{{Name: "ops", URL: "http://localhost"}}
+
+	testCases := []struct {
+		testName string
+		config   Config
+	}{
+		{testName: "Should return error if receiver has no url", config: Config{Receivers: []ReceiverConfig{{Name: "ops"}}}},
+		{testName: "Should return error if receiver template is invalid", config: Config{Receivers: []ReceiverConfig{{Name: "ops", URL: "http://localhost", Template: "{{.Receiver"}}}},
+		{testName: "Should return error if receiver is duplicated", config: Config{Receivers: append(receivers, receivers...)}},
+		{testName: "Should return error if watch refers unknown receiver", config: Config{Receivers: receivers, Watches: []WatchConfig{{Name: "heap", Metric: "HeapAlloc", Type: models.GaugeMetricType, Condition: ConditionAbove, Receivers: []string{"dev"}}}}},
+		{testName: "Should return error if condition doesn't match type", config: Config{Receivers: receivers, Watches: []WatchConfig{{Name: "heap", Metric: "HeapAlloc", Type: models.CounterMetricType, Condition: ConditionAbove}}}},
+		{testName: "Should return error if not increasing watch has no duration", config: Config{Receivers: receivers, Watches: []WatchConfig{{Name: "poll", Metric: "PollCount", Type: models.CounterMetricType, Condition: ConditionNotIncreasing}}}},
+		{testName: "Should return error if group wait is invalid", config: Config{GroupWait: "soon"}},
+		{testName: "Should return error if delivery timeout is invalid", config: Config{Receivers: []ReceiverConfig{{Name: "ops", URL: "http://localhost", DeliveryTimeout: "0s"}}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			_, err := New(tc.config, nil)
+
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/daremove/go-metrics-service/internal/storage"
+)
+
+// Имена подготовленных запросов, создаваемых для каждого соединения пула.
+const (
+	insertGaugeMetricStatement     = "insert_gauge_metric"
+	insertCounterMetricStatement   = "insert_counter_metric"
+	insertHistogramMetricStatement = "insert_histogram_metric"
+	insertMetricSampleStatement    = "insert_metric_sample"
+)
+
+// preparedStatements запросы, которые подготавливаются при открытии соединения.
+var preparedStatements = map[string]string{
+	insertGaugeMetricStatement:     InsertGaugeMetricQuery,
+	insertCounterMetricStatement:   InsertCounterMetricQuery,
+	insertHistogramMetricStatement: InsertHistogramMetricQuery,
+	insertMetricSampleStatement:    InsertMetricSampleQuery,
+}
+
+// prepareStatements подготавливает запросы вставки метрик для нового соединения.
+func prepareStatements(ctx context.Context, conn *pgx.Conn) error {
+	for name, query := range preparedStatements {
+		if _, err := conn.Prepare(ctx, name, query); err != nil {
+			return fmt.Errorf("cannot prepare statement %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// aggregateMetrics объединяет метрики пакета с одинаковыми именами: для gauge остается последнее значение,
+// приращения counter суммируются, histogram объединяются. Порядок первого появления имен сохраняется.
+func aggregateMetrics(gaugeMetrics []storage.GaugeMetric, counterMetrics []storage.CounterMetric, histogramMetrics []storage.HistogramMetric) ([]storage.GaugeMetric, []storage.CounterMetric, []storage.HistogramMetric, error) {
+	gauges := make([]storage.GaugeMetric, 0, len(gaugeMetrics))
+	gaugeIndex := make(map[string]int, len(gaugeMetrics))
+
+	for _, metric := range gaugeMetrics {
+		if i, ok := gaugeIndex[metric.Name]; ok {
+			gauges[i].Value = metric.Value
+			continue
+		}
+
+		gaugeIndex[metric.Name] = len(gauges)
+		gauges = append(gauges, metric)
+	}
+
+	counters := make([]storage.CounterMetric, 0, len(counterMetrics))
+	counterIndex := make(map[string]int, len(counterMetrics))
+
+	for _, metric := range counterMetrics {
+		if i, ok := counterIndex[metric.Name]; ok {
+			counters[i].Value += metric.Value
+			continue
+		}
+
+		counterIndex[metric.Name] = len(counters)
+		counters = append(counters, metric)
+	}
+
+	histograms := make([]storage.HistogramMetric, 0, len(histogramMetrics))
+	histogramIndex := make(map[string]int, len(histogramMetrics))
+
+	for _, metric := range histogramMetrics {
+		i, ok := histogramIndex[metric.Name]
+
+		if !ok {
+			histogramIndex[metric.Name] = len(histograms)
+			histograms = append(histograms, metric)
+			continue
+		}
+
+		merged, err := storage.MergeHistograms(histograms[i], metric)
+
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("cannot add histogram %s: %w", metric.Name, err)
+		}
+
+		histograms[i] = merged
+	}
+
+	return gauges, counters, histograms, nil
+}
+
+// execBatch читает результаты пакета запросов. Первые plain запросов проверяются только на ошибку,
+// для оставшихся запросов вставки гистограмм проверяется, что строка была затронута.
+func execBatch(results pgx.BatchResults, plain int, histograms []storage.HistogramMetric) error {
+	for i := 0; i < plain; i++ {
+		if _, err := results.Exec(); err != nil {
+			return err
+		}
+	}
+
+	for _, histogram := range histograms {
+		tag, err := results.Exec()
+
+		if err != nil {
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("cannot add histogram %s: %w", histogram.Name, storage.ErrHistogramBoundsMismatch)
+		}
+	}
+
+	return nil
+}
+
+// sendBatch отправляет пакет запросов в рамках транзакции одним обращением к базе данных.
+func (d *Database) sendBatch(ctx context.Context, batch *pgx.Batch, plain int, histograms []storage.HistogramMetric) error {
+	tx, err := d.db.BeginTx(ctx, pgx.TxOptions{})
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	results := tx.SendBatch(ctx, batch)
+
+	if err := execBatch(results, plain, histograms); err != nil {
+		results.Close()
+		return err
+	}
+
+	if err := results.Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
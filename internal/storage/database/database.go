@@ -5,12 +5,13 @@ package database
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgconn"
 
+	"github.com/daremove/go-metrics-service/internal/models"
 	"github.com/daremove/go-metrics-service/internal/storage"
-	_ "github.com/golang-migrate/migrate/source/file"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -22,17 +23,60 @@ const (
 			gauge_metrics (id, value)
 		VALUES ($1, $2)
 		ON CONFLICT (id) DO UPDATE
-		SET value = EXCLUDED.value
+		SET value = EXCLUDED.value, updated_at = now()
 	`
 	InsertCounterMetricQuery = `
 		INSERT INTO
 			counter_metrics (id, value)
 		VALUES ($1, $2)
 		ON CONFLICT (id) DO UPDATE
-		SET value = counter_metrics.value + EXCLUDED.value
+		SET value = counter_metrics.value + EXCLUDED.value, updated_at = now()
+	`
+	InsertHistogramMetricQuery = `
+		INSERT INTO
+			histogram_metrics (id, bounds, counts, sum, count)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE
+		SET
+			counts = ARRAY(
+				SELECT a + b
+				FROM unnest(histogram_metrics.counts, EXCLUDED.counts) WITH ORDINALITY AS t(a, b, i)
+				ORDER BY i
+			),
+			sum = histogram_metrics.sum + EXCLUDED.sum,
+			count = histogram_metrics.count + EXCLUDED.count,
+			updated_at = now()
+		WHERE histogram_metrics.bounds = EXCLUDED.bounds
+	`
+	InsertMetricSampleQuery = `
+		INSERT INTO
+			metric_samples (type, id, value, created_at)
+		VALUES ($1, $2, $3, $4)
 	`
 )
 
+// ListMetricsQuery запрос страницы метрик всех типов. Ключи сравниваются побайтово (COLLATE "C"),
+// чтобы порядок и курсор не зависели от настроек сортировки базы данных.
+const ListMetricsQuery = `
+	SELECT type, id, value, delta, bounds, counts, sum, count, updated_at
+	FROM (
+		SELECT
+			'gauge' AS type, id, value, NULL::BIGINT AS delta, NULL::DOUBLE PRECISION[] AS bounds,
+			NULL::BIGINT[] AS counts, NULL::DOUBLE PRECISION AS sum, NULL::BIGINT AS count, updated_at
+		FROM gauge_metrics
+		UNION ALL
+		SELECT 'counter', id, NULL, value, NULL, NULL, NULL, NULL, updated_at FROM counter_metrics
+		UNION ALL
+		SELECT 'histogram', id, NULL, NULL, bounds, counts, sum, count, updated_at FROM histogram_metrics
+	) AS metrics
+	WHERE
+		(cardinality($1::TEXT[]) = 0 OR type = ANY($1))
+		AND starts_with(id, $2)
+		AND (id COLLATE "C" > $3 OR (id = $3 AND type > $4))
+	ORDER BY id COLLATE "C", type
+	LIMIT NULLIF($5, 0)
+`
+
 type DB interface {
 	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
 	Query(context.Context, string, ...interface{}) (pgx.Rows, error)
@@ -42,8 +86,10 @@ type DB interface {
 }
 
 // Database структура для взаимодействия с базой данных.
+// Операции, завершившиеся временной ошибкой, повторяются согласно настройкам retry.
 type Database struct {
-	db DB
+	db    DB
+	retry RetryConfig
 }
 
 // checkConnection проверяет соединение с базой данных.
@@ -65,164 +111,610 @@ func (d *Database) Ping(ctx context.Context) error {
 
 // GetGaugeMetric извлекает метрику типа gauge из базы данных.
 func (d *Database) GetGaugeMetric(ctx context.Context, key string) (storage.GaugeMetric, error) {
-	var result float64
+	return withRetryValue(ctx, d, true, func() (storage.GaugeMetric, error) {
+		result := storage.GaugeMetric{Name: key}
+		row := d.db.QueryRow(ctx, "SELECT value, updated_at FROM gauge_metrics WHERE id = $1", key)
 
-	row := d.db.QueryRow(ctx, "SELECT value FROM gauge_metrics WHERE id = $1", key)
+		if err := row.Scan(&result.Value, &result.UpdatedAt); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return storage.GaugeMetric{}, storage.ErrDataNotFound
+			}
 
-	if err := row.Scan(&result); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return storage.GaugeMetric{}, storage.ErrDataNotFound
+			return storage.GaugeMetric{}, err
 		}
 
-		return storage.GaugeMetric{}, err
-	}
-
-	return storage.GaugeMetric{Name: key, Value: result}, nil
+		return result, nil
+	})
 }
 
 // GetGaugeMetrics извлекает все метрики типа gauge из базы данных.
 func (d *Database) GetGaugeMetrics(ctx context.Context) ([]storage.GaugeMetric, error) {
-	var result []storage.GaugeMetric
+	return withRetryValue(ctx, d, true, func() ([]storage.GaugeMetric, error) {
+		var result []storage.GaugeMetric
 
-	rows, err := d.db.Query(ctx, "SELECT id, value FROM gauge_metrics")
+		rows, err := d.db.Query(ctx, "SELECT id, value, updated_at FROM gauge_metrics")
 
-	if err != nil {
-		return []storage.GaugeMetric{}, err
-	}
+		if err != nil {
+			return []storage.GaugeMetric{}, err
+		}
 
-	defer rows.Close()
+		defer rows.Close()
 
-	for rows.Next() {
-		var item storage.GaugeMetric
+		for rows.Next() {
+			var item storage.GaugeMetric
 
-		if err := rows.Scan(&item.Name, &item.Value); err != nil {
-			return []storage.GaugeMetric{}, err
-		}
+			if err := rows.Scan(&item.Name, &item.Value, &item.UpdatedAt); err != nil {
+				return []storage.GaugeMetric{}, err
+			}
 
-		result = append(result, item)
-	}
+			result = append(result, item)
+		}
 
-	if err := rows.Err(); err != nil {
-		return []storage.GaugeMetric{}, err
-	}
+		if err := rows.Err(); err != nil {
+			return []storage.GaugeMetric{}, err
+		}
 
-	return result, nil
+		return result, nil
+	})
 }
 
 // GetCounterMetric извлекает метрику типа counter из базы данных.
 func (d *Database) GetCounterMetric(ctx context.Context, key string) (storage.CounterMetric, error) {
-	var result int64
+	return withRetryValue(ctx, d, true, func() (storage.CounterMetric, error) {
+		result := storage.CounterMetric{Name: key}
+		row := d.db.QueryRow(ctx, "SELECT value, updated_at FROM counter_metrics WHERE id = $1", key)
 
-	row := d.db.QueryRow(ctx, "SELECT value FROM counter_metrics WHERE id = $1", key)
+		if err := row.Scan(&result.Value, &result.UpdatedAt); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return storage.CounterMetric{}, storage.ErrDataNotFound
+			}
 
-	if err := row.Scan(&result); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return storage.CounterMetric{}, storage.ErrDataNotFound
+			return storage.CounterMetric{}, err
 		}
 
-		return storage.CounterMetric{}, err
-	}
-
-	return storage.CounterMetric{Name: key, Value: result}, nil
+		return result, nil
+	})
 }
 
 // GetCounterMetrics извлекает все метрики типа counter из базы данных.
 func (d *Database) GetCounterMetrics(ctx context.Context) ([]storage.CounterMetric, error) {
-	var result []storage.CounterMetric
+	return withRetryValue(ctx, d, true, func() ([]storage.CounterMetric, error) {
+		var result []storage.CounterMetric
 
-	rows, err := d.db.Query(ctx, "SELECT id, value FROM counter_metrics")
+		rows, err := d.db.Query(ctx, "SELECT id, value, updated_at FROM counter_metrics")
 
-	if err != nil {
-		return []storage.CounterMetric{}, err
-	}
+		if err != nil {
+			return []storage.CounterMetric{}, err
+		}
+
+		defer rows.Close()
 
-	defer rows.Close()
+		for rows.Next() {
+			var item storage.CounterMetric
 
-	for rows.Next() {
-		var item storage.CounterMetric
+			if err := rows.Scan(&item.Name, &item.Value, &item.UpdatedAt); err != nil {
+				return []storage.CounterMetric{}, err
+			}
 
-		if err := rows.Scan(&item.Name, &item.Value); err != nil {
+			result = append(result, item)
+		}
+
+		if err := rows.Err(); err != nil {
 			return []storage.CounterMetric{}, err
 		}
 
-		result = append(result, item)
-	}
+		return result, nil
+	})
+}
 
-	if err := rows.Err(); err != nil {
-		return []storage.CounterMetric{}, err
-	}
+// GetHistogramMetric извлекает метрику типа histogram из базы данных.
+func (d *Database) GetHistogramMetric(ctx context.Context, key string) (storage.HistogramMetric, error) {
+	return withRetryValue(ctx, d, true, func() (storage.HistogramMetric, error) {
+		var counts []int64
+
+		result := storage.HistogramMetric{Name: key}
+		row := d.db.QueryRow(ctx, "SELECT bounds, counts, sum, count, updated_at FROM histogram_metrics WHERE id = $1", key)
+
+		if err := row.Scan(&result.Bounds, &counts, &result.Sum, &result.Count, &result.UpdatedAt); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return storage.HistogramMetric{}, storage.ErrDataNotFound
+			}
+
+			return storage.HistogramMetric{}, err
+		}
+
+		result.Counts = toUint64Slice(counts)
+
+		return result, nil
+	})
+}
+
+// GetHistogramMetrics извлекает все метрики типа histogram из базы данных.
+func (d *Database) GetHistogramMetrics(ctx context.Context) ([]storage.HistogramMetric, error) {
+	return withRetryValue(ctx, d, true, func() ([]storage.HistogramMetric, error) {
+		var result []storage.HistogramMetric
+
+		rows, err := d.db.Query(ctx, "SELECT id, bounds, counts, sum, count, updated_at FROM histogram_metrics")
+
+		if err != nil {
+			return []storage.HistogramMetric{}, err
+		}
+
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				item   storage.HistogramMetric
+				counts []int64
+			)
+
+			if err := rows.Scan(&item.Name, &item.Bounds, &counts, &item.Sum, &item.Count, &item.UpdatedAt); err != nil {
+				return []storage.HistogramMetric{}, err
+			}
+
+			item.Counts = toUint64Slice(counts)
+			result = append(result, item)
+		}
+
+		if err := rows.Err(); err != nil {
+			return []storage.HistogramMetric{}, err
+		}
+
+		return result, nil
+	})
+}
+
+// ListMetrics возвращает страницу метрик всех типов, удовлетворяющих условиям, упорядоченную по ключу и типу.
+func (d *Database) ListMetrics(ctx context.Context, parameters storage.ListParameters) ([]storage.MetricRecord, error) {
+	types := append([]string{}, parameters.Types...)
+
+	return withRetryValue(ctx, d, true, func() ([]storage.MetricRecord, error) {
+		var result []storage.MetricRecord
 
-	return result, nil
+		rows, err := d.db.Query(ctx, ListMetricsQuery, types, parameters.Prefix, parameters.AfterName, parameters.AfterType, parameters.Limit)
+
+		if err != nil {
+			return []storage.MetricRecord{}, err
+		}
+
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				item   storage.MetricRecord
+				value  *float64
+				delta  *int64
+				bounds []float64
+				counts []int64
+				sum    *float64
+				count  *int64
+			)
+
+			if err := rows.Scan(&item.Type, &item.Name, &value, &delta, &bounds, &counts, &sum, &count, &item.UpdatedAt); err != nil {
+				return []storage.MetricRecord{}, err
+			}
+
+			switch item.Type {
+			case models.GaugeMetricType:
+				if value != nil {
+					item.Value = *value
+				}
+			case models.CounterMetricType:
+				if delta != nil {
+					item.Delta = *delta
+				}
+			case models.HistogramMetricType:
+				item.Histogram = &storage.HistogramMetric{Name: item.Name, Bounds: bounds, Counts: toUint64Slice(counts), UpdatedAt: item.UpdatedAt}
+
+				if sum != nil {
+					item.Histogram.Sum = *sum
+				}
+
+				if count != nil {
+					item.Histogram.Count = uint64(*count)
+				}
+			}
+
+			result = append(result, item)
+		}
+
+		if err := rows.Err(); err != nil {
+			return []storage.MetricRecord{}, err
+		}
+
+		return result, nil
+	})
 }
 
 // AddGaugeMetric добавляет или обновляет метрику типа gauge в базе данных.
 func (d *Database) AddGaugeMetric(ctx context.Context, key string, value float64) error {
-	if _, err := d.db.Exec(ctx, InsertGaugeMetricQuery, key, value); err != nil {
-		return err
-	}
+	return d.withRetry(ctx, true, func() error {
+		if _, err := d.db.Exec(ctx, insertGaugeMetricStatement, key, value); err != nil {
+			return err
+		}
 
-	return nil
+		return nil
+	})
 }
 
 // AddCounterMetric добавляет или обновляет метрику типа counter в базе данных.
 func (d *Database) AddCounterMetric(ctx context.Context, key string, value int64) error {
-	if _, err := d.db.Exec(ctx, InsertCounterMetricQuery, key, value); err != nil {
+	return d.withRetry(ctx, false, func() error {
+		if _, err := d.db.Exec(ctx, insertCounterMetricStatement, key, value); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// AddHistogramMetric добавляет метрику типа histogram в базу данных или объединяет ее с уже сохраненной.
+func (d *Database) AddHistogramMetric(ctx context.Context, key string, value storage.HistogramMetric) error {
+	return d.withRetry(ctx, false, func() error {
+		return addHistogramMetric(ctx, d.db, key, value)
+	})
+}
+
+type execer interface {
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+}
+
+// addHistogramMetric выполняет вставку гистограммы. Если строка не была затронута,
+// значит сохраненная гистограмма имеет другие границы корзин.
+func addHistogramMetric(ctx context.Context, db execer, key string, value storage.HistogramMetric) error {
+	tag, err := db.Exec(ctx, insertHistogramMetricStatement, key, value.Bounds, toInt64Slice(value.Counts), value.Sum, value.Count)
+
+	if err != nil {
 		return err
 	}
 
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("cannot add histogram %s: %w", key, storage.ErrHistogramBoundsMismatch)
+	}
+
 	return nil
 }
 
+func toInt64Slice(values []uint64) []int64 {
+	result := make([]int64, len(values))
+
+	for i, v := range values {
+		result[i] = int64(v)
+	}
+
+	return result
+}
+
+func toUint64Slice(values []int64) []uint64 {
+	result := make([]uint64, len(values))
+
+	for i, v := range values {
+		result[i] = uint64(v)
+	}
+
+	return result
+}
+
 // AddMetrics добавляет или обновляет несколько метрик в базе данных в рамках одной транзакции.
-func (d *Database) AddMetrics(ctx context.Context, gaugeMetrics []storage.GaugeMetric, counterMetrics []storage.CounterMetric) error {
-	tx, err := d.db.BeginTx(ctx, pgx.TxOptions{})
+// Метрики с одинаковыми именами предварительно объединяются, а запросы отправляются одним пакетом.
+func (d *Database) AddMetrics(ctx context.Context, gaugeMetrics []storage.GaugeMetric, counterMetrics []storage.CounterMetric, histogramMetrics []storage.HistogramMetric) error {
+	gauges, counters, histograms, err := aggregateMetrics(gaugeMetrics, counterMetrics, histogramMetrics)
 
 	if err != nil {
 		return err
 	}
 
-	defer tx.Rollback(ctx)
+	if len(gauges)+len(counters)+len(histograms) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+
+	for _, gaugeMetric := range gauges {
+		batch.Queue(insertGaugeMetricStatement, gaugeMetric.Name, gaugeMetric.Value)
+	}
+
+	for _, counterMetric := range counters {
+		batch.Queue(insertCounterMetricStatement, counterMetric.Name, counterMetric.Value)
+	}
+
+	for _, histogramMetric := range histograms {
+		batch.Queue(insertHistogramMetricStatement, histogramMetric.Name, histogramMetric.Bounds, toInt64Slice(histogramMetric.Counts), histogramMetric.Sum, histogramMetric.Count)
+	}
+
+	return d.withRetry(ctx, false, func() error {
+		return d.sendBatch(ctx, batch, len(gauges)+len(counters), histograms)
+	})
+}
+
+// deleteMetric удаляет метрику из таблицы и ее историю в рамках одной транзакции.
+// Если задан updatedAt, метрика удаляется только при условии, что она не обновлялась позже.
+func (d *Database) deleteMetric(ctx context.Context, table, metricType, key string, updatedAt time.Time) error {
+	query, args := fmt.Sprintf("DELETE FROM %s WHERE id = $1", table), []any{key}
+
+	if !updatedAt.IsZero() {
+		query += " AND updated_at <= $2"
+		args = append(args, updatedAt)
+	}
+
+	return d.withRetry(ctx, true, func() error {
+		tx, err := d.db.BeginTx(ctx, pgx.TxOptions{})
+
+		if err != nil {
+			return err
+		}
+
+		defer tx.Rollback(ctx)
+
+		tag, err := tx.Exec(ctx, query, args...)
+
+		if err != nil {
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return storage.ErrDataNotFound
+		}
 
-	for _, gaugeMetric := range gaugeMetrics {
-		if _, err := tx.Exec(ctx, InsertGaugeMetricQuery, gaugeMetric.Name, gaugeMetric.Value); err != nil {
+		if _, err := tx.Exec(ctx, "DELETE FROM metric_samples WHERE type = $1 AND id = $2", metricType, key); err != nil {
 			return err
 		}
+
+		return tx.Commit(ctx)
+	})
+}
+
+// DeleteGaugeMetric удаляет метрику типа gauge и ее историю из базы данных.
+func (d *Database) DeleteGaugeMetric(ctx context.Context, key string) error {
+	return d.deleteMetric(ctx, "gauge_metrics", models.GaugeMetricType, key, time.Time{})
+}
+
+// DeleteCounterMetric удаляет метрику типа counter и ее историю из базы данных.
+func (d *Database) DeleteCounterMetric(ctx context.Context, key string) error {
+	return d.deleteMetric(ctx, "counter_metrics", models.CounterMetricType, key, time.Time{})
+}
+
+// DeleteHistogramMetric удаляет метрику типа histogram и ее историю из базы данных.
+func (d *Database) DeleteHistogramMetric(ctx context.Context, key string) error {
+	return d.deleteMetric(ctx, "histogram_metrics", models.HistogramMetricType, key, time.Time{})
+}
+
+// DeleteStaleMetric удаляет метрику и ее историю, если она не обновлялась после updatedAt.
+// Если метрика обновлена позже или отсутствует, возвращается storage.ErrDataNotFound.
+func (d *Database) DeleteStaleMetric(ctx context.Context, metricType, key string, updatedAt time.Time) error {
+	tables := map[string]string{
+		models.GaugeMetricType:     "gauge_metrics",
+		models.CounterMetricType:   "counter_metrics",
+		models.HistogramMetricType: "histogram_metrics",
 	}
 
-	for _, counterMetric := range counterMetrics {
-		if _, err := tx.Exec(ctx, InsertCounterMetricQuery, counterMetric.Name, counterMetric.Value); err != nil {
+	table, ok := tables[metricType]
+
+	if !ok {
+		return storage.ErrDataNotFound
+	}
+
+	return d.deleteMetric(ctx, table, metricType, key, updatedAt)
+}
+
+// DeleteMetricsByPrefix удаляет метрики всех типов, ключ которых начинается с префикса, и возвращает их количество.
+func (d *Database) DeleteMetricsByPrefix(ctx context.Context, prefix string) (int, error) {
+	return withRetryValue(ctx, d, true, func() (int, error) {
+		tx, err := d.db.BeginTx(ctx, pgx.TxOptions{})
+
+		if err != nil {
+			return 0, err
+		}
+
+		defer tx.Rollback(ctx)
+
+		var deleted int64
+
+		for _, table := range []string{"gauge_metrics", "counter_metrics", "histogram_metrics"} {
+			tag, err := tx.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE starts_with(id, $1)", table), prefix)
+
+			if err != nil {
+				return 0, err
+			}
+
+			deleted += tag.RowsAffected()
+		}
+
+		if _, err := tx.Exec(ctx, "DELETE FROM metric_samples WHERE starts_with(id, $1)", prefix); err != nil {
+			return 0, err
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return 0, err
+		}
+
+		return int(deleted), nil
+	})
+}
+
+// ResetCounterMetric обнуляет значение метрики типа counter в базе данных.
+func (d *Database) ResetCounterMetric(ctx context.Context, key string) error {
+	return d.withRetry(ctx, true, func() error {
+		tag, err := d.db.Exec(ctx, "UPDATE counter_metrics SET value = 0, updated_at = now() WHERE id = $1", key)
+
+		if err != nil {
 			return err
 		}
+
+		if tag.RowsAffected() == 0 {
+			return storage.ErrDataNotFound
+		}
+
+		return nil
+	})
+}
+
+// AddMetricSamples сохраняет значения истории метрик в базе данных одним пакетом в рамках одной транзакции.
+func (d *Database) AddMetricSamples(ctx context.Context, samples []storage.MetricSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+
+	for _, sample := range samples {
+		batch.Queue(insertMetricSampleStatement, sample.Type, sample.Name, sample.Value, sample.Timestamp)
+	}
+
+	return d.withRetry(ctx, false, func() error {
+		return d.sendBatch(ctx, batch, len(samples), nil)
+	})
+}
+
+// DeleteMetricSamplesBefore удаляет из базы данных значения истории, сохраненные раньше before,
+// и возвращает их количество.
+func (d *Database) DeleteMetricSamplesBefore(ctx context.Context, before time.Time) (int, error) {
+	return withRetryValue(ctx, d, true, func() (int, error) {
+		tag, err := d.db.Exec(ctx, "DELETE FROM metric_samples WHERE created_at < $1", before)
+
+		if err != nil {
+			return 0, err
+		}
+
+		return int(tag.RowsAffected()), nil
+	})
+}
+
+// GetMetricSamples извлекает историю значений метрики в диапазоне [from, to] из базы данных.
+func (d *Database) GetMetricSamples(ctx context.Context, metricType, key string, from, to time.Time) ([]storage.MetricSample, error) {
+	return withRetryValue(ctx, d, true, func() ([]storage.MetricSample, error) {
+		result := make([]storage.MetricSample, 0)
+
+		rows, err := d.db.Query(ctx, `
+			SELECT value, created_at
+			FROM metric_samples
+			WHERE type = $1 AND id = $2 AND created_at BETWEEN $3 AND $4
+			ORDER BY created_at
+		`, metricType, key, from, to)
+
+		if err != nil {
+			return []storage.MetricSample{}, err
+		}
+
+		defer rows.Close()
+
+		for rows.Next() {
+			item := storage.MetricSample{Name: key, Type: metricType}
+
+			if err := rows.Scan(&item.Value, &item.Timestamp); err != nil {
+				return []storage.MetricSample{}, err
+			}
+
+			result = append(result, item)
+		}
+
+		if err := rows.Err(); err != nil {
+			return []storage.MetricSample{}, err
+		}
+
+		return result, nil
+	})
+}
+
+// Config содержит настройки пула соединений и повторных попыток. Нулевые значения означают настройки по умолчанию.
+type Config struct {
+	MaxConns        int32         // Максимальное количество соединений в пуле
+	MinConns        int32         // Минимальное количество открытых соединений
+	MaxConnLifetime time.Duration // Время жизни соединения, после которого оно закрывается
+	MaxConnIdleTime time.Duration // Время простоя соединения, после которого оно закрывается
+	ConnectTimeout  time.Duration // Время ожидания установки соединения
+	Retry           RetryConfig   // Настройки повторных попыток, по умолчанию DefaultRetryConfig
+}
+
+// newPoolConfig формирует конфигурацию пула соединений с подготовкой запросов для каждого соединения.
+func newPoolConfig(dsn string, config Config) (*pgxpool.Config, error) {
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if config.MaxConns > 0 {
+		poolConfig.MaxConns = config.MaxConns
+	}
+
+	if config.MinConns > 0 {
+		poolConfig.MinConns = config.MinConns
+	}
+
+	if config.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = config.MaxConnLifetime
+	}
+
+	if config.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = config.MaxConnIdleTime
 	}
 
-	return tx.Commit(ctx)
+	if config.ConnectTimeout > 0 {
+		poolConfig.ConnConfig.ConnectTimeout = config.ConnectTimeout
+	}
+
+	poolConfig.AfterConnect = prepareStatements
+
+	return poolConfig, nil
+}
+
+// applyMigrations применяет миграции через отдельный пул без подготовки запросов,
+// так как до применения миграций таблиц для подготавливаемых запросов может не существовать.
+func applyMigrations(ctx context.Context, poolConfig *pgxpool.Config) error {
+	migrationConfig := poolConfig.Copy()
+	migrationConfig.AfterConnect = nil
+	migrationConfig.MinConns = 0
+
+	pool, err := pgxpool.NewWithConfig(ctx, migrationConfig)
+
+	if err != nil {
+		return err
+	}
+
+	defer pool.Close()
+
+	if err := checkConnection(ctx, pool); err != nil {
+		return err
+	}
+
+	return migrateSchema(pool)
 }
 
-// New инициализирует и возвращает новый экземпляр Database.
+// New инициализирует и возвращает новый экземпляр Database с настройками пула по умолчанию.
 func New(ctx context.Context, dsn string) (*Database, error) {
-	db, err := pgxpool.New(ctx, dsn)
+	return NewWithConfig(ctx, dsn, Config{})
+}
+
+// NewWithConfig инициализирует и возвращает новый экземпляр Database.
+// Перед началом работы к базе данных применяются непримененные миграции схемы.
+func NewWithConfig(ctx context.Context, dsn string, config Config) (*Database, error) {
+	poolConfig, err := newPoolConfig(dsn, config)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if err := checkConnection(ctx, db); err != nil {
+	if err := applyMigrations(ctx, poolConfig); err != nil {
 		return nil, err
 	}
 
-	if _, err := db.Exec(ctx, `
-		CREATE TABLE IF NOT EXISTS gauge_metrics (
-		    id      TEXT PRIMARY KEY,
-    		value   DOUBLE PRECISION NOT NULL
-		);
+	db, err := pgxpool.NewWithConfig(ctx, poolConfig)
 
-		CREATE TABLE IF NOT EXISTS counter_metrics (
-			id      TEXT PRIMARY KEY,
-			value   BIGSERIAL NOT NULL
-		);
-	`); err != nil {
+	if err != nil {
 		return nil, err
 	}
 
-	return &Database{db}, nil
+	if err := checkConnection(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	retry := config.Retry
+
+	if retry.Attempts == 0 {
+		retry = DefaultRetryConfig
+	}
+
+	return &Database{db: db, retry: retry}, nil
 }
@@ -0,0 +1,208 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratepgx "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// ErrSchemaVersion ошибка, возникающая если версия схемы базы данных не совпадает с версией, поддерживаемой сервером.
+var ErrSchemaVersion = errors.New("database schema version isn't supported")
+
+// SchemaStatus описывает состояние схемы базы данных.
+type SchemaStatus struct {
+	Version uint // Версия последней примененной миграции, 0 если миграции не применялись
+	Dirty   bool // Признак того, что последняя миграция завершилась с ошибкой
+	Latest  uint // Версия последней миграции, встроенной в сервер
+}
+
+// Migrator применяет и откатывает миграции схемы базы данных, встроенные в сервер.
+type Migrator struct {
+	migrate *migrate.Migrate
+	latest  uint
+	pool    *pgxpool.Pool // Пул соединений, принадлежащий мигратору, nil если пул передан извне
+}
+
+func newMigrationSource() (source.Driver, error) {
+	migrations, err := fs.Sub(migrationsFS, "migrations")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return iofs.New(migrations, ".")
+}
+
+// latestVersion возвращает версию последней миграции источника.
+func latestVersion(driver source.Driver) (uint, error) {
+	version, err := driver.First()
+
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		next, err := driver.Next(version)
+
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		version = next
+	}
+}
+
+func newMigrator(pool *pgxpool.Pool) (*Migrator, error) {
+	sourceDriver, err := newMigrationSource()
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot read migrations: %w", err)
+	}
+
+	latest, err := latestVersion(sourceDriver)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot read migrations: %w", err)
+	}
+
+	databaseDriver, err := migratepgx.WithInstance(stdlib.OpenDBFromPool(pool), &migratepgx.Config{})
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot prepare database for migrations: %w", err)
+	}
+
+	instance, err := migrate.NewWithInstance("iofs", sourceDriver, "pgx5", databaseDriver)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot prepare database for migrations: %w", err)
+	}
+
+	return &Migrator{migrate: instance, latest: latest}, nil
+}
+
+// NewMigrator создает новый экземпляр Migrator с собственным подключением к базе данных.
+func NewMigrator(ctx context.Context, dsn string) (*Migrator, error) {
+	pool, err := pgxpool.New(ctx, dsn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkConnection(ctx, pool); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	migrator, err := newMigrator(pool)
+
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	migrator.pool = pool
+
+	return migrator, nil
+}
+
+// Status возвращает текущую версию схемы и версию последней встроенной миграции.
+func (m *Migrator) Status() (SchemaStatus, error) {
+	version, dirty, err := m.migrate.Version()
+
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return SchemaStatus{}, err
+	}
+
+	return SchemaStatus{Version: version, Dirty: dirty, Latest: m.latest}, nil
+}
+
+// Up применяет все непримененные миграции.
+func (m *Migrator) Up() error {
+	if err := m.migrate.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	return nil
+}
+
+// Down откатывает последнюю примененную миграцию.
+func (m *Migrator) Down() error {
+	return m.migrate.Steps(-1)
+}
+
+// Close освобождает соединение, используемое для миграций.
+func (m *Migrator) Close() error {
+	sourceErr, databaseErr := m.migrate.Close()
+
+	if m.pool != nil {
+		m.pool.Close()
+	}
+
+	return errors.Join(sourceErr, databaseErr)
+}
+
+// checkSchema проверяет, что схема базы данных может быть обновлена встроенными миграциями.
+func checkSchema(status SchemaStatus) error {
+	if status.Dirty {
+		return fmt.Errorf("%w: migration %d wasn't completed, fix it and run migrate command", ErrSchemaVersion, status.Version)
+	}
+
+	if status.Version > status.Latest {
+		return fmt.Errorf("%w: database version %d is newer than supported version %d", ErrSchemaVersion, status.Version, status.Latest)
+	}
+
+	return nil
+}
+
+// migrateSchema применяет миграции при запуске сервера и проверяет итоговую версию схемы.
+func migrateSchema(pool *pgxpool.Pool) error {
+	migrator, err := newMigrator(pool)
+
+	if err != nil {
+		return err
+	}
+
+	defer migrator.Close()
+
+	status, err := migrator.Status()
+
+	if err != nil {
+		return err
+	}
+
+	if err := checkSchema(status); err != nil {
+		return err
+	}
+
+	if err := migrator.Up(); err != nil {
+		return fmt.Errorf("cannot apply migrations: %w", err)
+	}
+
+	status, err = migrator.Status()
+
+	if err != nil {
+		return err
+	}
+
+	if status.Dirty || status.Version != status.Latest {
+		return fmt.Errorf("%w: database version %d, expected %d", ErrSchemaVersion, status.Version, status.Latest)
+	}
+
+	return nil
+}
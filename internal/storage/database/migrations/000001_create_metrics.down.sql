@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS metric_samples;
+DROP TABLE IF EXISTS histogram_metrics;
+DROP TABLE IF EXISTS counter_metrics;
+DROP TABLE IF EXISTS gauge_metrics;
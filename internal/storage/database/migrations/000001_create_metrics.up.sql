@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS gauge_metrics (
+    id      TEXT PRIMARY KEY,
+    value   DOUBLE PRECISION NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS counter_metrics (
+    id      TEXT PRIMARY KEY,
+    value   BIGSERIAL NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS histogram_metrics (
+    id      TEXT PRIMARY KEY,
+    bounds  DOUBLE PRECISION[] NOT NULL,
+    counts  BIGINT[] NOT NULL,
+    sum     DOUBLE PRECISION NOT NULL,
+    count   BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS metric_samples (
+    type        TEXT NOT NULL,
+    id          TEXT NOT NULL,
+    value       DOUBLE PRECISION NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS metric_samples_series_idx ON metric_samples (type, id, created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE histogram_metrics DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE counter_metrics DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE gauge_metrics DROP COLUMN IF EXISTS updated_at;
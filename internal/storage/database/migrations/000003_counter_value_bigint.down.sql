@@ -0,0 +1,2 @@
+CREATE SEQUENCE IF NOT EXISTS counter_metrics_value_seq OWNED BY counter_metrics.value;
+ALTER TABLE counter_metrics ALTER COLUMN value SET DEFAULT nextval('counter_metrics_value_seq');
@@ -0,0 +1,3 @@
+-- Значение counter не должно генерироваться последовательностью: BIGSERIAL заменяется на BIGINT.
+ALTER TABLE counter_metrics ALTER COLUMN value DROP DEFAULT;
+DROP SEQUENCE IF EXISTS counter_metrics_value_seq;
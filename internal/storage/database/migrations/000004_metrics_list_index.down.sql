@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS histogram_metrics_list_idx;
+DROP INDEX IF EXISTS counter_metrics_list_idx;
+DROP INDEX IF EXISTS gauge_metrics_list_idx;
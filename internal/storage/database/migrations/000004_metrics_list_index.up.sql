@@ -0,0 +1,4 @@
+-- Индексы для постраничного чтения метрик в побайтовом порядке ключей.
+CREATE INDEX IF NOT EXISTS gauge_metrics_list_idx ON gauge_metrics (id COLLATE "C");
+CREATE INDEX IF NOT EXISTS counter_metrics_list_idx ON counter_metrics (id COLLATE "C");
+CREATE INDEX IF NOT EXISTS histogram_metrics_list_idx ON histogram_metrics (id COLLATE "C");
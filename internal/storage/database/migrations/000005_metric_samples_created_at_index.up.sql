@@ -0,0 +1,2 @@
+-- Индекс для удаления значений истории старше срока хранения.
+CREATE INDEX IF NOT EXISTS metric_samples_created_at_idx ON metric_samples (created_at);
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations(t *testing.T) {
+	t.Run("Should have down migration for every up migration", func(t *testing.T) {
+		files, err := fs.Glob(migrationsFS, "migrations/*.up.sql")
+		require.NoError(t, err)
+		require.NotEmpty(t, files)
+
+		for _, file := range files {
+			_, err := fs.Stat(migrationsFS, strings.TrimSuffix(file, ".up.sql")+".down.sql")
+			assert.NoError(t, err, file)
+		}
+	})
+
+	t.Run("Should return latest migration version", func(t *testing.T) {
+		driver, err := newMigrationSource()
+		require.NoError(t, err)
+
+		version, err := latestVersion(driver)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint(5), version)
+	})
+}
+
+func TestCheckSchema(t *testing.T) {
+	testCases := []struct {
+		testName string
+		status   SchemaStatus
+		hasError bool
+	}{
+		{
+			testName: "Should allow empty database",
+			status:   SchemaStatus{Version: 0, Latest: 3},
+		},
+		{
+			testName: "Should allow outdated schema",
+			status:   SchemaStatus{Version: 1, Latest: 3},
+		},
+		{
+			testName: "Should reject dirty schema",
+			status:   SchemaStatus{Version: 2, Dirty: true, Latest: 3},
+			hasError: true,
+		},
+		{
+			testName: "Should reject schema newer than supported",
+			status:   SchemaStatus{Version: 4, Latest: 3},
+			hasError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			err := checkSchema(tc.status)
+
+			if tc.hasError {
+				assert.ErrorIs(t, err, ErrSchemaVersion)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+
+	"github.com/daremove/go-metrics-service/internal/logger"
+	"github.com/daremove/go-metrics-service/internal/storage"
+)
+
+// RetryConfig содержит настройки повторного выполнения операций при временных ошибках базы данных.
+type RetryConfig struct {
+	Attempts     int           // Максимальное количество попыток, включая первую
+	InitialDelay time.Duration // Задержка перед первой повторной попыткой
+	MaxDelay     time.Duration // Максимальная задержка между попытками
+}
+
+// DefaultRetryConfig настройки повторных попыток, используемые если они не заданы.
+var DefaultRetryConfig = RetryConfig{
+	Attempts:     3,
+	InitialDelay: 100 * time.Millisecond,
+	MaxDelay:     time.Second,
+}
+
+// retryAfter возвращает время, через которое клиенту рекомендуется повторить запрос.
+func (c RetryConfig) retryAfter() time.Duration {
+	if c.MaxDelay > time.Second {
+		return c.MaxDelay
+	}
+
+	return time.Second
+}
+
+// errorClass класс ошибки базы данных.
+type errorClass int
+
+const (
+	errorPermanent   errorClass = iota // Ошибка не связана с доступностью базы данных
+	errorRetriable                     // Операция не была выполнена и ее можно безопасно повторить
+	errorUnavailable                   // База данных недоступна, но результат операции неизвестен
+)
+
+// classifyError определяет класс ошибки. Ошибки, которые Postgres возвращает для соединения,
+// конфликтов сериализации и взаимных блокировок, гарантируют откат операции, поэтому ее можно повторить.
+// Разрыв соединения во время выполнения запроса повторяется только для идемпотентных операций.
+func classifyError(err error, idempotent bool) errorClass {
+	var pgErr *pgconn.PgError
+
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgerrcode.SerializationFailure,
+			pgerrcode.DeadlockDetected,
+			pgerrcode.TooManyConnections,
+			pgerrcode.CannotConnectNow,
+			pgerrcode.AdminShutdown,
+			pgerrcode.CrashShutdown:
+			return errorRetriable
+		}
+
+		if pgerrcode.IsConnectionException(pgErr.Code) {
+			return errorRetriable
+		}
+
+		return errorPermanent
+	}
+
+	var connectErr *pgconn.ConnectError
+
+	if errors.As(err, &connectErr) || pgconn.SafeToRetry(err) {
+		return errorRetriable
+	}
+
+	var netErr net.Error
+
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		if idempotent {
+			return errorRetriable
+		}
+
+		return errorUnavailable
+	}
+
+	return errorPermanent
+}
+
+// withRetry выполняет операцию, повторяя ее с экспоненциальной задержкой при временных ошибках.
+// Если база данных остается недоступной, возвращается storage.UnavailableError.
+func (d *Database) withRetry(ctx context.Context, idempotent bool, operation func() error) error {
+	attempts := d.retry.Attempts
+
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	delay := d.retry.InitialDelay
+
+	for attempt := 1; ; attempt++ {
+		err := operation()
+
+		if err == nil {
+			return nil
+		}
+
+		class := classifyError(err, idempotent)
+
+		if class == errorPermanent || ctx.Err() != nil {
+			return err
+		}
+
+		if class == errorUnavailable || attempt >= attempts {
+			return &storage.UnavailableError{Err: err, RetryAfter: d.retry.retryAfter()}
+		}
+
+		logger.Log.Warn("retrying database operation", zap.Int("attempt", attempt), zap.Duration("delay", delay), zap.Error(err))
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return &storage.UnavailableError{Err: err, RetryAfter: d.retry.retryAfter()}
+		case <-timer.C:
+		}
+
+		delay *= 2
+
+		if delay > d.retry.MaxDelay {
+			delay = d.retry.MaxDelay
+		}
+	}
+}
+
+// withRetryValue выполняет операцию, возвращающую значение, с повторными попытками как withRetry.
+func withRetryValue[T any](ctx context.Context, d *Database, idempotent bool, operation func() (T, error)) (T, error) {
+	var result T
+
+	err := d.withRetry(ctx, idempotent, func() error {
+		var err error
+		result, err = operation()
+
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,170 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/daremove/go-metrics-service/internal/storage"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	testCases := []struct {
+		testName   string
+		err        error
+		idempotent bool
+		expected   errorClass
+	}{
+		{
+			testName: "Should retry serialization failure",
+			err:      &pgconn.PgError{Code: pgerrcode.SerializationFailure},
+			expected: errorRetriable,
+		},
+		{
+			testName: "Should retry deadlock",
+			err:      fmt.Errorf("exec: %w", &pgconn.PgError{Code: pgerrcode.DeadlockDetected}),
+			expected: errorRetriable,
+		},
+		{
+			testName: "Should retry connection exception",
+			err:      &pgconn.PgError{Code: pgerrcode.ConnectionFailure},
+			expected: errorRetriable,
+		},
+		{
+			testName: "Should retry server shutdown",
+			err:      &pgconn.PgError{Code: pgerrcode.AdminShutdown},
+			expected: errorRetriable,
+		},
+		{
+			testName: "Should not retry constraint violation",
+			err:      &pgconn.PgError{Code: pgerrcode.UniqueViolation},
+			expected: errorPermanent,
+		},
+		{
+			testName:   "Should retry network error for idempotent operation",
+			err:        &net.OpError{Op: "read", Err: errors.New("connection reset")},
+			idempotent: true,
+			expected:   errorRetriable,
+		},
+		{
+			testName: "Should not retry network error for non idempotent operation",
+			err:      fmt.Errorf("commit: %w", io.ErrUnexpectedEOF),
+			expected: errorUnavailable,
+		},
+		{
+			testName: "Should not retry not found error",
+			err:      pgx.ErrNoRows,
+			expected: errorPermanent,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			assert.Equal(t, tc.expected, classifyError(tc.err, tc.idempotent))
+		})
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	ctx := context.Background()
+	retry := RetryConfig{Attempts: 3, InitialDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+	retriable := &pgconn.PgError{Code: pgerrcode.SerializationFailure}
+
+	t.Run("Should retry until operation succeeds", func(t *testing.T) {
+		db := &Database{retry: retry}
+		calls := 0
+
+		err := db.withRetry(ctx, false, func() error {
+			calls++
+
+			if calls < 3 {
+				return retriable
+			}
+
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("Should return unavailable error after attempts are exhausted", func(t *testing.T) {
+		db := &Database{retry: retry}
+		calls := 0
+
+		err := db.withRetry(ctx, true, func() error {
+			calls++
+			return retriable
+		})
+
+		var unavailable *storage.UnavailableError
+
+		require.ErrorAs(t, err, &unavailable)
+		assert.ErrorIs(t, err, storage.ErrUnavailable)
+		assert.Equal(t, retriable, unavailable.Err)
+		assert.Equal(t, time.Second, unavailable.RetryAfter)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("Should not retry permanent error", func(t *testing.T) {
+		db := &Database{retry: retry}
+		calls := 0
+
+		err := db.withRetry(ctx, true, func() error {
+			calls++
+			return storage.ErrDataNotFound
+		})
+
+		assert.Equal(t, storage.ErrDataNotFound, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Should not retry non idempotent operation with unknown result", func(t *testing.T) {
+		db := &Database{retry: retry}
+		calls := 0
+
+		err := db.withRetry(ctx, false, func() error {
+			calls++
+			return io.ErrUnexpectedEOF
+		})
+
+		assert.ErrorIs(t, err, storage.ErrUnavailable)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Should retry database reads", func(t *testing.T) {
+		db, mock := setupMockDB()
+		db.retry = retry
+		calls := 0
+
+		mock.QueryRowFunc = func(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+			calls++
+
+			if calls == 1 {
+				return &MockRow{ScanFunc: func(dest ...interface{}) error {
+					return &pgconn.PgError{Code: pgerrcode.CannotConnectNow}
+				}}
+			}
+
+			return &MockRow{ScanFunc: func(dest ...interface{}) error {
+				*dest[0].(*float64) = 1.5
+				return nil
+			}}
+		}
+
+		result, err := db.GetGaugeMetric(ctx, "gauge")
+
+		require.NoError(t, err)
+		assert.Equal(t, 1.5, result.Value)
+		assert.Equal(t, 2, calls)
+	})
+}
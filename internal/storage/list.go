@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// ListParameters определяет условия постраничного получения метрик.
+// Метрики упорядочены по ключу, а при совпадении ключей — по типу.
+type ListParameters struct {
+	Types     []string // Типы метрик, пустой список — все типы
+	Prefix    string   // Префикс ключа метрики
+	AfterName string   // Ключ метрики, после которой начинается страница
+	AfterType string   // Тип метрики, после которой начинается страница
+	Limit     int      // Максимальное количество метрик, 0 — без ограничения
+}
+
+// MetricRecord описывает метрику любого типа, полученную при постраничном чтении.
+type MetricRecord struct {
+	Type      string           // Тип метрики
+	Name      string           // Ключ метрики
+	Value     float64          // Значение метрики типа "gauge"
+	Delta     int64            // Накопленное значение метрики типа "counter"
+	Histogram *HistogramMetric // Распределение значений метрики типа "histogram"
+	UpdatedAt time.Time        // Время последнего обновления метрики
+}
+
+// Matches проверяет, удовлетворяет ли метрика с указанными типом и ключом условиям по типу, префиксу и курсору.
+func (p ListParameters) Matches(metricType, name string) bool {
+	if len(p.Types) > 0 {
+		found := false
+
+		for _, item := range p.Types {
+			if item == metricType {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if !strings.HasPrefix(name, p.Prefix) {
+		return false
+	}
+
+	return name > p.AfterName || (name == p.AfterName && metricType > p.AfterType)
+}
+
+// SortRecords упорядочивает метрики по ключу и типу и оставляет не более limit первых, если limit больше 0.
+func SortRecords(records []MetricRecord, limit int) []MetricRecord {
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Name != records[j].Name {
+			return records[i].Name < records[j].Name
+		}
+
+		return records[i].Type < records[j].Type
+	})
+
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+
+	return records
+}
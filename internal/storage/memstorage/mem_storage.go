@@ -3,33 +3,119 @@ package memstorage
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/daremove/go-metrics-service/internal/models"
 	"github.com/daremove/go-metrics-service/internal/storage"
 )
 
+// MaxSamplesPerSeries ограничивает количество значений истории, хранящихся для одной метрики.
+// При превышении лимита самые старые значения удаляются.
+const MaxSamplesPerSeries = 10000
+
+// DefaultShardCount количество сегментов хранилища, создаваемых по умолчанию. Должно быть степенью двойки.
+const DefaultShardCount = 32
+
+// gaugeEntry хранит значение метрики типа gauge и время ее обновления.
+type gaugeEntry struct {
+	value     float64
+	updatedAt time.Time
+}
+
+// counterEntry хранит значение метрики типа counter и время ее обновления.
+type counterEntry struct {
+	value     int64
+	updatedAt time.Time
+}
+
+// shard хранит часть метрик под собственной блокировкой.
+type shard struct {
+	mu        sync.RWMutex
+	gauge     map[string]gaugeEntry              // Хранение метрик типа gauge
+	counter   map[string]counterEntry            // Хранение метрик типа counter
+	histogram map[string]storage.HistogramMetric // Хранение метрик типа histogram
+	history   map[string][]storage.MetricSample  // Хранение истории значений метрик
+}
+
+func newShard() *shard {
+	return &shard{
+		gauge:     map[string]gaugeEntry{},
+		counter:   map[string]counterEntry{},
+		histogram: map[string]storage.HistogramMetric{},
+		history:   map[string][]storage.MetricSample{},
+	}
+}
+
 // MemStorage реализует интерфейс Storage, предоставляя операции с метриками, хранящимися в памяти.
+// Хранилище безопасно для конкурентного использования: ключи распределяются по сегментам,
+// каждый из которых защищен собственной блокировкой, поэтому запись разных метрик не блокирует друг друга.
 type MemStorage struct {
-	gauge   map[string]float64 // Хранение метрик типа gauge
-	counter map[string]int64   // Хранение метрик типа counter
+	shards []*shard
+	now    func() time.Time // Источник текущего времени
+}
+
+func historyKey(metricType, key string) string {
+	return metricType + ":" + key
+}
+
+// shardIndex возвращает номер сегмента, в котором хранится метрика с указанным ключом.
+// Используется хеш FNV-1a, вычисляемый без выделения памяти.
+func (s *MemStorage) shardIndex(key string) int {
+	if len(s.shards) == 1 {
+		return 0
+	}
+
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+
+	hash := uint32(offset32)
+
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= prime32
+	}
+
+	return int(hash & uint32(len(s.shards)-1))
+}
+
+// shardFor возвращает сегмент, в котором хранится метрика с указанным ключом.
+func (s *MemStorage) shardFor(key string) *shard {
+	return s.shards[s.shardIndex(key)]
 }
 
 // GetGaugeMetric извлекает метрику типа gauge по ключу.
 func (s *MemStorage) GetGaugeMetric(_ context.Context, key string) (storage.GaugeMetric, error) {
-	value, ok := s.gauge[key]
+	sh := s.shardFor(key)
+
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	entry, ok := sh.gauge[key]
 
 	if !ok {
 		return storage.GaugeMetric{}, storage.ErrDataNotFound
 	}
 
-	return storage.GaugeMetric{Name: key, Value: value}, nil
+	return storage.GaugeMetric{Name: key, Value: entry.value, UpdatedAt: entry.updatedAt}, nil
 }
 
 // GetGaugeMetrics возвращает все метрики типа gauge.
 func (s *MemStorage) GetGaugeMetrics(_ context.Context) ([]storage.GaugeMetric, error) {
-	data := make([]storage.GaugeMetric, 0, len(s.gauge))
+	data := make([]storage.GaugeMetric, 0)
+
+	for _, sh := range s.shards {
+		sh.mu.RLock()
 
-	for key, value := range s.gauge {
-		data = append(data, storage.GaugeMetric{Name: key, Value: value})
+		for key, entry := range sh.gauge {
+			data = append(data, storage.GaugeMetric{Name: key, Value: entry.value, UpdatedAt: entry.updatedAt})
+		}
+
+		sh.mu.RUnlock()
 	}
 
 	return data, nil
@@ -37,50 +123,299 @@ func (s *MemStorage) GetGaugeMetrics(_ context.Context) ([]storage.GaugeMetric,
 
 // GetCounterMetric извлекает метрику типа counter по ключу.
 func (s *MemStorage) GetCounterMetric(_ context.Context, key string) (storage.CounterMetric, error) {
-	value, ok := s.counter[key]
+	sh := s.shardFor(key)
+
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	entry, ok := sh.counter[key]
 
 	if !ok {
 		return storage.CounterMetric{}, storage.ErrDataNotFound
 	}
 
-	return storage.CounterMetric{Name: key, Value: value}, nil
+	return storage.CounterMetric{Name: key, Value: entry.value, UpdatedAt: entry.updatedAt}, nil
 }
 
 // GetCounterMetrics возвращает все метрики типа counter.
 func (s *MemStorage) GetCounterMetrics(_ context.Context) ([]storage.CounterMetric, error) {
-	data := make([]storage.CounterMetric, 0, len(s.counter))
+	data := make([]storage.CounterMetric, 0)
+
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+
+		for key, entry := range sh.counter {
+			data = append(data, storage.CounterMetric{Name: key, Value: entry.value, UpdatedAt: entry.updatedAt})
+		}
+
+		sh.mu.RUnlock()
+	}
+
+	return data, nil
+}
+
+// GetHistogramMetric извлекает метрику типа histogram по ключу.
+func (s *MemStorage) GetHistogramMetric(_ context.Context, key string) (storage.HistogramMetric, error) {
+	sh := s.shardFor(key)
 
-	for key, value := range s.counter {
-		data = append(data, storage.CounterMetric{Name: key, Value: value})
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	value, ok := sh.histogram[key]
+
+	if !ok {
+		return storage.HistogramMetric{}, storage.ErrDataNotFound
+	}
+
+	return copyHistogram(value), nil
+}
+
+// GetHistogramMetrics возвращает все метрики типа histogram.
+func (s *MemStorage) GetHistogramMetrics(_ context.Context) ([]storage.HistogramMetric, error) {
+	data := make([]storage.HistogramMetric, 0)
+
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+
+		for _, value := range sh.histogram {
+			data = append(data, copyHistogram(value))
+		}
+
+		sh.mu.RUnlock()
 	}
 
 	return data, nil
 }
 
+// ListMetrics возвращает страницу метрик всех типов, удовлетворяющих условиям, упорядоченную по ключу и типу.
+func (s *MemStorage) ListMetrics(_ context.Context, parameters storage.ListParameters) ([]storage.MetricRecord, error) {
+	data := make([]storage.MetricRecord, 0)
+
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+
+		for key, entry := range sh.gauge {
+			if parameters.Matches(models.GaugeMetricType, key) {
+				data = append(data, storage.MetricRecord{Type: models.GaugeMetricType, Name: key, Value: entry.value, UpdatedAt: entry.updatedAt})
+			}
+		}
+
+		for key, entry := range sh.counter {
+			if parameters.Matches(models.CounterMetricType, key) {
+				data = append(data, storage.MetricRecord{Type: models.CounterMetricType, Name: key, Delta: entry.value, UpdatedAt: entry.updatedAt})
+			}
+		}
+
+		for key, value := range sh.histogram {
+			if parameters.Matches(models.HistogramMetricType, key) {
+				histogram := copyHistogram(value)
+				data = append(data, storage.MetricRecord{Type: models.HistogramMetricType, Name: key, Histogram: &histogram, UpdatedAt: value.UpdatedAt})
+			}
+		}
+
+		sh.mu.RUnlock()
+	}
+
+	return storage.SortRecords(data, parameters.Limit), nil
+}
+
+// copyHistogram копирует гистограмму, чтобы вызывающий код не разделял срезы с хранилищем.
+func copyHistogram(value storage.HistogramMetric) storage.HistogramMetric {
+	value.Bounds = append([]float64(nil), value.Bounds...)
+	value.Counts = append([]uint64(nil), value.Counts...)
+
+	return value
+}
+
 // AddGaugeMetric добавляет или обновляет метрику типа gauge.
 func (s *MemStorage) AddGaugeMetric(_ context.Context, key string, value float64) error {
-	s.gauge[key] = value
+	sh := s.shardFor(key)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sh.addGauge(key, value, s.now())
 
 	return nil
 }
 
 // AddCounterMetric добавляет или инкрементирует метрику типа counter.
 func (s *MemStorage) AddCounterMetric(_ context.Context, key string, value int64) error {
-	s.counter[key] += value
+	sh := s.shardFor(key)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sh.addCounter(key, value, s.now())
+
+	return nil
+}
+
+// AddHistogramMetric добавляет метрику типа histogram или объединяет ее с уже сохраненной.
+func (s *MemStorage) AddHistogramMetric(_ context.Context, key string, value storage.HistogramMetric) error {
+	sh := s.shardFor(key)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	return sh.addHistogram(key, value, s.now())
+}
+
+func (sh *shard) addGauge(key string, value float64, now time.Time) {
+	sh.gauge[key] = gaugeEntry{value: value, updatedAt: now}
+}
+
+func (sh *shard) addCounter(key string, value int64, now time.Time) {
+	sh.counter[key] = counterEntry{value: sh.counter[key].value + value, updatedAt: now}
+}
+
+func (sh *shard) addHistogram(key string, value storage.HistogramMetric, now time.Time) error {
+	value.Name = key
+	value.UpdatedAt = now
+	current, ok := sh.histogram[key]
+
+	if !ok {
+		sh.histogram[key] = copyHistogram(value)
+
+		return nil
+	}
+
+	merged, err := storage.MergeHistograms(current, value)
+
+	if err != nil {
+		return err
+	}
+
+	merged.UpdatedAt = now
+	sh.histogram[key] = merged
 
 	return nil
 }
 
-// AddMetrics добавляет набор метрик типа gauge и counter.
-func (s *MemStorage) AddMetrics(ctx context.Context, gaugeMetrics []storage.GaugeMetric, counterMetrics []storage.CounterMetric) error {
-	for _, gaugeMetric := range gaugeMetrics {
-		if err := s.AddGaugeMetric(ctx, gaugeMetric.Name, gaugeMetric.Value); err != nil {
+// metricsBatch описывает набор метрик разных типов со сквозной нумерацией позиций:
+// сначала gauge, затем counter, затем histogram.
+type metricsBatch struct {
+	gauges     []storage.GaugeMetric
+	counters   []storage.CounterMetric
+	histograms []storage.HistogramMetric
+	keepTime   bool // Сохранять время обновления, заданное в метриках набора
+}
+
+func (b metricsBatch) len() int {
+	return len(b.gauges) + len(b.counters) + len(b.histograms)
+}
+
+func (b metricsBatch) name(position int) string {
+	switch {
+	case position < len(b.gauges):
+		return b.gauges[position].Name
+	case position < len(b.gauges)+len(b.counters):
+		return b.counters[position-len(b.gauges)].Name
+	default:
+		return b.histograms[position-len(b.gauges)-len(b.counters)].Name
+	}
+}
+
+// updatedAt возвращает время обновления метрики набора: заданное в метрике, если набор
+// восстанавливается и время задано, иначе текущее.
+func (b metricsBatch) updatedAt(updatedAt, now time.Time) time.Time {
+	if b.keepTime && !updatedAt.IsZero() {
+		return updatedAt
+	}
+
+	return now
+}
+
+// add добавляет в сегмент метрику набора, находящуюся на указанной позиции.
+func (b metricsBatch) add(sh *shard, position int, now time.Time) error {
+	switch {
+	case position < len(b.gauges):
+		metric := b.gauges[position]
+		sh.addGauge(metric.Name, metric.Value, b.updatedAt(metric.UpdatedAt, now))
+	case position < len(b.gauges)+len(b.counters):
+		metric := b.counters[position-len(b.gauges)]
+		sh.addCounter(metric.Name, metric.Value, b.updatedAt(metric.UpdatedAt, now))
+	default:
+		metric := b.histograms[position-len(b.gauges)-len(b.counters)]
+		return sh.addHistogram(metric.Name, metric, b.updatedAt(metric.UpdatedAt, now))
+	}
+
+	return nil
+}
+
+// AddMetrics добавляет набор метрик типа gauge, counter и histogram.
+// Метрики группируются по сегментам, и каждый затронутый сегмент блокируется один раз на весь набор.
+// Набор применяется целиком: если гистограмма набора не объединяется с сохраненной, не добавляется ни одна метрика.
+func (s *MemStorage) AddMetrics(_ context.Context, gaugeMetrics []storage.GaugeMetric, counterMetrics []storage.CounterMetric, histogramMetrics []storage.HistogramMetric) error {
+	return s.addMetrics(metricsBatch{gauges: gaugeMetrics, counters: counterMetrics, histograms: histogramMetrics})
+}
+
+// RestoreMetrics добавляет набор метрик так же, как AddMetrics, но сохраняет время обновления,
+// заданное в каждой метрике. Используется при восстановлении данных из файла, чтобы восстановленные
+// метрики не считались только что обновленными. Метрики без времени обновления получают текущее время.
+func (s *MemStorage) RestoreMetrics(_ context.Context, gaugeMetrics []storage.GaugeMetric, counterMetrics []storage.CounterMetric, histogramMetrics []storage.HistogramMetric) error {
+	return s.addMetrics(metricsBatch{gauges: gaugeMetrics, counters: counterMetrics, histograms: histogramMetrics, keepTime: true})
+}
+
+func (s *MemStorage) addMetrics(batch metricsBatch) error {
+	size := batch.len()
+	now := s.now()
+
+	if len(s.shards) == 1 {
+		sh := s.shards[0]
+
+		sh.mu.Lock()
+		defer sh.mu.Unlock()
+
+		if err := batch.checkHistograms(s.shardFor); err != nil {
 			return err
 		}
+
+		return sh.addPositions(batch, nil, size, now)
+	}
+
+	// Позиции метрик раскладываются по сегментам сортировкой подсчетом:
+	// bounds[i]:bounds[i+1] — диапазон позиций сегмента i в order.
+	buffer := make([]int, 2*size+len(s.shards)+1)
+	index, order, bounds := buffer[:size], buffer[size:2*size], buffer[2*size:]
+
+	for position := 0; position < size; position++ {
+		index[position] = s.shardIndex(batch.name(position))
+		bounds[index[position]+1]++
+	}
+
+	for i := 1; i < len(bounds); i++ {
+		bounds[i] += bounds[i-1]
 	}
 
-	for _, counterMetric := range counterMetrics {
-		if err := s.AddCounterMetric(ctx, counterMetric.Name, counterMetric.Value); err != nil {
+	next := append([]int(nil), bounds[:len(s.shards)]...)
+
+	for position, i := range index {
+		order[next[i]] = position
+		next[i]++
+	}
+
+	// Затронутые сегменты блокируются по возрастанию номера, чтобы конкурентные наборы не ждали
+	// друг друга бесконечно, и удерживаются, пока набор не будет проверен и применен целиком.
+	for i, sh := range s.shards {
+		if bounds[i] == bounds[i+1] {
+			continue
+		}
+
+		sh.mu.Lock()
+		defer sh.mu.Unlock()
+	}
+
+	if err := batch.checkHistograms(s.shardFor); err != nil {
+		return err
+	}
+
+	for i, sh := range s.shards {
+		if bounds[i] == bounds[i+1] {
+			continue
+		}
+
+		if err := sh.addPositions(batch, order[bounds[i]:bounds[i+1]], 0, now); err != nil {
 			return err
 		}
 	}
@@ -88,12 +423,340 @@ func (s *MemStorage) AddMetrics(ctx context.Context, gaugeMetrics []storage.Gaug
 	return nil
 }
 
+// checkHistograms проверяет, что каждая гистограмма набора объединяется с сохраненной и с предыдущими
+// гистограммами набора с тем же ключом. Проверка выполняется до изменения сегментов, поэтому
+// набор с несовместимой гистограммой не применяется частично.
+// Вызывающий код должен удерживать блокировки сегментов, в которых хранятся гистограммы набора.
+func (b metricsBatch) checkHistograms(shardFor func(key string) *shard) error {
+	if len(b.histograms) == 0 {
+		return nil
+	}
+
+	merged := make(map[string]storage.HistogramMetric, len(b.histograms))
+
+	for _, histogram := range b.histograms {
+		current, ok := merged[histogram.Name]
+
+		if !ok {
+			current, ok = shardFor(histogram.Name).histogram[histogram.Name]
+		}
+
+		if !ok {
+			merged[histogram.Name] = histogram
+			continue
+		}
+
+		result, err := storage.MergeHistograms(current, histogram)
+
+		if err != nil {
+			return fmt.Errorf("cannot add histogram %s: %w", histogram.Name, err)
+		}
+
+		merged[histogram.Name] = result
+	}
+
+	return nil
+}
+
+// addPositions добавляет в сегмент метрики набора на указанных позициях.
+// Если позиции не заданы, добавляются первые count метрик набора.
+// Вызывающий код должен удерживать блокировку сегмента.
+func (sh *shard) addPositions(batch metricsBatch, positions []int, count int, now time.Time) error {
+	if positions == nil {
+		for position := 0; position < count; position++ {
+			if err := batch.add(sh, position, now); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for _, position := range positions {
+		if err := batch.add(sh, position, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteGaugeMetric удаляет метрику типа gauge и ее историю.
+func (s *MemStorage) DeleteGaugeMetric(_ context.Context, key string) error {
+	sh := s.shardFor(key)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if _, ok := sh.gauge[key]; !ok {
+		return storage.ErrDataNotFound
+	}
+
+	delete(sh.gauge, key)
+	delete(sh.history, historyKey(models.GaugeMetricType, key))
+
+	return nil
+}
+
+// DeleteCounterMetric удаляет метрику типа counter и ее историю.
+func (s *MemStorage) DeleteCounterMetric(_ context.Context, key string) error {
+	sh := s.shardFor(key)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if _, ok := sh.counter[key]; !ok {
+		return storage.ErrDataNotFound
+	}
+
+	delete(sh.counter, key)
+	delete(sh.history, historyKey(models.CounterMetricType, key))
+
+	return nil
+}
+
+// DeleteHistogramMetric удаляет метрику типа histogram и ее историю.
+func (s *MemStorage) DeleteHistogramMetric(_ context.Context, key string) error {
+	sh := s.shardFor(key)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if _, ok := sh.histogram[key]; !ok {
+		return storage.ErrDataNotFound
+	}
+
+	delete(sh.histogram, key)
+	delete(sh.history, historyKey(models.HistogramMetricType, key))
+
+	return nil
+}
+
+// DeleteStaleMetric удаляет метрику и ее историю, если она не обновлялась после updatedAt.
+// Время обновления проверяется под блокировкой шарда, поэтому метрика, обновленная после отбора
+// на удаление, сохраняется; в этом случае, как и при отсутствии метрики, возвращается storage.ErrDataNotFound.
+func (s *MemStorage) DeleteStaleMetric(_ context.Context, metricType, key string, updatedAt time.Time) error {
+	sh := s.shardFor(key)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	var (
+		current time.Time
+		ok      bool
+	)
+
+	switch metricType {
+	case models.GaugeMetricType:
+		var entry gaugeEntry
+		entry, ok = sh.gauge[key]
+		current = entry.updatedAt
+	case models.CounterMetricType:
+		var entry counterEntry
+		entry, ok = sh.counter[key]
+		current = entry.updatedAt
+	case models.HistogramMetricType:
+		var entry storage.HistogramMetric
+		entry, ok = sh.histogram[key]
+		current = entry.UpdatedAt
+	}
+
+	if !ok || current.After(updatedAt) {
+		return storage.ErrDataNotFound
+	}
+
+	switch metricType {
+	case models.GaugeMetricType:
+		delete(sh.gauge, key)
+	case models.CounterMetricType:
+		delete(sh.counter, key)
+	case models.HistogramMetricType:
+		delete(sh.histogram, key)
+	}
+
+	delete(sh.history, historyKey(metricType, key))
+
+	return nil
+}
+
+// DeleteMetricsByPrefix удаляет метрики всех типов, ключ которых начинается с префикса, и возвращает их количество.
+func (s *MemStorage) DeleteMetricsByPrefix(_ context.Context, prefix string) (int, error) {
+	deleted := 0
+
+	for _, sh := range s.shards {
+		deleted += sh.deleteByPrefix(prefix)
+	}
+
+	return deleted, nil
+}
+
+func (sh *shard) deleteByPrefix(prefix string) int {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	deleted := 0
+
+	for key := range sh.gauge {
+		if strings.HasPrefix(key, prefix) {
+			delete(sh.gauge, key)
+			deleted++
+		}
+	}
+
+	for key := range sh.counter {
+		if strings.HasPrefix(key, prefix) {
+			delete(sh.counter, key)
+			deleted++
+		}
+	}
+
+	for key := range sh.histogram {
+		if strings.HasPrefix(key, prefix) {
+			delete(sh.histogram, key)
+			deleted++
+		}
+	}
+
+	for key := range sh.history {
+		if _, name, _ := strings.Cut(key, ":"); strings.HasPrefix(name, prefix) {
+			delete(sh.history, key)
+		}
+	}
+
+	return deleted
+}
+
+// ResetCounterMetric обнуляет значение метрики типа counter.
+func (s *MemStorage) ResetCounterMetric(_ context.Context, key string) error {
+	sh := s.shardFor(key)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if _, ok := sh.counter[key]; !ok {
+		return storage.ErrDataNotFound
+	}
+
+	sh.counter[key] = counterEntry{updatedAt: s.now()}
+
+	return nil
+}
+
+// AddMetricSamples добавляет значения в историю метрик.
+func (s *MemStorage) AddMetricSamples(_ context.Context, samples []storage.MetricSample) error {
+	for _, sample := range samples {
+		sh := s.shardFor(sample.Name)
+
+		sh.mu.Lock()
+
+		key := historyKey(sample.Type, sample.Name)
+		series := append(sh.history[key], sample)
+
+		if len(series) > MaxSamplesPerSeries {
+			series = series[len(series)-MaxSamplesPerSeries:]
+		}
+
+		sh.history[key] = series
+
+		sh.mu.Unlock()
+	}
+
+	return nil
+}
+
+// DeleteMetricSamplesBefore удаляет значения истории, сохраненные раньше before, и возвращает их количество.
+func (s *MemStorage) DeleteMetricSamplesBefore(_ context.Context, before time.Time) (int, error) {
+	deleted := 0
+
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+
+		for key, series := range sh.history {
+			kept := series[:0]
+
+			for _, sample := range series {
+				if sample.Timestamp.Before(before) {
+					deleted++
+					continue
+				}
+
+				kept = append(kept, sample)
+			}
+
+			if len(kept) == 0 {
+				delete(sh.history, key)
+				continue
+			}
+
+			sh.history[key] = kept
+		}
+
+		sh.mu.Unlock()
+	}
+
+	return deleted, nil
+}
+
+// GetMetricSamples возвращает историю значений метрики в диапазоне [from, to].
+func (s *MemStorage) GetMetricSamples(_ context.Context, metricType, key string, from, to time.Time) ([]storage.MetricSample, error) {
+	sh := s.shardFor(key)
+
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	series := sh.history[historyKey(metricType, key)]
+	data := make([]storage.MetricSample, 0)
+
+	for _, sample := range series {
+		if sample.Timestamp.Before(from) || sample.Timestamp.After(to) {
+			continue
+		}
+
+		data = append(data, sample)
+	}
+
+	return data, nil
+}
+
 // New создает новый экземпляр MemStorage с пустыми картами для метрик.
 func New() *MemStorage {
-	return &MemStorage{gauge: map[string]float64{}, counter: map[string]int64{}}
+	return NewWithShards(DefaultShardCount)
+}
+
+// NewWithShards создает новый пустой экземпляр MemStorage с указанным количеством сегментов.
+// Количество сегментов округляется вверх до степени двойки.
+func NewWithShards(count int) *MemStorage {
+	size := 1
+
+	for size < count {
+		size <<= 1
+	}
+
+	shards := make([]*shard, size)
+
+	for i := range shards {
+		shards[i] = newShard()
+	}
+
+	return &MemStorage{
+		shards: shards,
+		now:    time.Now,
+	}
 }
 
 // NewWithPrefilledData создает новый экземпляр MemStorage с предварительно заполненными данными.
+// Временем обновления предварительно заполненных метрик считается время создания хранилища.
 func NewWithPrefilledData(gauge map[string]float64, counter map[string]int64) *MemStorage {
-	return &MemStorage{gauge, counter}
+	s := New()
+	now := s.now()
+
+	for key, value := range gauge {
+		s.shardFor(key).addGauge(key, value, now)
+	}
+
+	for key, value := range counter {
+		s.shardFor(key).addCounter(key, value, now)
+	}
+
+	return s
 }
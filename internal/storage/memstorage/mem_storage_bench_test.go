@@ -0,0 +1,118 @@
+package memstorage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/daremove/go-metrics-service/internal/storage"
+)
+
+// lockedStorage повторяет прежнее устройство хранилища: общие карты под одной блокировкой.
+// Используется как точка отсчета при сравнении производительности с сегментированным хранилищем.
+type lockedStorage struct {
+	mu      sync.Mutex
+	gauge   map[string]float64
+	counter map[string]int64
+}
+
+func (s *lockedStorage) AddMetrics(_ context.Context, gaugeMetrics []storage.GaugeMetric, counterMetrics []storage.CounterMetric, _ []storage.HistogramMetric) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, gaugeMetric := range gaugeMetrics {
+		s.gauge[gaugeMetric.Name] = gaugeMetric.Value
+	}
+
+	for _, counterMetric := range counterMetrics {
+		s.counter[counterMetric.Name] += counterMetric.Value
+	}
+
+	return nil
+}
+
+type batchWriter interface {
+	AddMetrics(ctx context.Context, gaugeMetrics []storage.GaugeMetric, counterMetrics []storage.CounterMetric, histogramMetrics []storage.HistogramMetric) error
+}
+
+// agentBatches формирует наборы метрик, которые отправляют агенты: у каждого агента свои имена метрик.
+func agentBatches(agents, metricsPerAgent int) [][]storage.GaugeMetric {
+	batches := make([][]storage.GaugeMetric, agents)
+
+	for a := range batches {
+		batch := make([]storage.GaugeMetric, metricsPerAgent)
+
+		for m := range batch {
+			batch[m] = storage.GaugeMetric{Name: fmt.Sprintf("agent_%d_metric_%d", a, m), Value: float64(m)}
+		}
+
+		batches[a] = batch
+	}
+
+	return batches
+}
+
+func benchmarkAddMetrics(b *testing.B, store batchWriter) {
+	ctx := context.Background()
+	batches := agentBatches(64, 30)
+	counters := []storage.CounterMetric{{Name: "PollCount", Value: 1}}
+
+	var next sync.Mutex
+	agent := 0
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		next.Lock()
+		batch := batches[agent%len(batches)]
+		agent++
+		next.Unlock()
+
+		for pb.Next() {
+			if err := store.AddMetrics(ctx, batch, counters, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkAddMetrics(b *testing.B) {
+	b.Run("single mutex", func(b *testing.B) {
+		benchmarkAddMetrics(b, &lockedStorage{gauge: map[string]float64{}, counter: map[string]int64{}})
+	})
+
+	b.Run("one shard", func(b *testing.B) {
+		benchmarkAddMetrics(b, NewWithShards(1))
+	})
+
+	b.Run(fmt.Sprintf("%d shards", DefaultShardCount), func(b *testing.B) {
+		benchmarkAddMetrics(b, NewWithShards(DefaultShardCount))
+	})
+}
+
+func BenchmarkGetGaugeMetric(b *testing.B) {
+	ctx := context.Background()
+	store := New()
+	batch := agentBatches(1, 1000)[0]
+
+	if err := store.AddMetrics(ctx, batch, nil, nil); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+
+		for pb.Next() {
+			if _, err := store.GetGaugeMetric(ctx, batch[i%len(batch)].Name); err != nil {
+				b.Fatal(err)
+			}
+
+			i++
+		}
+	})
+}
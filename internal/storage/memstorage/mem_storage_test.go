@@ -2,8 +2,12 @@ package memstorage
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/daremove/go-metrics-service/internal/models"
 	"github.com/daremove/go-metrics-service/internal/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -12,6 +16,8 @@ import (
 func TestMemStorage(t *testing.T) {
 	ctx := context.Background()
 	memStore := New()
+	updatedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	memStore.now = func() time.Time { return updatedAt }
 
 	t.Run("Should add and retrieve a gauge metric correctly", func(t *testing.T) {
 		err := memStore.AddGaugeMetric(ctx, "temperature", 25.5)
@@ -19,7 +25,7 @@ func TestMemStorage(t *testing.T) {
 
 		metric, err := memStore.GetGaugeMetric(ctx, "temperature")
 		require.NoError(t, err)
-		assert.Equal(t, storage.GaugeMetric{Name: "temperature", Value: 25.5}, metric)
+		assert.Equal(t, storage.GaugeMetric{Name: "temperature", Value: 25.5, UpdatedAt: updatedAt}, metric)
 	})
 
 	t.Run("Should return an error when retrieving a non-existent gauge metric", func(t *testing.T) {
@@ -36,7 +42,7 @@ func TestMemStorage(t *testing.T) {
 
 		metric, err := memStore.GetCounterMetric(ctx, "requests")
 		require.NoError(t, err)
-		assert.Equal(t, storage.CounterMetric{Name: "requests", Value: 8}, metric)
+		assert.Equal(t, storage.CounterMetric{Name: "requests", Value: 8, UpdatedAt: updatedAt}, metric)
 	})
 
 	t.Run("Should return an error when retrieving a non-existent counter metric", func(t *testing.T) {
@@ -58,7 +64,7 @@ func TestMemStorage(t *testing.T) {
 		gaugesToAdd := []storage.GaugeMetric{{Name: "speed", Value: 88.0}}
 		countersToAdd := []storage.CounterMetric{{Name: "errors", Value: 1}}
 
-		err := memStore.AddMetrics(ctx, gaugesToAdd, countersToAdd)
+		err := memStore.AddMetrics(ctx, gaugesToAdd, countersToAdd, nil)
 		require.NoError(t, err)
 
 		gauges, err := memStore.GetGaugeMetrics(ctx)
@@ -69,4 +75,356 @@ func TestMemStorage(t *testing.T) {
 		require.NoError(t, err)
 		assert.Len(t, counters, 2)
 	})
+
+	t.Run("Should add and merge a histogram metric correctly", func(t *testing.T) {
+		histogram := storage.HistogramMetric{Bounds: []float64{1, 5}, Counts: []uint64{1, 1, 0}, Sum: 4, Count: 2}
+
+		err := memStore.AddHistogramMetric(ctx, "latency", histogram)
+		require.NoError(t, err)
+
+		err = memStore.AddMetrics(ctx, nil, nil, []storage.HistogramMetric{{Name: "latency", Bounds: []float64{1, 5}, Counts: []uint64{0, 0, 1}, Sum: 10, Count: 1}})
+		require.NoError(t, err)
+
+		metric, err := memStore.GetHistogramMetric(ctx, "latency")
+		require.NoError(t, err)
+		assert.Equal(t, storage.HistogramMetric{Name: "latency", Bounds: []float64{1, 5}, Counts: []uint64{1, 1, 1}, Sum: 14, Count: 3, UpdatedAt: updatedAt}, metric)
+
+		histograms, err := memStore.GetHistogramMetrics(ctx)
+		require.NoError(t, err)
+		assert.Len(t, histograms, 1)
+	})
+
+	t.Run("Should return an error when histogram bounds are different", func(t *testing.T) {
+		err := memStore.AddHistogramMetric(ctx, "latency", storage.HistogramMetric{Bounds: []float64{2}, Counts: []uint64{1, 0}, Sum: 1, Count: 1})
+		assert.ErrorIs(t, err, storage.ErrHistogramBoundsMismatch)
+	})
+
+	t.Run("Should return metric samples within period", func(t *testing.T) {
+		now := time.Now()
+		samples := []storage.MetricSample{
+			{Name: "speed", Type: "gauge", Value: 1, Timestamp: now.Add(-2 * time.Minute)},
+			{Name: "speed", Type: "gauge", Value: 2, Timestamp: now.Add(-time.Minute)},
+			{Name: "speed", Type: "counter", Value: 3, Timestamp: now.Add(-time.Minute)},
+		}
+
+		err := memStore.AddMetricSamples(ctx, samples)
+		require.NoError(t, err)
+
+		result, err := memStore.GetMetricSamples(ctx, "gauge", "speed", now.Add(-90*time.Second), now)
+		require.NoError(t, err)
+		assert.Equal(t, []storage.MetricSample{samples[1]}, result)
+	})
+
+	t.Run("Should keep limited number of metric samples", func(t *testing.T) {
+		now := time.Now()
+		samples := make([]storage.MetricSample, MaxSamplesPerSeries+1)
+
+		for i := range samples {
+			samples[i] = storage.MetricSample{Name: "limited", Type: "gauge", Value: float64(i), Timestamp: now}
+		}
+
+		err := memStore.AddMetricSamples(ctx, samples)
+		require.NoError(t, err)
+
+		result, err := memStore.GetMetricSamples(ctx, "gauge", "limited", now, now)
+		require.NoError(t, err)
+		assert.Len(t, result, MaxSamplesPerSeries)
+		assert.Equal(t, float64(1), result[0].Value)
+	})
+
+	t.Run("Should update timestamp on every write", func(t *testing.T) {
+		require.NoError(t, memStore.AddGaugeMetric(ctx, "refreshed", 1))
+
+		memStore.now = func() time.Time { return updatedAt.Add(time.Minute) }
+		defer func() { memStore.now = func() time.Time { return updatedAt } }()
+
+		require.NoError(t, memStore.AddGaugeMetric(ctx, "refreshed", 2))
+
+		metric, err := memStore.GetGaugeMetric(ctx, "refreshed")
+		require.NoError(t, err)
+		assert.Equal(t, updatedAt.Add(time.Minute), metric.UpdatedAt)
+	})
+
+	t.Run("Should keep update time of restored metrics", func(t *testing.T) {
+		restoredAt := updatedAt.Add(-time.Hour)
+
+		require.NoError(t, memStore.RestoreMetrics(ctx,
+			[]storage.GaugeMetric{{Name: "restored_gauge", Value: 1, UpdatedAt: restoredAt}, {Name: "restored_fresh", Value: 2}},
+			[]storage.CounterMetric{{Name: "restored_counter", Value: 3, UpdatedAt: restoredAt}},
+			[]storage.HistogramMetric{{Name: "restored_histogram", Bounds: []float64{1}, Counts: []uint64{1, 0}, Sum: 0.5, Count: 1, UpdatedAt: restoredAt}},
+		))
+
+		gauge, err := memStore.GetGaugeMetric(ctx, "restored_gauge")
+		require.NoError(t, err)
+		assert.Equal(t, restoredAt, gauge.UpdatedAt)
+
+		fresh, err := memStore.GetGaugeMetric(ctx, "restored_fresh")
+		require.NoError(t, err)
+		assert.Equal(t, updatedAt, fresh.UpdatedAt)
+
+		counter, err := memStore.GetCounterMetric(ctx, "restored_counter")
+		require.NoError(t, err)
+		assert.Equal(t, restoredAt, counter.UpdatedAt)
+
+		histogram, err := memStore.GetHistogramMetric(ctx, "restored_histogram")
+		require.NoError(t, err)
+		assert.Equal(t, restoredAt, histogram.UpdatedAt)
+	})
+
+	t.Run("Should delete metric with its history", func(t *testing.T) {
+		require.NoError(t, memStore.AddGaugeMetric(ctx, "deleted", 1))
+		require.NoError(t, memStore.AddMetricSamples(ctx, []storage.MetricSample{{Name: "deleted", Type: "gauge", Value: 1, Timestamp: time.Now()}}))
+
+		require.NoError(t, memStore.DeleteGaugeMetric(ctx, "deleted"))
+
+		_, err := memStore.GetGaugeMetric(ctx, "deleted")
+		assert.ErrorIs(t, err, storage.ErrDataNotFound)
+
+		samples, err := memStore.GetMetricSamples(ctx, "gauge", "deleted", time.Time{}, time.Now())
+		require.NoError(t, err)
+		assert.Empty(t, samples)
+
+		assert.ErrorIs(t, memStore.DeleteCounterMetric(ctx, "deleted"), storage.ErrDataNotFound)
+	})
+
+	t.Run("Should delete metrics of all types by prefix", func(t *testing.T) {
+		require.NoError(t, memStore.AddGaugeMetric(ctx, "job_gauge", 1))
+		require.NoError(t, memStore.AddCounterMetric(ctx, "job_counter", 1))
+		require.NoError(t, memStore.AddHistogramMetric(ctx, "job_histogram", storage.HistogramMetric{Bounds: []float64{1}, Counts: []uint64{1, 0}, Sum: 0.5, Count: 1}))
+		require.NoError(t, memStore.AddGaugeMetric(ctx, "other_gauge", 1))
+
+		deleted, err := memStore.DeleteMetricsByPrefix(ctx, "job_")
+		require.NoError(t, err)
+		assert.Equal(t, 3, deleted)
+
+		_, err = memStore.GetGaugeMetric(ctx, "other_gauge")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Should reset counter metric", func(t *testing.T) {
+		require.NoError(t, memStore.AddCounterMetric(ctx, "reset", 10))
+		require.NoError(t, memStore.ResetCounterMetric(ctx, "reset"))
+
+		value, err := memStore.GetCounterMetric(ctx, "reset")
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), value.Value)
+
+		assert.ErrorIs(t, memStore.ResetCounterMetric(ctx, "unknown"), storage.ErrDataNotFound)
+	})
+
+	t.Run("Should list metrics page by page in key order", func(t *testing.T) {
+		require.NoError(t, memStore.AddGaugeMetric(ctx, "list_b", 2))
+		require.NoError(t, memStore.AddCounterMetric(ctx, "list_b", 3))
+		require.NoError(t, memStore.AddGaugeMetric(ctx, "list_a", 1))
+		require.NoError(t, memStore.AddHistogramMetric(ctx, "list_c", storage.HistogramMetric{Bounds: []float64{1}, Counts: []uint64{1, 0}, Sum: 0.5, Count: 1}))
+
+		page, err := memStore.ListMetrics(ctx, storage.ListParameters{Prefix: "list_", Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []storage.MetricRecord{
+			{Type: "gauge", Name: "list_a", Value: 1, UpdatedAt: updatedAt},
+			{Type: "counter", Name: "list_b", Delta: 3, UpdatedAt: updatedAt},
+		}, page)
+
+		page, err = memStore.ListMetrics(ctx, storage.ListParameters{Prefix: "list_", AfterName: "list_b", AfterType: "counter", Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, page, 2)
+		assert.Equal(t, storage.MetricRecord{Type: "gauge", Name: "list_b", Value: 2, UpdatedAt: updatedAt}, page[0])
+		assert.Equal(t, "list_c", page[1].Name)
+		assert.Equal(t, uint64(1), page[1].Histogram.Count)
+
+		page, err = memStore.ListMetrics(ctx, storage.ListParameters{Types: []string{"histogram"}, Prefix: "list_"})
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		assert.Equal(t, "list_c", page[0].Name)
+	})
+}
+
+func TestMemStorage_AddMetricsAtomic(t *testing.T) {
+	ctx := context.Background()
+	mismatched := storage.HistogramMetric{Name: "latency", Bounds: []float64{2}, Counts: []uint64{1, 0}, Sum: 1, Count: 1}
+
+	testCases := []struct {
+		name       string
+		shards     int
+		stored     bool
+		histograms []storage.HistogramMetric
+	}{
+		{name: "mismatch with stored histogram in single shard", shards: 1, stored: true, histograms: []storage.HistogramMetric{mismatched}},
+		{name: "mismatch with stored histogram in sharded storage", shards: DefaultShardCount, stored: true, histograms: []storage.HistogramMetric{mismatched}},
+		{
+			name:   "mismatch within batch",
+			shards: DefaultShardCount,
+			histograms: []storage.HistogramMetric{
+				{Name: "latency", Bounds: []float64{1}, Counts: []uint64{1, 0}, Sum: 0.5, Count: 1},
+				mismatched,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			memStore := NewWithShards(tc.shards)
+
+			if tc.stored {
+				require.NoError(t, memStore.AddHistogramMetric(ctx, "latency", storage.HistogramMetric{Bounds: []float64{1}, Counts: []uint64{1, 0}, Sum: 0.5, Count: 1}))
+			}
+
+			gauges := make([]storage.GaugeMetric, 0, 100)
+			counters := make([]storage.CounterMetric, 0, 100)
+
+			for i := 0; i < 100; i++ {
+				gauges = append(gauges, storage.GaugeMetric{Name: fmt.Sprintf("gauge_%d", i), Value: 1})
+				counters = append(counters, storage.CounterMetric{Name: fmt.Sprintf("counter_%d", i), Value: 1})
+			}
+
+			err := memStore.AddMetrics(ctx, gauges, counters, tc.histograms)
+			assert.ErrorIs(t, err, storage.ErrHistogramBoundsMismatch)
+
+			storedGauges, err := memStore.GetGaugeMetrics(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, storedGauges)
+
+			storedCounters, err := memStore.GetCounterMetrics(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, storedCounters)
+
+			histograms, err := memStore.GetHistogramMetrics(ctx)
+			require.NoError(t, err)
+
+			if tc.stored {
+				require.Len(t, histograms, 1)
+				assert.Equal(t, uint64(1), histograms[0].Count)
+				return
+			}
+
+			assert.Empty(t, histograms)
+		})
+	}
+}
+
+func TestMemStorage_DeleteStaleMetric(t *testing.T) {
+	ctx := context.Background()
+	updatedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name       string
+		metricType string
+		checkedAt  time.Time
+		deleted    bool
+	}{
+		{name: "gauge not updated since check", metricType: models.GaugeMetricType, checkedAt: updatedAt, deleted: true},
+		{name: "counter not updated since check", metricType: models.CounterMetricType, checkedAt: updatedAt.Add(time.Second), deleted: true},
+		{name: "histogram not updated since check", metricType: models.HistogramMetricType, checkedAt: updatedAt, deleted: true},
+		{name: "gauge updated after check", metricType: models.GaugeMetricType, checkedAt: updatedAt.Add(-time.Second)},
+		{name: "counter updated after check", metricType: models.CounterMetricType, checkedAt: updatedAt.Add(-time.Second)},
+		{name: "histogram updated after check", metricType: models.HistogramMetricType, checkedAt: updatedAt.Add(-time.Second)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			memStore := New()
+			memStore.now = func() time.Time { return updatedAt }
+
+			require.NoError(t, memStore.AddGaugeMetric(ctx, "metric", 1))
+			require.NoError(t, memStore.AddCounterMetric(ctx, "metric", 1))
+			require.NoError(t, memStore.AddHistogramMetric(ctx, "metric", storage.HistogramMetric{Bounds: []float64{1}, Counts: []uint64{1, 0}, Sum: 0.5, Count: 1}))
+
+			err := memStore.DeleteStaleMetric(ctx, tc.metricType, "metric", tc.checkedAt)
+
+			records, listErr := memStore.ListMetrics(ctx, storage.ListParameters{})
+			require.NoError(t, listErr)
+
+			if !tc.deleted {
+				assert.ErrorIs(t, err, storage.ErrDataNotFound)
+				assert.Len(t, records, 3)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, records, 2)
+
+			for _, record := range records {
+				assert.NotEqual(t, tc.metricType, record.Type)
+			}
+
+			assert.ErrorIs(t, memStore.DeleteStaleMetric(ctx, tc.metricType, "metric", tc.checkedAt), storage.ErrDataNotFound)
+		})
+	}
+}
+
+func TestNewWithShards(t *testing.T) {
+	t.Run("Should round shard count up to power of two", func(t *testing.T) {
+		assert.Len(t, NewWithShards(0).shards, 1)
+		assert.Len(t, NewWithShards(5).shards, 8)
+		assert.Len(t, NewWithShards(DefaultShardCount).shards, DefaultShardCount)
+	})
+
+	t.Run("Should store each key in a single shard", func(t *testing.T) {
+		ctx := context.Background()
+		memStore := NewWithShards(8)
+
+		require.NoError(t, memStore.AddMetrics(ctx, []storage.GaugeMetric{{Name: "a", Value: 1}, {Name: "b", Value: 2}}, []storage.CounterMetric{{Name: "a", Value: 1}}, nil))
+		require.NoError(t, memStore.AddGaugeMetric(ctx, "a", 3))
+
+		gauge, err := memStore.GetGaugeMetric(ctx, "a")
+		require.NoError(t, err)
+		assert.Equal(t, float64(3), gauge.Value)
+
+		gauges, err := memStore.GetGaugeMetrics(ctx)
+		require.NoError(t, err)
+		assert.Len(t, gauges, 2)
+	})
+}
+
+func TestMemStorageConcurrency(t *testing.T) {
+	ctx := context.Background()
+	memStore := New()
+
+	const (
+		workers    = 16
+		iterations = 200
+	)
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func(worker int) {
+			defer wg.Done()
+
+			for i := 0; i < iterations; i++ {
+				gauges := []storage.GaugeMetric{{Name: fmt.Sprintf("gauge_%d", worker), Value: float64(i)}}
+				counters := []storage.CounterMetric{{Name: "shared_counter", Value: 1}, {Name: fmt.Sprintf("counter_%d", i%10), Value: 1}}
+				histograms := []storage.HistogramMetric{{Name: "shared_histogram", Bounds: []float64{1}, Counts: []uint64{1, 0}, Sum: 0.5, Count: 1}}
+
+				assert.NoError(t, memStore.AddMetrics(ctx, gauges, counters, histograms))
+				assert.NoError(t, memStore.AddMetricSamples(ctx, []storage.MetricSample{{Name: "shared_counter", Type: "counter", Value: 1, Timestamp: time.Now()}}))
+
+				_, err := memStore.GetGaugeMetrics(ctx)
+				assert.NoError(t, err)
+
+				_, err = memStore.GetHistogramMetric(ctx, "shared_histogram")
+				assert.NoError(t, err)
+			}
+		}(w)
+	}
+
+	wg.Wait()
+
+	counter, err := memStore.GetCounterMetric(ctx, "shared_counter")
+	require.NoError(t, err)
+	assert.Equal(t, int64(workers*iterations), counter.Value)
+
+	histogram, err := memStore.GetHistogramMetric(ctx, "shared_histogram")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(workers*iterations), histogram.Count)
+
+	gauges, err := memStore.GetGaugeMetrics(ctx)
+	require.NoError(t, err)
+	assert.Len(t, gauges, workers)
+
+	samples, err := memStore.GetMetricSamples(ctx, "counter", "shared_counter", time.Time{}, time.Now())
+	require.NoError(t, err)
+	assert.Len(t, samples, workers*iterations)
 }
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SeriesKey формирует ключ хранения метрики из имени и набора меток.
+// Метки сортируются по имени, поэтому один и тот же набор меток всегда дает один ключ,
+// например: cpu{env="prod",host="a"}. Метрика без меток хранится под своим именем.
+func SeriesKey(name string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return name
+	}
+
+	names := make([]string, 0, len(labels))
+
+	for labelName := range labels {
+		names = append(names, labelName)
+	}
+
+	sort.Strings(names)
+
+	var b strings.Builder
+
+	b.WriteString(name)
+	b.WriteByte('{')
+
+	for i, labelName := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		b.WriteString(labelName)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[labelName]))
+	}
+
+	b.WriteByte('}')
+
+	return b.String()
+}
+
+// ParseSeriesKey разбирает ключ хранения, сформированный SeriesKey, на имя метрики и метки.
+// Ключ без блока меток возвращается как имя метрики без меток.
+func ParseSeriesKey(key string) (string, map[string]string, error) {
+	start := strings.IndexByte(key, '{')
+
+	if start < 0 || !strings.HasSuffix(key, "}") {
+		return key, nil, nil
+	}
+
+	name := key[:start]
+	rest := key[start+1 : len(key)-1]
+	labels := map[string]string{}
+
+	for rest != "" {
+		eq := strings.IndexByte(rest, '=')
+
+		if eq <= 0 {
+			return "", nil, fmt.Errorf("series key %s has invalid label", key)
+		}
+
+		labelName := rest[:eq]
+		quoted, err := strconv.QuotedPrefix(rest[eq+1:])
+
+		if err != nil {
+			return "", nil, fmt.Errorf("series key %s has invalid label value: %w", key, err)
+		}
+
+		value, err := strconv.Unquote(quoted)
+
+		if err != nil {
+			return "", nil, fmt.Errorf("series key %s has invalid label value: %w", key, err)
+		}
+
+		labels[labelName] = value
+		rest = strings.TrimPrefix(rest[eq+1+len(quoted):], ",")
+	}
+
+	return name, labels, nil
+}
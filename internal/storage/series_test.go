@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesKey(t *testing.T) {
+	t.Run("Should return metric name if labels are empty", func(t *testing.T) {
+		assert.Equal(t, "cpu", SeriesKey("cpu", nil))
+	})
+
+	t.Run("Should sort labels by name", func(t *testing.T) {
+		key := SeriesKey("cpu", map[string]string{"host": "a", "env": "prod"})
+
+		assert.Equal(t, `cpu{env="prod",host="a"}`, key)
+	})
+
+	t.Run("Should escape label values", func(t *testing.T) {
+		key := SeriesKey("cpu", map[string]string{"path": `c:\"x",y`})
+
+		assert.Equal(t, `cpu{path="c:\\\"x\",y"}`, key)
+	})
+}
+
+func TestParseSeriesKey(t *testing.T) {
+	t.Run("Should parse key without labels", func(t *testing.T) {
+		name, labels, err := ParseSeriesKey("cpu")
+
+		require.NoError(t, err)
+		assert.Equal(t, "cpu", name)
+		assert.Nil(t, labels)
+	})
+
+	t.Run("Should parse key built by SeriesKey", func(t *testing.T) {
+		expected := map[string]string{"host": "a", "path": `c:\"x",y`, "empty": ""}
+
+		name, labels, err := ParseSeriesKey(SeriesKey("cpu", expected))
+
+		require.NoError(t, err)
+		assert.Equal(t, "cpu", name)
+		assert.Equal(t, expected, labels)
+	})
+
+	t.Run("Should return error for malformed labels", func(t *testing.T) {
+		_, _, err := ParseSeriesKey(`cpu{host=a}`)
+
+		assert.Error(t, err)
+	})
+}
@@ -1,19 +1,101 @@
 // Package storage предоставляет определения структур данных и ошибок для системы метрик.
 package storage
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 // ErrDataNotFound ошибка, возникающая когда запрашиваемые данные не найдены.
 var ErrDataNotFound = errors.New("data is not found")
 
+// ErrHistogramBoundsMismatch ошибка, возникающая при объединении гистограмм с разными границами корзин.
+var ErrHistogramBoundsMismatch = errors.New("histogram bounds mismatch")
+
+// ErrUnavailable ошибка, возникающая когда хранилище временно недоступно и операцию можно повторить позже.
+var ErrUnavailable = errors.New("storage is temporarily unavailable")
+
+// UnavailableError ошибка временной недоступности хранилища, содержащая исходную ошибку
+// и рекомендуемое время до повторной попытки.
+type UnavailableError struct {
+	Err        error         // Исходная ошибка хранилища
+	RetryAfter time.Duration // Рекомендуемое время до повторной попытки
+}
+
+// Error возвращает текст ошибки.
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrUnavailable, e.Err)
+}
+
+// Unwrap возвращает исходную ошибку хранилища.
+func (e *UnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// Is позволяет сравнивать ошибку с ErrUnavailable с помощью errors.Is.
+func (e *UnavailableError) Is(target error) bool {
+	return target == ErrUnavailable
+}
+
 // GaugeMetric определяет структуру для метрик типа "gauge", которые представляют собой мгновенное значение.
 type GaugeMetric struct {
-	Name  string  `json:"name"`  // Имя метрики
-	Value float64 `json:"value"` // Значение метрики
+	Name      string    `json:"name"`       // Имя метрики
+	Value     float64   `json:"value"`      // Значение метрики
+	UpdatedAt time.Time `json:"updated_at"` // Время последнего обновления метрики
 }
 
 // CounterMetric определяет структуру для метрик типа "counter", которые накапливают значение со временем.
 type CounterMetric struct {
-	Name  string `json:"name"`  // Имя метрики
-	Value int64  `json:"value"` // Накопленное значение метрики
+	Name      string    `json:"name"`       // Имя метрики
+	Value     int64     `json:"value"`      // Накопленное значение метрики
+	UpdatedAt time.Time `json:"updated_at"` // Время последнего обновления метрики
+}
+
+// HistogramMetric определяет структуру для метрик типа "histogram", которые накапливают распределение значений.
+type HistogramMetric struct {
+	Name      string    `json:"name"`       // Имя метрики
+	Bounds    []float64 `json:"bounds"`     // Верхние границы корзин
+	Counts    []uint64  `json:"counts"`     // Накопленное количество значений в корзинах
+	Sum       float64   `json:"sum"`        // Накопленная сумма значений
+	Count     uint64    `json:"count"`      // Накопленное количество значений
+	UpdatedAt time.Time `json:"updated_at"` // Время последнего обновления метрики
+}
+
+// MergeHistograms складывает количество значений и сумму двух гистограмм с одинаковыми границами корзин.
+func MergeHistograms(current, added HistogramMetric) (HistogramMetric, error) {
+	if len(current.Bounds) != len(added.Bounds) || len(current.Counts) != len(added.Counts) {
+		return HistogramMetric{}, ErrHistogramBoundsMismatch
+	}
+
+	for i := range current.Bounds {
+		if current.Bounds[i] != added.Bounds[i] {
+			return HistogramMetric{}, ErrHistogramBoundsMismatch
+		}
+	}
+
+	result := HistogramMetric{
+		Name:      current.Name,
+		Bounds:    append([]float64(nil), current.Bounds...),
+		Counts:    make([]uint64, len(current.Counts)),
+		Sum:       current.Sum + added.Sum,
+		Count:     current.Count + added.Count,
+		UpdatedAt: current.UpdatedAt,
+	}
+
+	for i := range current.Counts {
+		result.Counts[i] = current.Counts[i] + added.Counts[i]
+	}
+
+	return result, nil
+}
+
+// MetricSample определяет значение метрики, зафиксированное в определенный момент времени.
+// Для метрик типа "gauge" хранится записанное значение, для "counter" — приращение,
+// для "histogram" — приращение количества значений.
+type MetricSample struct {
+	Name      string    `json:"name"`      // Имя метрики
+	Type      string    `json:"type"`      // Тип метрики
+	Value     float64   `json:"value"`     // Значение метрики либо приращение счетчика
+	Timestamp time.Time `json:"timestamp"` // Время записи значения
 }
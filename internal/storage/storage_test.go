@@ -43,9 +43,54 @@ func TestCounterMetricSerialization(t *testing.T) {
 	})
 }
 
+func TestMergeHistograms(t *testing.T) {
+	t.Run("Should add counts and sum of histograms with equal bounds", func(t *testing.T) {
+		current := HistogramMetric{Name: "latency", Bounds: []float64{0.1, 1}, Counts: []uint64{1, 2, 3}, Sum: 10, Count: 6}
+		added := HistogramMetric{Name: "latency", Bounds: []float64{0.1, 1}, Counts: []uint64{1, 0, 1}, Sum: 2.5, Count: 2}
+
+		result, err := MergeHistograms(current, added)
+
+		assert.NoError(t, err)
+		assert.Equal(t, HistogramMetric{Name: "latency", Bounds: []float64{0.1, 1}, Counts: []uint64{2, 2, 4}, Sum: 12.5, Count: 8}, result)
+		assert.Equal(t, []uint64{1, 2, 3}, current.Counts)
+	})
+
+	t.Run("Should return error if bounds are different", func(t *testing.T) {
+		current := HistogramMetric{Bounds: []float64{0.1, 1}, Counts: []uint64{1, 2, 3}}
+		added := HistogramMetric{Bounds: []float64{0.5, 1}, Counts: []uint64{1, 2, 3}}
+
+		_, err := MergeHistograms(current, added)
+
+		assert.ErrorIs(t, err, ErrHistogramBoundsMismatch)
+	})
+}
+
 func TestErrDataNotFound(t *testing.T) {
 	t.Run("Should return correct error message", func(t *testing.T) {
 		err := ErrDataNotFound
 		assert.EqualError(t, err, "data is not found")
 	})
 }
+
+func TestListParametersMatches(t *testing.T) {
+	testCases := []struct {
+		testName   string
+		parameters ListParameters
+		metricType string
+		name       string
+		expected   bool
+	}{
+		{testName: "Should match any metric without conditions", parameters: ListParameters{}, metricType: "gauge", name: "a", expected: true},
+		{testName: "Should filter by type", parameters: ListParameters{Types: []string{"counter"}}, metricType: "gauge", name: "a", expected: false},
+		{testName: "Should filter by prefix", parameters: ListParameters{Prefix: "b"}, metricType: "gauge", name: "a", expected: false},
+		{testName: "Should skip metrics before cursor", parameters: ListParameters{AfterName: "b"}, metricType: "gauge", name: "a", expected: false},
+		{testName: "Should skip cursor metric", parameters: ListParameters{AfterName: "a", AfterType: "gauge"}, metricType: "gauge", name: "a", expected: false},
+		{testName: "Should compare type for equal keys", parameters: ListParameters{AfterName: "a", AfterType: "counter"}, metricType: "gauge", name: "a", expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.parameters.Matches(tc.metricType, tc.name))
+		})
+	}
+}